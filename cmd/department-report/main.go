@@ -0,0 +1,55 @@
+// Command department-report lists the free-text department values of medical records and record
+// templates that are not exactly the name of a department, and which department each matches when
+// letter case is ignored. Run it after creating the departments to see which stored values still need
+// one. With -fix, matched values are rewritten to the department's name; unmatched values must be
+// corrected by hand or get a department. It reads the same .env file as the server.
+//
+//	go run ./cmd/department-report -fix
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/models"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "rewrite values that only differ from a department's name in letter case")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	db, err := models.InitDB(models.DatabaseConfig{DSN: cfg.Database.DSN})
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+
+	matches, err := jobs.DepartmentReport(db, *fix)
+	if err != nil {
+		log.Fatalf("Department report stopped: %v", err)
+	}
+	unmatched := 0
+	for _, match := range matches {
+		switch {
+		case match.Canonical == "":
+			unmatched++
+			log.Printf("UNMATCHED %s: %q (%d rows)", match.Table, match.Value, match.Rows)
+		case *fix:
+			log.Printf("FIXED     %s: %q -> %q (%d rows)", match.Table, match.Value, match.Canonical, match.Rows)
+		default:
+			log.Printf("CASE      %s: %q should be %q (%d rows)", match.Table, match.Value, match.Canonical, match.Rows)
+		}
+	}
+	log.Printf("Department report complete: %d values differ, %d without a department", len(matches), unmatched)
+}
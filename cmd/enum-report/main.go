@@ -0,0 +1,59 @@
+// Command enum-report lists the rows whose enum columns hold a value the API no longer accepts:
+// user roles, appointment statuses and medical record and template types. Run it before relying
+// on the enum validation, as rows written before it may hold uppercase roles and statuses or
+// misspelled record types. With -fix, values that only differ in letter case are rewritten; the
+// rest must be corrected by hand. It reads the same .env file as the server.
+//
+//	go run ./cmd/enum-report -fix
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/models"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "rewrite values that only differ in letter case")
+	batchSize := flag.Int("batch-size", 500, "rows loaded per batch")
+	flag.Parse()
+	if *batchSize <= 0 {
+		log.Fatalf("-batch-size must be positive")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	db, err := models.InitDB(models.DatabaseConfig{DSN: cfg.Database.DSN})
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+
+	issues, err := jobs.EnumReport(db, *fix, *batchSize)
+	if err != nil {
+		log.Fatalf("Enum report stopped: %v", err)
+	}
+	unknown := 0
+	for _, issue := range issues {
+		switch {
+		case issue.Canonical == "":
+			unknown++
+			log.Printf("UNKNOWN %s.%s of %s: %q", issue.Table, issue.Column, issue.ID, issue.Value)
+		case *fix:
+			log.Printf("FIXED   %s.%s of %s: %q -> %q", issue.Table, issue.Column, issue.ID, issue.Value, issue.Canonical)
+		default:
+			log.Printf("CASE    %s.%s of %s: %q should be %q", issue.Table, issue.Column, issue.ID, issue.Value, issue.Canonical)
+		}
+	}
+	log.Printf("Enum report complete: %d rows differ, %d with unknown values", len(issues), unknown)
+}
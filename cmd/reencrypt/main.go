@@ -0,0 +1,54 @@
+// Command reencrypt rewrites the encrypted columns to match the current encryption settings:
+// it encrypts rows stored before ENCRYPTION_KEYS was set, moves rows sealed with an older key
+// to ENCRYPTION_KEY_ID after a rotation, and encrypts or decrypts message content when
+// ENCRYPT_MESSAGES was switched. It reads the same .env file as the server.
+//
+//	go run ./cmd/reencrypt -batch-size 20
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/encryption"
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/models"
+)
+
+func main() {
+	// Attachments can be up to MAX_UPLOAD_BYTES each, so batches are kept small
+	batchSize := flag.Int("batch-size", 20, "rows loaded per batch")
+	flag.Parse()
+	if *batchSize <= 0 {
+		log.Fatalf("-batch-size must be positive")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	if err := encryption.Configure(cfg); err != nil {
+		log.Fatalf("Error loading encryption keys: %v", err)
+	}
+	ring := encryption.Active()
+	if ring == nil {
+		log.Fatalf("ENCRYPTION_KEYS is not set; there is nothing to encrypt with")
+	}
+
+	db, err := models.InitDB(models.DatabaseConfig{DSN: cfg.Database.DSN})
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+
+	log.Printf("Re-encrypting with key %q (message encryption: %t)", ring.PrimaryKeyID(), encryption.MessagesEncrypted())
+	if err := jobs.Reencrypt(db, ring, encryption.MessagesEncrypted(), *batchSize); err != nil {
+		log.Fatalf("Re-encryption stopped: %v", err)
+	}
+	log.Printf("Re-encryption complete")
+}
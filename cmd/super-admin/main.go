@@ -0,0 +1,52 @@
+// Command super-admin promotes an existing user to super admin, the platform role of a multi-tenant
+// deployment that manages organizations and sees every organization's data. The API never grants
+// the role, so the first super admin is created with this command. The user leaves their
+// organization; they must sign in again for the new role to take effect. It reads the same .env
+// file as the server.
+//
+//	go run ./cmd/super-admin -email ops@example.com
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/models"
+)
+
+func main() {
+	email := flag.String("email", "", "email address of the user to promote")
+	flag.Parse()
+	if strings.TrimSpace(*email) == "" {
+		log.Fatalf("-email is required")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	db, err := models.InitDB(models.DatabaseConfig{DSN: cfg.Database.DSN})
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+
+	var user models.User
+	if err := db.First(&user, "email = ?", strings.TrimSpace(*email)).Error; err != nil {
+		log.Fatalf("Error finding user %s: %v", *email, err)
+	}
+	if err := db.Model(&user).Updates(map[string]interface{}{
+		"role":            models.RoleSuperAdmin,
+		"organization_id": nil,
+	}).Error; err != nil {
+		log.Fatalf("Error promoting user %s: %v", *email, err)
+	}
+	log.Printf("Promoted %s (%s) to super admin", user.Email, user.ID)
+}
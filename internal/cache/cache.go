@@ -0,0 +1,88 @@
+// Package cache keeps short-lived copies of expensive read results.
+//
+// Values are stored as JSON behind the Cache interface, so the in-process Memory store can be
+// swapped for a shared one such as Redis without touching the handlers. Loader sits in front of
+// a store, collapses concurrent misses for the same key into one load and counts hits and misses.
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores serialized values by key until they expire.
+type Cache interface {
+	// Get returns the value stored under key, if it has not expired.
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	// DeletePrefix removes every key that starts with prefix.
+	DeletePrefix(ctx context.Context, prefix string)
+}
+
+// memoryEntry is a value held by Memory together with its expiry.
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory is a Cache held in the process's memory. Expired entries are dropped when read
+// and swept out periodically as new entries are stored.
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemory creates an empty in-process cache.
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]memoryEntry), lastSweep: time.Now()}
+}
+
+// Get implements Cache.
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set implements Cache. Entries that expired since the last sweep are removed first, so keys
+// that are never read again do not pile up.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.lastSweep) >= ttl {
+		for k, entry := range m.entries {
+			if now.After(entry.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.lastSweep = now
+	}
+	m.entries[key] = memoryEntry{value: value, expiresAt: now.Add(ttl)}
+}
+
+// DeletePrefix implements Cache.
+func (m *Memory) DeletePrefix(_ context.Context, prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.entries, key)
+		}
+	}
+}
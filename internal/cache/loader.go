@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the hit and miss counts of a Loader since startup.
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// call is a load in progress that concurrent misses for the same key wait on.
+type call struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+// Loader reads values through a Cache, loading and storing them on a miss. Concurrent misses for
+// the same key share a single load. Failed loads are not cached.
+type Loader struct {
+	store Cache
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*call
+	// generation is bumped by Invalidate so a load that started before it does not store its stale result
+	generation uint64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewLoader creates a Loader keeping values in store for ttl. A ttl of zero or less disables
+// caching; concurrent loads are still shared.
+func NewLoader(store Cache, ttl time.Duration) *Loader {
+	return &Loader{store: store, ttl: ttl, calls: make(map[string]*call)}
+}
+
+// Load decodes the value cached under key into dst. On a miss it calls load, caches the JSON
+// encoding of its result and decodes that into dst, so hits and misses return the same data.
+// The value returned by load must therefore be safe to hand to every client that may read it.
+func (l *Loader) Load(ctx context.Context, key string, dst interface{}, load func() (interface{}, error)) error {
+	if l.ttl > 0 {
+		if cached, ok := l.store.Get(ctx, key); ok {
+			l.hits.Add(1)
+			return json.Unmarshal(cached, dst)
+		}
+	}
+	l.misses.Add(1)
+
+	l.mu.Lock()
+	if pending, ok := l.calls[key]; ok {
+		l.mu.Unlock()
+		select {
+		case <-pending.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if pending.err != nil {
+			return pending.err
+		}
+		return json.Unmarshal(pending.value, dst)
+	}
+	pending := &call{done: make(chan struct{})}
+	l.calls[key] = pending
+	generation := l.generation
+	l.mu.Unlock()
+
+	value, err := load()
+	if err == nil {
+		pending.value, err = json.Marshal(value)
+	}
+	pending.err = err
+
+	l.mu.Lock()
+	if l.calls[key] == pending {
+		delete(l.calls, key)
+	}
+	if err == nil && l.ttl > 0 && generation == l.generation {
+		l.store.Set(ctx, key, pending.value, l.ttl)
+	}
+	l.mu.Unlock()
+	close(pending.done)
+
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(pending.value, dst)
+}
+
+// Invalidate drops every cached value whose key starts with prefix. Loads already in progress
+// for those keys still answer their callers but do not store their result, and later misses
+// start a fresh load instead of joining them.
+func (l *Loader) Invalidate(ctx context.Context, prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.generation++
+	for key := range l.calls {
+		if strings.HasPrefix(key, prefix) {
+			delete(l.calls, key)
+		}
+	}
+	l.store.DeletePrefix(ctx, prefix)
+}
+
+// Stats returns the hit and miss counts so far.
+func (l *Loader) Stats() Stats {
+	return Stats{Hits: l.hits.Load(), Misses: l.misses.Load()}
+}
@@ -2,27 +2,107 @@ package config
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 // Config holds all configuration for our application
 type Config struct {
 	Port                      string
 	Origin                    string
+	CORSAllowedMethods        []string // Methods cross-origin clients may use
+	CORSAllowedHeaders        []string // Request headers cross-origin clients may send
 	Environment               string
+	ErrorDetail               string // "full" adds raw error details to error responses, "generic" only logs them
 	JWTSecret                 string
 	JWTRefreshSecret          string
 	JWTPasswordReset          string
+	JWTAlgorithm              string // HS256 (shared secret) or RS256 (key pair) for access tokens
+	JWTPrivateKeyFile         string // PEM-encoded RSA private key, required for RS256
+	JWTPublicKeyFile          string // PEM-encoded RSA public key, required for RS256
 	CookieSecret              string
 	Database                  DatabaseConfig
 	Mailer                    MailerConfig
 	Google                    GoogleOAuthConfig
 	JWTExpirationMinutes      int
 	JWTRefreshExpirationHours int
+	RefreshIdleHours          int // Hours a refresh token may go unused before it stops working, 0 disables the idle expiry
 	PasswordResetTokenExpiry  int
 	VerificationTokenExpiry   int
-	AppURL                    string
+	AppURL                    string        // Public base URL of this API
+	FrontendURL               string        // Base URL of the client app that user-facing links in emails point to
+	MaxBodyBytes              int64         // Default request body limit for all routes
+	MaxUploadBytes            int64         // Request body limit for file upload routes
+	MaxMultipartMemory        int64         // Multipart bytes kept in memory before spilling to temp files
+	AllowedAttachmentTypes    []string      // Media types accepted for medical record attachments, checked against the file contents
+	AttachmentThumbnailSize   int           // Longer side in pixels of the previews made for image attachments, 0 disables them
+	ReviewEditWindowHours     int           // How long after posting a patient may edit their review
+	AppointmentDurationMins   int           // Default length of an appointment when no end time is given
+	DefaultLocale             string        // Response language when Accept-Language matches no catalog
+	MaxPageSize               int           // Largest page a list endpoint returns, whatever limit the client asks for
+	MinimumAgeYears           int           // Age a date of birth entered on a profile must give, 0 only requires a past date
+	DoctorsCanListAllPatients bool          // Whether doctors may pass all=true to list patients they have never seen
+	MessageArchiveAfterDays   int           // Age at which messages are archived by the background job, 0 disables it
+	MaxMessageLength          int           // Characters a message's content may have
+	MaxMessageSubjectLength   int           // Characters a message's subject may have
+	MessageRatePerMinute      int           // Messages a patient may send per minute, 0 disables the limit
+	MessageRatePerHour        int           // Messages a patient may send per hour, 0 disables the limit
+	StaffMessageRatePerMinute int           // Messages a doctor or admin may send per minute, 0 disables the limit
+	StaffMessageRatePerHour   int           // Messages a doctor or admin may send per hour, 0 disables the limit
+	MessageDuplicateWindow    int           // Seconds in which the same content cannot be sent to the same recipient again, 0 disables the check
+	AppointmentSweepHours     int           // Hours after its end a still-confirmed appointment is swept, 0 disables the sweep
+	AppointmentSweepPolicy    string        // What the sweep does: "review" marks needs_review, "complete" marks completed
+	ProposalExpiryHours       int           // Hours a patient has to confirm an appointment a doctor or admin booked for them, 0 disables expiry
+	PendingConfirmationHours  int           // Hours a pending appointment may wait for confirmation before it is cancelled, 0 disables expiry
+	BlockBookingOnNoShows     bool          // Whether patients with too many recent no-shows may not book themselves
+	NoShowLimit               int           // No-shows a patient may have in the window before self-booking is blocked
+	NoShowWindowDays          int           // Rolling window in which no-shows are counted
+	RestrictPatientMessaging  bool          // Whether patients may only message doctors they have an appointment or record with
+	StrictRecordAccess        bool          // Whether doctors only see the records they wrote or the patient consented to
+	MultiTenant               bool          // Whether users only see the data of their own organization (clinic)
+	AccessTokenCookie         bool          // Whether the access token is also set as an HTTP-only cookie and accepted from it
+	CookieDomain              string        // Domain attribute of the auth cookies, empty for the current host only
+	CookiePath                string        // Path attribute of the auth cookies
+	CookieSameSite            http.SameSite // SameSite mode of the auth cookies; None also forces Secure
+	RequestTimeout            int           // Seconds a request may spend on database work before it is cancelled with a 503
+	UploadRequestTimeout      int           // Request timeout in seconds for attachment upload and download routes
+	MaxImportRows             int           // Largest number of rows accepted by the bulk user import
+	BcryptCost                int           // bcrypt cost of password hashes; weaker stored hashes are upgraded at login
+	InvitationExpiryHours     int           // How long the password-set link emailed to imported users stays valid
+	TLSCertFile               string        // PEM certificate chain; with TLSKeyFile set, the server serves HTTPS on Port
+	TLSKeyFile                string        // PEM private key for TLSCertFile
+	HTTPRedirectPort          string        // When serving TLS, plain HTTP port that redirects to HTTPS (empty disables)
+	MetricsAddr               string        // Address Prometheus metrics are served on, apart from the API (empty disables)
+	RecordTrashRetentionDays  int           // Days a deleted medical record can be restored before it is purged, 0 disables purging
+	AppointmentRetentionDays  int           // Days after its start a finished appointment is deleted, 0 keeps appointments forever
+	JobMaxAttempts            int           // Attempts per run of a scheduled job, the first included, before it waits for its next run
+	TokenCleanupSchedule      string        // When expired refresh tokens are deleted, "off" disables the job
+	ReminderSchedule          string        // When due appointment reminders are emailed, "off" disables the job
+	AppointmentReminderHours  int           // Hours before a confirmed appointment its patient is emailed a reminder
+	EncryptionKeys            string        // Key ring for PHI at rest: comma-separated id:base64 32-byte keys, empty stores plaintext
+	EncryptionKeyID           string        // ID of the key new data is encrypted with, defaults to the first key
+	EncryptMessages           bool          // Whether message content is encrypted too, not only attachment files
+	MessageSearchFullText     bool          // Whether message search uses a MySQL FULLTEXT index instead of LIKE
+	DoctorCacheTTL            int           // Seconds doctor listings and profiles are cached, 0 disables caching
+	WebhookMaxAttempts        int           // Attempts per webhook delivery, the first included, before it is given up
+	WebhookDisableAfter       int           // Failed deliveries in a row after which a subscription is disabled, 0 never disables
+	WebhookTimeout            int           // Seconds a webhook endpoint has to answer a delivery
+	SlowQueryThresholdMs      int           // Queries slower than this are logged, 0 disables the query log
+	SlowRequestThresholdMs    int           // Requests slower than this are logged, 0 disables the request log
+	SlowLogSize               int           // Slow queries and requests kept for GET /admin/slow-queries
+	PolicyEnforcement         string        // "hard" blocks users until they accept new policy versions, "soft" only tells them, "off"
+	AdminEmail                string        // Email of the admin created at startup when no admin exists, empty disables it
+	AdminPassword             string        // Password of that admin; never logged
+	SeedDemo                  bool          // Whether a demo doctor, patient, appointment and medical record are created at startup
+}
+
+// TLSEnabled reports whether the server terminates TLS itself.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
 }
 
 // DatabaseConfig holds database connection details
@@ -59,8 +139,8 @@ func LoadConfig() (*Config, error) {
 		Name:     getEnv("DB_NAME", "medi"),
 	}
 
-	// Build DSN (Data Source Name) for MySQL connection
-	dbConfig.DSN = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	// Build DSN (Data Source Name) for MySQL connection; timestamps are stored and read as UTC
+	dbConfig.DSN = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
 		dbConfig.Username, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Name)
 
 	// Load mailer configuration
@@ -86,6 +166,11 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid JWT_REFRESH_EXPIRATION_HOURS: %w", err)
 	}
 
+	refreshIdleHours, err := strconv.Atoi(getEnv("REFRESH_TOKEN_IDLE_HOURS", "72"))
+	if err != nil || refreshIdleHours < 0 {
+		return nil, fmt.Errorf("invalid REFRESH_TOKEN_IDLE_HOURS: must be a non-negative integer")
+	}
+
 	passwordResetTokenExpiry, err := strconv.Atoi(getEnv("PASSWORD_RESET_TOKEN_EXPIRY_MINUTES", "60"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid PASSWORD_RESET_TOKEN_EXPIRY_MINUTES: %w", err)
@@ -96,26 +181,447 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid VERIFICATION_TOKEN_EXPIRY_HOURS: %w", err)
 	}
 
+	maxBodyBytes, err := strconv.ParseInt(getEnv("MAX_BODY_BYTES", "1048576"), 10, 64) // 1 MB
+	if err != nil || maxBodyBytes <= 0 {
+		return nil, fmt.Errorf("invalid MAX_BODY_BYTES: must be a positive integer")
+	}
+
+	maxUploadBytes, err := strconv.ParseInt(getEnv("MAX_UPLOAD_BYTES", "26214400"), 10, 64) // 25 MB
+	if err != nil || maxUploadBytes <= 0 {
+		return nil, fmt.Errorf("invalid MAX_UPLOAD_BYTES: must be a positive integer")
+	}
+
+	maxMultipartMemory, err := strconv.ParseInt(getEnv("MAX_MULTIPART_MEMORY_BYTES", "8388608"), 10, 64) // 8 MB
+	if err != nil || maxMultipartMemory <= 0 {
+		return nil, fmt.Errorf("invalid MAX_MULTIPART_MEMORY_BYTES: must be a positive integer")
+	}
+
+	reviewEditWindowHours, err := strconv.Atoi(getEnv("REVIEW_EDIT_WINDOW_HOURS", "48"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REVIEW_EDIT_WINDOW_HOURS: %w", err)
+	}
+
+	appointmentDurationMins, err := strconv.Atoi(getEnv("DEFAULT_APPOINTMENT_DURATION_MINUTES", "30"))
+	if err != nil || appointmentDurationMins <= 0 {
+		return nil, fmt.Errorf("invalid DEFAULT_APPOINTMENT_DURATION_MINUTES: must be a positive integer")
+	}
+
+	maxPageSize, err := strconv.Atoi(getEnv("MAX_PAGE_SIZE", "100"))
+	if err != nil || maxPageSize <= 0 {
+		return nil, fmt.Errorf("invalid MAX_PAGE_SIZE: must be a positive integer")
+	}
+
+	doctorsCanListAllPatients, err := strconv.ParseBool(getEnv("DOCTORS_CAN_LIST_ALL_PATIENTS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCTORS_CAN_LIST_ALL_PATIENTS: %w", err)
+	}
+
+	minimumAgeYears, err := strconv.Atoi(getEnv("MINIMUM_AGE_YEARS", "0"))
+	if err != nil || minimumAgeYears < 0 {
+		return nil, fmt.Errorf("invalid MINIMUM_AGE_YEARS: must be a non-negative integer")
+	}
+
+	maxMessageLength, err := strconv.Atoi(getEnv("MESSAGE_MAX_LENGTH", "10000"))
+	if err != nil || maxMessageLength <= 0 {
+		return nil, fmt.Errorf("invalid MESSAGE_MAX_LENGTH: must be a positive integer")
+	}
+
+	maxMessageSubjectLength, err := strconv.Atoi(getEnv("MESSAGE_MAX_SUBJECT_LENGTH", "255"))
+	if err != nil || maxMessageSubjectLength <= 0 {
+		return nil, fmt.Errorf("invalid MESSAGE_MAX_SUBJECT_LENGTH: must be a positive integer")
+	}
+
+	messageRatePerMinute, err := strconv.Atoi(getEnv("MESSAGE_RATE_PER_MINUTE", "10"))
+	if err != nil || messageRatePerMinute < 0 {
+		return nil, fmt.Errorf("invalid MESSAGE_RATE_PER_MINUTE: must be a non-negative integer")
+	}
+
+	messageRatePerHour, err := strconv.Atoi(getEnv("MESSAGE_RATE_PER_HOUR", "60"))
+	if err != nil || messageRatePerHour < 0 {
+		return nil, fmt.Errorf("invalid MESSAGE_RATE_PER_HOUR: must be a non-negative integer")
+	}
+
+	staffMessageRatePerMinute, err := strconv.Atoi(getEnv("STAFF_MESSAGE_RATE_PER_MINUTE", "30"))
+	if err != nil || staffMessageRatePerMinute < 0 {
+		return nil, fmt.Errorf("invalid STAFF_MESSAGE_RATE_PER_MINUTE: must be a non-negative integer")
+	}
+
+	staffMessageRatePerHour, err := strconv.Atoi(getEnv("STAFF_MESSAGE_RATE_PER_HOUR", "300"))
+	if err != nil || staffMessageRatePerHour < 0 {
+		return nil, fmt.Errorf("invalid STAFF_MESSAGE_RATE_PER_HOUR: must be a non-negative integer")
+	}
+
+	messageDuplicateWindow, err := strconv.Atoi(getEnv("MESSAGE_DUPLICATE_WINDOW_SECONDS", "60"))
+	if err != nil || messageDuplicateWindow < 0 {
+		return nil, fmt.Errorf("invalid MESSAGE_DUPLICATE_WINDOW_SECONDS: must be a non-negative integer")
+	}
+
+	messageArchiveAfterDays, err := strconv.Atoi(getEnv("MESSAGE_ARCHIVE_AFTER_DAYS", "365"))
+	if err != nil || messageArchiveAfterDays < 0 {
+		return nil, fmt.Errorf("invalid MESSAGE_ARCHIVE_AFTER_DAYS: must be a non-negative integer")
+	}
+
+	var allowedAttachmentTypes []string
+	for _, mediaType := range strings.Split(getEnv("ALLOWED_ATTACHMENT_TYPES", "application/pdf,image/png,image/jpeg,application/dicom,text/plain"), ",") {
+		if mediaType = strings.ToLower(strings.TrimSpace(mediaType)); mediaType != "" {
+			allowedAttachmentTypes = append(allowedAttachmentTypes, mediaType)
+		}
+	}
+	if len(allowedAttachmentTypes) == 0 {
+		return nil, fmt.Errorf("invalid ALLOWED_ATTACHMENT_TYPES: at least one media type is required")
+	}
+
+	bcryptCost, err := strconv.Atoi(getEnv("BCRYPT_COST", "10"))
+	if err != nil || bcryptCost < 4 || bcryptCost > 31 {
+		return nil, fmt.Errorf("invalid BCRYPT_COST: must be an integer from 4 to 31")
+	}
+
+	attachmentThumbnailSize, err := strconv.Atoi(getEnv("ATTACHMENT_THUMBNAIL_SIZE", "256"))
+	if err != nil || attachmentThumbnailSize < 0 {
+		return nil, fmt.Errorf("invalid ATTACHMENT_THUMBNAIL_SIZE: must be a non-negative integer")
+	}
+
+	proposalExpiryHours, err := strconv.Atoi(getEnv("PROPOSAL_EXPIRY_HOURS", "48"))
+	if err != nil || proposalExpiryHours < 0 {
+		return nil, fmt.Errorf("invalid PROPOSAL_EXPIRY_HOURS: must be a non-negative integer")
+	}
+
+	pendingConfirmationHours, err := strconv.Atoi(getEnv("PENDING_CONFIRMATION_HOURS", "0"))
+	if err != nil || pendingConfirmationHours < 0 {
+		return nil, fmt.Errorf("invalid PENDING_CONFIRMATION_HOURS: must be a non-negative integer")
+	}
+
+	appointmentSweepHours, err := strconv.Atoi(getEnv("APPOINTMENT_SWEEP_AFTER_HOURS", "24"))
+	if err != nil || appointmentSweepHours < 0 {
+		return nil, fmt.Errorf("invalid APPOINTMENT_SWEEP_AFTER_HOURS: must be a non-negative integer")
+	}
+
+	appointmentSweepPolicy := strings.ToLower(getEnv("APPOINTMENT_SWEEP_POLICY", "review"))
+	if appointmentSweepPolicy != "review" && appointmentSweepPolicy != "complete" {
+		return nil, fmt.Errorf("invalid APPOINTMENT_SWEEP_POLICY %q: must be review or complete", appointmentSweepPolicy)
+	}
+
+	blockBookingOnNoShows, err := strconv.ParseBool(getEnv("BLOCK_BOOKING_ON_NO_SHOWS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BLOCK_BOOKING_ON_NO_SHOWS: %w", err)
+	}
+
+	noShowLimit, err := strconv.Atoi(getEnv("NO_SHOW_LIMIT", "3"))
+	if err != nil || noShowLimit < 0 {
+		return nil, fmt.Errorf("invalid NO_SHOW_LIMIT: must be a non-negative integer")
+	}
+
+	noShowWindowDays, err := strconv.Atoi(getEnv("NO_SHOW_WINDOW_DAYS", "90"))
+	if err != nil || noShowWindowDays <= 0 {
+		return nil, fmt.Errorf("invalid NO_SHOW_WINDOW_DAYS: must be a positive integer")
+	}
+
+	restrictPatientMessaging, err := strconv.ParseBool(getEnv("RESTRICT_PATIENT_MESSAGING", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESTRICT_PATIENT_MESSAGING: %w", err)
+	}
+
+	strictRecordAccess, err := strconv.ParseBool(getEnv("STRICT_RECORD_ACCESS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STRICT_RECORD_ACCESS: %w", err)
+	}
+
+	multiTenant, err := strconv.ParseBool(getEnv("MULTI_TENANT", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MULTI_TENANT: %w", err)
+	}
+
+	accessTokenCookie, err := strconv.ParseBool(getEnv("ACCESS_TOKEN_COOKIE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACCESS_TOKEN_COOKIE: %w", err)
+	}
+
+	requestTimeout, err := strconv.Atoi(getEnv("REQUEST_TIMEOUT_SECONDS", "10"))
+	if err != nil || requestTimeout <= 0 {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_SECONDS: must be a positive integer")
+	}
+
+	uploadRequestTimeout, err := strconv.Atoi(getEnv("UPLOAD_REQUEST_TIMEOUT_SECONDS", "120"))
+	if err != nil || uploadRequestTimeout <= 0 {
+		return nil, fmt.Errorf("invalid UPLOAD_REQUEST_TIMEOUT_SECONDS: must be a positive integer")
+	}
+
+	maxImportRows, err := strconv.Atoi(getEnv("MAX_IMPORT_ROWS", "500"))
+	if err != nil || maxImportRows <= 0 {
+		return nil, fmt.Errorf("invalid MAX_IMPORT_ROWS: must be a positive integer")
+	}
+
+	invitationExpiryHours, err := strconv.Atoi(getEnv("INVITATION_EXPIRY_HOURS", "72"))
+	if err != nil || invitationExpiryHours <= 0 {
+		return nil, fmt.Errorf("invalid INVITATION_EXPIRY_HOURS: must be a positive integer")
+	}
+
+	tlsCertFile := getEnv("TLS_CERT_FILE", "")
+	tlsKeyFile := getEnv("TLS_KEY_FILE", "")
+	if (tlsCertFile == "") != (tlsKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	recordTrashRetentionDays, err := strconv.Atoi(getEnv("RECORD_TRASH_RETENTION_DAYS", "30"))
+	if err != nil || recordTrashRetentionDays < 0 {
+		return nil, fmt.Errorf("invalid RECORD_TRASH_RETENTION_DAYS: must be a non-negative integer")
+	}
+
+	appointmentRetentionDays, err := strconv.Atoi(getEnv("APPOINTMENT_RETENTION_DAYS", "0"))
+	if err != nil || appointmentRetentionDays < 0 {
+		return nil, fmt.Errorf("invalid APPOINTMENT_RETENTION_DAYS: must be a non-negative integer")
+	}
+
+	jobMaxAttempts, err := strconv.Atoi(getEnv("JOB_MAX_ATTEMPTS", "3"))
+	if err != nil || jobMaxAttempts <= 0 {
+		return nil, fmt.Errorf("invalid JOB_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	appointmentReminderHours, err := strconv.Atoi(getEnv("APPOINTMENT_REMINDER_HOURS", "24"))
+	if err != nil || appointmentReminderHours <= 0 {
+		return nil, fmt.Errorf("invalid APPOINTMENT_REMINDER_HOURS: must be a positive integer")
+	}
+
+	doctorCacheTTL, err := strconv.Atoi(getEnv("DOCTOR_CACHE_TTL_SECONDS", "60"))
+	if err != nil || doctorCacheTTL < 0 {
+		return nil, fmt.Errorf("invalid DOCTOR_CACHE_TTL_SECONDS: must be a non-negative integer")
+	}
+
+	webhookMaxAttempts, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "6"))
+	if err != nil || webhookMaxAttempts <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: must be a positive integer")
+	}
+
+	webhookDisableAfter, err := strconv.Atoi(getEnv("WEBHOOK_DISABLE_AFTER_FAILURES", "5"))
+	if err != nil || webhookDisableAfter < 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_DISABLE_AFTER_FAILURES: must be a non-negative integer")
+	}
+
+	webhookTimeout, err := strconv.Atoi(getEnv("WEBHOOK_TIMEOUT_SECONDS", "10"))
+	if err != nil || webhookTimeout <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT_SECONDS: must be a positive integer")
+	}
+
+	slowQueryThresholdMs, err := strconv.Atoi(getEnv("SLOW_QUERY_THRESHOLD_MS", "200"))
+	if err != nil || slowQueryThresholdMs < 0 {
+		return nil, fmt.Errorf("invalid SLOW_QUERY_THRESHOLD_MS: must be a non-negative integer")
+	}
+
+	slowRequestThresholdMs, err := strconv.Atoi(getEnv("SLOW_REQUEST_THRESHOLD_MS", "1000"))
+	if err != nil || slowRequestThresholdMs < 0 {
+		return nil, fmt.Errorf("invalid SLOW_REQUEST_THRESHOLD_MS: must be a non-negative integer")
+	}
+
+	slowLogSize, err := strconv.Atoi(getEnv("SLOW_LOG_SIZE", "200"))
+	if err != nil || slowLogSize < 0 {
+		return nil, fmt.Errorf("invalid SLOW_LOG_SIZE: must be a non-negative integer")
+	}
+
+	policyEnforcement := strings.ToLower(strings.TrimSpace(getEnv("POLICY_ENFORCEMENT", "hard")))
+	switch policyEnforcement {
+	case "hard", "soft", "off":
+	default:
+		return nil, fmt.Errorf("invalid POLICY_ENFORCEMENT %q: must be hard, soft or off", policyEnforcement)
+	}
+
+	adminEmail := strings.TrimSpace(getEnv("ADMIN_EMAIL", ""))
+	adminPassword := getEnv("ADMIN_PASSWORD", "")
+	if (adminEmail == "") != (adminPassword == "") {
+		return nil, fmt.Errorf("ADMIN_EMAIL and ADMIN_PASSWORD must be set together")
+	}
+	if adminPassword != "" && len(adminPassword) < 8 {
+		return nil, fmt.Errorf("invalid ADMIN_PASSWORD: must be at least 8 characters")
+	}
+
+	environment := getEnv("NODE_ENV", "development")
+	// Raw error details help while developing but expose database internals anywhere else
+	errorDetail := strings.ToLower(strings.TrimSpace(getEnv("ERROR_DETAIL", "")))
+	switch errorDetail {
+	case "":
+		errorDetail = "generic"
+		if environment == "development" {
+			errorDetail = "full"
+		}
+	case "full", "generic":
+	default:
+		return nil, fmt.Errorf("invalid ERROR_DETAIL %q: must be full or generic", errorDetail)
+	}
+	seedDemo, err := strconv.ParseBool(getEnv("SEED_DEMO", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SEED_DEMO: %w", err)
+	}
+	if seedDemo && environment == "production" {
+		return nil, fmt.Errorf("SEED_DEMO must not be enabled in production: the demo accounts have a public password")
+	}
+
+	origin := getEnv("ORIGIN", "http://localhost:4200")
+	corsAllowedMethods := commaList(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS"))
+	if len(corsAllowedMethods) == 0 {
+		return nil, fmt.Errorf("invalid CORS_ALLOWED_METHODS: at least one method is required")
+	}
+	for i, method := range corsAllowedMethods {
+		if !httpToken.MatchString(method) {
+			return nil, fmt.Errorf("invalid CORS_ALLOWED_METHODS: %q is not an HTTP method", method)
+		}
+		corsAllowedMethods[i] = strings.ToUpper(method)
+	}
+	// X-Request-ID lets clients correlate their requests with the server logs
+	corsAllowedHeaders := commaList(getEnv("CORS_ALLOWED_HEADERS", "Origin,Content-Type,Accept,Authorization,Accept-Language,X-Request-ID,Idempotency-Key"))
+	for _, header := range corsAllowedHeaders {
+		if !httpToken.MatchString(header) {
+			return nil, fmt.Errorf("invalid CORS_ALLOWED_HEADERS: %q is not a header name", header)
+		}
+	}
+	frontendURL := strings.TrimRight(getEnv("FRONTEND_URL", ""), "/")
+	if frontendURL == "" {
+		frontendURL = strings.TrimRight(origin, "/")
+	} else if parsed, err := url.Parse(frontendURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid FRONTEND_URL %q: must be an absolute http(s) URL", frontendURL)
+	}
+
+	var cookieSameSite http.SameSite
+	switch sameSite := strings.ToLower(getEnv("COOKIE_SAMESITE", "lax")); sameSite {
+	case "lax":
+		cookieSameSite = http.SameSiteLaxMode
+	case "strict":
+		cookieSameSite = http.SameSiteStrictMode
+	case "none":
+		cookieSameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("invalid COOKIE_SAMESITE %q: must be lax, strict or none", sameSite)
+	}
+
+	encryptionKeys := getEnv("ENCRYPTION_KEYS", "")
+	encryptMessages, err := strconv.ParseBool(getEnv("ENCRYPT_MESSAGES", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENCRYPT_MESSAGES: %w", err)
+	}
+	if encryptMessages && encryptionKeys == "" {
+		return nil, fmt.Errorf("ENCRYPT_MESSAGES requires ENCRYPTION_KEYS")
+	}
+	messageSearchFullText, err := strconv.ParseBool(getEnv("MESSAGE_SEARCH_FULLTEXT", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MESSAGE_SEARCH_FULLTEXT: %w", err)
+	}
+
+	jwtAlgorithm := strings.ToUpper(getEnv("JWT_ALG", "HS256"))
+	jwtPrivateKeyFile := getEnv("JWT_PRIVATE_KEY_FILE", "")
+	jwtPublicKeyFile := getEnv("JWT_PUBLIC_KEY_FILE", "")
+	switch jwtAlgorithm {
+	case "HS256":
+	case "RS256":
+		if jwtPrivateKeyFile == "" || jwtPublicKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE and JWT_PUBLIC_KEY_FILE are required when JWT_ALG is RS256")
+		}
+	default:
+		return nil, fmt.Errorf("invalid JWT_ALG %q: must be HS256 or RS256", jwtAlgorithm)
+	}
+
 	// Return complete configuration
 	return &Config{
 		Port:                      getEnv("PORT", "3001"),
-		Origin:                    getEnv("ORIGIN", "http://localhost:4200"),
-		Environment:               getEnv("NODE_ENV", "development"),
+		Origin:                    origin,
+		CORSAllowedMethods:        corsAllowedMethods,
+		CORSAllowedHeaders:        corsAllowedHeaders,
+		Environment:               environment,
+		ErrorDetail:               errorDetail,
 		JWTSecret:                 getEnv("JWT_SECRET", "default_jwt_secret"),
 		JWTRefreshSecret:          getEnv("JWT_REFRESH_SECRET", "default_refresh_secret"),
 		JWTPasswordReset:          getEnv("JWT_PASSWORD_SECRET", "default_password_reset_secret"),
+		JWTAlgorithm:              jwtAlgorithm,
+		JWTPrivateKeyFile:         jwtPrivateKeyFile,
+		JWTPublicKeyFile:          jwtPublicKeyFile,
 		CookieSecret:              getEnv("COOKIE_SECRET", "default_cookie_secret"),
 		Database:                  dbConfig,
 		Mailer:                    mailerConfig,
 		Google:                    googleConfig,
 		JWTExpirationMinutes:      jwtExpMinutes,
 		JWTRefreshExpirationHours: jwtRefreshExpHours,
+		RefreshIdleHours:          refreshIdleHours,
 		PasswordResetTokenExpiry:  passwordResetTokenExpiry,
 		VerificationTokenExpiry:   verificationTokenExpiry,
 		AppURL:                    getEnv("APP_URL", "http://localhost:3001"),
+		FrontendURL:               frontendURL,
+		MaxBodyBytes:              maxBodyBytes,
+		MaxUploadBytes:            maxUploadBytes,
+		MaxMultipartMemory:        maxMultipartMemory,
+		AllowedAttachmentTypes:    allowedAttachmentTypes,
+		AttachmentThumbnailSize:   attachmentThumbnailSize,
+		ReviewEditWindowHours:     reviewEditWindowHours,
+		AppointmentDurationMins:   appointmentDurationMins,
+		DefaultLocale:             strings.ToLower(getEnv("DEFAULT_LOCALE", "en")),
+		MaxPageSize:               maxPageSize,
+		MinimumAgeYears:           minimumAgeYears,
+		DoctorsCanListAllPatients: doctorsCanListAllPatients,
+		MessageArchiveAfterDays:   messageArchiveAfterDays,
+		MaxMessageLength:          maxMessageLength,
+		MaxMessageSubjectLength:   maxMessageSubjectLength,
+		MessageRatePerMinute:      messageRatePerMinute,
+		MessageRatePerHour:        messageRatePerHour,
+		StaffMessageRatePerMinute: staffMessageRatePerMinute,
+		StaffMessageRatePerHour:   staffMessageRatePerHour,
+		MessageDuplicateWindow:    messageDuplicateWindow,
+		AppointmentSweepHours:     appointmentSweepHours,
+		AppointmentSweepPolicy:    appointmentSweepPolicy,
+		ProposalExpiryHours:       proposalExpiryHours,
+		PendingConfirmationHours:  pendingConfirmationHours,
+		BlockBookingOnNoShows:     blockBookingOnNoShows,
+		NoShowLimit:               noShowLimit,
+		NoShowWindowDays:          noShowWindowDays,
+		RestrictPatientMessaging:  restrictPatientMessaging,
+		StrictRecordAccess:        strictRecordAccess,
+		MultiTenant:               multiTenant,
+		AccessTokenCookie:         accessTokenCookie,
+		CookieDomain:              getEnv("COOKIE_DOMAIN", ""),
+		CookiePath:                getEnv("COOKIE_PATH", "/"),
+		CookieSameSite:            cookieSameSite,
+		RequestTimeout:            requestTimeout,
+		UploadRequestTimeout:      uploadRequestTimeout,
+		MaxImportRows:             maxImportRows,
+		BcryptCost:                bcryptCost,
+		InvitationExpiryHours:     invitationExpiryHours,
+		TLSCertFile:               tlsCertFile,
+		TLSKeyFile:                tlsKeyFile,
+		HTTPRedirectPort:          getEnv("HTTP_REDIRECT_PORT", ""),
+		MetricsAddr:               getEnv("METRICS_ADDR", ""),
+		RecordTrashRetentionDays:  recordTrashRetentionDays,
+		AppointmentRetentionDays:  appointmentRetentionDays,
+		JobMaxAttempts:            jobMaxAttempts,
+		TokenCleanupSchedule:      strings.TrimSpace(getEnv("REFRESH_TOKEN_CLEANUP_SCHEDULE", "@hourly")),
+		ReminderSchedule:          strings.TrimSpace(getEnv("APPOINTMENT_REMINDER_SCHEDULE", "*/15 * * * *")),
+		AppointmentReminderHours:  appointmentReminderHours,
+		EncryptionKeys:            encryptionKeys,
+		EncryptionKeyID:           getEnv("ENCRYPTION_KEY_ID", ""),
+		EncryptMessages:           encryptMessages,
+		MessageSearchFullText:     messageSearchFullText,
+		DoctorCacheTTL:            doctorCacheTTL,
+		WebhookMaxAttempts:        webhookMaxAttempts,
+		WebhookDisableAfter:       webhookDisableAfter,
+		WebhookTimeout:            webhookTimeout,
+		SlowQueryThresholdMs:      slowQueryThresholdMs,
+		SlowRequestThresholdMs:    slowRequestThresholdMs,
+		SlowLogSize:               slowLogSize,
+		PolicyEnforcement:         policyEnforcement,
+		AdminEmail:                adminEmail,
+		AdminPassword:             adminPassword,
+		SeedDemo:                  seedDemo,
 	}, nil
 }
 
+// httpToken matches an HTTP token, the syntax of method and header names.
+var httpToken = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// commaList splits a comma-separated setting, dropping blanks around and between the items.
+func commaList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // Helper function to get environment variable with a default value
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
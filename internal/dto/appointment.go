@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"healthcare-app-server/internal/models"
+	"time"
+)
+
+// AppointmentResponse is an appointment as returned by the API, with the patient and doctor
+// sanitized when they were loaded.
+type AppointmentResponse struct {
+	ID                string                   `json:"id"`
+	PatientID         string                   `json:"patientId"`
+	DoctorID          string                   `json:"doctorId"`
+	StartTime         time.Time                `json:"startTime"`
+	EndTime           time.Time                `json:"endTime"`
+	Status            models.AppointmentStatus `json:"status"`
+	Reason            string                   `json:"reason"`
+	Notes             string                   `json:"notes"`
+	PrivateNotes      string                   `json:"privateNotes,omitempty"` // Cleared by the handlers for patients
+	IsFollowUp        bool                     `json:"isFollowUp"`
+	HasIntake         bool                     `json:"hasIntake"`
+	AppointmentTypeID *string                  `json:"appointmentTypeId,omitempty"`
+	AppointmentType   *models.AppointmentType  `json:"appointmentType,omitempty"`
+	Patient           *models.UserSanitized    `json:"patient,omitempty"`
+	Doctor            *models.UserSanitized    `json:"doctor,omitempty"`
+	CreatedByID       *string                  `json:"createdById,omitempty"`
+	CreatedBy         *models.UserSanitized    `json:"createdBy,omitempty"` // Who booked it, when loaded
+	CreatedAt         time.Time                `json:"createdAt"`
+	UpdatedAt         time.Time                `json:"updatedAt"`
+}
+
+// NewAppointmentResponse maps an appointment to its response.
+func NewAppointmentResponse(appointment models.Appointment) AppointmentResponse {
+	response := AppointmentResponse{
+		ID:                appointment.ID,
+		PatientID:         appointment.PatientID,
+		DoctorID:          appointment.DoctorID,
+		StartTime:         appointment.StartTime,
+		EndTime:           appointment.EndTime,
+		Status:            appointment.Status,
+		Reason:            appointment.Reason,
+		Notes:             appointment.Notes,
+		PrivateNotes:      appointment.PrivateNotes,
+		IsFollowUp:        appointment.IsFollowUp,
+		HasIntake:         appointment.HasIntake,
+		AppointmentTypeID: appointment.AppointmentTypeID,
+		AppointmentType:   appointment.AppointmentType,
+		Patient:           sanitizedUser(appointment.Patient),
+		Doctor:            sanitizedUser(appointment.Doctor),
+		CreatedByID:       appointment.CreatedByID,
+		CreatedAt:         appointment.CreatedAt,
+		UpdatedAt:         appointment.UpdatedAt,
+	}
+	if appointment.CreatedBy != nil {
+		response.CreatedBy = sanitizedUser(*appointment.CreatedBy)
+	}
+	return response
+}
+
+// NewAppointmentResponses maps a list of appointments.
+func NewAppointmentResponses(appointments []models.Appointment) []AppointmentResponse {
+	responses := make([]AppointmentResponse, len(appointments))
+	for i, appointment := range appointments {
+		responses[i] = NewAppointmentResponse(appointment)
+	}
+	return responses
+}
@@ -0,0 +1,17 @@
+// Package dto defines the JSON shapes the API returns for its core resources.
+// Handlers map models to these types instead of serializing GORM models directly,
+// so adding a column to a model does not change the public API by accident.
+package dto
+
+import (
+	"healthcare-app-server/internal/models"
+)
+
+// sanitizedUser returns the sanitized form of a preloaded relation, or nil when it was not loaded.
+func sanitizedUser(user models.User) *models.UserSanitized {
+	if user.ID == "" {
+		return nil
+	}
+	sanitized := user.Sanitize()
+	return &sanitized
+}
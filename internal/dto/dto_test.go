@@ -0,0 +1,198 @@
+package dto
+
+import (
+	"encoding/json"
+	"healthcare-app-server/internal/models"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	testTime = time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	testOrg  = "org-1"
+)
+
+func testUser(id string, role models.Role) models.User {
+	return models.User{
+		BaseModel:         models.BaseModel{ID: id, CreatedAt: testTime, UpdatedAt: testTime},
+		Email:             id + "@example.test",
+		Password:          "$2a$10$hash",
+		FirstName:         "Test",
+		LastName:          id,
+		Role:              role,
+		VerificationToken: "verification-token",
+		ResetToken:        "reset-token",
+		GoogleID:          "google-id",
+		LastLoginIP:       "203.0.113.1",
+		OrganizationID:    &testOrg,
+	}
+}
+
+// jsonKeys marshals v and returns its top-level keys, sorted.
+func jsonKeys(t *testing.T, v interface{}) (map[string]json.RawMessage, []string) {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshalling %T: %v", v, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshalling %T: %v", v, err)
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return fields, keys
+}
+
+// assertKeys fails unless v serializes with exactly want as its top-level keys.
+func assertKeys(t *testing.T, v interface{}, want ...string) map[string]json.RawMessage {
+	t.Helper()
+
+	fields, got := jsonKeys(t, v)
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("%T keys = %v, want %v", v, got, want)
+	}
+	return fields
+}
+
+// assertNoSecrets fails if the serialized form of v contains any of the values internal fields were set to.
+func assertNoSecrets(t *testing.T, v interface{}, secrets ...string) {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshalling %T: %v", v, err)
+	}
+	for _, secret := range secrets {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("%T leaks %q: %s", v, secret, data)
+		}
+	}
+}
+
+// userSecrets are the values of the user fields that must never be serialized.
+var userSecrets = []string{"$2a$10$hash", "verification-token", "reset-token", "google-id", "203.0.113.1"}
+
+func TestAppointmentResponseContract(t *testing.T) {
+	typeID, createdByID := "type-1", "admin-1"
+	createdBy := testUser(createdByID, models.RoleAdmin)
+	appointment := models.Appointment{
+		BaseModel:         models.BaseModel{ID: "appointment-1", CreatedAt: testTime, UpdatedAt: testTime},
+		PatientID:         "patient-1",
+		DoctorID:          "doctor-1",
+		OrganizationID:    &testOrg,
+		StartTime:         testTime,
+		EndTime:           testTime.Add(30 * time.Minute),
+		Status:            models.StatusConfirmed,
+		Reason:            "Checkup",
+		Notes:             "Bring results",
+		PrivateNotes:      "Watch blood pressure",
+		IsFollowUp:        true,
+		HasIntake:         true,
+		AppointmentTypeID: &typeID,
+		AppointmentType:   &models.AppointmentType{BaseModel: models.BaseModel{ID: typeID}, Name: "Consultation"},
+		CreatedByID:       &createdByID,
+		ReminderSentAt:    &testTime,
+		Patient:           testUser("patient-1", models.RolePatient),
+		Doctor:            testUser("doctor-1", models.RoleDoctor),
+		CreatedBy:         &createdBy,
+	}
+
+	response := NewAppointmentResponse(appointment)
+	assertKeys(t, response,
+		"id", "patientId", "doctorId", "startTime", "endTime", "status", "reason", "notes", "privateNotes",
+		"isFollowUp", "hasIntake", "appointmentTypeId", "appointmentType", "patient", "doctor", "createdById",
+		"createdBy", "createdAt", "updatedAt")
+	assertNoSecrets(t, response, userSecrets...)
+
+	// Optional fields drop out when unset
+	assertKeys(t, NewAppointmentResponse(models.Appointment{}),
+		"id", "patientId", "doctorId", "startTime", "endTime", "status", "reason", "notes",
+		"isFollowUp", "hasIntake", "createdAt", "updatedAt")
+}
+
+func TestMedicalRecordResponseContract(t *testing.T) {
+	record := models.MedicalRecord{
+		BaseModel:      models.BaseModel{ID: "record-1", CreatedAt: testTime, UpdatedAt: testTime},
+		PatientID:      "patient-1",
+		DoctorID:       "doctor-1",
+		OrganizationID: &testOrg,
+		RecordType:     models.RecordTypeLabResult,
+		RecordDate:     testTime,
+		Title:          "Blood panel",
+		Department:     "Cardiology",
+		Summary:        "Normal",
+		Details:        "All values within range",
+		Version:        3,
+		DeletedAt:      gorm.DeletedAt{Time: testTime, Valid: true},
+		Patient:        testUser("patient-1", models.RolePatient),
+		Doctor:         testUser("doctor-1", models.RoleDoctor),
+		Attachments: []models.MedicalRecordAttachment{{
+			BaseModel:       models.BaseModel{ID: "attachment-1", CreatedAt: testTime, UpdatedAt: testTime},
+			MedicalRecordID: "record-1",
+			FileName:        "results.pdf",
+			FileType:        "application/pdf",
+			FileData:        []byte("file-bytes"),
+			ContentHash:     "content-hash",
+			ThumbnailData:   []byte("thumbnail-bytes"),
+		}},
+		Diagnoses: []models.RecordDiagnosis{{
+			BaseModel:       models.BaseModel{ID: "diagnosis-1", CreatedAt: testTime, UpdatedAt: testTime},
+			MedicalRecordID: "record-1",
+			Code:            "E11.9",
+			Description:     "Type 2 diabetes",
+			AddedByID:       "doctor-1",
+		}},
+	}
+
+	response := NewMedicalRecordResponse(record)
+	fields := assertKeys(t, response,
+		"id", "patientId", "doctorId", "recordType", "recordDate", "date", "title", "department", "summary",
+		"details", "version", "attachments", "diagnoses", "patient", "createdAt", "updatedAt", "deletedAt")
+	if string(fields["date"]) != string(fields["recordDate"]) {
+		t.Errorf("date = %s, want it to repeat recordDate %s", fields["date"], fields["recordDate"])
+	}
+	assertNoSecrets(t, response, append(userSecrets, "content-hash", "ZmlsZS1ieXRlcw", "dGh1bWJuYWlsLWJ5dGVz")...)
+
+	assertKeys(t, response.Attachments[0],
+		"id", "medicalRecordId", "fileName", "fileType", "hasThumbnail", "createdAt", "updatedAt")
+	assertKeys(t, response.Diagnoses[0], "id", "code", "description", "addedById", "createdAt")
+
+	// Records outside the trash have no deletedAt
+	assertKeys(t, NewMedicalRecordResponse(models.MedicalRecord{}),
+		"id", "patientId", "doctorId", "recordType", "recordDate", "date", "title", "department", "summary",
+		"details", "version", "createdAt", "updatedAt")
+}
+
+func TestMessageResponseContract(t *testing.T) {
+	message := models.Message{
+		BaseModel:      models.BaseModel{ID: "message-1", CreatedAt: testTime, UpdatedAt: testTime},
+		SenderID:       "patient-1",
+		ReceiverID:     "doctor-1",
+		OrganizationID: &testOrg,
+		ConversationID: "conversation-1",
+		ParentID:       "message-0",
+		Subject:        "Results",
+		Content:        "Are my results in?",
+		Status:         models.MessageStatus("read"),
+		ReadAt:         &testTime,
+		ArchivedAt:     &testTime,
+		Sender:         testUser("patient-1", models.RolePatient),
+		Receiver:       testUser("doctor-1", models.RoleDoctor),
+	}
+
+	response := NewMessageResponse(message)
+	assertKeys(t, response,
+		"id", "senderId", "receiverId", "conversationId", "parentId", "subject", "content", "status",
+		"readAt", "archivedAt", "sender", "receiver", "createdAt", "updatedAt")
+	assertNoSecrets(t, response, userSecrets...)
+}
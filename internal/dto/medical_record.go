@@ -0,0 +1,118 @@
+package dto
+
+import (
+	"healthcare-app-server/internal/models"
+	"time"
+)
+
+// AttachmentResponse is the metadata of a medical record attachment; the file itself is downloaded separately.
+type AttachmentResponse struct {
+	ID              string    `json:"id"`
+	MedicalRecordID string    `json:"medicalRecordId"`
+	FileName        string    `json:"fileName"`
+	FileType        string    `json:"fileType"`
+	HasThumbnail    bool      `json:"hasThumbnail"` // A preview is available from the attachment's /thumbnail route
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// NewAttachmentResponse maps an attachment to its response, leaving out the file data.
+func NewAttachmentResponse(attachment models.MedicalRecordAttachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:              attachment.ID,
+		MedicalRecordID: attachment.MedicalRecordID,
+		FileName:        attachment.FileName,
+		FileType:        attachment.FileType,
+		HasThumbnail:    attachment.HasThumbnail,
+		CreatedAt:       attachment.CreatedAt,
+		UpdatedAt:       attachment.UpdatedAt,
+	}
+}
+
+// DiagnosisResponse is an ICD-10 coded diagnosis on a medical record.
+type DiagnosisResponse struct {
+	ID          string    `json:"id"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	AddedByID   string    `json:"addedById"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// NewDiagnosisResponse maps a record diagnosis to its response.
+func NewDiagnosisResponse(diagnosis models.RecordDiagnosis) DiagnosisResponse {
+	return DiagnosisResponse{
+		ID:          diagnosis.ID,
+		Code:        diagnosis.Code,
+		Description: diagnosis.Description,
+		AddedByID:   diagnosis.AddedByID,
+		CreatedAt:   diagnosis.CreatedAt,
+	}
+}
+
+// MedicalRecordResponse is a medical record as returned by the API.
+type MedicalRecordResponse struct {
+	ID         string                   `json:"id"`
+	PatientID  string                   `json:"patientId"`
+	DoctorID   string                   `json:"doctorId"`
+	RecordType models.MedicalRecordType `json:"recordType"`
+	RecordDate time.Time                `json:"recordDate"`
+	// Date repeats RecordDate under the name older clients read.
+	// Deprecated: use recordDate; date will be removed in a future release.
+	Date        time.Time             `json:"date"`
+	Title       string                `json:"title"`
+	Department  string                `json:"department"`
+	Summary     string                `json:"summary"`
+	Details     string                `json:"details"`
+	Version     int                   `json:"version"`
+	Attachments []AttachmentResponse  `json:"attachments,omitempty"`
+	Diagnoses   []DiagnosisResponse   `json:"diagnoses,omitempty"`
+	Patient     *models.UserSanitized `json:"patient,omitempty"` // When loaded
+	CreatedAt   time.Time             `json:"createdAt"`
+	UpdatedAt   time.Time             `json:"updatedAt"`
+	DeletedAt   *time.Time            `json:"deletedAt,omitempty"` // Only set for records in the trash
+}
+
+// NewMedicalRecordResponse maps a medical record, and any loaded attachments, to its response.
+func NewMedicalRecordResponse(record models.MedicalRecord) MedicalRecordResponse {
+	response := MedicalRecordResponse{
+		ID:         record.ID,
+		PatientID:  record.PatientID,
+		DoctorID:   record.DoctorID,
+		RecordType: record.RecordType,
+		RecordDate: record.RecordDate,
+		Date:       record.RecordDate,
+		Title:      record.Title,
+		Department: record.Department,
+		Summary:    record.Summary,
+		Details:    record.Details,
+		Version:    record.Version,
+		Patient:    sanitizedUser(record.Patient),
+		CreatedAt:  record.CreatedAt,
+		UpdatedAt:  record.UpdatedAt,
+	}
+	if record.DeletedAt.Valid {
+		response.DeletedAt = &record.DeletedAt.Time
+	}
+	if len(record.Diagnoses) > 0 {
+		response.Diagnoses = make([]DiagnosisResponse, len(record.Diagnoses))
+		for i, diagnosis := range record.Diagnoses {
+			response.Diagnoses[i] = NewDiagnosisResponse(diagnosis)
+		}
+	}
+	if len(record.Attachments) > 0 {
+		response.Attachments = make([]AttachmentResponse, len(record.Attachments))
+		for i, attachment := range record.Attachments {
+			response.Attachments[i] = NewAttachmentResponse(attachment)
+		}
+	}
+	return response
+}
+
+// NewMedicalRecordResponses maps a list of medical records.
+func NewMedicalRecordResponses(records []models.MedicalRecord) []MedicalRecordResponse {
+	responses := make([]MedicalRecordResponse, len(records))
+	for i, record := range records {
+		responses[i] = NewMedicalRecordResponse(record)
+	}
+	return responses
+}
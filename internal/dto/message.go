@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"healthcare-app-server/internal/models"
+	"time"
+)
+
+// MessageResponse is a message as returned by the API, with the sender and receiver
+// sanitized when they were loaded.
+type MessageResponse struct {
+	ID             string                `json:"id"`
+	SenderID       string                `json:"senderId"`
+	ReceiverID     string                `json:"receiverId"`
+	ConversationID string                `json:"conversationId"`
+	ParentID       string                `json:"parentId,omitempty"`
+	Subject        string                `json:"subject"`
+	Content        string                `json:"content"`
+	Status         models.MessageStatus  `json:"status"`
+	ReadAt         *time.Time            `json:"readAt,omitempty"`
+	ArchivedAt     *time.Time            `json:"archivedAt,omitempty"`
+	Sender         *models.UserSanitized `json:"sender,omitempty"`
+	Receiver       *models.UserSanitized `json:"receiver,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+	UpdatedAt      time.Time             `json:"updatedAt"`
+}
+
+// NewMessageResponse maps a message to its response.
+func NewMessageResponse(message models.Message) MessageResponse {
+	return MessageResponse{
+		ID:             message.ID,
+		SenderID:       message.SenderID,
+		ReceiverID:     message.ReceiverID,
+		ConversationID: message.ConversationID,
+		ParentID:       message.ParentID,
+		Subject:        message.Subject,
+		Content:        message.Content,
+		Status:         message.Status,
+		ReadAt:         message.ReadAt,
+		ArchivedAt:     message.ArchivedAt,
+		Sender:         sanitizedUser(message.Sender),
+		Receiver:       sanitizedUser(message.Receiver),
+		CreatedAt:      message.CreatedAt,
+		UpdatedAt:      message.UpdatedAt,
+	}
+}
+
+// NewMessageResponses maps a list of messages.
+func NewMessageResponses(messages []models.Message) []MessageResponse {
+	responses := make([]MessageResponse, len(messages))
+	for i, message := range messages {
+		responses[i] = NewMessageResponse(message)
+	}
+	return responses
+}
@@ -0,0 +1,221 @@
+// Package encryption encrypts protected health information at rest with AES-256-GCM.
+//
+// Every ciphertext carries the ID of the key that sealed it, so keys can be rotated:
+// new data is sealed with the primary key while the older keys stay in the ring to open
+// what they sealed until the reencrypt command has moved those rows to the primary key.
+// Values without the envelope header are treated as plaintext written before encryption
+// was enabled and are returned unchanged.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"healthcare-app-server/internal/config"
+	"strings"
+)
+
+const (
+	// envelopeMagic starts every binary ciphertext. The NUL byte keeps it from matching text,
+	// and no supported attachment type starts with it.
+	envelopeMagic = "\x00PHI1"
+	// textPrefix starts every ciphertext stored in a text column; the envelope follows in base64.
+	textPrefix = "enc:v1:"
+	keySize    = 32
+	maxKeyID   = 255
+)
+
+// ErrNoKeys is returned when encrypted data is read but no key ring is configured.
+var ErrNoKeys = errors.New("encryption: data is encrypted but no ENCRYPTION_KEYS are configured")
+
+// KeyRing holds the AES-256-GCM keys by ID and the ID of the key used for new data.
+type KeyRing struct {
+	primaryID string
+	keys      map[string]cipher.AEAD
+}
+
+// ParseKeyRing parses a comma-separated list of id:base64key pairs. Each key must decode to 32 bytes.
+// primaryID names the key new data is sealed with; empty means the first key in the list.
+func ParseKeyRing(spec, primaryID string) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encodedKey, ok := strings.Cut(entry, ":")
+		if !ok || id == "" || len(id) > maxKeyID {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS entry: expected id:base64key")
+		}
+		if _, exists := ring.keys[id]; exists {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS: key ID %q is listed twice", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS: key %q must be %d base64-encoded bytes", id, keySize)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[id] = aead
+		if ring.primaryID == "" {
+			ring.primaryID = id
+		}
+	}
+
+	if len(ring.keys) == 0 {
+		return nil, fmt.Errorf("invalid ENCRYPTION_KEYS: no keys given")
+	}
+	if primaryID != "" {
+		if _, ok := ring.keys[primaryID]; !ok {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEY_ID %q: not in ENCRYPTION_KEYS", primaryID)
+		}
+		ring.primaryID = primaryID
+	}
+	return ring, nil
+}
+
+// PrimaryKeyID returns the ID of the key new data is sealed with.
+func (r *KeyRing) PrimaryKeyID() string {
+	return r.primaryID
+}
+
+// Encrypt seals plaintext with the primary key. The result is
+// magic | key ID length | key ID | nonce | ciphertext, and the header is authenticated with the data.
+func (r *KeyRing) Encrypt(plaintext []byte) ([]byte, error) {
+	aead := r.keys[r.primaryID]
+	header := make([]byte, 0, len(envelopeMagic)+1+len(r.primaryID))
+	header = append(header, envelopeMagic...)
+	header = append(header, byte(len(r.primaryID)))
+	header = append(header, r.primaryID...)
+
+	out := make([]byte, len(header)+aead.NonceSize(), len(header)+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(out, header)
+	nonce := out[len(header):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("encryption: generating nonce: %w", err)
+	}
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// Decrypt opens data sealed by Encrypt with any key in the ring. Data without the envelope header
+// is returned as it is.
+func (r *KeyRing) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if r == nil {
+		return nil, ErrNoKeys
+	}
+
+	rest := data[len(envelopeMagic):]
+	if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+		return nil, fmt.Errorf("encryption: truncated envelope")
+	}
+	keyID := string(rest[1 : 1+int(rest[0])])
+	header := data[:len(envelopeMagic)+1+len(keyID)]
+	aead, ok := r.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("encryption: unknown key ID %q", keyID)
+	}
+
+	body := data[len(header):]
+	if len(body) < aead.NonceSize() {
+		return nil, fmt.Errorf("encryption: truncated envelope")
+	}
+	plaintext, err := aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: opening data sealed with key %q: %w", keyID, err)
+	}
+	return plaintext, nil
+}
+
+// EncryptString seals plaintext for a text column.
+func (r *KeyRing) EncryptString(plaintext string) (string, error) {
+	sealed, err := r.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return textPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString opens a value sealed by EncryptString. Values without the prefix are returned as they are.
+func (r *KeyRing) DecryptString(value string) (string, error) {
+	if !strings.HasPrefix(value, textPrefix) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(textPrefix):])
+	if err != nil || !IsEncrypted(sealed) {
+		return "", fmt.Errorf("encryption: malformed text envelope")
+	}
+	plaintext, err := r.Decrypt(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether data starts with the envelope header.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(envelopeMagic))
+}
+
+// IsEncryptedString reports whether value was sealed by EncryptString.
+func IsEncryptedString(value string) bool {
+	return strings.HasPrefix(value, textPrefix)
+}
+
+// IsCurrent reports whether data is already sealed with the primary key.
+func (r *KeyRing) IsCurrent(data []byte) bool {
+	header := append([]byte(envelopeMagic), byte(len(r.primaryID)))
+	return bytes.HasPrefix(data, append(header, r.primaryID...))
+}
+
+// IsCurrentString reports whether value is already sealed with the primary key.
+func (r *KeyRing) IsCurrentString(value string) bool {
+	if !IsEncryptedString(value) {
+		return false
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(textPrefix):])
+	return err == nil && r.IsCurrent(sealed)
+}
+
+// active is the key ring the GORM serializers use; nil leaves new data in plaintext.
+var active *KeyRing
+
+// encryptMessages controls whether the encrypted_optional serializer seals new values.
+var encryptMessages bool
+
+// Configure loads the key ring from cfg and installs it for the GORM serializers, so that a bad key
+// is reported at startup rather than on the first query. Without ENCRYPTION_KEYS nothing is encrypted.
+func Configure(cfg *config.Config) error {
+	active, encryptMessages = nil, false
+	if cfg.EncryptionKeys == "" {
+		return nil
+	}
+	ring, err := ParseKeyRing(cfg.EncryptionKeys, cfg.EncryptionKeyID)
+	if err != nil {
+		return err
+	}
+	active, encryptMessages = ring, cfg.EncryptMessages
+	return nil
+}
+
+// Active returns the configured key ring, or nil when encryption is disabled.
+func Active() *KeyRing {
+	return active
+}
+
+// MessagesEncrypted reports whether new message content is encrypted.
+func MessagesEncrypted() bool {
+	return encryptMessages
+}
@@ -0,0 +1,292 @@
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"healthcare-app-server/internal/encryption"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"strings"
+	"testing"
+)
+
+const (
+	key1 = "k1:MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE="
+	key2 = "k2:YWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXoxMjM0NTY="
+)
+
+func mustKeyRing(t testing.TB, spec, primaryID string) *encryption.KeyRing {
+	t.Helper()
+
+	ring, err := encryption.ParseKeyRing(spec, primaryID)
+	if err != nil {
+		t.Fatalf("parsing key ring %q: %v", spec, err)
+	}
+	return ring
+}
+
+func TestParseKeyRingRejectsInvalidSpecs(t *testing.T) {
+	tests := map[string]struct{ spec, primaryID string }{
+		"empty":             {"", ""},
+		"missing id":        {":MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=", ""},
+		"not base64":        {"k1:not-base64!", ""},
+		"short key":         {"k1:c2hvcnQ=", ""},
+		"duplicate id":      {key1 + "," + key1, ""},
+		"unknown primary":   {key1, "k9"},
+		"missing separator": {"MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDE=", ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := encryption.ParseKeyRing(tt.spec, tt.primaryID); err == nil {
+				t.Errorf("ParseKeyRing(%q, %q) succeeded", tt.spec, tt.primaryID)
+			}
+		})
+	}
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	ring := mustKeyRing(t, key1, "")
+
+	for name, plaintext := range map[string][]byte{
+		"empty":  {},
+		"text":   []byte("glucose 5.1 mmol/L"),
+		"binary": {0x00, 0xff, 0x10, 0x00},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sealed, err := ring.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("encrypting: %v", err)
+			}
+			if !encryption.IsEncrypted(sealed) || !ring.IsCurrent(sealed) {
+				t.Errorf("sealed data has no current envelope header")
+			}
+			if len(plaintext) > 0 && bytes.Contains(sealed, plaintext) {
+				t.Errorf("sealed data contains the plaintext")
+			}
+			opened, err := ring.Decrypt(sealed)
+			if err != nil {
+				t.Fatalf("decrypting: %v", err)
+			}
+			if !bytes.Equal(opened, plaintext) {
+				t.Errorf("decrypted %q, want %q", opened, plaintext)
+			}
+		})
+	}
+
+	sealed, err := ring.EncryptString("Are my results in?")
+	if err != nil {
+		t.Fatalf("encrypting string: %v", err)
+	}
+	if !encryption.IsEncryptedString(sealed) || !ring.IsCurrentString(sealed) {
+		t.Errorf("sealed string %q has no current envelope", sealed)
+	}
+	if opened, err := ring.DecryptString(sealed); err != nil || opened != "Are my results in?" {
+		t.Errorf("DecryptString = %q, %v", opened, err)
+	}
+}
+
+func TestDecryptPassesPlaintextThrough(t *testing.T) {
+	ring := mustKeyRing(t, key1, "")
+
+	if opened, err := ring.Decrypt([]byte("%PDF-1.7")); err != nil || string(opened) != "%PDF-1.7" {
+		t.Errorf("Decrypt(plaintext) = %q, %v", opened, err)
+	}
+	if opened, err := ring.DecryptString("hello"); err != nil || opened != "hello" {
+		t.Errorf("DecryptString(plaintext) = %q, %v", opened, err)
+	}
+
+	// Without a key ring plaintext still reads, but ciphertext must not
+	var none *encryption.KeyRing
+	if opened, err := none.Decrypt([]byte("hello")); err != nil || string(opened) != "hello" {
+		t.Errorf("nil ring Decrypt(plaintext) = %q, %v", opened, err)
+	}
+	sealed, _ := ring.Encrypt([]byte("hello"))
+	if _, err := none.Decrypt(sealed); !errors.Is(err, encryption.ErrNoKeys) {
+		t.Errorf("nil ring Decrypt(ciphertext) error = %v, want ErrNoKeys", err)
+	}
+}
+
+func TestDecryptRejectsTamperedData(t *testing.T) {
+	ring := mustKeyRing(t, key1, "")
+	sealed, err := ring.Encrypt([]byte("glucose 5.1 mmol/L"))
+	if err != nil {
+		t.Fatalf("encrypting: %v", err)
+	}
+
+	tests := map[string][]byte{
+		"flipped ciphertext bit": func() []byte {
+			tampered := append([]byte(nil), sealed...)
+			tampered[len(tampered)-1] ^= 0x01
+			return tampered
+		}(),
+		"truncated":   sealed[:len(sealed)-20],
+		"header only": sealed[:8],
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if opened, err := ring.Decrypt(data); err == nil {
+				t.Errorf("Decrypt succeeded with %q", opened)
+			}
+		})
+	}
+
+	if _, err := ring.DecryptString("enc:v1:not base64"); err == nil {
+		t.Errorf("DecryptString accepted a malformed envelope")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldRing := mustKeyRing(t, key1, "")
+	sealedWithOld, err := oldRing.Encrypt([]byte("before rotation"))
+	if err != nil {
+		t.Fatalf("encrypting: %v", err)
+	}
+
+	// After rotation k2 seals new data and k1 stays in the ring to open old data
+	rotated := mustKeyRing(t, key1+","+key2, "k2")
+	if rotated.PrimaryKeyID() != "k2" {
+		t.Fatalf("primary key = %q, want k2", rotated.PrimaryKeyID())
+	}
+	if rotated.IsCurrent(sealedWithOld) {
+		t.Errorf("data sealed with k1 reported current after rotating to k2")
+	}
+	opened, err := rotated.Decrypt(sealedWithOld)
+	if err != nil || string(opened) != "before rotation" {
+		t.Fatalf("rotated ring Decrypt(old) = %q, %v", opened, err)
+	}
+
+	resealed, err := rotated.Encrypt(opened)
+	if err != nil {
+		t.Fatalf("re-encrypting: %v", err)
+	}
+	if !rotated.IsCurrent(resealed) {
+		t.Errorf("re-encrypted data not sealed with the primary key")
+	}
+
+	// Once k1 is dropped, its data can no longer be opened, but k2's can
+	retired := mustKeyRing(t, key2, "")
+	if _, err := retired.Decrypt(sealedWithOld); err == nil {
+		t.Errorf("ring without k1 opened data sealed with k1")
+	}
+	if opened, err := retired.Decrypt(resealed); err != nil || string(opened) != "before rotation" {
+		t.Errorf("ring with only k2 Decrypt(resealed) = %q, %v", opened, err)
+	}
+}
+
+func TestKeyIDsAreBoundToTheCiphertext(t *testing.T) {
+	ring := mustKeyRing(t, key1+","+key2, "")
+	sealed, err := ring.Encrypt([]byte("hello"))
+	if err != nil {
+		t.Fatalf("encrypting: %v", err)
+	}
+	// Relabelling the envelope with the other key's ID must not open it
+	relabelled := []byte(strings.Replace(string(sealed), "k1", "k2", 1))
+	if _, err := ring.Decrypt(relabelled); err == nil {
+		t.Errorf("relabelled envelope decrypted")
+	}
+}
+
+func TestSerializerEncryptsAttachmentsAtRest(t *testing.T) {
+	cfg := testutil.NewTestConfig()
+	cfg.EncryptionKeys = key1
+	if err := encryption.Configure(cfg); err != nil {
+		t.Fatalf("configuring encryption: %v", err)
+	}
+	t.Cleanup(func() { encryption.Configure(testutil.NewTestConfig()) })
+
+	db := testutil.NewTestDB(t)
+	patient, doctor := testutil.NewTestUser(models.RolePatient), testutil.NewTestUser(models.RoleDoctor)
+	for _, user := range []*models.User{patient, doctor} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("creating user: %v", err)
+		}
+	}
+	record := models.MedicalRecord{PatientID: patient.ID, DoctorID: doctor.ID, RecordType: models.RecordTypeLabResult, Title: "Blood panel"}
+	if err := db.Create(&record).Error; err != nil {
+		t.Fatalf("creating record: %v", err)
+	}
+	plaintext := []byte("glucose 5.1 mmol/L\n")
+	attachment := models.MedicalRecordAttachment{MedicalRecordID: record.ID, FileName: "results.txt", FileType: "text/plain", FileData: plaintext, ContentHash: "hash"}
+	if err := db.Create(&attachment).Error; err != nil {
+		t.Fatalf("creating attachment: %v", err)
+	}
+
+	var stored []byte
+	if err := db.Raw("SELECT file_data FROM medical_record_attachments WHERE id = ?", attachment.ID).Row().Scan(&stored); err != nil {
+		t.Fatalf("reading raw column: %v", err)
+	}
+	if !encryption.IsEncrypted(stored) || bytes.Contains(stored, plaintext) {
+		t.Errorf("file data stored unencrypted: %q", stored)
+	}
+
+	var loaded models.MedicalRecordAttachment
+	if err := db.First(&loaded, "id = ?", attachment.ID).Error; err != nil {
+		t.Fatalf("loading attachment: %v", err)
+	}
+	if !bytes.Equal(loaded.FileData, plaintext) {
+		t.Errorf("loaded file data %q, want %q", loaded.FileData, plaintext)
+	}
+
+	// A corrupted ciphertext fails the query rather than being served
+	stored[len(stored)-1] ^= 0x01
+	if err := db.Exec("UPDATE medical_record_attachments SET file_data = ? WHERE id = ?", stored, attachment.ID).Error; err != nil {
+		t.Fatalf("corrupting column: %v", err)
+	}
+	if err := db.First(&loaded, "id = ?", attachment.ID).Error; err == nil {
+		t.Errorf("loading a corrupted attachment succeeded")
+	}
+}
+
+// fiveMegabytes is the attachment size the benchmarks measure, a typical scanned document.
+const fiveMegabytes = 5 << 20
+
+func benchmarkAttachment(b *testing.B) []byte {
+	b.Helper()
+
+	data := make([]byte, fiveMegabytes)
+	if _, err := rand.Read(data); err != nil {
+		b.Fatalf("generating attachment: %v", err)
+	}
+	return data
+}
+
+func BenchmarkEncrypt5MB(b *testing.B) {
+	ring := mustKeyRing(b, key1, "")
+	data := benchmarkAttachment(b)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ring.Encrypt(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecrypt5MB(b *testing.B) {
+	ring := mustKeyRing(b, key1, "")
+	sealed, err := ring.Encrypt(benchmarkAttachment(b))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(len(sealed)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ring.Decrypt(sealed); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPlaintextCopy5MB is the baseline: reading an unencrypted attachment still copies it out of the driver buffer.
+func BenchmarkPlaintextCopy5MB(b *testing.B) {
+	data := benchmarkAttachment(b)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = append([]byte(nil), data...)
+	}
+}
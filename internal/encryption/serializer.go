@@ -0,0 +1,79 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", Serializer{})
+	schema.RegisterSerializer("encrypted_optional", Serializer{Optional: true})
+}
+
+// Serializer is a GORM serializer that seals []byte and string fields with the active key ring
+// on write and opens them on read, so handlers work with plaintext. It is registered as
+// "encrypted" and, for data that is only encrypted when ENCRYPT_MESSAGES is on, "encrypted_optional".
+// Reading always decrypts, whatever the current settings.
+type Serializer struct {
+	Optional bool
+}
+
+// Scan implements schema.SerializerInterface. A value that cannot be decrypted fails the query
+// instead of reaching the client as ciphertext.
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	if dbValue == nil {
+		return field.Set(ctx, dst, reflect.Zero(field.FieldType).Interface())
+	}
+
+	var raw []byte
+	switch v := dbValue.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("encryption: unsupported database value %T for %s", dbValue, field.Name)
+	}
+
+	var value interface{}
+	var err error
+	switch field.FieldType.Kind() {
+	case reflect.String:
+		value, err = active.DecryptString(string(raw))
+	case reflect.Slice:
+		var plaintext []byte
+		plaintext, err = active.Decrypt(raw)
+		// The driver may reuse its buffer, so plaintext rows must be copied
+		if err == nil && !IsEncrypted(raw) {
+			plaintext = append([]byte(nil), plaintext...)
+		}
+		value = plaintext
+	default:
+		return fmt.Errorf("encryption: unsupported field type %s for %s", field.FieldType, field.Name)
+	}
+	if err != nil {
+		log.Printf("ALERT: failed to decrypt %s.%s: %v", field.Schema.Table, field.DBName, err)
+		return err
+	}
+	return field.Set(ctx, dst, value)
+}
+
+// Value implements schema.SerializerInterface.
+func (s Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	if active == nil || (s.Optional && !encryptMessages) {
+		return fieldValue, nil
+	}
+
+	switch v := fieldValue.(type) {
+	case string:
+		return active.EncryptString(v)
+	case []byte:
+		return active.Encrypt(v)
+	default:
+		return nil, fmt.Errorf("encryption: unsupported field type %T for %s", fieldValue, field.Name)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/tenancy"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// announcementBatchSize is how many recipients are loaded and messaged per database round trip.
+const announcementBatchSize = 500
+
+// AnnouncementHandler handles broadcast announcement requests (admin).
+type AnnouncementHandler struct {
+	DB *gorm.DB
+}
+
+// NewAnnouncementHandler creates a new AnnouncementHandler.
+func NewAnnouncementHandler(db *gorm.DB) *AnnouncementHandler {
+	return &AnnouncementHandler{DB: db}
+}
+
+// CreateAnnouncementRequest represents the request body for broadcasting an announcement.
+type CreateAnnouncementRequest struct {
+	TargetRole string `json:"targetRole" binding:"required,oneof=patient doctor all"`
+	Subject    string `json:"subject" binding:"max=255"`
+	Content    string `json:"content" binding:"required"`
+}
+
+// CreateAnnouncement handles an admin broadcasting a message to all patients, all doctors or everyone.
+// The announcement is stored and the request returns 202 straight away; one Message per recipient
+// is then created in the background, so thousands of recipients never hold up the request.
+func (h *AnnouncementHandler) CreateAnnouncement(c *gin.Context) {
+	var req CreateAnnouncementRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	adminID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	announcement := models.Announcement{
+		SenderID:   adminID,
+		TargetRole: req.TargetRole,
+		Subject:    req.Subject,
+		Content:    req.Content,
+		Status:     models.AnnouncementStatusPending,
+	}
+	if err := reqDB(h.DB, c).Create(&announcement).Error; err != nil {
+		utils.HandleDBError(c, err, "announcements.create_failed")
+		return
+	}
+
+	// Recipients are looked up in the sender's organization, or every organization for a super admin
+	go deliverAnnouncement(h.DB.WithContext(tenancy.Detach(c.Request.Context())), announcement)
+
+	utils.Accepted(c, "Announcement queued for delivery", announcement)
+}
+
+// GetAnnouncements handles listing sent announcements with their delivery status, newest first (admin).
+func (h *AnnouncementHandler) GetAnnouncements(c *gin.Context) {
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	var total int64
+	if err := reqDB(h.DB, c).Model(&models.Announcement{}).Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "announcements.fetch_failed")
+		return
+	}
+
+	var announcements []models.Announcement
+	if err := reqDB(h.DB, c).Order("created_at desc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&announcements).Error; err != nil {
+		utils.HandleDBError(c, err, "announcements.fetch_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Announcements fetched successfully", announcements, pagination.Meta(total))
+}
+
+// deliverAnnouncement fans an announcement out as one message per targeted user, in batches,
+// and records the outcome on the announcement. It runs outside the request, so errors are logged.
+func deliverAnnouncement(db *gorm.DB, announcement models.Announcement) {
+	recipients := db.Model(&models.User{}).Select("id").Where("id <> ?", announcement.SenderID)
+	if announcement.TargetRole != "all" {
+		// Roles have been stored in both cases over time
+		recipients = recipients.Where("role = ?", announcement.TargetRole)
+	}
+
+	var delivered int64
+	var users []models.User
+	err := recipients.FindInBatches(&users, announcementBatchSize, func(tx *gorm.DB, batch int) error {
+		messages := make([]models.Message, len(users))
+		for i, user := range users {
+			messages[i] = models.Message{
+				SenderID:   announcement.SenderID,
+				ReceiverID: user.ID,
+				Subject:    announcement.Subject,
+				Content:    announcement.Content,
+				Status:     models.MessageStatusSent,
+			}
+		}
+		if err := db.Create(&messages).Error; err != nil {
+			return err
+		}
+		delivered += int64(len(messages))
+		return nil
+	}).Error
+
+	updates := map[string]interface{}{"recipient_count": delivered}
+	if err != nil {
+		log.Printf("Failed to deliver announcement %s after %d recipients: %v", announcement.ID, delivered, err)
+		updates["status"] = models.AnnouncementStatusFailed
+	} else {
+		updates["status"] = models.AnnouncementStatusSent
+		updates["delivered_at"] = time.Now()
+	}
+	if err := db.Model(&models.Announcement{}).Where("id = ?", announcement.ID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record delivery of announcement %s: %v", announcement.ID, err)
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"healthcare-app-server/internal/webhooks"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CancelDoctorAppointmentsRequest represents the request body for cancelling a doctor's appointments in a date range.
+type CancelDoctorAppointmentsRequest struct {
+	From   time.Time `json:"from" binding:"required"`
+	To     time.Time `json:"to" binding:"required"`
+	Reason string    `json:"reason" binding:"required,max=255"` // Stored as the appointments' notes and sent to the patients
+}
+
+// CancelDoctorAppointmentsResult summarises a bulk cancellation.
+type CancelDoctorAppointmentsResult struct {
+	Cancelled      int      `json:"cancelled"`
+	AppointmentIDs []string `json:"appointmentIds"`
+}
+
+// CancelDoctorAppointments handles cancelling every pending or confirmed appointment of a doctor starting
+// in [from, to), e.g. when the doctor goes on leave (admin). The status updates happen in one transaction;
+// the affected patients are messaged in the background afterwards, so a slow or failed notification
+// never holds up or undoes the cancellation.
+func (h *AppointmentHandler) CancelDoctorAppointments(c *gin.Context) {
+	var req CancelDoctorAppointmentsRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	if !req.To.After(req.From) {
+		utils.BadRequest(c, "appointments.invalid_cancel_range")
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		utils.BadRequest(c, "appointments.cancel_reason_required")
+		return
+	}
+
+	doctorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_doctor_id")
+		return
+	}
+	var doctor models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", doctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "appointments.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	var appointments []models.Appointment
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("doctor_id = ? AND status IN ? AND start_time >= ? AND start_time < ?",
+			doctor.ID, []models.AppointmentStatus{models.StatusPending, models.StatusConfirmed}, req.From, req.To).
+			Order("start_time asc").
+			Find(&appointments).Error; err != nil {
+			return err
+		}
+		for i := range appointments {
+			appointments[i].Status = models.StatusCancelled
+			appointments[i].Notes = reason
+			if err := tx.Model(&appointments[i]).Updates(map[string]interface{}{
+				"status": models.StatusCancelled,
+				"notes":  reason,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "appointments.bulk_cancel_failed")
+		return
+	}
+
+	result := CancelDoctorAppointmentsResult{Cancelled: len(appointments), AppointmentIDs: []string{}}
+	for _, appointment := range appointments {
+		result.AppointmentIDs = append(result.AppointmentIDs, appointment.ID)
+		h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookAppointmentCancelled, webhooks.NewAppointmentData(appointment))
+	}
+	if len(appointments) > 0 {
+		// h.DB rather than reqDB(h.DB, c): the request context is cancelled once the response is written
+		go notifyBulkCancellation(h.DB, doctor, appointments, reason)
+	}
+
+	utils.Success(c, "Appointments cancelled successfully", result)
+}
+
+// notifyBulkCancellation messages the patient of each cancelled appointment. Failures are logged, not returned.
+func notifyBulkCancellation(db *gorm.DB, doctor models.User, appointments []models.Appointment, reason string) {
+	for _, appointment := range appointments {
+		message := models.Message{
+			SenderID:   doctor.ID,
+			ReceiverID: appointment.PatientID,
+			Subject:    "Your appointment has been cancelled",
+			Content: fmt.Sprintf("Your appointment with Dr. %s %s on %s has been cancelled: %s\nPlease book another time.",
+				doctor.FirstName, doctor.LastName, appointment.StartTime.UTC().Format("2006-01-02 15:04 MST"), reason),
+			Status: models.MessageStatusSent,
+		}
+		if err := db.Create(&message).Error; err != nil {
+			log.Printf("Failed to notify patient %s of cancelled appointment %s: %v", appointment.PatientID, appointment.ID, err)
+		}
+	}
+}
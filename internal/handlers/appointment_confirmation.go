@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"healthcare-app-server/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ConfirmAppointment handles the appointment's patient confirming a pending appointment they booked, or one
+// proposed to them, which moves it to confirmed. With PENDING_CONFIRMATION_HOURS set, pending appointments
+// the patient does not confirm in time are cancelled. Doctors and admins confirm through UpdateAppointmentStatus.
+func (h *AppointmentHandler) ConfirmAppointment(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_appointment_id")
+		return
+	}
+
+	var appointment models.Appointment
+	if err := reqDB(h.DB, c).First(&appointment, "id = ?", appointmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.appointment_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if userID != appointment.PatientID {
+		utils.Forbidden(c, "appointments.confirm_patient_only")
+		return
+	}
+
+	currentStatus := appointment.Status.Normalize()
+	if currentStatus != models.StatusPending && currentStatus != models.StatusProposed {
+		utils.BadRequest(c, "appointments.invalid_status_transition", utils.Params{"from": string(currentStatus), "to": string(models.StatusConfirmed)})
+		return
+	}
+
+	// Only confirmed while the status is unchanged, so an appointment the expiry job cancels at the same moment stays cancelled
+	result := reqDB(h.DB, c).Model(&models.Appointment{}).
+		Where("id = ? AND status = ?", appointment.ID, appointment.Status).
+		Update("status", models.StatusConfirmed)
+	if result.Error != nil {
+		utils.HandleDBError(c, result.Error, "appointments.status_update_failed")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Conflict(c, "appointments.status_changed")
+		return
+	}
+	appointment.Status = models.StatusConfirmed
+
+	h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookAppointmentConfirmed, webhooks.NewAppointmentData(appointment))
+	if currentStatus == models.StatusProposed {
+		notifyProposalAnswer(reqDB(h.DB, c), appointment, true)
+	}
+
+	redactAppointmentForRole(&appointment, userRole)
+	utils.Success(c, "Appointment confirmed successfully", dto.NewAppointmentResponse(appointment))
+}
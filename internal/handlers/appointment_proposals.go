@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/models"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// isProposal reports whether an appointment booked by creatorID for patientID waits for the patient to accept
+// it: doctors and admins booking on a patient's behalf propose, patients booking themselves do not.
+func isProposal(creatorRole models.Role, creatorID, patientID string) bool {
+	return creatorRole != models.RolePatient && creatorID != patientID
+}
+
+// notifyProposal messages the patient that creator proposed the appointment with doctor. Failures are logged.
+func notifyProposal(db *gorm.DB, creator, doctor models.User, appointment models.Appointment) {
+	message := models.Message{
+		SenderID:   creator.ID,
+		ReceiverID: appointment.PatientID,
+		Subject:    "Please confirm your appointment",
+		Content: fmt.Sprintf("%s %s booked an appointment for you with Dr. %s %s on %s.\n"+
+			"Please confirm or decline it in your appointments; it is cancelled if it is not confirmed in time.",
+			creator.FirstName, creator.LastName, doctor.FirstName, doctor.LastName,
+			appointment.StartTime.UTC().Format("2006-01-02 15:04 MST")),
+		Status: models.MessageStatusSent,
+	}
+	if err := db.Create(&message).Error; err != nil {
+		log.Printf("Failed to notify patient %s of proposed appointment %s: %v", appointment.PatientID, appointment.ID, err)
+	}
+}
+
+// notifyProposalAnswer messages whoever proposed the appointment that the patient accepted or declined it.
+// Failures are logged.
+func notifyProposalAnswer(db *gorm.DB, appointment models.Appointment, accepted bool) {
+	if appointment.CreatedByID == nil {
+		return
+	}
+	answer, subject := "declined", "Proposed appointment declined"
+	if accepted {
+		answer, subject = "confirmed", "Proposed appointment confirmed"
+	}
+	message := models.Message{
+		SenderID:   appointment.PatientID,
+		ReceiverID: *appointment.CreatedByID,
+		Subject:    subject,
+		Content: fmt.Sprintf("The patient %s the appointment proposed for %s.",
+			answer, appointment.StartTime.UTC().Format("2006-01-02 15:04 MST")),
+		Status: models.MessageStatusSent,
+	}
+	if err := db.Create(&message).Error; err != nil {
+		log.Printf("Failed to notify %s of the answer to proposed appointment %s: %v", *appointment.CreatedByID, appointment.ID, err)
+	}
+}
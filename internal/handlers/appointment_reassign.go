@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
+	"healthcare-app-server/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Reassignment scopes: which of the departing doctor's future appointments are moved.
+const (
+	reassignScopeAll       = "all"       // Every appointment still going to happen: pending, confirmed and rescheduled
+	reassignScopeConfirmed = "confirmed" // Only confirmed appointments
+)
+
+// Reasons an appointment could not be moved to the target doctor.
+const (
+	reassignSkippedSlot    = "slot_taken"  // The target doctor's slot is full
+	reassignSkippedDay     = "daily_limit" // The target doctor's day is full
+	reassignSkippedTimeOff = "time_off"    // The target doctor is off at that time
+)
+
+// ReassignDoctorRequest represents the request body for moving a doctor's future appointments to another doctor.
+type ReassignDoctorRequest struct {
+	TargetDoctorID string     `json:"targetDoctorId" binding:"required,uuid"`
+	Scope          string     `json:"scope" binding:"omitempty,oneof=all confirmed"` // Defaults to all
+	After          *time.Time `json:"after"`                                         // Only appointments starting after this time; defaults to now
+}
+
+// UnmovedAppointment is an appointment left with the departing doctor because it conflicts with the target doctor's schedule.
+type UnmovedAppointment struct {
+	Appointment dto.AppointmentResponse `json:"appointment"`
+	Reason      string                  `json:"reason"`
+}
+
+// ReassignDoctorResult lists the appointments that were moved and those left unmoved.
+type ReassignDoctorResult struct {
+	Moved   []dto.AppointmentResponse `json:"moved"`
+	Unmoved []UnmovedAppointment      `json:"unmoved"`
+}
+
+// ReassignDoctorAppointments handles moving a departing doctor's future appointments to another doctor (admin).
+// Each appointment that fits the target doctor's booking limits and time off is moved, its patient is
+// messaged and an AppointmentReassignment is recorded, all in one transaction. Appointments that do not
+// fit stay with the original doctor and are returned with the reason. Medical records are not touched,
+// so they keep the doctor who wrote them.
+func (h *AppointmentHandler) ReassignDoctorAppointments(c *gin.Context) {
+	var req ReassignDoctorRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	fromDoctorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_doctor_id")
+		return
+	}
+	if req.TargetDoctorID == fromDoctorID.String() {
+		utils.BadRequest(c, "appointments.reassign_same_doctor")
+		return
+	}
+
+	// The departing doctor may already have been demoted, so only the target must still be a doctor
+	var fromDoctor models.User
+	if err := reqDB(h.DB, c).First(&fromDoctor, "id = ?", fromDoctorID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	var toDoctor models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", req.TargetDoctorID, models.RoleDoctor).First(&toDoctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "appointments.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	after := time.Now().UTC()
+	if req.After != nil && req.After.After(after) {
+		after = req.After.UTC()
+	}
+	statuses := scheduling.BlockingStatuses
+	if req.Scope == reassignScopeConfirmed {
+		statuses = []models.AppointmentStatus{models.StatusConfirmed}
+	}
+
+	adminID, _ := middleware.GetUserIDFromContext(c)
+	result := ReassignDoctorResult{Moved: []dto.AppointmentResponse{}, Unmoved: []UnmovedAppointment{}}
+
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		var appointments []models.Appointment
+		if err := tx.Preload("Patient").Preload("AppointmentType").
+			Where("doctor_id = ? AND status IN ? AND start_time > ?", fromDoctor.ID, statuses, after).
+			Order("start_time asc").
+			Find(&appointments).Error; err != nil {
+			return err
+		}
+
+		for _, appointment := range appointments {
+			// Checked inside the transaction so appointments moved earlier in the loop count against the limits
+			reason, err := reassignConflict(tx, toDoctor.ID, appointment)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				result.Unmoved = append(result.Unmoved, UnmovedAppointment{Appointment: dto.NewAppointmentResponse(appointment), Reason: reason})
+				continue
+			}
+
+			if err := tx.Model(&appointment).Update("doctor_id", toDoctor.ID).Error; err != nil {
+				return err
+			}
+			appointment.DoctorID = toDoctor.ID
+			if err := tx.Create(&models.AppointmentReassignment{
+				AppointmentID:  appointment.ID,
+				FromDoctorID:   fromDoctor.ID,
+				ToDoctorID:     toDoctor.ID,
+				ReassignedByID: adminID,
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(reassignmentMessage(fromDoctor, toDoctor, appointment)).Error; err != nil {
+				return err
+			}
+			result.Moved = append(result.Moved, dto.NewAppointmentResponse(appointment))
+		}
+		return nil
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "appointments.reassign_failed")
+		return
+	}
+
+	utils.Success(c, "Appointments reassigned successfully", result)
+}
+
+// reassignConflict returns why appointment cannot move to the target doctor, or "" when it fits.
+func reassignConflict(db *gorm.DB, toDoctorID string, appointment models.Appointment) (string, error) {
+	limit, _, err := scheduling.CheckCapacity(db, toDoctorID, appointment.StartTime, appointment.EndTime, appointment.ID)
+	if err != nil {
+		return "", err
+	}
+	switch limit {
+	case scheduling.LimitSlot:
+		return reassignSkippedSlot, nil
+	case scheduling.LimitDay:
+		return reassignSkippedDay, nil
+	}
+
+	offDuty, err := scheduling.IsDuringTimeOff(db, toDoctorID, appointment.StartTime, appointment.EndTime)
+	if err != nil {
+		return "", err
+	}
+	if offDuty {
+		return reassignSkippedTimeOff, nil
+	}
+	return "", nil
+}
+
+// reassignmentMessage is the message telling a patient their appointment is now with another doctor.
+func reassignmentMessage(fromDoctor, toDoctor models.User, appointment models.Appointment) *models.Message {
+	return &models.Message{
+		SenderID:   toDoctor.ID,
+		ReceiverID: appointment.PatientID,
+		Subject:    "Your appointment has a new doctor",
+		Content: fmt.Sprintf("Dr. %s %s is no longer available, so your appointment on %s will be with Dr. %s %s instead.",
+			fromDoctor.FirstName, fromDoctor.LastName, appointment.StartTime.UTC().Format("2006-01-02 15:04 MST"),
+			toDoctor.FirstName, toDoctor.LastName),
+		Status: models.MessageStatusSent,
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PurgeAppointmentsResult describes a purge started on demand.
+type PurgeAppointmentsResult struct {
+	Cutoff time.Time `json:"cutoff"` // Finished appointments that started before this are deleted
+}
+
+// PurgeOldAppointments handles deleting finished appointments past APPOINTMENT_RETENTION_DAYS now rather
+// than at the next daily run (admin). The purge runs in the background, as a large backlog takes longer
+// than a request may; how many appointments were deleted is logged.
+func (h *AppointmentHandler) PurgeOldAppointments(c *gin.Context) {
+	if h.Cfg.AppointmentRetentionDays <= 0 {
+		utils.BadRequest(c, "appointments.retention_disabled")
+		return
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -h.Cfg.AppointmentRetentionDays)
+	// h.DB rather than reqDB(h.DB, c): the request context is cancelled once the response is written
+	go func() {
+		if _, err := jobs.PurgeOldAppointments(h.DB, cutoff); err != nil {
+			log.Printf("Failed to purge old appointments: %v", err)
+		}
+	}()
+
+	utils.Accepted(c, "Appointment purge started", PurgeAppointmentsResult{Cutoff: cutoff})
+}
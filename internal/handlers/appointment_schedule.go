@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
+	"healthcare-app-server/internal/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScheduleGap is a free stretch between two busy periods of a doctor's day.
+type ScheduleGap struct {
+	StartTime       time.Time `json:"startTime"`
+	EndTime         time.Time `json:"endTime"`
+	DurationMinutes int       `json:"durationMinutes"`
+}
+
+// DayScheduleResponse is a doctor's calendar for one day.
+type DayScheduleResponse struct {
+	Date         string                    `json:"date"`
+	DoctorID     string                    `json:"doctorId"`
+	Timezone     string                    `json:"timezone"` // IANA name the day's boundaries were taken in
+	Appointments []dto.AppointmentResponse `json:"appointments"`
+	TimeOff      []models.TimeOff          `json:"timeOff"`
+	Gaps         []ScheduleGap             `json:"gaps"`
+}
+
+// WeekScheduleResponse is a doctor's calendar for the seven days from StartDate, keyed by date.
+type WeekScheduleResponse struct {
+	StartDate string                         `json:"startDate"`
+	DoctorID  string                         `json:"doctorId"`
+	Timezone  string                         `json:"timezone"`
+	Days      map[string]DayScheduleResponse `json:"days"`
+}
+
+// scheduleDateLayout is the format of the date parameter and of the dates in schedule responses.
+const scheduleDateLayout = "2006-01-02"
+
+// busyPeriod is a stretch of a doctor's day taken by an appointment or time off.
+type busyPeriod struct {
+	start, end time.Time
+}
+
+// GetDaySchedule handles fetching a doctor's appointments for one day (`date`, YYYY-MM-DD, defaulting
+// to today) ordered by start time, with the free gaps between them. Doctors see their own day; admins
+// must name the doctor with `doctorId`. The day runs from midnight to midnight in `tz` (an IANA name),
+// or else in the timezone of the doctor's profile, or else in UTC, so days on which the clock changes
+// are 23 or 25 hours long. `range=week` returns the seven days from `date`, keyed by date.
+func (h *AppointmentHandler) GetDaySchedule(c *gin.Context) {
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	days := 1
+	switch c.Query("range") {
+	case "", "day":
+	case "week":
+		days = 7
+	default:
+		utils.BadRequest(c, "appointments.invalid_schedule_range")
+		return
+	}
+
+	doctorID := userID
+	if userRole.IsAdmin() {
+		parsed, err := uuid.Parse(c.Query("doctorId"))
+		if err != nil {
+			utils.BadRequest(c, "appointments.schedule_doctor_required")
+			return
+		}
+		var doctor models.User
+		if err := reqDB(h.DB, c).Where("id = ? AND role = ?", parsed, models.RoleDoctor).First(&doctor).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				utils.NotFound(c, "appointments.doctor_not_found")
+			} else {
+				utils.HandleDBError(c, err, "common.database_error")
+			}
+			return
+		}
+		doctorID = doctor.ID
+	}
+
+	location, ok := scheduleLocation(c, reqDB(h.DB, c), doctorID)
+	if !ok {
+		return
+	}
+
+	now := time.Now().In(location)
+	firstDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.ParseInLocation(scheduleDateLayout, dateStr, location)
+		if err != nil {
+			utils.BadRequest(c, "appointments.invalid_schedule_date")
+			return
+		}
+		firstDay = parsed
+	}
+	// Adding calendar days rather than hours keeps midnight across clock changes
+	rangeEnd := firstDay.AddDate(0, 0, days)
+
+	var appointments []models.Appointment
+	if err := reqDB(h.DB, c).Preload("Patient").Preload("AppointmentType").
+		Where("doctor_id = ? AND start_time >= ? AND start_time < ?", doctorID, firstDay.UTC(), rangeEnd.UTC()).
+		Order("start_time asc").
+		Find(&appointments).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_schedule_failed")
+		return
+	}
+
+	timeOff, err := scheduling.OverlappingTimeOff(reqDB(h.DB, c), doctorID, firstDay.UTC(), rangeEnd.UTC(), "")
+	if err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_schedule_failed")
+		return
+	}
+
+	redactAppointmentsForRole(appointments, userRole)
+	if days == 1 {
+		utils.Success(c, "Schedule fetched successfully", daySchedule(doctorID, firstDay, appointments, timeOff))
+		return
+	}
+
+	week := WeekScheduleResponse{
+		StartDate: firstDay.Format(scheduleDateLayout),
+		DoctorID:  doctorID,
+		Timezone:  location.String(),
+		Days:      make(map[string]DayScheduleResponse, days),
+	}
+	for day := firstDay; day.Before(rangeEnd); day = day.AddDate(0, 0, 1) {
+		week.Days[day.Format(scheduleDateLayout)] = daySchedule(doctorID, day, appointments, timeOff)
+	}
+	utils.Success(c, "Schedule fetched successfully", week)
+}
+
+// scheduleLocation returns the timezone a schedule's days are taken in: the `tz` query parameter, else the
+// doctor's profile timezone, else UTC. It writes a 400 response for an unknown `tz`.
+func scheduleLocation(c *gin.Context, db *gorm.DB, doctorID string) (*time.Location, bool) {
+	name := c.Query("tz")
+	if name == "" {
+		var profile models.DoctorProfile
+		err := db.Select("timezone").Where("doctor_id = ?", doctorID).First(&profile).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			utils.HandleDBError(c, err, "appointments.fetch_schedule_failed")
+			return nil, false
+		}
+		if profile.Timezone == "" {
+			return time.UTC, true
+		}
+		// Profile timezones were validated when saved
+		if location, err := time.LoadLocation(profile.Timezone); err == nil {
+			return location, true
+		}
+		return time.UTC, true
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		utils.BadRequest(c, "appointments.invalid_schedule_timezone", utils.Params{"tz": name})
+		return nil, false
+	}
+	return location, true
+}
+
+// daySchedule builds the schedule of the day starting at dayStart from the appointments and time off
+// of a longer range, keeping the appointments that start that day and the time off that overlaps it.
+func daySchedule(doctorID string, dayStart time.Time, appointments []models.Appointment, timeOff []models.TimeOff) DayScheduleResponse {
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	dayAppointments := []models.Appointment{}
+	for _, appointment := range appointments {
+		if !appointment.StartTime.Before(dayStart) && appointment.StartTime.Before(dayEnd) {
+			dayAppointments = append(dayAppointments, appointment)
+		}
+	}
+	dayTimeOff := []models.TimeOff{}
+	for _, block := range timeOff {
+		if block.StartTime.Before(dayEnd) && block.EndTime.After(dayStart) {
+			dayTimeOff = append(dayTimeOff, block)
+		}
+	}
+
+	return DayScheduleResponse{
+		Date:         dayStart.Format(scheduleDateLayout),
+		DoctorID:     doctorID,
+		Timezone:     dayStart.Location().String(),
+		Appointments: dto.NewAppointmentResponses(dayAppointments),
+		TimeOff:      dayTimeOff,
+		Gaps:         scheduleGaps(dayAppointments, dayTimeOff, dayStart, dayEnd),
+	}
+}
+
+// scheduleGaps returns the free stretches between the first and last busy period of the day.
+// Only appointments that still occupy the calendar count as busy, together with time off clipped
+// to the day; overlapping periods are merged first. Time before the first and after the last
+// busy period is not reported, as it depends on working hours rather than on the bookings.
+func scheduleGaps(appointments []models.Appointment, timeOff []models.TimeOff, dayStart, dayEnd time.Time) []ScheduleGap {
+	var busy []busyPeriod
+	for _, appointment := range appointments {
+		if !isBlockingStatus(appointment.Status) {
+			continue
+		}
+		end := appointment.EndTime
+		if end.Before(appointment.StartTime) {
+			end = appointment.StartTime // Stored without an end time, see scheduling.ConflictingAppointments
+		}
+		busy = append(busy, busyPeriod{start: appointment.StartTime, end: end})
+	}
+	for _, block := range timeOff {
+		start, end := block.StartTime, block.EndTime
+		if start.Before(dayStart) {
+			start = dayStart
+		}
+		if end.After(dayEnd) {
+			end = dayEnd
+		}
+		busy = append(busy, busyPeriod{start: start, end: end})
+	}
+	sort.Slice(busy, func(i, j int) bool { return busy[i].start.Before(busy[j].start) })
+
+	gaps := []ScheduleGap{}
+	for i := 0; i < len(busy); i++ {
+		// Extend the current period over everything that overlaps it
+		end := busy[i].end
+		for i+1 < len(busy) && !busy[i+1].start.After(end) {
+			i++
+			if busy[i].end.After(end) {
+				end = busy[i].end
+			}
+		}
+		if i+1 < len(busy) {
+			next := busy[i+1].start
+			gaps = append(gaps, ScheduleGap{
+				StartTime:       end,
+				EndTime:         next,
+				DurationMinutes: int(next.Sub(end) / time.Minute),
+			})
+		}
+	}
+	return gaps
+}
+
+// isBlockingStatus reports whether an appointment with status still occupies the doctor's calendar.
+func isBlockingStatus(status models.AppointmentStatus) bool {
+	for _, blocking := range scheduling.BlockingStatuses {
+		if strings.EqualFold(string(status), string(blocking)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AppointmentStats summarizes a set of appointments for dashboards.
+type AppointmentStats struct {
+	Total int64 `json:"total"`
+	// Appointments per status; statuses without appointments are left out
+	ByStatus       map[models.AppointmentStatus]int64 `json:"byStatus"`
+	CompletedCount int64                              `json:"completedCount"`
+	NoShowCount    int64                              `json:"noShowCount"`
+	// Completed appointments over those with an outcome (completed or no-show), 0 when there are none
+	CompletionRate float64 `json:"completionRate"`
+}
+
+// DoctorNoShowStats is the no-show rate of one doctor's appointments.
+// The rate is taken over appointments with an outcome, i.e. completed or no-show.
+type DoctorNoShowStats struct {
+	DoctorID      string  `json:"doctorId"`
+	FirstName     string  `json:"firstName"`
+	LastName      string  `json:"lastName"`
+	FinishedCount int64   `json:"finishedCount"`
+	NoShowCount   int64   `json:"noShowCount"`
+	NoShowRate    float64 `json:"noShowRate"`
+}
+
+// GetNoShowStats handles reporting the no-show rate per doctor, highest first (admin).
+func (h *AppointmentHandler) GetNoShowStats(c *gin.Context) {
+	var stats []DoctorNoShowStats
+	if err := reqDB(h.DB, c).Model(&models.Appointment{}).
+		Select("appointments.doctor_id, users.first_name, users.last_name, "+
+			"COUNT(*) AS finished_count, SUM(CASE WHEN appointments.status = ? THEN 1 ELSE 0 END) AS no_show_count", models.StatusNoShow).
+		Joins("JOIN users ON users.id = appointments.doctor_id").
+		Where("appointments.status IN ?", []models.AppointmentStatus{models.StatusCompleted, models.StatusNoShow}).
+		Group("appointments.doctor_id, users.first_name, users.last_name").
+		Scan(&stats).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_stats_failed")
+		return
+	}
+
+	for i := range stats {
+		if stats[i].FinishedCount > 0 {
+			stats[i].NoShowRate = float64(stats[i].NoShowCount) / float64(stats[i].FinishedCount)
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].NoShowRate > stats[j].NoShowRate })
+
+	utils.Success(c, "No-show statistics fetched successfully", stats)
+}
+
+// GetAppointmentStats handles counting appointments by status, optionally limited to those starting
+// between `from` and `to` (RFC3339 or YYYY-MM-DD). Patients count their own appointments and doctors
+// their own patients'; `doctorId` narrows patients and admins to one doctor's appointments. The counts
+// come from one grouped query.
+func (h *AppointmentHandler) GetAppointmentStats(c *gin.Context) {
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	query := reqDB(h.DB, c).Model(&models.Appointment{})
+	switch {
+	case userRole.IsAdmin():
+		// Admins count every appointment
+	case userRole == models.RoleDoctor:
+		query = query.Where("doctor_id = ?", userID)
+	default:
+		query = query.Where("patient_id = ?", userID)
+	}
+
+	if doctorIDStr := c.Query("doctorId"); doctorIDStr != "" {
+		doctorID, err := uuid.Parse(doctorIDStr)
+		if err != nil {
+			utils.BadRequest(c, "common.invalid_doctor_id")
+			return
+		}
+		if userRole == models.RoleDoctor && doctorID.String() != userID {
+			utils.Forbidden(c, "appointments.stats_forbidden")
+			return
+		}
+		var doctors int64
+		if err := reqDB(h.DB, c).Model(&models.User{}).Where("id = ? AND role = ?", doctorID, models.RoleDoctor).Count(&doctors).Error; err != nil {
+			utils.HandleDBError(c, err, "common.database_error")
+			return
+		}
+		if doctors == 0 {
+			utils.NotFound(c, "appointments.doctor_not_found")
+			return
+		}
+		query = query.Where("doctor_id = ?", doctorID)
+	}
+
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			utils.BadRequest(c, "appointments.invalid_export_from")
+			return
+		}
+		query = query.Where("start_time >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			utils.BadRequest(c, "appointments.invalid_export_to")
+			return
+		}
+		query = query.Where("start_time <= ?", toTime)
+	}
+
+	var counts []struct {
+		Status models.AppointmentStatus
+		Count  int64
+	}
+	if err := query.Select("status, COUNT(*) AS count").Group("status").Scan(&counts).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_stats_failed")
+		return
+	}
+
+	stats := AppointmentStats{ByStatus: make(map[models.AppointmentStatus]int64, len(counts))}
+	for _, count := range counts {
+		stats.ByStatus[count.Status] += count.Count
+		stats.Total += count.Count
+	}
+	stats.CompletedCount = stats.ByStatus[models.StatusCompleted]
+	stats.NoShowCount = stats.ByStatus[models.StatusNoShow]
+	if finished := stats.CompletedCount + stats.NoShowCount; finished > 0 {
+		stats.CompletionRate = float64(stats.CompletedCount) / float64(finished)
+	}
+
+	utils.Success(c, "Appointment statistics fetched successfully", stats)
+}
+
+// PatientNoShows is how often a patient missed appointments recently.
+type PatientNoShows struct {
+	PatientID   string `json:"patientId"`
+	NoShowCount int64  `json:"noShowCount"` // No-shows among the appointments that started in the window
+	WindowDays  int    `json:"windowDays"`
+	// More no-shows than NO_SHOW_LIMIT; with BLOCK_BOOKING_ON_NO_SHOWS the patient cannot book themselves
+	Frequent bool `json:"frequent"`
+}
+
+// recentNoShows counts the no-shows of patientID among the appointments that started in the last windowDays days.
+func recentNoShows(db *gorm.DB, patientID string, windowDays int) (int64, error) {
+	var count int64
+	err := db.Model(&models.Appointment{}).
+		Where("patient_id = ? AND status = ? AND start_time >= ?", patientID, models.StatusNoShow, time.Now().AddDate(0, 0, -windowDays)).
+		Count(&count).Error
+	return count, err
+}
+
+// GetPatientNoShows handles reporting a patient's recent no-shows, so staff can spot frequent ones.
+// Patients see their own; doctors and admins any patient's.
+func (h *AppointmentHandler) GetPatientNoShows(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patientId"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_patient_id")
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canViewPatientRecords(userRole, userID, patientID.String()) && !userRole.IsAdmin() {
+		utils.Forbidden(c, "appointments.no_shows_forbidden")
+		return
+	}
+	if !ensureUserInOrganization(c, reqDB(h.DB, c), patientID.String(), "common.patient_not_found") {
+		return
+	}
+
+	count, err := recentNoShows(reqDB(h.DB, c), patientID.String(), h.Cfg.NoShowWindowDays)
+	if err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_stats_failed")
+		return
+	}
+
+	utils.Success(c, "No-shows fetched successfully", PatientNoShows{
+		PatientID:   patientID.String(),
+		NoShowCount: count,
+		WindowDays:  h.Cfg.NoShowWindowDays,
+		Frequent:    count > int64(h.Cfg.NoShowLimit),
+	})
+}
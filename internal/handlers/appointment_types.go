@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AppointmentTypeHandler handles appointment type related requests.
+type AppointmentTypeHandler struct {
+	DB *gorm.DB
+}
+
+// NewAppointmentTypeHandler creates a new AppointmentTypeHandler.
+func NewAppointmentTypeHandler(db *gorm.DB) *AppointmentTypeHandler {
+	return &AppointmentTypeHandler{DB: db}
+}
+
+// CreateAppointmentTypeRequest represents the request body for creating an appointment type.
+type CreateAppointmentTypeRequest struct {
+	Name                   string `json:"name" binding:"required,max=100"`
+	Description            string `json:"description"`
+	DefaultDurationMinutes int    `json:"defaultDurationMinutes" binding:"required,min=5,max=480"`
+	Color                  string `json:"color" binding:"omitempty,hexcolor"`
+	Active                 *bool  `json:"active"` // Defaults to true
+}
+
+// UpdateAppointmentTypeRequest represents the request body for updating an appointment type.
+// Only the fields present in the body are changed.
+type UpdateAppointmentTypeRequest struct {
+	Name                   *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description            *string `json:"description"`
+	DefaultDurationMinutes *int    `json:"defaultDurationMinutes" binding:"omitempty,min=5,max=480"`
+	Color                  *string `json:"color" binding:"omitempty,hexcolor"`
+	Active                 *bool   `json:"active"`
+}
+
+// AppointmentTypeCount holds the number of appointments booked with a type.
+type AppointmentTypeCount struct {
+	AppointmentTypeID *string `json:"appointmentTypeId"` // nil for appointments booked without a type
+	Name              string  `json:"name"`
+	Count             int64   `json:"count"`
+}
+
+// GetAppointmentTypes handles listing appointment types for the booking UI.
+// Only active types are returned, unless an admin asks for ?includeInactive=true.
+func (h *AppointmentTypeHandler) GetAppointmentTypes(c *gin.Context) {
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	isAdmin := userRole.IsAdmin()
+
+	query := reqDB(h.DB, c).Order("name asc")
+	if !(isAdmin && c.Query("includeInactive") == "true") {
+		query = query.Where("active = ?", true)
+	}
+
+	var appointmentTypes []models.AppointmentType
+	if err := query.Find(&appointmentTypes).Error; err != nil {
+		utils.HandleDBError(c, err, "appointment_types.fetch_failed")
+		return
+	}
+
+	utils.Success(c, "Appointment types fetched successfully", appointmentTypes)
+}
+
+// CreateAppointmentType handles creating an appointment type (admin).
+func (h *AppointmentTypeHandler) CreateAppointmentType(c *gin.Context) {
+	var req CreateAppointmentTypeRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	appointmentType := models.AppointmentType{
+		Name:                   strings.TrimSpace(req.Name),
+		Description:            req.Description,
+		DefaultDurationMinutes: req.DefaultDurationMinutes,
+		Color:                  req.Color,
+		Active:                 req.Active == nil || *req.Active,
+	}
+
+	if err := reqDB(h.DB, c).Create(&appointmentType).Error; err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.Conflict(c, "appointment_types.name_taken")
+		} else {
+			utils.HandleDBError(c, err, "appointment_types.create_failed")
+		}
+		return
+	}
+
+	utils.Created(c, "Appointment type created successfully", appointmentType)
+}
+
+// UpdateAppointmentType handles updating an appointment type (admin).
+// Changing the duration only affects appointments booked afterwards.
+func (h *AppointmentTypeHandler) UpdateAppointmentType(c *gin.Context) {
+	appointmentTypeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "appointment_types.invalid_id")
+		return
+	}
+
+	var req UpdateAppointmentTypeRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	var appointmentType models.AppointmentType
+	if err := reqDB(h.DB, c).First(&appointmentType, "id = ?", appointmentTypeID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "appointment_types.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	if req.Name != nil {
+		appointmentType.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		appointmentType.Description = *req.Description
+	}
+	if req.DefaultDurationMinutes != nil {
+		appointmentType.DefaultDurationMinutes = *req.DefaultDurationMinutes
+	}
+	if req.Color != nil {
+		appointmentType.Color = *req.Color
+	}
+	if req.Active != nil {
+		appointmentType.Active = *req.Active
+	}
+
+	if err := reqDB(h.DB, c).Save(&appointmentType).Error; err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.Conflict(c, "appointment_types.name_taken")
+		} else {
+			utils.HandleDBError(c, err, "appointment_types.update_failed")
+		}
+		return
+	}
+
+	utils.Success(c, "Appointment type updated successfully", appointmentType)
+}
+
+// DeleteAppointmentType handles deleting an unused appointment type (admin).
+// Types already referenced by appointments must be deactivated instead so reporting stays intact.
+func (h *AppointmentTypeHandler) DeleteAppointmentType(c *gin.Context) {
+	appointmentTypeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "appointment_types.invalid_id")
+		return
+	}
+
+	var usage int64
+	if err := reqDB(h.DB, c).Model(&models.Appointment{}).Where("appointment_type_id = ?", appointmentTypeID).Count(&usage).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if usage > 0 {
+		utils.Conflict(c, "appointment_types.in_use")
+		return
+	}
+
+	result := reqDB(h.DB, c).Delete(&models.AppointmentType{}, "id = ?", appointmentTypeID)
+	if result.Error != nil {
+		utils.HandleDBError(c, result.Error, "appointment_types.delete_failed")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.NotFound(c, "appointment_types.not_found")
+		return
+	}
+
+	utils.Success(c, "Appointment type deleted successfully", nil)
+}
+
+// GetAppointmentTypeStats handles counting appointments grouped by type (admin).
+func (h *AppointmentTypeHandler) GetAppointmentTypeStats(c *gin.Context) {
+	counts, err := appointmentCountsByType(reqDB(h.DB, c))
+	if err != nil {
+		utils.HandleDBError(c, err, "appointment_types.fetch_failed")
+		return
+	}
+
+	utils.Success(c, "Appointment counts by type fetched successfully", counts)
+}
+
+// appointmentCountsByType counts appointments per appointment type in a single grouped query.
+// Appointments without a type are reported with a nil ID and an empty name.
+func appointmentCountsByType(db *gorm.DB) ([]AppointmentTypeCount, error) {
+	var counts []AppointmentTypeCount
+	err := db.Model(&models.Appointment{}).
+		Select("appointments.appointment_type_id, COALESCE(appointment_types.name, '') AS name, COUNT(*) AS count").
+		Joins("LEFT JOIN appointment_types ON appointment_types.id = appointments.appointment_type_id").
+		Group("appointments.appointment_type_id, appointment_types.name").
+		Order("count desc").
+		Scan(&counts).Error
+	return counts, err
+}
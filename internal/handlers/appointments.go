@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/metrics"
 	"healthcare-app-server/internal/middleware"
 	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
 	"healthcare-app-server/internal/utils"
+	"healthcare-app-server/internal/webhooks"
+	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,12 +23,80 @@ import (
 
 // AppointmentHandler handles appointment related requests.
 type AppointmentHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Cfg      *config.Config
+	Webhooks *webhooks.Dispatcher // Receives the appointment.* events
 }
 
 // NewAppointmentHandler creates a new AppointmentHandler.
-func NewAppointmentHandler(db *gorm.DB) *AppointmentHandler {
-	return &AppointmentHandler{DB: db}
+func NewAppointmentHandler(db *gorm.DB, cfg *config.Config, webhooks *webhooks.Dispatcher) *AppointmentHandler {
+	return &AppointmentHandler{DB: db, Cfg: cfg, Webhooks: webhooks}
+}
+
+// defaultDuration returns the configured length of an appointment.
+func (h *AppointmentHandler) defaultDuration() time.Duration {
+	return time.Duration(h.Cfg.AppointmentDurationMins) * time.Minute
+}
+
+// canSeePrivateNotes reports whether the role may read and edit appointment private notes.
+func canSeePrivateNotes(role models.Role) bool {
+	return role == models.RoleDoctor || role.IsAdmin()
+}
+
+// redactAppointmentsForRole clears doctor-only fields from appointments returned to other roles.
+func redactAppointmentsForRole(appointments []models.Appointment, role models.Role) {
+	if canSeePrivateNotes(role) {
+		return
+	}
+	for i := range appointments {
+		appointments[i].PrivateNotes = ""
+	}
+}
+
+// redactAppointmentForRole clears doctor-only fields from a single appointment returned to other roles.
+func redactAppointmentForRole(appointment *models.Appointment, role models.Role) {
+	if !canSeePrivateNotes(role) {
+		appointment.PrivateNotes = ""
+	}
+}
+
+// checkBookingCapacity enforces the doctor's per-slot and per-day appointment limits, writing a 409 that
+// names the limit that was hit. Admins can book past both limits with override=true.
+func (h *AppointmentHandler) checkBookingCapacity(c *gin.Context, doctorID string, start, end time.Time, excludeAppointmentID string) bool {
+	override := false
+	if overrideStr := c.Query("override"); overrideStr != "" {
+		var err error
+		if override, err = strconv.ParseBool(overrideStr); err != nil {
+			utils.BadRequest(c, "appointments.invalid_override_flag")
+			return false
+		}
+	}
+	if override {
+		userRole, _ := middleware.GetUserRoleFromContext(c)
+		if !userRole.IsAdmin() {
+			utils.Forbidden(c, "appointments.override_forbidden")
+			return false
+		}
+		return true
+	}
+
+	limit, max, err := scheduling.CheckCapacity(reqDB(h.DB, c), doctorID, start, end, excludeAppointmentID)
+	if err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return false
+	}
+	switch {
+	case limit == scheduling.LimitSlot && max == 1:
+		utils.Conflict(c, "appointments.slot_taken")
+		return false
+	case limit == scheduling.LimitSlot:
+		utils.Conflict(c, "appointments.slot_full", utils.Params{"max": strconv.Itoa(max)})
+		return false
+	case limit == scheduling.LimitDay:
+		utils.Conflict(c, "appointments.daily_limit_reached", utils.Params{"max": strconv.Itoa(max)})
+		return false
+	}
+	return true
 }
 
 // CreateAppointmentRequest represents the request body for creating an appointment.
@@ -29,92 +104,183 @@ type CreateAppointmentRequest struct {
 	DoctorID  string    `json:"doctorId" binding:"required,uuid"`
 	PatientID string    `json:"patientId" binding:"required,uuid"` // Should be set from authenticated user (patient)
 	StartTime time.Time `json:"startTime" binding:"required"`
-	Reason    string    `json:"reason" binding:"required"`
-	Notes     string    `json:"notes"`
+	// Optional appointment type; its default duration sets the end time
+	AppointmentTypeID string `json:"appointmentTypeId" binding:"omitempty,uuid"`
+	// Free-text reason, required unless an appointment type is given
+	Reason string `json:"reason" binding:"required_without=AppointmentTypeID,max=255"`
+	Notes  string `json:"notes" binding:"max=5000"`
 }
 
 // CreateAppointment handles creating a new appointment.
-// Typically initiated by a patient.
+// Typically initiated by a patient. When a doctor or admin books for a patient, the appointment is
+// proposed instead: the patient is messaged and must confirm it, or it expires after PROPOSAL_EXPIRY_HOURS.
 func (h *AppointmentHandler) CreateAppointment(c *gin.Context) {
 	var req CreateAppointmentRequest
 	if !utils.BindAndValidate(c, &req) {
 		return
 	}
+	req.StartTime = req.StartTime.UTC() // Clients may send any offset; times are stored and returned in UTC
 
 	patientIDStr, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "Patient ID not found in token")
+		utils.Unauthorized(c, "common.unauthenticated")
 		return
 	}
 	// Ensure the patient ID from token matches the one in request, or that requestor is an admin/doctor booking for patient
 	requestingUserRole, _ := middleware.GetUserRoleFromContext(c)
 	if requestingUserRole == models.RolePatient && patientIDStr != req.PatientID {
-		utils.Forbidden(c, "Patients can only book appointments for themselves.")
+		utils.Forbidden(c, "appointments.book_for_self_only")
 		return
 	}
 
 	patientID, err := uuid.Parse(req.PatientID)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Patient ID format")
+		utils.BadRequest(c, "common.invalid_patient_id")
 		return
 	}
 	doctorID, err := uuid.Parse(req.DoctorID)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Doctor ID format")
+		utils.BadRequest(c, "common.invalid_doctor_id")
 		return
 	}
 
 	// Verify doctor exists and is a doctor
 	var doctor models.User
-	if err := h.DB.Where("id = ? AND role = ?", doctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", doctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Doctor not found or user is not a doctor")
+			utils.NotFound(c, "appointments.doctor_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error verifying doctor: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
 	// Verify patient exists
 	var patient models.User
-	if err := h.DB.Where("id = ? AND role = ?", patientID, models.RolePatient).First(&patient).Error; err != nil {
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", patientID, models.RolePatient).First(&patient).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Patient not found")
+			utils.NotFound(c, "common.patient_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error verifying patient: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
 
 	// Basic validation for appointment time (e.g., not in the past)
 	if req.StartTime.Before(time.Now()) {
-		utils.BadRequest(c, "Appointment date must be in the future.")
+		utils.BadRequest(c, "appointments.date_in_past")
 		return
 	}
 
-	// TODO: Add more complex validation (e.g., doctor availability, no overlapping appointments)
+	// Patients who keep missing appointments may have to book through the clinic
+	if requestingUserRole == models.RolePatient && h.Cfg.BlockBookingOnNoShows {
+		noShows, err := recentNoShows(reqDB(h.DB, c), patient.ID, h.Cfg.NoShowWindowDays)
+		if err != nil {
+			utils.HandleDBError(c, err, "common.database_error")
+			return
+		}
+		if noShows > int64(h.Cfg.NoShowLimit) {
+			utils.Forbidden(c, "appointments.booking_blocked_no_shows")
+			return
+		}
+	}
+
+	duration := h.defaultDuration()
+	var appointmentTypeID *string
+	if req.AppointmentTypeID != "" {
+		var appointmentType models.AppointmentType
+		if err := reqDB(h.DB, c).Where("id = ? AND active = ?", req.AppointmentTypeID, true).First(&appointmentType).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				utils.NotFound(c, "appointment_types.not_found")
+			} else {
+				utils.HandleDBError(c, err, "common.database_error")
+			}
+			return
+		}
+		duration = time.Duration(appointmentType.DefaultDurationMinutes) * time.Minute
+		appointmentTypeID = &appointmentType.ID
+	}
+	endTime := req.StartTime.Add(duration)
+
+	// Reject double-booking of the doctor beyond their slot and daily capacity
+	if !h.checkBookingCapacity(c, doctor.ID, req.StartTime, endTime, "") {
+		return
+	}
+	onTimeOff, err := scheduling.IsDuringTimeOff(reqDB(h.DB, c), doctor.ID, req.StartTime, endTime)
+	if err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if onTimeOff {
+		utils.Conflict(c, "appointments.doctor_time_off")
+		return
+	}
 
 	appointment := models.Appointment{
-		PatientID: req.PatientID, // Directly assign as string
-		DoctorID:  req.DoctorID,  // Directly assign as string
-		StartTime: req.StartTime,
-		Reason:    req.Reason,
-		Notes:     req.Notes,
-		Status:    models.StatusPending, // Default status
+		PatientID:         req.PatientID, // Directly assign as string
+		DoctorID:          req.DoctorID,  // Directly assign as string
+		StartTime:         req.StartTime,
+		EndTime:           endTime,
+		Reason:            req.Reason,
+		Notes:             req.Notes,
+		Status:            models.StatusPending, // Default status
+		AppointmentTypeID: appointmentTypeID,
+		CreatedByID:       &patientIDStr, // The requesting user, who may be a doctor or admin booking for the patient
+	}
+	proposed := isProposal(requestingUserRole, patientIDStr, patient.ID)
+	if proposed {
+		appointment.Status = models.StatusProposed
 	}
 
-	if err := h.DB.Create(&appointment).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create appointment: "+err.Error())
+	if err := reqDB(h.DB, c).Create(&appointment).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.create_failed")
 		return
 	}
+	h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookAppointmentCreated, webhooks.NewAppointmentData(appointment))
+	metrics.AppointmentsCreated.Inc()
 
-	utils.Created(c, "Appointment created successfully", appointment)
+	if proposed {
+		var creator models.User
+		if err := reqDB(h.DB, c).First(&creator, "id = ?", patientIDStr).Error; err != nil {
+			log.Printf("Failed to load creator %s of proposed appointment %s: %v", patientIDStr, appointment.ID, err)
+		} else {
+			notifyProposal(reqDB(h.DB, c), creator, doctor, appointment)
+		}
+		utils.Created(c, "Appointment proposed to the patient", dto.NewAppointmentResponse(appointment))
+		return
+	}
+	utils.Created(c, "Appointment created successfully", dto.NewAppointmentResponse(appointment))
+}
+
+// appointmentListFields are the fields the appointment list can be narrowed to with `fields`.
+var appointmentListFields = utils.FieldSpec{
+	Required: []string{"id", "patientId", "doctorId"},
+	Columns:  []string{"id", "patient_id", "doctor_id"},
+	Optional: map[string][]string{
+		"startTime":         {"start_time"},
+		"endTime":           {"end_time"},
+		"status":            {"status"},
+		"reason":            {"reason"},
+		"notes":             {"notes"},
+		"privateNotes":      {"private_notes"},
+		"isFollowUp":        {"is_follow_up"},
+		"hasIntake":         {"has_intake"},
+		"appointmentTypeId": {"appointment_type_id"},
+		"appointmentType":   {"appointment_type_id"},
+		"patient":           nil,
+		"doctor":            nil,
+		"createdById":       {"created_by_id"},
+		"createdBy":         {"created_by_id"},
+		"createdAt":         {"created_at"},
+		"updatedAt":         {"updated_at"},
+	},
 }
 
 // GetAppointmentsForUser handles fetching appointments for the logged-in user (patient or doctor).
+// `fields` narrows the response to the listed fields; the IDs are always returned.
 func (h *AppointmentHandler) GetAppointmentsForUser(c *gin.Context) {
 	userIDStr, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "User not authenticated")
+		utils.Unauthorized(c, "common.unauthenticated")
 		return
 	}
 
@@ -123,31 +289,143 @@ func (h *AppointmentHandler) GetAppointmentsForUser(c *gin.Context) {
 	// Debug: Log the role that was extracted from the context
 	c.Writer.Header().Set("X-Debug-User-Role", string(userRole))
 
-	// Normalize user role to handle case sensitivity issues
-	userRoleLower := strings.ToLower(string(userRole))
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+	fields, ok := utils.ParseFields(c, appointmentListFields)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.Appointment{})
+
+	switch userRole {
+	case models.RolePatient:
+		query = query.Where("patient_id = ?", userIDStr)
+	case models.RoleDoctor:
+		query = query.Where("doctor_id = ?", userIDStr)
+	case models.RoleAdmin, models.RoleSuperAdmin: // Admins can see all appointments
+		// No filter
+	default:
+		utils.Forbidden(c, "appointments.role_not_permitted", utils.Params{"role": string(userRole)})
+		return
+	}
 
-	var appointments []models.Appointment
-	var err error
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_failed")
+		return
+	}
 
-	query := h.DB.Preload("Patient").Preload("Doctor").Order("start_time asc")
+	if !fields.All() {
+		query = query.Select(fields.Columns())
+	}
+	for field, relation := range map[string]string{"patient": "Patient", "doctor": "Doctor", "appointmentType": "AppointmentType", "createdBy": "CreatedBy"} {
+		if fields.Has(field) {
+			query = query.Preload(relation)
+		}
+	}
 
-	if userRoleLower == string(models.RolePatient) || userRoleLower == "user" || userRoleLower == "patient" {
-		err = query.Where("patient_id = ?", userIDStr).Find(&appointments).Error
-	} else if userRoleLower == string(models.RoleDoctor) || userRoleLower == "doctor" {
-		err = query.Where("doctor_id = ?", userIDStr).Find(&appointments).Error
-	} else if userRoleLower == string(models.RoleAdmin) || userRoleLower == "admin" { // Admins can see all appointments
-		err = query.Find(&appointments).Error
-	} else {
-		utils.Forbidden(c, "User role not permitted to view appointments this way. Role: "+string(userRole))
+	var appointments []models.Appointment
+	if err := query.Order("start_time asc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&appointments).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_failed")
 		return
 	}
 
+	redactAppointmentsForRole(appointments, userRole)
+	response, err := fields.Apply(dto.NewAppointmentResponses(appointments))
 	if err != nil {
-		utils.InternalServerError(c, "Failed to fetch appointments: "+err.Error())
+		utils.InternalServerError(c, "appointments.fetch_failed")
 		return
 	}
+	utils.SuccessWithMeta(c, "Appointments fetched successfully", response, pagination.Meta(total))
 
-	utils.Success(c, "Appointments fetched successfully", appointments)
+}
+
+// GetAppointmentsForPatient handles fetching one patient's appointments for the patient detail view.
+// Doctors see only the patient's appointments with them, and only once the two have an appointment or
+// medical record together; admins see all of the patient's appointments. `status` (comma-separated)
+// and `from`/`to` on the start time narrow the list.
+func (h *AppointmentHandler) GetAppointmentsForPatient(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patientId"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_patient_id")
+		return
+	}
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+	if !ensureUserInOrganization(c, reqDB(h.DB, c), patientID.String(), "common.patient_not_found") {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.Appointment{}).Where("patient_id = ?", patientID)
+	if !userRole.IsAdmin() {
+		related, err := hasCareRelationship(reqDB(h.DB, c), patientID.String(), userID)
+		if err != nil {
+			utils.HandleDBError(c, err, "appointments.fetch_failed")
+			return
+		}
+		if !related {
+			utils.Forbidden(c, "appointments.patient_forbidden")
+			return
+		}
+		query = query.Where("doctor_id = ?", userID)
+	}
+
+	if status := c.Query("status"); status != "" {
+		var statuses []models.AppointmentStatus
+		for _, value := range strings.Split(status, ",") {
+			s := models.AppointmentStatus(strings.TrimSpace(value)).Normalize()
+			if !s.IsValid() {
+				utils.BadRequest(c, "appointments.invalid_status_filter", utils.Params{"status": value})
+				return
+			}
+			statuses = append(statuses, s)
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			utils.BadRequest(c, "appointments.invalid_from")
+			return
+		}
+		query = query.Where("start_time >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			utils.BadRequest(c, "appointments.invalid_to")
+			return
+		}
+		query = query.Where("start_time <= ?", toTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_failed")
+		return
+	}
+
+	var appointments []models.Appointment
+	if err := query.Preload("Patient").Preload("Doctor").Preload("AppointmentType").Preload("CreatedBy").
+		Order("start_time asc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&appointments).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_failed")
+		return
+	}
+
+	redactAppointmentsForRole(appointments, userRole)
+	utils.SuccessWithMeta(c, "Appointments fetched successfully", dto.NewAppointmentResponses(appointments), pagination.Meta(total))
 }
 
 // GetAppointmentByID handles fetching a single appointment by its ID.
@@ -156,16 +434,16 @@ func (h *AppointmentHandler) GetAppointmentByID(c *gin.Context) {
 	appointmentIDStr := c.Param("id")
 	appointmentID, err := uuid.Parse(appointmentIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Appointment ID format")
+		utils.BadRequest(c, "common.invalid_appointment_id")
 		return
 	}
 
 	var appointment models.Appointment
-	if err := h.DB.Preload("Patient").Preload("Doctor").First(&appointment, "id = ?", appointmentID).Error; err != nil {
+	if err := reqDB(h.DB, c).Preload("Patient").Preload("Doctor").Preload("AppointmentType").Preload("CreatedBy").First(&appointment, "id = ?", appointmentID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Appointment not found")
+			utils.NotFound(c, "common.appointment_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
@@ -176,18 +454,21 @@ func (h *AppointmentHandler) GetAppointmentByID(c *gin.Context) {
 	isPatientInvolved := userIDStr == appointment.PatientID
 	isDoctorInvolved := userIDStr == appointment.DoctorID
 
-	if userRole != models.RoleAdmin && !isPatientInvolved && !isDoctorInvolved {
-		utils.Forbidden(c, "You are not authorized to view this appointment")
+	if !userRole.IsAdmin() && !isPatientInvolved && !isDoctorInvolved {
+		utils.Forbidden(c, "appointments.view_forbidden")
 		return
 	}
 
-	utils.Success(c, "Appointment fetched successfully", appointment)
+	redactAppointmentForRole(&appointment, userRole)
+	utils.Success(c, "Appointment fetched successfully", dto.NewAppointmentResponse(appointment))
 }
 
 // UpdateAppointmentStatusRequest represents the request body for updating an appointment's status.
 type UpdateAppointmentStatusRequest struct {
-	Status models.AppointmentStatus `json:"status" binding:"required,oneof=PENDING CONFIRMED CANCELLED COMPLETED"`
-	Notes  string                   `json:"notes"` // Optional notes for status change (e.g., cancellation reason)
+	Status models.AppointmentStatus `json:"status" binding:"required,appointment_status"`
+	Notes  string                   `json:"notes" binding:"max=5000"` // Optional notes for status change (e.g., cancellation reason)
+	// Optional doctor-only notes; rejected for patients
+	PrivateNotes string `json:"privateNotes" binding:"max=5000"`
 }
 
 // UpdateAppointmentStatus handles updating the status of an appointment.
@@ -196,7 +477,7 @@ func (h *AppointmentHandler) UpdateAppointmentStatus(c *gin.Context) {
 	appointmentIDStr := c.Param("id")
 	appointmentID, err := uuid.Parse(appointmentIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Appointment ID format")
+		utils.BadRequest(c, "common.invalid_appointment_id")
 		return
 	}
 
@@ -204,13 +485,14 @@ func (h *AppointmentHandler) UpdateAppointmentStatus(c *gin.Context) {
 	if !utils.BindAndValidate(c, &req) {
 		return
 	}
+	req.Status = req.Status.Normalize()
 
 	var appointment models.Appointment
-	if err := h.DB.First(&appointment, "id = ?", appointmentID).Error; err != nil {
+	if err := reqDB(h.DB, c).First(&appointment, "id = ?", appointmentID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Appointment not found")
+			utils.NotFound(c, "common.appointment_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
@@ -219,30 +501,55 @@ func (h *AppointmentHandler) UpdateAppointmentStatus(c *gin.Context) {
 	userRole, _ := middleware.GetUserRoleFromContext(c)
 
 	// Authorization logic:
-	// - Patient can cancel their own appointments (if status allows)
-	// - Doctor can update status for their appointments
-	// - Admin can update any appointment
+	// - Patient can cancel their own appointments (if status allows), and confirm or decline proposed ones
+	// - Doctor can update status for their appointments, but only the patient can confirm a proposal
+	// - Admin can update any appointment, with the same exception
+	currentStatus := appointment.Status.Normalize()
+	wasProposed := currentStatus == models.StatusProposed
+	if wasProposed && req.Status == models.StatusConfirmed && userIDStr != appointment.PatientID {
+		utils.Forbidden(c, "appointments.proposal_patient_only")
+		return
+	}
 	canUpdate := false
-	if userRole == models.RoleAdmin {
+	if userRole.IsAdmin() {
 		canUpdate = true
 	} else if userRole == models.RoleDoctor && userIDStr == appointment.DoctorID {
 		canUpdate = true
 	} else if userRole == models.RolePatient && userIDStr == appointment.PatientID {
-		// Patients can only cancel, and only if it's currently scheduled or confirmed
-		if req.Status == models.StatusCancelled &&
-			(appointment.Status == models.StatusPending || appointment.Status == models.StatusConfirmed) {
+		// Patients answer proposals; otherwise they can only cancel, and only if it's currently scheduled or confirmed
+		if wasProposed && (req.Status == models.StatusConfirmed || req.Status == models.StatusCancelled) {
+			canUpdate = true
+		} else if req.Status == models.StatusCancelled &&
+			(currentStatus == models.StatusPending || currentStatus == models.StatusConfirmed) {
 			canUpdate = true
 		} else if req.Status != models.StatusCancelled {
-			utils.Forbidden(c, "Patients can only cancel appointments.")
+			utils.Forbidden(c, "appointments.patient_cancel_only")
 			return
 		}
 	}
 
 	if !canUpdate {
-		utils.Forbidden(c, "You are not authorized to update this appointment's status or perform this status transition.")
+		utils.Forbidden(c, "appointments.status_forbidden")
 		return
 	}
 
+	if req.PrivateNotes != "" && !canSeePrivateNotes(userRole) {
+		utils.Forbidden(c, "appointments.private_notes_forbidden")
+		return
+	}
+
+	if !appointment.Status.CanTransitionTo(req.Status) {
+		utils.BadRequest(c, "appointments.invalid_status_transition", utils.Params{"from": string(appointment.Status.Normalize()), "to": string(req.Status)})
+		return
+	}
+	// A patient can only fail to turn up once the appointment has begun
+	if req.Status == models.StatusNoShow && time.Now().Before(appointment.StartTime) {
+		utils.BadRequest(c, "appointments.no_show_before_start")
+		return
+	}
+
+	wasCancelled := req.Status == models.StatusCancelled
+
 	appointment.Status = req.Status
 	if req.Notes != "" {
 		// Uncomment the preferred behavior:
@@ -251,19 +558,41 @@ func (h *AppointmentHandler) UpdateAppointmentStatus(c *gin.Context) {
 		// Or append to existing notes:
 		// appointment.Notes += "\nStatus Update: " + req.Notes
 	}
+	if req.PrivateNotes != "" {
+		appointment.PrivateNotes = req.PrivateNotes
+	}
 
-	if err := h.DB.Save(&appointment).Error; err != nil {
-		utils.InternalServerError(c, "Failed to update appointment status: "+err.Error())
+	if err := reqDB(h.DB, c).Save(&appointment).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.status_update_failed")
 		return
 	}
+	switch req.Status {
+	case models.StatusConfirmed:
+		h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookAppointmentConfirmed, webhooks.NewAppointmentData(appointment))
+	case models.StatusCancelled:
+		h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookAppointmentCancelled, webhooks.NewAppointmentData(appointment))
+	}
+	if wasProposed && userIDStr == appointment.PatientID {
+		notifyProposalAnswer(reqDB(h.DB, c), appointment, req.Status == models.StatusConfirmed)
+	}
 
-	utils.Success(c, "Appointment status updated successfully", appointment)
+	// Let patients waiting for this doctor know the slot is free again
+	if wasCancelled {
+		slotEnd := appointment.EndTime
+		if !slotEnd.After(appointment.StartTime) {
+			slotEnd = appointment.StartTime.Add(h.defaultDuration())
+		}
+		notifyWaitlistOfOpenSlot(reqDB(h.DB, c), appointment.DoctorID, appointment.StartTime, slotEnd)
+	}
+
+	redactAppointmentForRole(&appointment, userRole)
+	utils.Success(c, "Appointment status updated successfully", dto.NewAppointmentResponse(appointment))
 }
 
 // RescheduleAppointmentRequest represents the request body for rescheduling an appointment.
 type RescheduleAppointmentRequest struct {
 	NewAppointmentAt time.Time `json:"newAppointmentAt" binding:"required"`
-	Notes            string    `json:"notes"` // Optional notes for rescheduling
+	Notes            string    `json:"notes" binding:"max=5000"` // Optional notes for rescheduling
 }
 
 // RescheduleAppointment handles rescheduling an appointment.
@@ -272,7 +601,7 @@ func (h *AppointmentHandler) RescheduleAppointment(c *gin.Context) {
 	appointmentIDStr := c.Param("id")
 	appointmentID, err := uuid.Parse(appointmentIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Appointment ID format")
+		utils.BadRequest(c, "common.invalid_appointment_id")
 		return
 	}
 
@@ -280,18 +609,19 @@ func (h *AppointmentHandler) RescheduleAppointment(c *gin.Context) {
 	if !utils.BindAndValidate(c, &req) {
 		return
 	}
+	req.NewAppointmentAt = req.NewAppointmentAt.UTC()
 
 	if req.NewAppointmentAt.Before(time.Now()) {
-		utils.BadRequest(c, "New appointment date must be in the future.")
+		utils.BadRequest(c, "appointments.new_date_in_past")
 		return
 	}
 
 	var appointment models.Appointment
-	if err := h.DB.First(&appointment, "id = ?", appointmentID).Error; err != nil {
+	if err := reqDB(h.DB, c).First(&appointment, "id = ?", appointmentID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Appointment not found")
+			utils.NotFound(c, "common.appointment_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
@@ -303,34 +633,123 @@ func (h *AppointmentHandler) RescheduleAppointment(c *gin.Context) {
 	// For simplicity, let's say only Doctor involved or Admin can reschedule.
 	// Patient might need to cancel and re-book.
 	canReschedule := false
-	if userRole == models.RoleAdmin {
+	if userRole.IsAdmin() {
 		canReschedule = true
 	} else if userRole == models.RoleDoctor && userIDStr == appointment.DoctorID {
 		canReschedule = true
 	} else if userRole == models.RolePatient && userIDStr == appointment.PatientID {
 		// Allow patient to reschedule if appointment is not too soon or already passed
-		if appointment.Status == models.StatusPending || appointment.Status == models.StatusConfirmed {
+		currentStatus := appointment.Status.Normalize()
+		if currentStatus == models.StatusPending || currentStatus == models.StatusConfirmed {
 			// Add more conditions, e.g., cannot reschedule within 24 hours of appointment time
 			canReschedule = true
 		}
 	}
 
 	if !canReschedule {
-		utils.Forbidden(c, "You are not authorized to reschedule this appointment.")
+		utils.Forbidden(c, "appointments.reschedule_forbidden")
+		return
+	}
+	if !appointment.Status.CanTransitionTo(models.StatusRescheduled) {
+		utils.BadRequest(c, "appointments.invalid_status_transition", utils.Params{"from": string(appointment.Status.Normalize()), "to": string(models.StatusRescheduled)})
+		return
+	}
+	// Keep the appointment's length; older appointments without an end time get the default length
+	duration := appointment.EndTime.Sub(appointment.StartTime)
+	if duration <= 0 {
+		duration = h.defaultDuration()
+	}
+	newEndTime := req.NewAppointmentAt.Add(duration)
+
+	if !h.checkBookingCapacity(c, appointment.DoctorID, req.NewAppointmentAt, newEndTime, appointment.ID) {
 		return
 	}
+	onTimeOff, err := scheduling.IsDuringTimeOff(reqDB(h.DB, c), appointment.DoctorID, req.NewAppointmentAt, newEndTime)
+	if err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if onTimeOff {
+		utils.Conflict(c, "appointments.doctor_time_off")
+		return
+	}
+
 	// Update the existing appointment object instead of creating a new one
-	appointment.StartTime = req.NewAppointmentAt  // Assuming NewAppointmentAt maps to StartTime
+	appointment.StartTime = req.NewAppointmentAt // Assuming NewAppointmentAt maps to StartTime
+	appointment.EndTime = newEndTime
 	appointment.Status = models.StatusRescheduled // Reset status to rescheduled after reschedule
+	appointment.ReminderSentAt = nil              // The patient is reminded of the new time
 
 	if req.Notes != "" {
 		appointment.Notes = req.Notes // Or append
 	}
 
-	if err := h.DB.Save(&appointment).Error; err != nil {
-		utils.InternalServerError(c, "Failed to reschedule appointment: "+err.Error())
+	if err := reqDB(h.DB, c).Save(&appointment).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.reschedule_failed")
+		return
+	}
+	h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookAppointmentRescheduled, webhooks.NewAppointmentData(appointment))
+
+	redactAppointmentForRole(&appointment, userRole)
+	utils.Success(c, "Appointment rescheduled successfully", dto.NewAppointmentResponse(appointment))
+}
+
+// UpdateAppointmentNotesRequest represents the request body for editing an appointment's notes.
+// Omitted fields are left unchanged; an empty string clears the field.
+type UpdateAppointmentNotesRequest struct {
+	Notes        *string `json:"notes" binding:"omitempty,max=5000"`
+	PrivateNotes *string `json:"privateNotes" binding:"omitempty,max=5000"`
+}
+
+// UpdateAppointmentNotes handles editing the shared and private notes of an appointment.
+// Only the appointment's doctor or an admin can edit notes.
+func (h *AppointmentHandler) UpdateAppointmentNotes(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_appointment_id")
+		return
+	}
+
+	var req UpdateAppointmentNotesRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	if req.Notes == nil && req.PrivateNotes == nil {
+		utils.BadRequest(c, "appointments.notes_required")
+		return
+	}
+
+	var appointment models.Appointment
+	if err := reqDB(h.DB, c).First(&appointment, "id = ?", appointmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.appointment_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	userIDStr, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	isAdmin := userRole.IsAdmin()
+	isAppointmentDoctor := userRole == models.RoleDoctor && userIDStr == appointment.DoctorID
+	if !isAdmin && !isAppointmentDoctor {
+		utils.Forbidden(c, "appointments.notes_forbidden")
+		return
+	}
+
+	if req.Notes != nil {
+		appointment.Notes = *req.Notes
+	}
+	if req.PrivateNotes != nil {
+		appointment.PrivateNotes = *req.PrivateNotes
+	}
+
+	if err := reqDB(h.DB, c).Save(&appointment).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.notes_update_failed")
 		return
 	}
 
-	utils.Success(c, "Appointment rescheduled successfully", appointment)
+	utils.Success(c, "Appointment notes updated successfully", dto.NewAppointmentResponse(appointment))
 }
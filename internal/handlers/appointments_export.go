@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// appointmentCSVBatchSize is how many appointments the CSV export loads at a time.
+const appointmentCSVBatchSize = 500
+
+// appointmentCSVHeader lists the columns of the appointments CSV export.
+var appointmentCSVHeader = []string{"Appointment ID", "Patient Name", "Doctor Name", "Start Time", "End Time", "Status", "Reason", "Notes"}
+
+// csvSafe neutralizes values that spreadsheet applications would otherwise evaluate as formulas.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
+
+// formatCSVTime formats a time for the export, leaving unset times empty.
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ExportAppointmentsCSV handles downloading appointments as CSV, optionally limited to ?from= and ?to= (RFC3339 or YYYY-MM-DD).
+// Doctors export their own appointments, patients their own, admins all appointments.
+// Rows are streamed in batches so large exports are never held in memory at once.
+func (h *AppointmentHandler) ExportAppointmentsCSV(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	query := reqDB(h.DB, c).Model(&models.Appointment{}).Preload("Patient").Preload("Doctor")
+	switch {
+	case userRole.IsAdmin():
+		// Admins export everything
+	case userRole == models.RoleDoctor:
+		query = query.Where("doctor_id = ?", userID)
+	default:
+		query = query.Where("patient_id = ?", userID)
+	}
+
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			utils.BadRequest(c, "appointments.invalid_export_from")
+			return
+		}
+		query = query.Where("start_time >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			utils.BadRequest(c, "appointments.invalid_export_to")
+			return
+		}
+		query = query.Where("start_time <= ?", toTime)
+	}
+
+	writer := csv.NewWriter(c.Writer)
+	started := false
+	startDownload := func() {
+		filename := fmt.Sprintf("appointments-%s.csv", time.Now().UTC().Format("20060102"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		writer.Write(appointmentCSVHeader)
+		started = true
+	}
+
+	// Batches are read by keyset on (start_time, id) rather than by offset, so rows sharing a start time
+	// are neither skipped nor repeated between batches
+	query = query.Order("start_time asc, id asc").Session(&gorm.Session{})
+	var (
+		batch []models.Appointment
+		err   error
+	)
+	for {
+		page := query
+		if len(batch) > 0 {
+			last := batch[len(batch)-1]
+			page = query.Where("start_time > ? OR (start_time = ? AND id > ?)", last.StartTime, last.StartTime, last.ID)
+		}
+		batch = nil
+		if err = page.Limit(appointmentCSVBatchSize).Find(&batch).Error; err != nil || len(batch) == 0 {
+			break
+		}
+
+		if !started {
+			startDownload()
+		}
+		for _, appointment := range batch {
+			writer.Write([]string{
+				appointment.ID,
+				csvSafe(strings.TrimSpace(appointment.Patient.FirstName + " " + appointment.Patient.LastName)),
+				csvSafe(strings.TrimSpace(appointment.Doctor.FirstName + " " + appointment.Doctor.LastName)),
+				formatCSVTime(appointment.StartTime),
+				formatCSVTime(appointment.EndTime),
+				string(appointment.Status),
+				csvSafe(appointment.Reason),
+				csvSafe(appointment.Notes),
+			})
+		}
+		writer.Flush()
+		if err = writer.Error(); err != nil || len(batch) < appointmentCSVBatchSize {
+			break
+		}
+	}
+
+	if err != nil {
+		if !started {
+			utils.HandleDBError(c, err, "appointments.fetch_failed")
+			return
+		}
+		// Headers are already sent; the client receives a truncated file
+		log.Printf("request %s: appointment CSV export aborted: %v", utils.RequestID(c), err)
+		return
+	}
+
+	if !started {
+		startDownload() // No appointments: header row only
+	}
+	writer.Flush()
+}
@@ -0,0 +1,60 @@
+package handlers_test
+
+import (
+	"encoding/csv"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportAppointmentsCSVKeepsRowsSharingAStartTime(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.createUser(t, models.RoleAdmin)
+	patient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+
+	// More than two batches, with every start time shared by hundreds of rows
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	appointments := make([]models.Appointment, 0, 1100)
+	for i := 0; i < 1100; i++ {
+		slot := start.Add(time.Duration(i%3) * time.Hour)
+		appointments = append(appointments, models.Appointment{
+			PatientID: patient.ID,
+			DoctorID:  doctor.ID,
+			StartTime: slot,
+			EndTime:   slot.Add(30 * time.Minute),
+			Status:    models.StatusConfirmed,
+		})
+	}
+	if err := api.db.CreateInBatches(&appointments, 200).Error; err != nil {
+		t.Fatalf("creating appointments: %v", err)
+	}
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/appointments/export.csv", nil, api.auth(t, admin))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	rows, err := csv.NewReader(strings.NewReader(recorder.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing CSV: %v", err)
+	}
+	if len(rows) != len(appointments)+1 {
+		t.Fatalf("got %d rows, want %d plus the header", len(rows)-1, len(appointments))
+	}
+
+	seen := make(map[string]bool, len(appointments))
+	var previous string
+	for _, row := range rows[1:] {
+		if seen[row[0]] {
+			t.Fatalf("appointment %s exported twice", row[0])
+		}
+		seen[row[0]] = true
+		if row[3] < previous {
+			t.Fatalf("rows out of start time order: %s after %s", row[3], previous)
+		}
+		previous = row[3]
+	}
+}
@@ -0,0 +1,291 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/i18n"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// appointmentStatuses lists every appointment status.
+var appointmentStatuses = []models.AppointmentStatus{
+	models.StatusPending, models.StatusConfirmed, models.StatusCancelled, models.StatusCompleted,
+	models.StatusRescheduled, models.StatusNoShow, models.StatusNeedsReview, models.StatusProposed,
+}
+
+// settableAppointmentStatuses are the statuses UpdateAppointmentStatus accepts at all; the others are
+// only set by rescheduling, proposals and the sweep, and fail validation.
+var settableAppointmentStatuses = []models.AppointmentStatus{
+	models.StatusPending, models.StatusConfirmed, models.StatusCancelled, models.StatusCompleted, models.StatusNoShow,
+}
+
+func TestUpdateAppointmentStatusRejectsInvalidTransitions(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.createUser(t, models.RoleAdmin)
+	patient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Minute)
+
+	for _, from := range appointmentStatuses {
+		for _, to := range settableAppointmentStatuses {
+			if from.CanTransitionTo(to) {
+				continue
+			}
+			t.Run(string(from)+" to "+string(to), func(t *testing.T) {
+				appointment := models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: from}
+				api.create(t, &appointment)
+
+				recorder := testutil.PerformRequest(t, api.router, http.MethodPatch, "/api/v1/appointments/"+appointment.ID+"/status",
+					map[string]string{"status": string(to)}, api.auth(t, admin))
+				if recorder.Code != http.StatusBadRequest {
+					t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+				}
+				want := i18n.Translate("en", "appointments.invalid_status_transition", i18n.Params{"from": string(from), "to": string(to)})
+				if response := testutil.DecodeResponse(t, recorder); response.Error != want {
+					t.Errorf("error = %q, want %q", response.Error, want)
+				}
+
+				var stored models.Appointment
+				if err := api.db.First(&stored, "id = ?", appointment.ID).Error; err != nil {
+					t.Fatalf("reloading appointment: %v", err)
+				}
+				if stored.Status != from {
+					t.Errorf("status changed to %s", stored.Status)
+				}
+			})
+		}
+	}
+}
+
+func TestRescheduleAppointmentRejectsInvalidTransitions(t *testing.T) {
+	api := newTestAPI(t)
+	admin := api.createUser(t, models.RoleAdmin)
+	patient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Minute)
+	newStart := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour)
+
+	for _, from := range appointmentStatuses {
+		if from.CanTransitionTo(models.StatusRescheduled) {
+			continue
+		}
+		t.Run(string(from), func(t *testing.T) {
+			appointment := models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: from}
+			api.create(t, &appointment)
+
+			recorder := testutil.PerformRequest(t, api.router, http.MethodPatch, "/api/v1/appointments/"+appointment.ID+"/reschedule",
+				map[string]time.Time{"newAppointmentAt": newStart}, api.auth(t, admin))
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+			}
+			want := i18n.Translate("en", "appointments.invalid_status_transition", i18n.Params{"from": string(from), "to": string(models.StatusRescheduled)})
+			if response := testutil.DecodeResponse(t, recorder); response.Error != want {
+				t.Errorf("error = %q, want %q", response.Error, want)
+			}
+		})
+	}
+}
+
+func TestAppointmentTimesRoundTripAsUTC(t *testing.T) {
+	api := newTestAPI(t)
+	patient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+	warsaw := time.FixedZone("+02:00", 2*60*60)
+	newYork := time.FixedZone("-05:00", -5*60*60)
+	start := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour)
+
+	// The client sends wall-clock time in its own zone
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/appointments", map[string]string{
+		"doctorId":  doctor.ID,
+		"patientId": patient.ID,
+		"startTime": start.In(warsaw).Format(time.RFC3339),
+		"reason":    "Checkup",
+	}, api.auth(t, patient))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body.String())
+	}
+	var created struct {
+		ID        string `json:"id"`
+		StartTime string `json:"startTime"`
+	}
+	decodeData(t, recorder, &created)
+	if want := start.Format(time.RFC3339); created.StartTime != want {
+		t.Errorf("created startTime = %q, want %q", created.StartTime, want)
+	}
+
+	var stored models.Appointment
+	if err := api.db.First(&stored, "id = ?", created.ID).Error; err != nil {
+		t.Fatalf("reloading appointment: %v", err)
+	}
+	if !stored.StartTime.Equal(start) || stored.StartTime.Location() != time.UTC {
+		t.Errorf("stored start = %v, want %v in UTC", stored.StartTime, start)
+	}
+
+	// Rescheduling from another zone lands on the same instant, and reads back in UTC
+	newStart := start.Add(24 * time.Hour)
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPatch, "/api/v1/appointments/"+created.ID+"/reschedule",
+		map[string]string{"newAppointmentAt": newStart.In(newYork).Format(time.RFC3339)}, api.auth(t, patient))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("reschedule status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+
+	recorder = testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/appointments/"+created.ID, nil, api.auth(t, patient))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("get status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	var fetched struct {
+		StartTime string `json:"startTime"`
+		EndTime   string `json:"endTime"`
+	}
+	decodeData(t, recorder, &fetched)
+	if want := newStart.Format(time.RFC3339); fetched.StartTime != want {
+		t.Errorf("fetched startTime = %q, want %q", fetched.StartTime, want)
+	}
+	if !strings.HasSuffix(fetched.EndTime, "Z") {
+		t.Errorf("fetched endTime = %q, want UTC", fetched.EndTime)
+	}
+}
+
+// proposeAppointment has doctor book an appointment for patient through the API and returns its ID.
+func (api *testAPI) proposeAppointment(t *testing.T, doctor, patient *models.User) string {
+	t.Helper()
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/appointments", map[string]string{
+		"doctorId":  doctor.ID,
+		"patientId": patient.ID,
+		"startTime": time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour).Format(time.RFC3339),
+		"reason":    "Follow-up",
+	}, api.auth(t, doctor))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("proposing appointment status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var created struct {
+		ID          string                   `json:"id"`
+		Status      models.AppointmentStatus `json:"status"`
+		CreatedByID string                   `json:"createdById"`
+	}
+	decodeData(t, recorder, &created)
+	if created.Status != models.StatusProposed || created.CreatedByID != doctor.ID {
+		t.Fatalf("booked appointment status %q created by %q, want proposed by %s", created.Status, created.CreatedByID, doctor.ID)
+	}
+	return created.ID
+}
+
+// messageSubjects returns the subjects of the messages from sender to receiver.
+func (api *testAPI) messageSubjects(t *testing.T, sender, receiver string) []string {
+	t.Helper()
+
+	var subjects []string
+	if err := api.db.Model(&models.Message{}).Where("sender_id = ? AND receiver_id = ?", sender, receiver).
+		Order("created_at asc").Pluck("subject", &subjects).Error; err != nil {
+		t.Fatalf("loading messages: %v", err)
+	}
+	return subjects
+}
+
+func TestAppointmentProposalAnswers(t *testing.T) {
+	tests := []struct {
+		name        string
+		answer      models.AppointmentStatus
+		wantSubject string
+	}{
+		{"accepted", models.StatusConfirmed, "Proposed appointment confirmed"},
+		{"declined", models.StatusCancelled, "Proposed appointment declined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			doctor := api.createUser(t, models.RoleDoctor)
+			patient := api.createUser(t, models.RolePatient)
+			id := api.proposeAppointment(t, doctor, patient)
+
+			if subjects := api.messageSubjects(t, doctor.ID, patient.ID); len(subjects) != 1 || subjects[0] != "Please confirm your appointment" {
+				t.Errorf("messages to the patient = %v, want the proposal notice", subjects)
+			}
+
+			// Only the patient can accept on their own behalf
+			path := "/api/v1/appointments/" + id + "/status"
+			recorder := testutil.PerformRequest(t, api.router, http.MethodPatch, path,
+				map[string]string{"status": string(models.StatusConfirmed)}, api.auth(t, doctor))
+			if recorder.Code != http.StatusForbidden {
+				t.Fatalf("doctor confirming status = %d, want %d: %s", recorder.Code, http.StatusForbidden, recorder.Body.String())
+			}
+
+			recorder = testutil.PerformRequest(t, api.router, http.MethodPatch, path,
+				map[string]string{"status": string(tt.answer)}, api.auth(t, patient))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("patient answering status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+			}
+
+			var stored models.Appointment
+			if err := api.db.First(&stored, "id = ?", id).Error; err != nil {
+				t.Fatalf("reloading appointment: %v", err)
+			}
+			if stored.Status != tt.answer {
+				t.Errorf("status = %s, want %s", stored.Status, tt.answer)
+			}
+			if subjects := api.messageSubjects(t, patient.ID, doctor.ID); len(subjects) != 1 || subjects[0] != tt.wantSubject {
+				t.Errorf("messages to the doctor = %v, want %q", subjects, tt.wantSubject)
+			}
+		})
+	}
+}
+
+func TestPatientSelfBookingIsNotAProposal(t *testing.T) {
+	api := newTestAPI(t)
+	doctor := api.createUser(t, models.RoleDoctor)
+	patient := api.createUser(t, models.RolePatient)
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/appointments", map[string]string{
+		"doctorId":  doctor.ID,
+		"patientId": patient.ID,
+		"startTime": time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour).Format(time.RFC3339),
+		"reason":    "Checkup",
+	}, api.auth(t, patient))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body.String())
+	}
+	var created struct {
+		Status models.AppointmentStatus `json:"status"`
+	}
+	decodeData(t, recorder, &created)
+	if created.Status != models.StatusPending {
+		t.Errorf("status = %s, want %s", created.Status, models.StatusPending)
+	}
+}
+
+func TestGetAppointmentsForUserSelectsOnlyRequestedFields(t *testing.T) {
+	api := newTestAPI(t)
+	patient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+	start := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour)
+	api.create(t, &models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(30 * time.Minute),
+		Status: models.StatusConfirmed, Reason: "Checkup", Notes: "Bring results", PrivateNotes: "Watch blood pressure"})
+
+	selects := api.captureSelects(t, "appointments")
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/appointments?fields=startTime,status", nil, api.auth(t, doctor))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	assertSelect(t, selects(), []string{"id", "patient_id", "doctor_id", "start_time", "status"},
+		[]string{"reason", "notes", "private_notes", "end_time", "created_by_id"})
+
+	var appointments []map[string]interface{}
+	decodeData(t, recorder, &appointments)
+	if len(appointments) != 1 {
+		t.Fatalf("got %d appointments, want 1", len(appointments))
+	}
+	for _, key := range []string{"reason", "notes", "privateNotes", "patient", "doctor"} {
+		if _, ok := appointments[0][key]; ok {
+			t.Errorf("response has the excluded field %s", key)
+		}
+	}
+
+	recorder = testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/appointments?fields=startTime,secret", nil, api.auth(t, doctor))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("unknown field status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Entity types of audit events.
+const (
+	auditEntityRecordAmendment = "record_amendment"
+	auditEntityInvitation      = "invitation"
+	auditEntityUser            = "user"
+	auditEntityConsentGrant    = "consent_grant"
+	auditEntityJob             = "job"
+	auditEntityRecordDiagnosis = "record_diagnosis"
+)
+
+// recordAudit writes an audit event for actorID ("" for the system). Pass the transaction the audited change
+// is made in, so the event is stored if and only if the change is.
+func recordAudit(db *gorm.DB, actorID, action, entityType, entityID, details string) error {
+	event := models.AuditEvent{Action: action, EntityType: entityType, EntityID: entityID, Details: details}
+	if actorID != "" {
+		event.ActorID = &actorID
+	}
+	return db.Create(&event).Error
+}
+
+// AuditHandler serves the audit log.
+type AuditHandler struct {
+	DB *gorm.DB
+}
+
+// NewAuditHandler creates a new AuditHandler.
+func NewAuditHandler(db *gorm.DB) *AuditHandler {
+	return &AuditHandler{DB: db}
+}
+
+// GetAuditEvents handles listing audit events newest first, optionally limited to ?action=, ?entityType=,
+// ?entityId= and ?actorId= (admin).
+func (h *AuditHandler) GetAuditEvents(c *gin.Context) {
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.AuditEvent{})
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if entityType := c.Query("entityType"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID := c.Query("entityId"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if actorID := c.Query("actorId"); actorID != "" {
+		if _, err := uuid.Parse(actorID); err != nil {
+			utils.BadRequest(c, "audit.invalid_actor_id")
+			return
+		}
+		query = query.Where("actor_id = ?", actorID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "audit.fetch_failed")
+		return
+	}
+
+	var events []models.AuditEvent
+	if err := query.Order("created_at desc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&events).Error; err != nil {
+		utils.HandleDBError(c, err, "audit.fetch_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Audit events fetched successfully", events, pagination.Meta(total))
+}
@@ -1,49 +1,91 @@
 package handlers
 
 import (
+	"errors"
+	"healthcare-app-server/internal/cache"
 	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/middleware"
 	"healthcare-app-server/internal/models"
 	"healthcare-app-server/internal/utils"
+	"log"
+	"net/http"
+	"strings"
 	"time" // Imported time
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// refreshTokenCookie is the name of the HTTP-only cookie carrying the refresh token.
+const refreshTokenCookie = "refresh_token"
+
 // AuthHandler handles authentication-related requests.
 type AuthHandler struct {
-	DB  *gorm.DB
-	Cfg *config.Config
+	DB      *gorm.DB
+	Cfg     *config.Config
+	Doctors *cache.Loader // Cached doctor listings and profiles, invalidated when a doctor registers or edits their profile
 }
 
 // NewAuthHandler creates a new AuthHandler.
-func NewAuthHandler(db *gorm.DB, cfg *config.Config) *AuthHandler {
-	return &AuthHandler{DB: db, Cfg: cfg}
+func NewAuthHandler(db *gorm.DB, cfg *config.Config, doctors *cache.Loader) *AuthHandler {
+	return &AuthHandler{DB: db, Cfg: cfg, Doctors: doctors}
 }
 
 // RegisterRequest represents the request body for user registration.
 type RegisterRequest struct {
-	FirstName string `json:"firstName" binding:"required"`
-	LastName  string `json:"lastName" binding:"required"`
-	Email     string `json:"email" binding:"required,email"`
+	FirstName string `json:"firstName" binding:"required,max=100"`
+	LastName  string `json:"lastName" binding:"required,max=100"`
+	Email     string `json:"email" binding:"required,email,max=255"`
 	Password  string `json:"password" binding:"required,min=8"`
-	Role      string `json:"role" binding:"required,oneof=PATIENT DOCTOR ADMIN"` // Validate role
+	// Optional and only "patient" is accepted; doctors and admins are created by admins or register from an invitation
+	Role string `json:"role" binding:"omitempty,role"`
+	// Invite code of the organization to join; required with MULTI_TENANT
+	InviteCode string `json:"inviteCode"`
+	// Version accepted of each policy in effect, e.g. {"terms_of_service": "2"}; see GET /policies/current
+	AcceptedPolicyVersions map[string]string `json:"acceptedPolicyVersions"`
 }
 
-// Register handles user registration.
+// Register handles public registration, which only creates patients.
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if !utils.BindAndValidate(c, &req) {
 		return // Error response handled by BindAndValidate
 	}
+	if req.Role != "" && models.Role(req.Role).Normalize() != models.RolePatient {
+		utils.BadRequest(c, "auth.register_role_forbidden")
+		return
+	}
+	policies, ok := checkAcceptedPolicies(c, reqDB(h.DB, c), req.AcceptedPolicyVersions)
+	if !ok {
+		return
+	}
+
+	var organizationID *string
+	if h.Cfg.MultiTenant {
+		if strings.TrimSpace(req.InviteCode) == "" {
+			utils.BadRequest(c, "organizations.invite_code_required")
+			return
+		}
+		var organization models.Organization
+		if err := reqDB(h.DB, c).First(&organization, "invite_code = ?", strings.TrimSpace(req.InviteCode)).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				utils.BadRequest(c, "organizations.invalid_invite_code")
+			} else {
+				utils.HandleDBError(c, err, "common.database_error")
+			}
+			return
+		}
+		organizationID = &organization.ID
+	}
 
 	// Check if user already exists
 	var existingUser models.User
-	if err := h.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		utils.BadRequest(c, "User with this email already exists")
+	if err := reqDB(h.DB, c).Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		utils.BadRequest(c, "users.email_taken")
 		return
 	} else if err != gorm.ErrRecordNotFound {
-		utils.InternalServerError(c, "Database error: "+err.Error())
+		utils.HandleDBError(c, err, "common.database_error")
 		return
 	}
 
@@ -51,18 +93,33 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Email:     req.Email,
-		Role:      models.Role(req.Role), // Convert string to models.Role
+		Role:      models.RolePatient,
+		// Registration is public, so nothing scopes it and the organization is set here
+		OrganizationID: organizationID,
 	}
 
 	if err := user.SetPassword(req.Password); err != nil {
-		utils.InternalServerError(c, "Failed to hash password: "+err.Error())
+		utils.InternalServerErrorWithDetail(c, "auth.password_hash_failed", err)
+		return
+	}
+	verificationToken, err := issueVerificationToken(h.Cfg, &user)
+	if err != nil {
+		utils.InternalServerErrorWithDetail(c, "auth.verification_failed", err)
 		return
 	}
 
-	if err := h.DB.Create(&user).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create user: "+err.Error())
+	// The acceptances are the proof of consent at signup, so the user is only created with them
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return recordPolicyAcceptances(tx, user.ID, c.ClientIP(), policies)
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "users.create_failed")
 		return
 	}
+	go sendVerificationEmail(h.Cfg, user, verificationToken)
 
 	// Omit password from response
 	userResponse := user.Sanitize()
@@ -77,9 +134,13 @@ type LoginRequest struct {
 
 // LoginResponse represents the response body for successful login.
 type LoginResponse struct {
-	AccessToken  string               `json:"accessToken"`
-	RefreshToken string               `json:"refreshToken"`
-	User         models.UserSanitized `json:"user"`
+	AccessToken           string               `json:"accessToken"`
+	RefreshToken          string               `json:"refreshToken"`
+	AccessTokenExpiresAt  time.Time            `json:"accessTokenExpiresAt"`
+	RefreshTokenExpiresAt time.Time            `json:"refreshTokenExpiresAt"`
+	ExpiresIn             int64                `json:"expiresIn"` // Access token lifetime in seconds
+	TokenType             string               `json:"tokenType"`
+	User                  models.UserSanitized `json:"user"`
 }
 
 // Login handles user login.
@@ -90,52 +151,63 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := h.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+	if err := reqDB(h.DB, c).Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.Unauthorized(c, "Invalid email or password")
+			recordLoginEvent(reqDB(h.DB, c), c, nil, req.Email, false, "unknown_email")
+			utils.Unauthorized(c, "auth.invalid_credentials")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
 
 	if !user.CheckPassword(req.Password) {
-		utils.Unauthorized(c, "Invalid email or password")
+		recordLoginEvent(reqDB(h.DB, c), c, &user.ID, req.Email, false, "invalid_password")
+		utils.Unauthorized(c, "auth.invalid_credentials")
 		return
 	}
 
+	// Record the login time, IP and event; failures here never prevent the user from logging in
+	recordSuccessfulLogin(reqDB(h.DB, c), c, &user)
+	// Upgrade a hash made before BCRYPT_COST was raised while the plaintext is at hand; a failure only means retrying next login
+	if user.PasswordNeedsRehash() {
+		rehashPassword(reqDB(h.DB, c), &user, req.Password)
+	}
+
 	accessToken, refreshTokenString, err := utils.GenerateTokens(&user, h.Cfg)
 	if err != nil {
-		utils.InternalServerError(c, "Failed to generate tokens: "+err.Error())
+		utils.InternalServerErrorWithDetail(c, "auth.token_generation_failed", err)
 		return
 	}
-	// Store refresh token in DB
+	// Store refresh token in DB; a token issued at login starts a new rotation family
+	refreshTokenID := uuid.New().String()
+	issuedAt := time.Now()
 	refreshToken := models.RefreshToken{
-		UserID:    user.ID, // Ensure user.ID is the correct UUID string
-		Token:     refreshTokenString,
-		ExpiresAt: time.Now().Add(time.Duration(h.Cfg.JWTRefreshExpirationHours) * time.Hour),
-		IsRevoked: false,
+		UserID:     user.ID, // Ensure user.ID is the correct UUID string
+		Token:      refreshTokenString,
+		ExpiresAt:  issuedAt.Add(time.Duration(h.Cfg.JWTRefreshExpirationHours) * time.Hour),
+		IsRevoked:  false,
+		LastUsedAt: &issuedAt,
+		FamilyID:   refreshTokenID,
 	}
-	if err := h.DB.Create(&refreshToken).Error; err != nil {
-		utils.InternalServerError(c, "Failed to store refresh token: "+err.Error())
+	refreshToken.ID = refreshTokenID
+	if err := reqDB(h.DB, c).Create(&refreshToken).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.session_update_failed")
 		return
 	}
 
 	// Set refresh token as HTTP-only cookie
-	c.SetCookie(
-		"refresh_token",                       // Name
-		refreshTokenString,                    // Value
-		h.Cfg.JWTRefreshExpirationHours*60*60, // Max age in seconds
-		"/",                                // Path
-		"",                                 // Domain (empty means current domain)
-		h.Cfg.Environment != "development", // Secure (true in prod, false in dev)
-		true,                               // HTTP only
-	)
+	h.setRefreshTokenCookie(c, refreshTokenString)
+	h.setAccessTokenCookie(c, accessToken)
 
 	utils.Success(c, "Login successful", LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshTokenString, // Still include in response for backward compatibility
-		User:         user.Sanitize(),
+		AccessToken:           accessToken,
+		RefreshToken:          refreshTokenString, // Still include in response for backward compatibility
+		AccessTokenExpiresAt:  h.accessTokenExpiry(),
+		RefreshTokenExpiresAt: refreshToken.ExpiresAt,
+		ExpiresIn:             h.accessTokenLifetimeSeconds(),
+		TokenType:             tokenTypeBearer,
+		User:                  user.Sanitize(),
 	})
 }
 
@@ -146,14 +218,18 @@ type RefreshTokenRequest struct {
 
 // RefreshTokenResponse represents the response body for successful token refresh.
 type RefreshTokenResponse struct {
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
+	AccessToken           string    `json:"accessToken"`
+	RefreshToken          string    `json:"refreshToken"`
+	AccessTokenExpiresAt  time.Time `json:"accessTokenExpiresAt"`
+	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt"`
+	ExpiresIn             int64     `json:"expiresIn"` // Access token lifetime in seconds
+	TokenType             string    `json:"tokenType"`
 }
 
 // RefreshToken handles refreshing an access token using a refresh token.
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// First try to get the refresh token from HTTP-only cookie
-	refreshTokenFromCookie, err := c.Cookie("refresh_token")
+	refreshTokenFromCookie, err := c.Cookie(refreshTokenCookie)
 
 	// If no cookie, fall back to request body (for backward compatibility)
 	if err != nil || refreshTokenFromCookie == "" {
@@ -167,67 +243,182 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// Validate the token regardless of source
 	claims, err := utils.ValidateToken(refreshTokenFromCookie, h.Cfg.JWTRefreshSecret)
 	if err != nil {
-		utils.Unauthorized(c, "Invalid refresh token structure or signature: "+err.Error())
+		if errors.Is(err, utils.ErrTokenExpired) {
+			utils.ErrorWithCode(c, http.StatusUnauthorized, utils.CodeRefreshTokenExpired, "auth.refresh_token_expired", err)
+		} else {
+			utils.ErrorWithCode(c, http.StatusUnauthorized, utils.CodeInvalidToken, "auth.invalid_refresh_token", err)
+		}
 		return
 	}
-	// Check if refresh token is revoked or still valid in DB
+	// Look up the presented token regardless of its state so that reuse of a rotated token can be detected
 	var storedToken models.RefreshToken
-	if err := h.DB.Where("token = ? AND user_id = ? AND is_revoked = ? AND expires_at > ?", refreshTokenFromCookie, claims.UserID, false, time.Now()).First(&storedToken).Error; err != nil {
+	if err := reqDB(h.DB, c).Where("token = ? AND user_id = ?", refreshTokenFromCookie, claims.UserID).First(&storedToken).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.Unauthorized(c, "Refresh token not found, expired, or revoked")
+			utils.Unauthorized(c, "auth.refresh_token_invalid")
 		} else {
-			utils.InternalServerError(c, "Database error checking refresh token: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
 
+	familyID := storedToken.FamilyID
+	if familyID == "" {
+		familyID = storedToken.ID // Tokens issued before family tracking form their own family
+	}
+
+	// A revoked token being presented again means it was stolen or replayed after rotation.
+	// Revoke the whole family so neither the attacker nor the legitimate client can keep refreshing.
+	if storedToken.IsRevoked {
+		h.revokeTokenFamily(c, storedToken, familyID)
+		return
+	}
+
+	now := time.Now()
+	if !storedToken.ExpiresAt.After(now) {
+		utils.ErrorWithCode(c, http.StatusUnauthorized, utils.CodeRefreshTokenExpired, "auth.refresh_token_expired", nil)
+		return
+	}
+	// A session nobody refreshed for the idle window ends, even before its absolute expiry
+	if storedToken.IdleExpired(now, time.Duration(h.Cfg.RefreshIdleHours)*time.Hour) {
+		utils.ErrorWithCode(c, http.StatusUnauthorized, utils.CodeRefreshTokenExpired, "auth.session_idle_expired", nil)
+		return
+	}
+
 	var user models.User
 	// Use claims.UserID which should be the string representation of the UUID
-	if err := h.DB.First(&user, "id = ?", claims.UserID).Error; err != nil {
-		utils.InternalServerError(c, "Failed to find user associated with token: "+err.Error())
+	if err := reqDB(h.DB, c).First(&user, "id = ?", claims.UserID).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
 		return
 	}
-	// Implement refresh token rotation for security:
-	// 1. Revoke the old refresh token
-	storedToken.IsRevoked = true
-	h.DB.Save(&storedToken)
 
-	// 2. Generate new tokens
+	// Implement refresh token rotation for security:
+	// 1. Generate new tokens
 	newAccessToken, newRefreshTokenString, err := utils.GenerateTokens(&user, h.Cfg)
 	if err != nil {
-		utils.InternalServerError(c, "Failed to generate new tokens: "+err.Error())
+		utils.InternalServerErrorWithDetail(c, "auth.token_generation_failed", err)
 		return
 	}
 
-	// 3. Store the new refresh token in DB
-	newRefreshToken := models.RefreshToken{
-		UserID:    user.ID,
-		Token:     newRefreshTokenString,
-		ExpiresAt: time.Now().Add(time.Duration(h.Cfg.JWTRefreshExpirationHours) * time.Hour),
-		IsRevoked: false,
+	newRefreshTokenExpiresAt := now.Add(time.Duration(h.Cfg.JWTRefreshExpirationHours) * time.Hour)
+
+	// 2. Revoke the old refresh token and store the new one atomically, so a failure
+	// can never leave the user without a valid token or with two valid ones
+	tokenReused := false
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND is_revoked = ?", storedToken.ID, false).
+			Updates(map[string]interface{}{"is_revoked": true, "last_used_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Another request rotated this token between our read and this update
+			tokenReused = true
+			return errTokenAlreadyRotated
+		}
+
+		parentID := storedToken.ID
+		newRefreshToken := models.RefreshToken{
+			UserID:        user.ID,
+			Token:         newRefreshTokenString,
+			ExpiresAt:     newRefreshTokenExpiresAt,
+			IsRevoked:     false,
+			LastUsedAt:    &now,
+			FamilyID:      familyID,
+			ParentTokenID: &parentID,
+		}
+		return tx.Create(&newRefreshToken).Error
+	})
+	if tokenReused {
+		h.revokeTokenFamily(c, storedToken, familyID)
+		return
 	}
-	if err := h.DB.Create(&newRefreshToken).Error; err != nil {
-		utils.InternalServerError(c, "Failed to store new refresh token: "+err.Error())
+	if err != nil {
+		utils.HandleDBError(c, err, "auth.session_update_failed")
 		return
 	}
 
-	// 4. Set the new refresh token as HTTP-only cookie
-	c.SetCookie(
-		"refresh_token",                       // Name
-		newRefreshTokenString,                 // Value
-		h.Cfg.JWTRefreshExpirationHours*60*60, // Max age in seconds
-		"/",                                // Path
-		"",                                 // Domain (empty means current domain)
-		h.Cfg.Environment != "development", // Secure (true in prod, false in dev)
-		true,                               // HTTP only
-	)
+	// 3. Set the new refresh token as HTTP-only cookie
+	h.setRefreshTokenCookie(c, newRefreshTokenString)
+	h.setAccessTokenCookie(c, newAccessToken)
 
 	utils.Success(c, "Access token refreshed successfully", RefreshTokenResponse{
-		AccessToken:  newAccessToken,
-		RefreshToken: newRefreshTokenString, // Include for backward compatibility
+		AccessToken:           newAccessToken,
+		RefreshToken:          newRefreshTokenString, // Include for backward compatibility
+		AccessTokenExpiresAt:  h.accessTokenExpiry(),
+		RefreshTokenExpiresAt: newRefreshTokenExpiresAt,
+		ExpiresIn:             h.accessTokenLifetimeSeconds(),
+		TokenType:             tokenTypeBearer,
 	})
 }
 
+// tokenTypeBearer is the scheme clients must use when sending access tokens.
+const tokenTypeBearer = "Bearer"
+
+// accessTokenExpiry returns when an access token issued now expires, so clients can refresh proactively.
+func (h *AuthHandler) accessTokenExpiry() time.Time {
+	return time.Now().Add(time.Duration(h.Cfg.JWTExpirationMinutes) * time.Minute)
+}
+
+// accessTokenLifetimeSeconds returns how long an access token issued now stays valid.
+func (h *AuthHandler) accessTokenLifetimeSeconds() int64 {
+	return int64(h.Cfg.JWTExpirationMinutes) * 60
+}
+
+// setCookie sets an HTTP-only auth cookie with the configured path, domain and SameSite mode.
+// SameSite=None cookies are always Secure, as browsers reject them otherwise.
+func (h *AuthHandler) setCookie(c *gin.Context, name, value string, maxAge int) {
+	secure := h.Cfg.Environment != "development" || h.Cfg.CookieSameSite == http.SameSiteNoneMode
+	c.SetSameSite(h.Cfg.CookieSameSite)
+	c.SetCookie(name, value, maxAge, h.Cfg.CookiePath, h.Cfg.CookieDomain, secure, true)
+}
+
+// setRefreshTokenCookie delivers the refresh token as an HTTP-only cookie that lives as long as the token.
+func (h *AuthHandler) setRefreshTokenCookie(c *gin.Context, refreshToken string) {
+	h.setCookie(c, refreshTokenCookie, refreshToken, h.Cfg.JWTRefreshExpirationHours*60*60)
+}
+
+// clearRefreshTokenCookie expires the refresh token cookie.
+func (h *AuthHandler) clearRefreshTokenCookie(c *gin.Context) {
+	h.setCookie(c, refreshTokenCookie, "", -1)
+}
+
+// setAccessTokenCookie also delivers the access token as an HTTP-only cookie when ACCESS_TOKEN_COOKIE is enabled.
+// The cookie expires together with the token; the response body still carries it for header-based clients.
+func (h *AuthHandler) setAccessTokenCookie(c *gin.Context, accessToken string) {
+	if !h.Cfg.AccessTokenCookie {
+		return
+	}
+	h.setCookie(c, middleware.AccessTokenCookie, accessToken, h.Cfg.JWTExpirationMinutes*60)
+}
+
+// clearAccessTokenCookie expires the access token cookie, if cookie delivery is enabled.
+func (h *AuthHandler) clearAccessTokenCookie(c *gin.Context) {
+	if !h.Cfg.AccessTokenCookie {
+		return
+	}
+	h.setCookie(c, middleware.AccessTokenCookie, "", -1)
+}
+
+// errTokenAlreadyRotated aborts the rotation transaction when the old token was revoked concurrently.
+var errTokenAlreadyRotated = errors.New("refresh token already rotated")
+
+// revokeTokenFamily revokes every refresh token in the family of a reused token, clears the
+// refresh cookie and responds with 401 so the client is forced to log in again.
+func (h *AuthHandler) revokeTokenFamily(c *gin.Context, reusedToken models.RefreshToken, familyID string) {
+	if err := reqDB(h.DB, c).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND (family_id = ? OR id = ?)", reusedToken.UserID, familyID, familyID).
+		Update("is_revoked", true).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.session_update_failed")
+		return
+	}
+	log.Printf("Refresh token reuse detected for user %s (family %s); all tokens in the family were revoked", reusedToken.UserID, familyID)
+
+	h.clearRefreshTokenCookie(c)
+	h.clearAccessTokenCookie(c)
+	utils.Unauthorized(c, "auth.refresh_token_reused")
+}
+
 // LogoutRequest represents the request body for user logout.
 type LogoutRequest struct {
 	RefreshToken string `json:"refreshToken" binding:"required"`
@@ -237,12 +428,12 @@ type LogoutRequest struct {
 func (h *AuthHandler) Logout(c *gin.Context) {
 	var req LogoutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.BadRequest(c, "Invalid request payload: "+err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	if req.RefreshToken == "" {
-		utils.BadRequest(c, "Refresh token is required")
+		utils.BadRequest(c, "auth.refresh_token_required")
 		return
 	}
 
@@ -250,12 +441,12 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	var storedToken models.RefreshToken
 	// We only care if it exists and is not already revoked, UserID isn't strictly necessary for logout
 	// as the token itself is unique.
-	if err := h.DB.Where("token = ? AND is_revoked = ?", req.RefreshToken, false).First(&storedToken).Error; err != nil {
+	if err := reqDB(h.DB, c).Where("token = ? AND is_revoked = ?", req.RefreshToken, false).First(&storedToken).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// Token not found or already revoked, which is acceptable for logout.
 			utils.Success(c, "Logout successful (token not found or already invalid).", nil)
 		} else {
-			utils.InternalServerError(c, "Database error during logout: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
@@ -263,85 +454,170 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	// Mark the token as revoked and effectively expire it
 	storedToken.IsRevoked = true
 	storedToken.ExpiresAt = time.Now() // Optional: force expiry
-	if err := h.DB.Save(&storedToken).Error; err != nil {
-		utils.InternalServerError(c, "Failed to revoke refresh token: "+err.Error())
+	if err := reqDB(h.DB, c).Save(&storedToken).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.session_update_failed")
 		return
 	}
 
 	// Clear the refresh token cookie
-	c.SetCookie(
-		"refresh_token",                    // Name
-		"",                                 // Value (empty to delete)
-		-1,                                 // MaxAge (negative to expire immediately)
-		"/",                                // Path
-		"",                                 // Domain
-		h.Cfg.Environment != "development", // Secure
-		true,                               // HttpOnly
-	)
+	h.clearRefreshTokenCookie(c)
+	h.clearAccessTokenCookie(c)
 
 	utils.Success(c, "Logout successful. Refresh token has been invalidated.", nil)
 }
 
-// GetProfile handles fetching the currently authenticated user's profile.
-func (h *AuthHandler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("userID")
+// VerifyTokenResponse represents the decoded claims of a valid access token.
+type VerifyTokenResponse struct {
+	UserID    string      `json:"userId"`
+	Role      models.Role `json:"role"`
+	IssuedAt  time.Time   `json:"issuedAt"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+// VerifyToken confirms the access token is valid and returns its decoded claims.
+// AuthMiddleware has already rejected expired or malformed tokens with 401, so no database lookup is needed.
+func (h *AuthHandler) VerifyToken(c *gin.Context) {
+	claims, exists := middleware.GetClaimsFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "User not authenticated")
+		utils.Unauthorized(c, "common.unauthenticated")
 		return
 	}
 
-	var user models.User
-	if err := h.DB.First(&user, "id = ?", userID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "User profile not found")
-		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+	response := VerifyTokenResponse{
+		UserID: claims.UserID,
+		Role:   claims.Role,
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Time
+	}
+
+	utils.Success(c, "Token is valid", response)
+}
+
+// TokenInfoResponse represents the current access token's claims and remaining lifetime.
+type TokenInfoResponse struct {
+	VerifyTokenResponse
+	SecondsRemaining int64 `json:"secondsRemaining"`
+}
+
+// GetTokenInfo returns the current access token's claims and how many seconds it stays valid,
+// computed server-side so clients do not depend on their own clock or JWT decoding.
+func (h *AuthHandler) GetTokenInfo(c *gin.Context) {
+	claims, exists := middleware.GetClaimsFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	response := TokenInfoResponse{
+		VerifyTokenResponse: VerifyTokenResponse{
+			UserID: claims.UserID,
+			Role:   claims.Role,
+		},
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Time
+		if remaining := time.Until(claims.ExpiresAt.Time); remaining > 0 {
+			response.SecondsRemaining = int64(remaining / time.Second)
 		}
+	}
+
+	utils.Success(c, "Token info fetched successfully", response)
+}
+
+// ProfileResponse is the current user's profile with whether they accepted each policy in effect.
+type ProfileResponse struct {
+	models.UserSanitized
+	Policies []PolicyAcceptanceStatus `json:"policies"`
+}
+
+// GetProfile handles fetching the currently authenticated user's profile.
+func (h *AuthHandler) GetProfile(c *gin.Context) {
+	user, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		utils.InternalServerError(c, "auth.user_missing")
+		return
+	}
+
+	policies, err := policyAcceptanceStatuses(reqDB(h.DB, c), user.ID)
+	if err != nil {
+		utils.HandleDBError(c, err, "policies.fetch_failed")
 		return
 	}
 
-	utils.Success(c, "Profile fetched successfully", user.Sanitize())
+	utils.Success(c, "Profile fetched successfully", ProfileResponse{UserSanitized: user.Sanitize(), Policies: policies})
 }
 
 // UpdateProfileRequest represents the request body for updating user profile.
+// Only the fields present in the body are changed.
 type UpdateProfileRequest struct {
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
+	FirstName *string `json:"firstName" binding:"omitempty,min=1,max=100"`
+	LastName  *string `json:"lastName" binding:"omitempty,min=1,max=100"`
+	// Stored in E.164 form, e.g. +48123456789; "" clears it, as it does the other optional fields
+	PhoneNumber *string `json:"phoneNumber" binding:"omitempty,phone"`
+	Address     *string `json:"address" binding:"omitempty,max=255"`
+	// YYYY-MM-DD, in the past and at least MINIMUM_AGE_YEARS ago
+	DateOfBirth  *string `json:"dateOfBirth" binding:"omitempty,birthdate"`
+	ProfileImage *string `json:"profileImage" binding:"omitempty,max=500,http_url_or_empty"`
 	// Email cannot be changed via this endpoint for simplicity, handle separately if needed
 }
 
 // UpdateProfile handles updating the currently authenticated user's profile.
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
-		utils.Unauthorized(c, "User not authenticated")
-		return
-	}
-
 	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.BadRequest(c, "Invalid request payload: "+err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
-	var user models.User
-	if err := h.DB.First(&user, "id = ?", userID).Error; err != nil {
-		utils.NotFound(c, "User not found")
+	user, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		utils.InternalServerError(c, "auth.user_missing")
 		return
 	}
 
-	if req.FirstName != "" {
-		user.FirstName = req.FirstName
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
 	}
-	if req.LastName != "" {
-		user.LastName = req.LastName
+	if req.LastName != nil {
+		user.LastName = *req.LastName
 	}
-	// Add other updatable fields here
+	applyProfileFields(user, req.PhoneNumber, req.Address, req.DateOfBirth, req.ProfileImage)
 
-	if err := h.DB.Save(&user).Error; err != nil {
-		utils.InternalServerError(c, "Failed to update profile: "+err.Error())
+	if err := reqDB(h.DB, c).Save(user).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.profile_update_failed")
 		return
 	}
+	if user.Role == models.RoleDoctor {
+		invalidateDoctorCache(c, h.Doctors)
+	}
 
 	utils.Success(c, "Profile updated successfully", user.Sanitize())
 }
+
+// applyProfileFields sets the optional profile fields sent in an update; nil fields are left alone and ""
+// clears one. The values passed their binding tags, so the phone number and date of birth parse.
+func applyProfileFields(user *models.User, phoneNumber, address, dateOfBirth, profileImage *string) {
+	if phoneNumber != nil {
+		user.PhoneNumber, _ = utils.NormalizePhoneNumber(*phoneNumber)
+	}
+	if address != nil {
+		user.Address = utils.SanitizeText(strings.TrimSpace(*address))
+	}
+	if dateOfBirth != nil {
+		user.DateOfBirth = nil
+		if *dateOfBirth != "" {
+			birthdate, _ := utils.ParseBirthdate(*dateOfBirth)
+			user.DateOfBirth = &birthdate
+		}
+	}
+	if profileImage != nil {
+		user.ProfileImage = *profileImage
+	}
+}
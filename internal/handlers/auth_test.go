@@ -0,0 +1,435 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/handlers"
+	"healthcare-app-server/internal/i18n"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"healthcare-app-server/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRefreshTokenReplayRevokesTheFamily(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.createUserWithPassword(t, models.RolePatient)
+	issued := api.login(t, user)
+
+	recorder := api.refresh(t, issued.RefreshToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("first refresh status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var rotated handlers.RefreshTokenResponse
+	decodeData(t, recorder, &rotated)
+	if rotated.RefreshToken == issued.RefreshToken {
+		t.Fatal("refresh did not rotate the refresh token")
+	}
+
+	// The rotated-out token comes back, as it would from whoever stole it
+	recorder = api.refresh(t, issued.RefreshToken)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("replay status = %d, want %d: %s", recorder.Code, http.StatusUnauthorized, recorder.Body.String())
+	}
+
+	var live int64
+	if err := api.db.Model(&models.RefreshToken{}).Where("user_id = ? AND is_revoked = ?", user.ID, false).Count(&live).Error; err != nil {
+		t.Fatalf("counting tokens: %v", err)
+	}
+	if live != 0 {
+		t.Errorf("%d tokens of the family are still live", live)
+	}
+	if recorder := api.refresh(t, rotated.RefreshToken); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("refresh with the token issued by the rotation status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRefreshTokenReplayLeavesOtherSessionsAlone(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.createUserWithPassword(t, models.RolePatient)
+	stolen := api.login(t, user)
+	otherDevice := api.login(t, user)
+
+	if recorder := api.refresh(t, stolen.RefreshToken); recorder.Code != http.StatusOK {
+		t.Fatalf("refresh status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := api.refresh(t, stolen.RefreshToken); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("replay status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+	if recorder := api.refresh(t, otherDevice.RefreshToken); recorder.Code != http.StatusOK {
+		t.Errorf("the session from another login status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+}
+
+// jsonKind names the JSON type a schema field must have.
+type jsonKind string
+
+const (
+	jsonString    jsonKind = "string"
+	jsonNumber    jsonKind = "number"
+	jsonObject    jsonKind = "object"
+	jsonTimestamp jsonKind = "timestamp" // An RFC 3339 string
+)
+
+// assertSchema checks that the data of the response in recorder has exactly the fields of schema,
+// each of the given kind.
+func assertSchema(t *testing.T, recorder *httptest.ResponseRecorder, schema map[string]jsonKind) map[string]interface{} {
+	t.Helper()
+
+	var data map[string]interface{}
+	decodeData(t, recorder, &data)
+
+	var got, want []string
+	for key := range data {
+		got = append(got, key)
+	}
+	for key := range schema {
+		want = append(want, key)
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("fields = %v, want %v", got, want)
+	}
+
+	for key, kind := range schema {
+		value := data[key]
+		ok := false
+		switch kind {
+		case jsonString:
+			_, ok = value.(string)
+		case jsonNumber:
+			_, ok = value.(float64)
+		case jsonObject:
+			_, ok = value.(map[string]interface{})
+		case jsonTimestamp:
+			if text, isString := value.(string); isString {
+				_, err := time.Parse(time.RFC3339, text)
+				ok = err == nil
+			}
+		}
+		if !ok {
+			t.Errorf("%s = %#v, want a %s", key, value, kind)
+		}
+	}
+	return data
+}
+
+// tokenResponseSchema is the schema shared by the login and refresh responses.
+var tokenResponseSchema = map[string]jsonKind{
+	"accessToken":           jsonString,
+	"refreshToken":          jsonString,
+	"accessTokenExpiresAt":  jsonTimestamp,
+	"refreshTokenExpiresAt": jsonTimestamp,
+	"expiresIn":             jsonNumber,
+	"tokenType":             jsonString,
+}
+
+func TestLoginResponseSchema(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.createUserWithPassword(t, models.RolePatient)
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/auth/login",
+		map[string]string{"email": user.Email, "password": testPassword}, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	schema := map[string]jsonKind{"user": jsonObject}
+	for key, kind := range tokenResponseSchema {
+		schema[key] = kind
+	}
+	data := assertSchema(t, recorder, schema)
+	if data["tokenType"] != "Bearer" {
+		t.Errorf("tokenType = %v, want Bearer", data["tokenType"])
+	}
+	if data["expiresIn"] != float64(api.cfg.JWTExpirationMinutes*60) {
+		t.Errorf("expiresIn = %v, want %d", data["expiresIn"], api.cfg.JWTExpirationMinutes*60)
+	}
+	loggedIn, _ := data["user"].(map[string]interface{})
+	if loggedIn["id"] != user.ID {
+		t.Errorf("user.id = %v, want %s", loggedIn["id"], user.ID)
+	}
+	if _, leaked := loggedIn["password"]; leaked {
+		t.Error("the login response carries the password hash")
+	}
+}
+
+func TestRefreshTokenResponseSchema(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.createUserWithPassword(t, models.RolePatient)
+	issued := api.login(t, user)
+
+	recorder := api.refresh(t, issued.RefreshToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	data := assertSchema(t, recorder, tokenResponseSchema)
+	if data["tokenType"] != "Bearer" {
+		t.Errorf("tokenType = %v, want Bearer", data["tokenType"])
+	}
+}
+
+func TestTokenInfoResponseSchema(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.createUser(t, models.RoleDoctor)
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/auth/token-info", nil, api.auth(t, user))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	data := assertSchema(t, recorder, map[string]jsonKind{
+		"userId":           jsonString,
+		"role":             jsonString,
+		"issuedAt":         jsonTimestamp,
+		"expiresAt":        jsonTimestamp,
+		"secondsRemaining": jsonNumber,
+	})
+	if data["userId"] != user.ID || data["role"] != string(models.RoleDoctor) {
+		t.Errorf("token info = %v, want %s as %s", data, user.ID, models.RoleDoctor)
+	}
+
+	var info handlers.TokenInfoResponse
+	decodeData(t, recorder, &info)
+	lifetime := int64(api.cfg.JWTExpirationMinutes * 60)
+	if info.SecondsRemaining <= 0 || info.SecondsRemaining > lifetime {
+		t.Errorf("secondsRemaining = %d, want within (0, %d]", info.SecondsRemaining, lifetime)
+	}
+	if got := info.ExpiresAt.Sub(info.IssuedAt); got != time.Duration(lifetime)*time.Second {
+		t.Errorf("expiresAt - issuedAt = %s, want %ds", got, lifetime)
+	}
+}
+
+func TestUpdateProfilePatchSemantics(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+		want       func(user models.User) bool
+	}{
+		{
+			name:       "absent fields are left unchanged",
+			body:       map[string]interface{}{"lastName": "Nowak"},
+			wantStatus: http.StatusOK,
+			want: func(user models.User) bool {
+				return user.LastName == "Nowak" && user.FirstName == "Anna" && user.Address == "Main St 1" && user.PhoneNumber == "+48123456789"
+			},
+		},
+		{
+			name:       "explicit empty strings clear optional fields",
+			body:       map[string]interface{}{"address": "", "phoneNumber": ""},
+			wantStatus: http.StatusOK,
+			want: func(user models.User) bool {
+				return user.Address == "" && user.PhoneNumber == "" && user.FirstName == "Anna"
+			},
+		},
+		{name: "empty first name", body: map[string]interface{}{"firstName": ""}, wantStatus: http.StatusBadRequest},
+		{name: "invalid phone number", body: map[string]interface{}{"phoneNumber": "call me"}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			user := api.createUser(t, models.RolePatient)
+			if err := api.db.Model(user).Updates(map[string]interface{}{
+				"first_name": "Anna", "address": "Main St 1", "phone_number": "+48123456789",
+			}).Error; err != nil {
+				t.Fatalf("filling profile: %v", err)
+			}
+
+			recorder := testutil.PerformRequest(t, api.router, http.MethodPut, "/api/v1/auth/profile", tt.body, api.auth(t, user))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+
+			var stored models.User
+			if err := api.db.First(&stored, "id = ?", user.ID).Error; err != nil {
+				t.Fatalf("reloading user: %v", err)
+			}
+			want := tt.want
+			if want == nil {
+				// A rejected update changes nothing
+				want = func(user models.User) bool {
+					return user.FirstName == "Anna" && user.Address == "Main St 1" && user.PhoneNumber == "+48123456789"
+				}
+			}
+			if !want(stored) {
+				t.Errorf("stored profile = %q %q, address %q, phone %q", stored.FirstName, stored.LastName, stored.Address, stored.PhoneNumber)
+			}
+		})
+	}
+}
+
+func TestRegisterNeverGrantsElevatedRoles(t *testing.T) {
+	api := newTestAPI(t)
+	register := func(t *testing.T, email string, extra map[string]interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body := map[string]interface{}{"firstName": "Eve", "lastName": "Mallory", "email": email, "password": testPassword}
+		for key, value := range extra {
+			body[key] = value
+		}
+		return testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/auth/register", body, nil)
+	}
+
+	// Asking for an elevated role is refused outright, in any casing
+	for _, role := range []string{"ADMIN", "admin", "Doctor", "DOCTOR", "super_admin", "SUPER_ADMIN"} {
+		t.Run(role, func(t *testing.T) {
+			email := "crafted-" + strings.ToLower(role) + "@example.test"
+			recorder := register(t, email, map[string]interface{}{"role": role})
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+			}
+			var count int64
+			api.db.Model(&models.User{}).Where("email = ?", email).Count(&count)
+			if count != 0 {
+				t.Errorf("a %s registration created a user", role)
+			}
+		})
+	}
+
+	// Patients, including old clients that send the role in uppercase, get patient claims whatever else they send
+	for name, extra := range map[string]map[string]interface{}{
+		"no role":           nil,
+		"uppercase patient": {"role": "PATIENT"},
+		"smuggled fields":   {"role": "patient", "Role": "admin", "isAdmin": true, "user_role": "admin", "isVerified": true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			email := strings.ReplaceAll(name, " ", "-") + "@example.test"
+			recorder := register(t, email, extra)
+			if recorder.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body.String())
+			}
+
+			var user models.User
+			if err := api.db.First(&user, "email = ?", email).Error; err != nil {
+				t.Fatalf("loading registered user: %v", err)
+			}
+			if user.Role != models.RolePatient {
+				t.Fatalf("stored role = %q, want %q", user.Role, models.RolePatient)
+			}
+
+			claims, err := utils.ValidateAccessToken(api.login(t, &user).AccessToken, api.cfg)
+			if err != nil {
+				t.Fatalf("validating access token: %v", err)
+			}
+			if claims.Role != models.RolePatient || claims.Role.IsAdmin() {
+				t.Errorf("access token role = %q, want %q", claims.Role, models.RolePatient)
+			}
+
+			recorder = testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/users", nil,
+				map[string]string{"Authorization": testutil.BearerHeader(api.login(t, &user).AccessToken)})
+			if recorder.Code != http.StatusForbidden {
+				t.Errorf("admin route status = %d, want %d", recorder.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenIdleExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		idleHours  int
+		unusedFor  time.Duration
+		wantStatus int
+	}{
+		{"used within the idle window", 72, 71 * time.Hour, http.StatusOK},
+		{"idle past the window", 72, 73 * time.Hour, http.StatusUnauthorized},
+		{"idle expiry disabled", 0, 30 * 24 * time.Hour, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, func(cfg *config.Config) {
+				cfg.RefreshIdleHours = tt.idleHours
+				cfg.JWTRefreshExpirationHours = 90 * 24 // Well past every idle period tried, so only idleness can end the session
+			})
+			user := api.createUserWithPassword(t, models.RolePatient)
+			issued := api.login(t, user)
+
+			lastUsed := time.Now().Add(-tt.unusedFor)
+			if err := api.db.Model(&models.RefreshToken{}).Where("token = ?", issued.RefreshToken).
+				Update("last_used_at", lastUsed).Error; err != nil {
+				t.Fatalf("ageing refresh token: %v", err)
+			}
+
+			recorder := api.refresh(t, issued.RefreshToken)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+
+			if tt.wantStatus == http.StatusOK {
+				// A successful refresh starts the idle window again
+				var rotated handlers.RefreshTokenResponse
+				decodeData(t, recorder, &rotated)
+				var stored models.RefreshToken
+				if err := api.db.First(&stored, "token = ?", rotated.RefreshToken).Error; err != nil {
+					t.Fatalf("loading rotated token: %v", err)
+				}
+				if stored.LastUsedAt == nil || time.Since(*stored.LastUsedAt) > time.Minute {
+					t.Errorf("rotated token last used at %v, want now", stored.LastUsedAt)
+				}
+				return
+			}
+
+			response := testutil.DecodeResponse(t, recorder)
+			if response.Code != utils.CodeRefreshTokenExpired {
+				t.Errorf("code = %q, want %q", response.Code, utils.CodeRefreshTokenExpired)
+			}
+			if want := i18n.Translate("en", "auth.session_idle_expired", nil); response.Error != want {
+				t.Errorf("error = %q, want %q", response.Error, want)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenFailureModes(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.createUserWithPassword(t, models.RolePatient)
+	now := time.Now()
+	refreshClaims := func(expires time.Time) *utils.Claims {
+		return &utils.Claims{UserID: user.ID, Role: user.Role, RegisteredClaims: jwt.RegisteredClaims{
+			ID: now.String(), Subject: user.ID, IssuedAt: jwt.NewNumericDate(expires.Add(-time.Hour)), ExpiresAt: jwt.NewNumericDate(expires),
+		}}
+	}
+	sign := func(claims *utils.Claims, secret string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("signing token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode string
+		wantKey  string
+	}{
+		{"expired", sign(refreshClaims(now.Add(-time.Minute)), api.cfg.JWTRefreshSecret), utils.CodeRefreshTokenExpired, "auth.refresh_token_expired"},
+		{"garbage", testutil.GarbageToken, utils.CodeInvalidToken, "auth.invalid_refresh_token"},
+		{"wrong secret", sign(refreshClaims(now.Add(time.Hour)), "another_secret"), utils.CodeInvalidToken, "auth.invalid_refresh_token"},
+		{"access token as refresh token", api.login(t, user).AccessToken, utils.CodeInvalidToken, "auth.invalid_refresh_token"},
+		{"never issued", sign(refreshClaims(now.Add(time.Hour)), api.cfg.JWTRefreshSecret), "", "auth.refresh_token_invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := api.refresh(t, tt.token)
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusUnauthorized, recorder.Body.String())
+			}
+			response := testutil.DecodeResponse(t, recorder)
+			if response.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", response.Code, tt.wantCode)
+			}
+			if want := i18n.Translate("en", tt.wantKey, nil); response.Error != want {
+				t.Errorf("error = %q, want %q", response.Error, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"bytes"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smallBodyLimits lowers the body limits so the tests need not send megabytes.
+func smallBodyLimits(cfg *config.Config) {
+	cfg.MaxBodyBytes = 4 << 10
+	cfg.MaxUploadBytes = 64 << 10
+	cfg.MaxMultipartMemory = 16 << 10
+	cfg.MaxMessageLength = 100000
+}
+
+func TestJSONBodySizeLimit(t *testing.T) {
+	api := newTestAPI(t, smallBodyLimits)
+	patient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+	start := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Minute)
+	api.create(t, &models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: models.StatusConfirmed})
+
+	tests := []struct {
+		name       string
+		content    string
+		wantStatus int
+	}{
+		{"under the limit", "When should I stop taking the antibiotics?", http.StatusCreated},
+		{"over the limit", strings.Repeat("a", 8<<10), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := map[string]string{"recipientId": doctor.ID, "content": tt.content}
+			recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/messages/send", body, api.auth(t, patient))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if response := testutil.DecodeResponse(t, recorder); response.Status != tt.wantStatus {
+				t.Errorf("envelope status = %d, want %d", response.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUploadRoutesGetTheRaisedBodySizeLimit(t *testing.T) {
+	api := newTestAPI(t, smallBodyLimits)
+	patient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+	record := models.MedicalRecord{PatientID: patient.ID, DoctorID: doctor.ID, RecordType: models.RecordTypeLabResult, RecordDate: time.Now().UTC(), Title: "Blood panel"}
+	api.create(t, &record)
+
+	tests := []struct {
+		name       string
+		size       int
+		wantStatus int
+	}{
+		// Over the global JSON limit, but within the upload limit
+		{"within the upload limit", 32 << 10, http.StatusOK},
+		{"over the upload limit", 128 << 10, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("file", "results.txt")
+			if err != nil {
+				t.Fatalf("creating form file: %v", err)
+			}
+			part.Write(bytes.Repeat([]byte("glucose 5.1 mmol/L\n"), tt.size/19))
+			form.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/medical-records/"+record.ID+"/attachments", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+			req.Header.Set("Authorization", testutil.BearerHeader(testutil.MintAccessToken(t, api.cfg, doctor)))
+			recorder := httptest.NewRecorder()
+			api.router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+}
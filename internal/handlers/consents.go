@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"errors"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errConsentRevoked aborts the revocation transaction when the grant was revoked concurrently.
+var errConsentRevoked = errors.New("consent grant already revoked")
+
+// Ways a doctor can hold access to a patient's records under STRICT_RECORD_ACCESS.
+const (
+	recordAccessCreator = "creator" // The doctor wrote at least one of the records
+	recordAccessConsent = "consent" // The patient granted consent
+)
+
+// ConsentHandler handles the consent patients give doctors to read their medical records.
+type ConsentHandler struct {
+	DB  *gorm.DB
+	Cfg *config.Config
+}
+
+// NewConsentHandler creates a new ConsentHandler.
+func NewConsentHandler(db *gorm.DB, cfg *config.Config) *ConsentHandler {
+	return &ConsentHandler{DB: db, Cfg: cfg}
+}
+
+// CreateConsentGrantRequest represents the request body for a patient granting a doctor consent.
+type CreateConsentGrantRequest struct {
+	DoctorID  string              `json:"doctorId" binding:"required,uuid"`
+	Scope     models.ConsentScope `json:"scope" binding:"required,oneof=all_records records"`
+	RecordIDs []string            `json:"recordIds" binding:"omitempty,max=100,dive,uuid"` // Required with the records scope
+	ExpiresAt *time.Time          `json:"expiresAt"`                                       // RFC3339; omitted for a grant that lasts until revoked
+}
+
+// RecordAccessHolder is a doctor who can read a patient's records and the ways they hold that access.
+type RecordAccessHolder struct {
+	Doctor models.UserSanitized `json:"doctor"`
+	// "creator" when the doctor wrote some of the records, "consent" when they hold an active grant
+	Mechanisms      []string `json:"mechanisms"`
+	ConsentGrantIDs []string `json:"consentGrantIds,omitempty"`
+}
+
+// RecordAccessResponse lists who can read the requesting patient's records.
+type RecordAccessResponse struct {
+	// Without strict access every doctor can read the records, and the holders only tell who wrote them or holds consent
+	StrictAccess bool                 `json:"strictAccess"`
+	Holders      []RecordAccessHolder `json:"holders"`
+}
+
+// activeConsent limits a query on consent grants to those neither revoked nor expired.
+func activeConsent(db *gorm.DB) *gorm.DB {
+	return db.Where("consent_grants.revoked = ? AND (consent_grants.expires_at IS NULL OR consent_grants.expires_at > ?)", false, time.Now().UTC())
+}
+
+// consentedRecords returns what the active grants of patientID give doctorID: every record, or only the
+// records with the returned IDs.
+func consentedRecords(db *gorm.DB, doctorID, patientID string) (bool, []string, error) {
+	var grants []models.ConsentGrant
+	if err := activeConsent(db.Model(&models.ConsentGrant{})).
+		Where("patient_id = ? AND doctor_id = ?", patientID, doctorID).
+		Preload("Records").Find(&grants).Error; err != nil {
+		return false, nil, err
+	}
+	recordIDs := []string{}
+	for _, grant := range grants {
+		if grant.Scope == models.ConsentScopeAllRecords {
+			return true, nil, nil
+		}
+		for _, record := range grant.Records {
+			recordIDs = append(recordIDs, record.MedicalRecordID)
+		}
+	}
+	return false, recordIDs, nil
+}
+
+// doctorCanViewRecord reports whether doctorID may read record. Without STRICT_RECORD_ACCESS every doctor
+// may; with it, only the doctor who wrote the record and doctors the patient consented to.
+func doctorCanViewRecord(db *gorm.DB, cfg *config.Config, doctorID string, record models.MedicalRecord) (bool, error) {
+	if !cfg.StrictRecordAccess || record.DoctorID == doctorID {
+		return true, nil
+	}
+	all, recordIDs, err := consentedRecords(db, doctorID, record.PatientID)
+	if err != nil || all {
+		return all, err
+	}
+	for _, recordID := range recordIDs {
+		if recordID == record.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateConsentGrant handles a patient letting a doctor read their records, all of them or the listed ones,
+// optionally until expiresAt.
+func (h *ConsentHandler) CreateConsentGrant(c *gin.Context) {
+	var req CreateConsentGrantRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	if req.Scope == models.ConsentScopeAllRecords && len(req.RecordIDs) > 0 {
+		utils.BadRequest(c, "consents.record_ids_not_allowed")
+		return
+	}
+	if req.Scope == models.ConsentScopeRecords && len(req.RecordIDs) == 0 {
+		utils.BadRequest(c, "consents.record_ids_required")
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		utils.BadRequest(c, "consents.expiry_in_past")
+		return
+	}
+
+	patientID, _ := middleware.GetUserIDFromContext(c)
+
+	var doctor models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", req.DoctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	grant := models.ConsentGrant{
+		PatientID: patientID,
+		DoctorID:  doctor.ID,
+		Scope:     req.Scope,
+	}
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		grant.ExpiresAt = &expiresAt
+	}
+	if req.Scope == models.ConsentScopeRecords {
+		recordIDs := make(map[string]bool, len(req.RecordIDs))
+		for _, recordID := range req.RecordIDs {
+			recordIDs[recordID] = true
+		}
+		var owned int64
+		if err := reqDB(h.DB, c).Model(&models.MedicalRecord{}).
+			Where("id IN ? AND patient_id = ?", req.RecordIDs, patientID).
+			Count(&owned).Error; err != nil {
+			utils.HandleDBError(c, err, "common.database_error")
+			return
+		}
+		if int(owned) != len(recordIDs) {
+			utils.BadRequest(c, "consents.record_not_owned")
+			return
+		}
+		for recordID := range recordIDs {
+			grant.Records = append(grant.Records, models.ConsentGrantRecord{MedicalRecordID: recordID})
+		}
+	}
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&grant).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, patientID, "consent.granted", auditEntityConsentGrant, grant.ID, "doctor="+doctor.ID+" scope="+string(grant.Scope))
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "consents.create_failed")
+		return
+	}
+
+	utils.Created(c, "Consent granted successfully", grant)
+}
+
+// RevokeConsentGrant handles a patient revoking one of their grants. The doctor loses the access at once.
+func (h *ConsentHandler) RevokeConsentGrant(c *gin.Context) {
+	grantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "consents.invalid_id")
+		return
+	}
+	patientID, _ := middleware.GetUserIDFromContext(c)
+
+	var grant models.ConsentGrant
+	if err := reqDB(h.DB, c).Where("id = ? AND patient_id = ?", grantID, patientID).First(&grant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "consents.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	if grant.Revoked {
+		utils.Conflict(c, "consents.already_revoked")
+		return
+	}
+
+	now := time.Now().UTC()
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ConsentGrant{}).
+			Where("id = ? AND revoked = ?", grant.ID, false).
+			Updates(map[string]interface{}{"revoked": true, "revoked_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errConsentRevoked
+		}
+		return recordAudit(tx, patientID, "consent.revoked", auditEntityConsentGrant, grant.ID, "doctor="+grant.DoctorID)
+	})
+	if errors.Is(err, errConsentRevoked) {
+		utils.Conflict(c, "consents.already_revoked")
+		return
+	}
+	if err != nil {
+		utils.HandleDBError(c, err, "consents.revoke_failed")
+		return
+	}
+
+	grant.Revoked = true
+	grant.RevokedAt = &now
+	utils.Success(c, "Consent revoked successfully", grant)
+}
+
+// GetConsentGrants handles listing consent grants, newest first: the grants a patient gave, including
+// revoked and expired ones, or the active grants a doctor holds. Supports page and limit.
+func (h *ConsentHandler) GetConsentGrants(c *gin.Context) {
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.ConsentGrant{})
+	switch userRole {
+	case models.RolePatient:
+		query = query.Where("patient_id = ?", userID)
+	case models.RoleDoctor:
+		query = activeConsent(query).Where("doctor_id = ?", userID)
+	default:
+		utils.Forbidden(c, "consents.list_forbidden")
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "consents.fetch_failed")
+		return
+	}
+
+	var grants []models.ConsentGrant
+	if err := query.Preload("Records").Order("created_at desc").
+		Offset(pagination.Offset).Limit(pagination.Limit).Find(&grants).Error; err != nil {
+		utils.HandleDBError(c, err, "consents.fetch_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Consent grants fetched successfully", grants, pagination.Meta(total))
+}
+
+// GetRecordAccess handles a patient asking which doctors can read their records and how: as the writer
+// of some of them, through an active consent grant, or both.
+func (h *ConsentHandler) GetRecordAccess(c *gin.Context) {
+	patientID, _ := middleware.GetUserIDFromContext(c)
+
+	var creatorIDs []string
+	if err := reqDB(h.DB, c).Model(&models.MedicalRecord{}).Where("patient_id = ?", patientID).
+		Distinct().Pluck("doctor_id", &creatorIDs).Error; err != nil {
+		utils.HandleDBError(c, err, "consents.fetch_failed")
+		return
+	}
+	var grants []models.ConsentGrant
+	if err := activeConsent(reqDB(h.DB, c)).Where("patient_id = ?", patientID).Order("created_at asc").
+		Find(&grants).Error; err != nil {
+		utils.HandleDBError(c, err, "consents.fetch_failed")
+		return
+	}
+
+	holders := map[string]*RecordAccessHolder{}
+	holder := func(doctorID string) *RecordAccessHolder {
+		if holders[doctorID] == nil {
+			holders[doctorID] = &RecordAccessHolder{}
+		}
+		return holders[doctorID]
+	}
+	for _, doctorID := range creatorIDs {
+		holder(doctorID).Mechanisms = append(holder(doctorID).Mechanisms, recordAccessCreator)
+	}
+	for _, grant := range grants {
+		entry := holder(grant.DoctorID)
+		if len(entry.ConsentGrantIDs) == 0 {
+			entry.Mechanisms = append(entry.Mechanisms, recordAccessConsent)
+		}
+		entry.ConsentGrantIDs = append(entry.ConsentGrantIDs, grant.ID)
+	}
+
+	doctorIDs := make([]string, 0, len(holders))
+	for doctorID := range holders {
+		doctorIDs = append(doctorIDs, doctorID)
+	}
+	var doctors []models.User
+	if err := reqDB(h.DB, c).Where("id IN ?", doctorIDs).Find(&doctors).Error; err != nil {
+		utils.HandleDBError(c, err, "consents.fetch_failed")
+		return
+	}
+
+	response := RecordAccessResponse{StrictAccess: h.Cfg.StrictRecordAccess, Holders: make([]RecordAccessHolder, 0, len(doctors))}
+	for _, doctor := range doctors {
+		entry := *holders[doctor.ID]
+		entry.Doctor = doctor.Sanitize()
+		response.Holders = append(response.Holders, entry)
+	}
+	sort.Slice(response.Holders, func(i, j int) bool {
+		a, b := response.Holders[i].Doctor, response.Holders[j].Doctor
+		if a.LastName != b.LastName {
+			return a.LastName < b.LastName
+		}
+		return a.FirstName < b.FirstName
+	})
+
+	utils.Success(c, "Record access fetched successfully", response)
+}
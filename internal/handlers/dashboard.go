@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
+	"healthcare-app-server/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileDashboard is the role-aware summary the frontend dashboard loads in one call.
+type ProfileDashboard struct {
+	User                     models.UserSanitized `json:"user"`
+	UpcomingAppointmentCount int64                `json:"upcomingAppointmentCount"`
+	UnreadMessageCount       int64                `json:"unreadMessageCount"`
+	// Only set for doctors
+	Doctor *DoctorProfileDetails `json:"doctor,omitempty"`
+}
+
+// GetProfileDashboard handles fetching the current user's profile together with the counts and
+// role-specific details the dashboard shows: upcoming appointments and unread messages for everyone,
+// plus specialty and availability for doctors.
+func (h *AuthHandler) GetProfileDashboard(c *gin.Context) {
+	user, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		utils.InternalServerError(c, "auth.user_missing")
+		return
+	}
+
+	dashboard := ProfileDashboard{User: user.Sanitize()}
+
+	// Appointments are counted on the side of the calendar the user is on
+	appointmentColumn := "patient_id"
+	isDoctor := user.Role == models.RoleDoctor
+	if isDoctor {
+		appointmentColumn = "doctor_id"
+	}
+	if err := reqDB(h.DB, c).Model(&models.Appointment{}).
+		Where(appointmentColumn+" = ? AND status IN ? AND start_time >= ?", user.ID, scheduling.BlockingStatuses, time.Now()).
+		Count(&dashboard.UpcomingAppointmentCount).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.dashboard_failed")
+		return
+	}
+
+	if err := reqDB(h.DB, c).Model(&models.Message{}).
+		Where("receiver_id = ? AND status = ?", user.ID, models.MessageStatusSent).
+		Count(&dashboard.UnreadMessageCount).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.dashboard_failed")
+		return
+	}
+
+	if isDoctor {
+		details, err := loadDoctorProfile(reqDB(h.DB, c), user.ID)
+		if err != nil {
+			utils.HandleDBError(c, err, "auth.dashboard_failed")
+			return
+		}
+		dashboard.Doctor = &details
+	}
+
+	utils.Success(c, "Dashboard fetched successfully", dashboard)
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// reqDB returns db bound to the request context, so queries stop when the client goes away.
+func reqDB(db *gorm.DB, c *gin.Context) *gorm.DB {
+	return db.WithContext(c.Request.Context())
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DepartmentHandler handles department related requests.
+type DepartmentHandler struct {
+	DB *gorm.DB
+}
+
+// NewDepartmentHandler creates a new DepartmentHandler.
+func NewDepartmentHandler(db *gorm.DB) *DepartmentHandler {
+	return &DepartmentHandler{DB: db}
+}
+
+// CreateDepartmentRequest represents the request body for creating a department.
+type CreateDepartmentRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	Active      *bool  `json:"active"` // Defaults to true
+}
+
+// UpdateDepartmentRequest represents the request body for updating a department.
+// Only the fields present in the body are changed.
+type UpdateDepartmentRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description"`
+	Active      *bool   `json:"active"`
+}
+
+// AssignDoctorDepartmentRequest represents the request body for adding a doctor to a department.
+type AssignDoctorDepartmentRequest struct {
+	DoctorID string `json:"doctorId" binding:"required,uuid"`
+}
+
+// GetDepartments handles listing departments for dropdowns.
+// Only active departments are returned, unless an admin asks for ?includeInactive=true.
+func (h *DepartmentHandler) GetDepartments(c *gin.Context) {
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	isAdmin := userRole.IsAdmin()
+
+	query := reqDB(h.DB, c).Order("name asc")
+	if !(isAdmin && c.Query("includeInactive") == "true") {
+		query = query.Where("active = ?", true)
+	}
+
+	var departments []models.Department
+	if err := query.Find(&departments).Error; err != nil {
+		utils.HandleDBError(c, err, "departments.fetch_failed")
+		return
+	}
+
+	utils.Success(c, "Departments fetched successfully", departments)
+}
+
+// loadDepartment loads the department in the :id path parameter, writing the error response itself on failure.
+// Inactive departments are only found for admins.
+func (h *DepartmentHandler) loadDepartment(c *gin.Context) (models.Department, bool) {
+	var department models.Department
+	departmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "departments.invalid_id")
+		return department, false
+	}
+
+	query := reqDB(h.DB, c)
+	if userRole, _ := middleware.GetUserRoleFromContext(c); !userRole.IsAdmin() {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.First(&department, "id = ?", departmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "departments.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return department, false
+	}
+	return department, true
+}
+
+// GetDepartmentDoctors handles listing the doctors of a department for the booking flow, with their
+// review ratings. Supports page and limit.
+func (h *DepartmentHandler) GetDepartmentDoctors(c *gin.Context) {
+	department, ok := h.loadDepartment(c)
+	if !ok {
+		return
+	}
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	doctorIDs := []string{}
+	if err := reqDB(h.DB, c).Model(&models.DoctorDepartment{}).Where("department_id = ?", department.ID).
+		Pluck("doctor_id", &doctorIDs).Error; err != nil {
+		utils.HandleDBError(c, err, "departments.fetch_doctors_failed")
+		return
+	}
+	page, err := loadDoctorListPage(reqDB(h.DB, c), pagination, doctorIDs)
+	if err != nil {
+		utils.HandleDBError(c, err, "departments.fetch_doctors_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Department doctors fetched successfully", page.Items, pagination.Meta(page.Total))
+}
+
+// CreateDepartment handles creating a department (admin).
+func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
+	var req CreateDepartmentRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	department := models.Department{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Active:      req.Active == nil || *req.Active,
+	}
+
+	if err := reqDB(h.DB, c).Create(&department).Error; err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.Conflict(c, "departments.name_taken")
+		} else {
+			utils.HandleDBError(c, err, "departments.create_failed")
+		}
+		return
+	}
+
+	utils.Created(c, "Department created successfully", department)
+}
+
+// UpdateDepartment handles updating a department (admin).
+// Renaming does not change the department stored on existing medical records.
+func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
+	department, ok := h.loadDepartment(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateDepartmentRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	if req.Name != nil {
+		department.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		department.Description = *req.Description
+	}
+	if req.Active != nil {
+		department.Active = *req.Active
+	}
+
+	if err := reqDB(h.DB, c).Save(&department).Error; err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.Conflict(c, "departments.name_taken")
+		} else {
+			utils.HandleDBError(c, err, "departments.update_failed")
+		}
+		return
+	}
+
+	utils.Success(c, "Department updated successfully", department)
+}
+
+// DeleteDepartment handles deleting a department together with its doctor assignments (admin).
+// Medical records keep the department name they were written with.
+func (h *DepartmentHandler) DeleteDepartment(c *gin.Context) {
+	department, ok := h.loadDepartment(c)
+	if !ok {
+		return
+	}
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("department_id = ?", department.ID).Delete(&models.DoctorDepartment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&department).Error
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "departments.delete_failed")
+		return
+	}
+
+	utils.Success(c, "Department deleted successfully", nil)
+}
+
+// AssignDoctorDepartment handles adding a doctor to a department (admin). Adding a doctor twice is not an error.
+func (h *DepartmentHandler) AssignDoctorDepartment(c *gin.Context) {
+	department, ok := h.loadDepartment(c)
+	if !ok {
+		return
+	}
+
+	var req AssignDoctorDepartmentRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	// Scoped to the admin's organization, so doctors of other clinics are not found
+	var doctor models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", req.DoctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	assignment := models.DoctorDepartment{DoctorID: doctor.ID, DepartmentID: department.ID}
+	err := reqDB(h.DB, c).Where("doctor_id = ? AND department_id = ?", doctor.ID, department.ID).
+		FirstOrCreate(&assignment).Error
+	if err != nil {
+		utils.HandleDBError(c, err, "departments.assign_failed")
+		return
+	}
+
+	utils.Success(c, "Doctor added to department successfully", assignment)
+}
+
+// UnassignDoctorDepartment handles removing a doctor from a department (admin).
+func (h *DepartmentHandler) UnassignDoctorDepartment(c *gin.Context) {
+	department, ok := h.loadDepartment(c)
+	if !ok {
+		return
+	}
+	doctorID, err := uuid.Parse(c.Param("doctorId"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_doctor_id")
+		return
+	}
+
+	var doctor models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", doctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	result := reqDB(h.DB, c).Where("doctor_id = ? AND department_id = ?", doctor.ID, department.ID).
+		Delete(&models.DoctorDepartment{})
+	if result.Error != nil {
+		utils.HandleDBError(c, result.Error, "departments.unassign_failed")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.NotFound(c, "departments.doctor_not_assigned")
+		return
+	}
+
+	utils.Success(c, "Doctor removed from department successfully", nil)
+}
+
+// departmentName returns the name of the active department departmentID, or gorm.ErrRecordNotFound.
+func departmentName(db *gorm.DB, departmentID string) (string, error) {
+	var department models.Department
+	if err := db.Select("name").Where("id = ? AND active = ?", departmentID, true).First(&department).Error; err != nil {
+		return "", err
+	}
+	return department.Name, nil
+}
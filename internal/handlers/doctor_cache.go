@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/cache"
+	"healthcare-app-server/internal/tenancy"
+	"healthcare-app-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// doctorCachePrefix starts the key of every cached doctor listing page and doctor profile.
+const doctorCachePrefix = "doctors:"
+
+// doctorListPage is a cached page of GetDoctors together with the total it was counted against.
+type doctorListPage struct {
+	Items []DoctorListItem `json:"items"`
+	Total int64            `json:"total"`
+}
+
+// doctorListCacheKey is the cache key of one GetDoctors page.
+func doctorListCacheKey(c *gin.Context, pagination utils.Pagination) string {
+	return fmt.Sprintf("%s%slist:page=%d:limit=%d", doctorCachePrefix, organizationCacheScope(c), pagination.Page, pagination.Limit)
+}
+
+// doctorProfileCacheKey is the cache key of a doctor's profile.
+func doctorProfileCacheKey(c *gin.Context, doctorID string) string {
+	return doctorCachePrefix + organizationCacheScope(c) + "profile:" + doctorID
+}
+
+// organizationCacheScope keeps the entries cached for one organization from being served to another,
+// since the queries behind them only see the requesting user's organization.
+func organizationCacheScope(c *gin.Context) string {
+	if organizationID, ok := tenancy.OrganizationID(c.Request.Context()); ok {
+		return "org=" + organizationID + ":"
+	}
+	return ""
+}
+
+// invalidateDoctorCache drops every cached doctor listing and profile. Listings embed names and
+// ratings of several doctors, so any change to a doctor clears them all rather than tracking
+// which pages the doctor appears on.
+func invalidateDoctorCache(c *gin.Context, doctors *cache.Loader) {
+	doctors.Invalidate(c.Request.Context(), doctorCachePrefix)
+}
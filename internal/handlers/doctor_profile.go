@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/cache"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"healthcare-app-server/internal/webhooks"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// availabilityTimeLayout is the HH:MM format of availability start and end times.
+const availabilityTimeLayout = "15:04"
+
+// DoctorProfileHandler handles doctor practice details: specialty, bio and weekly availability.
+type DoctorProfileHandler struct {
+	DB       *gorm.DB
+	Doctors  *cache.Loader        // Cached doctor listings and profiles, invalidated when a profile is saved
+	Webhooks *webhooks.Dispatcher // Receives appointment.cancelled for appointments cancelled by time off
+}
+
+// NewDoctorProfileHandler creates a new DoctorProfileHandler.
+func NewDoctorProfileHandler(db *gorm.DB, doctors *cache.Loader, webhooks *webhooks.Dispatcher) *DoctorProfileHandler {
+	return &DoctorProfileHandler{DB: db, Doctors: doctors, Webhooks: webhooks}
+}
+
+// DoctorProfileDetails is a doctor's specialty, bio and weekly availability.
+// Doctors who never saved a profile have empty details.
+type DoctorProfileDetails struct {
+	Specialty             string                      `json:"specialty"`
+	Bio                   string                      `json:"bio"`
+	MaxConcurrentPerSlot  int                         `json:"maxConcurrentPerSlot"`
+	MaxAppointmentsPerDay int                         `json:"maxAppointmentsPerDay"` // 0 means no limit
+	Timezone              string                      `json:"timezone"`              // Default for the day schedule, empty for UTC
+	Availability          []models.DoctorAvailability `json:"availability"`
+}
+
+// DoctorProfileResponse represents a doctor's sanitized user details together with their profile.
+type DoctorProfileResponse struct {
+	Doctor models.UserSanitized `json:"doctor"`
+	DoctorProfileDetails
+}
+
+// AvailabilityBlockRequest represents one weekly block of working hours.
+type AvailabilityBlockRequest struct {
+	Weekday   int    `json:"weekday" binding:"min=0,max=6"`
+	StartTime string `json:"startTime" binding:"required"`
+	EndTime   string `json:"endTime" binding:"required"`
+}
+
+// UpdateDoctorProfileRequest represents the request body for saving a doctor's profile.
+// The availability list replaces the doctor's whole weekly schedule; omitted booking limits and timezone keep their value.
+type UpdateDoctorProfileRequest struct {
+	Specialty             string                     `json:"specialty" binding:"max=100"`
+	Bio                   string                     `json:"bio"`
+	MaxConcurrentPerSlot  *int                       `json:"maxConcurrentPerSlot" binding:"omitempty,min=1"`
+	MaxAppointmentsPerDay *int                       `json:"maxAppointmentsPerDay" binding:"omitempty,min=0"`
+	Timezone              *string                    `json:"timezone" binding:"omitempty,timezone,max=64"` // IANA name, "" for UTC
+	Availability          []AvailabilityBlockRequest `json:"availability" binding:"dive"`
+}
+
+// loadDoctorProfile returns the doctor's profile details, ordered by weekday and start time.
+func loadDoctorProfile(db *gorm.DB, doctorID string) (DoctorProfileDetails, error) {
+	details := DoctorProfileDetails{MaxConcurrentPerSlot: 1, Availability: []models.DoctorAvailability{}}
+
+	var profile models.DoctorProfile
+	if err := db.Where("doctor_id = ?", doctorID).First(&profile).Error; err == nil {
+		details.Specialty = profile.Specialty
+		details.Bio = profile.Bio
+		details.MaxConcurrentPerSlot = profile.MaxConcurrentPerSlot
+		details.MaxAppointmentsPerDay = profile.MaxAppointmentsPerDay
+		details.Timezone = profile.Timezone
+	} else if err != gorm.ErrRecordNotFound {
+		return details, err
+	}
+
+	if err := db.Where("doctor_id = ?", doctorID).Order("weekday asc, start_time asc").Find(&details.Availability).Error; err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+// findDoctor loads the user with the :id path parameter and writes an error response unless it is a doctor.
+func (h *DoctorProfileHandler) findDoctor(c *gin.Context) (models.User, bool) {
+	var doctor models.User
+	doctorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_doctor_id")
+		return doctor, false
+	}
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", doctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "appointments.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return doctor, false
+	}
+	return doctor, true
+}
+
+// GetDoctorProfile handles fetching a doctor's specialty, bio and weekly availability.
+// Accessible by all authenticated users. Profiles are cached like the doctor listing.
+func (h *DoctorProfileHandler) GetDoctorProfile(c *gin.Context) {
+	doctorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_doctor_id")
+		return
+	}
+
+	var response DoctorProfileResponse
+	err = h.Doctors.Load(c.Request.Context(), doctorProfileCacheKey(c, doctorID.String()), &response, func() (interface{}, error) {
+		var doctor models.User
+		if err := reqDB(h.DB, c).Where("id = ? AND role = ?", doctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+			return nil, err
+		}
+		details, err := loadDoctorProfile(reqDB(h.DB, c), doctor.ID)
+		if err != nil {
+			return nil, err
+		}
+		return DoctorProfileResponse{Doctor: doctor.Sanitize(), DoctorProfileDetails: details}, nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "appointments.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "doctors.fetch_profile_failed")
+		}
+		return
+	}
+
+	utils.Success(c, "Doctor profile fetched successfully", response)
+}
+
+// UpdateDoctorProfile handles saving a doctor's profile and replacing their weekly availability.
+// Only the doctor themselves or an admin may change it.
+func (h *DoctorProfileHandler) UpdateDoctorProfile(c *gin.Context) {
+	var req UpdateDoctorProfileRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	doctor, ok := h.findDoctor(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !userRole.IsAdmin() && userID != doctor.ID {
+		utils.Forbidden(c, "doctors.profile_forbidden")
+		return
+	}
+
+	availability, ok := parseAvailability(c, doctor.ID, req.Availability)
+	if !ok {
+		return
+	}
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		profile := models.DoctorProfile{DoctorID: doctor.ID, MaxConcurrentPerSlot: 1}
+		if err := tx.Where("doctor_id = ?", doctor.ID).FirstOrInit(&profile).Error; err != nil {
+			return err
+		}
+		profile.Specialty = strings.TrimSpace(req.Specialty)
+		profile.Bio = req.Bio
+		if req.MaxConcurrentPerSlot != nil {
+			profile.MaxConcurrentPerSlot = *req.MaxConcurrentPerSlot
+		}
+		if req.MaxAppointmentsPerDay != nil {
+			profile.MaxAppointmentsPerDay = *req.MaxAppointmentsPerDay
+		}
+		if req.Timezone != nil {
+			profile.Timezone = *req.Timezone
+		}
+		if err := tx.Save(&profile).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("doctor_id = ?", doctor.ID).Delete(&models.DoctorAvailability{}).Error; err != nil {
+			return err
+		}
+		if len(availability) > 0 {
+			return tx.Create(&availability).Error
+		}
+		return nil
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "doctors.update_profile_failed")
+		return
+	}
+	invalidateDoctorCache(c, h.Doctors)
+
+	details, err := loadDoctorProfile(reqDB(h.DB, c), doctor.ID)
+	if err != nil {
+		utils.HandleDBError(c, err, "doctors.fetch_profile_failed")
+		return
+	}
+
+	utils.Success(c, "Doctor profile updated successfully", DoctorProfileResponse{
+		Doctor:               doctor.Sanitize(),
+		DoctorProfileDetails: details,
+	})
+}
+
+// parseAvailability validates the requested availability blocks: HH:MM times, end after start,
+// and no overlapping blocks on the same weekday. On failure it writes a 400 response.
+func parseAvailability(c *gin.Context, doctorID string, blocks []AvailabilityBlockRequest) ([]models.DoctorAvailability, bool) {
+	availability := make([]models.DoctorAvailability, 0, len(blocks))
+	for _, block := range blocks {
+		start, startErr := time.Parse(availabilityTimeLayout, block.StartTime)
+		end, endErr := time.Parse(availabilityTimeLayout, block.EndTime)
+		if startErr != nil || endErr != nil {
+			utils.BadRequest(c, "doctors.invalid_availability_time")
+			return nil, false
+		}
+		if !end.After(start) {
+			utils.BadRequest(c, "doctors.invalid_availability_range")
+			return nil, false
+		}
+		availability = append(availability, models.DoctorAvailability{
+			DoctorID:  doctorID,
+			Weekday:   block.Weekday,
+			StartTime: start.Format(availabilityTimeLayout),
+			EndTime:   end.Format(availabilityTimeLayout),
+		})
+	}
+
+	// HH:MM strings sort chronologically, so sorted neighbours are enough to find overlaps
+	sort.Slice(availability, func(i, j int) bool {
+		if availability[i].Weekday != availability[j].Weekday {
+			return availability[i].Weekday < availability[j].Weekday
+		}
+		return availability[i].StartTime < availability[j].StartTime
+	})
+	for i := 1; i < len(availability); i++ {
+		if availability[i].Weekday == availability[i-1].Weekday && availability[i].StartTime < availability[i-1].EndTime {
+			utils.BadRequest(c, "doctors.overlapping_availability")
+			return nil, false
+		}
+	}
+	return availability, true
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
+	"healthcare-app-server/internal/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSlotMinutes = 30  // Slot length when the duration parameter is omitted
+	maxSlotMinutes     = 480 // Longest slot that can be asked for, a full working day
+)
+
+// DoctorSlot is a bookable stretch of a doctor's working hours and how many more patients it takes.
+// Full slots are listed with no remaining capacity.
+type DoctorSlot struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Capacity  int       `json:"capacity"`  // The doctor's MaxConcurrentPerSlot
+	Remaining int       `json:"remaining"` // Appointments the slot still accepts, also limited by the daily cap
+}
+
+// DoctorSlotsResponse is the list of a doctor's slots on one day.
+type DoctorSlotsResponse struct {
+	Date            string       `json:"date"`
+	DoctorID        string       `json:"doctorId"`
+	Timezone        string       `json:"timezone"` // IANA name the date and working hours were taken in
+	DurationMinutes int          `json:"durationMinutes"`
+	Slots           []DoctorSlot `json:"slots"`
+}
+
+// GetDoctorSlots handles listing the slots of `duration` minutes (default 30) that a doctor's weekly
+// availability offers on `date` (YYYY-MM-DD, defaulting to today), with the remaining capacity of each.
+// Slots that have already started or that overlap the doctor's time off are left out. The date and the
+// working hours are taken in `tz`, else the doctor's profile timezone, else UTC, as in GetDaySchedule.
+// Accessible by all authenticated users.
+func (h *DoctorProfileHandler) GetDoctorSlots(c *gin.Context) {
+	duration := defaultSlotMinutes
+	if durationStr := c.Query("duration"); durationStr != "" {
+		parsed, err := strconv.Atoi(durationStr)
+		if err != nil || parsed < 1 || parsed > maxSlotMinutes {
+			utils.BadRequest(c, "doctors.invalid_slot_duration", utils.Params{"max": strconv.Itoa(maxSlotMinutes)})
+			return
+		}
+		duration = parsed
+	}
+
+	doctor, ok := h.findDoctor(c)
+	if !ok {
+		return
+	}
+	location, ok := scheduleLocation(c, reqDB(h.DB, c), doctor.ID)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	local := now.In(location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.ParseInLocation(scheduleDateLayout, dateStr, location)
+		if err != nil {
+			utils.BadRequest(c, "appointments.invalid_schedule_date")
+			return
+		}
+		day = parsed
+	}
+	nextDay := day.AddDate(0, 0, 1)
+
+	var availability []models.DoctorAvailability
+	if err := reqDB(h.DB, c).Where("doctor_id = ? AND weekday = ?", doctor.ID, int(day.Weekday())).
+		Order("start_time asc").
+		Find(&availability).Error; err != nil {
+		utils.HandleDBError(c, err, "doctors.fetch_slots_failed")
+		return
+	}
+	timeOff, err := scheduling.OverlappingTimeOff(reqDB(h.DB, c), doctor.ID, day.UTC(), nextDay.UTC(), "")
+	if err != nil {
+		utils.HandleDBError(c, err, "doctors.fetch_slots_failed")
+		return
+	}
+	limits, err := scheduling.LoadBookingLimits(reqDB(h.DB, c), doctor.ID)
+	if err != nil {
+		utils.HandleDBError(c, err, "doctors.fetch_slots_failed")
+		return
+	}
+
+	slots := []DoctorSlot{}
+	length := time.Duration(duration) * time.Minute
+	for _, block := range availability {
+		blockStart, blockEnd := availabilityBounds(day, block)
+		for start := blockStart; !start.Add(length).After(blockEnd); start = start.Add(length) {
+			end := start.Add(length)
+			if start.Before(now) || overlapsTimeOff(timeOff, start, end) {
+				continue
+			}
+			remaining, err := scheduling.RemainingCapacity(reqDB(h.DB, c), doctor.ID, limits, start.UTC(), end.UTC())
+			if err != nil {
+				utils.HandleDBError(c, err, "doctors.fetch_slots_failed")
+				return
+			}
+			slots = append(slots, DoctorSlot{
+				StartTime: start.UTC(),
+				EndTime:   end.UTC(),
+				Capacity:  limits.MaxConcurrentPerSlot,
+				Remaining: remaining,
+			})
+		}
+	}
+
+	utils.Success(c, "Slots fetched successfully", DoctorSlotsResponse{
+		Date:            day.Format(scheduleDateLayout),
+		DoctorID:        doctor.ID,
+		Timezone:        location.String(),
+		DurationMinutes: duration,
+		Slots:           slots,
+	})
+}
+
+// availabilityBounds returns when a weekly availability block starts and ends on day, in day's location.
+// Block times were validated as HH:MM when saved.
+func availabilityBounds(day time.Time, block models.DoctorAvailability) (time.Time, time.Time) {
+	at := func(clock string) time.Time {
+		parsed, _ := time.Parse(availabilityTimeLayout, clock)
+		return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, day.Location())
+	}
+	return at(block.StartTime), at(block.EndTime)
+}
+
+// overlapsTimeOff reports whether any part of [start, end) falls inside one of the time-off blocks.
+func overlapsTimeOff(timeOff []models.TimeOff, start, end time.Time) bool {
+	for _, block := range timeOff {
+		if block.StartTime.Before(end) && block.EndTime.After(start) {
+			return true
+		}
+	}
+	return false
+}
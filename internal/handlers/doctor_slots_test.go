@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/handlers"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newSlotsDoctor creates a doctor working 09:00-11:00 UTC on the weekday of day with the given booking limits.
+func newSlotsDoctor(t *testing.T, api *testAPI, day time.Time, perSlot, perDay int) *models.User {
+	t.Helper()
+
+	doctor := api.createUser(t, models.RoleDoctor)
+	api.create(t, &models.DoctorProfile{DoctorID: doctor.ID, MaxConcurrentPerSlot: perSlot, MaxAppointmentsPerDay: perDay})
+	api.create(t, &models.DoctorAvailability{DoctorID: doctor.ID, Weekday: int(day.Weekday()), StartTime: "09:00", EndTime: "11:00"})
+	return doctor
+}
+
+func getSlots(t *testing.T, api *testAPI, user *models.User, doctorID, query string) handlers.DoctorSlotsResponse {
+	t.Helper()
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/doctors/"+doctorID+"/slots?"+query, nil, api.auth(t, user))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	var response handlers.DoctorSlotsResponse
+	decodeData(t, recorder, &response)
+	return response
+}
+
+func TestGetDoctorSlotsExcludesTimeOffAndReportsRemainingCapacity(t *testing.T) {
+	api := newTestAPI(t)
+	day := time.Now().UTC().AddDate(0, 0, 7).Truncate(24 * time.Hour)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	doctor := newSlotsDoctor(t, api, day, 2, 0)
+	patient := api.createUser(t, models.RolePatient)
+
+	api.create(t, &models.TimeOff{DoctorID: doctor.ID, StartTime: at(10, 0), EndTime: at(10, 30), Reason: "Staff meeting", CreatedByID: doctor.ID})
+	api.create(t, &models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: at(9, 0), EndTime: at(9, 30), Status: models.StatusConfirmed})
+	// Cancelled appointments give their place back
+	api.create(t, &models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: at(9, 30), EndTime: at(10, 0), Status: models.StatusCancelled})
+
+	response := getSlots(t, api, patient, doctor.ID, "date="+day.Format("2006-01-02")+"&duration=30")
+
+	want := []struct {
+		start     time.Time
+		remaining int
+	}{
+		{at(9, 0), 1},
+		{at(9, 30), 2},
+		{at(10, 30), 2},
+	}
+	if len(response.Slots) != len(want) {
+		t.Fatalf("slots = %+v, want %d", response.Slots, len(want))
+	}
+	for i, slot := range response.Slots {
+		if !slot.StartTime.Equal(want[i].start) || !slot.EndTime.Equal(want[i].start.Add(30*time.Minute)) {
+			t.Errorf("slot %d = %s-%s, want start %s", i, slot.StartTime, slot.EndTime, want[i].start)
+		}
+		if slot.Capacity != 2 || slot.Remaining != want[i].remaining {
+			t.Errorf("slot %d capacity = %d remaining = %d, want 2 and %d", i, slot.Capacity, slot.Remaining, want[i].remaining)
+		}
+	}
+}
+
+func TestGetDoctorSlotsHonoursTheDailyLimit(t *testing.T) {
+	api := newTestAPI(t)
+	day := time.Now().UTC().AddDate(0, 0, 7).Truncate(24 * time.Hour)
+	doctor := newSlotsDoctor(t, api, day, 1, 1)
+	patient := api.createUser(t, models.RolePatient)
+	api.create(t, &models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: day.Add(9 * time.Hour), EndTime: day.Add(9*time.Hour + 30*time.Minute), Status: models.StatusPending})
+
+	response := getSlots(t, api, patient, doctor.ID, "date="+day.Format("2006-01-02")+"&duration=60")
+	if len(response.Slots) != 2 {
+		t.Fatalf("slots = %+v, want 2", response.Slots)
+	}
+	for _, slot := range response.Slots {
+		if slot.Remaining != 0 {
+			t.Errorf("slot at %s remaining = %d, want 0 once the day is full", slot.StartTime, slot.Remaining)
+		}
+	}
+}
+
+func TestGetDoctorSlotsValidatesParameters(t *testing.T) {
+	api := newTestAPI(t)
+	day := time.Now().UTC().AddDate(0, 0, 7)
+	doctor := newSlotsDoctor(t, api, day, 1, 0)
+	patient := api.createUser(t, models.RolePatient)
+
+	tests := []struct {
+		name       string
+		doctorID   string
+		query      string
+		wantStatus int
+	}{
+		{"zero duration", doctor.ID, "duration=0", http.StatusBadRequest},
+		{"duration over a day's work", doctor.ID, "duration=481", http.StatusBadRequest},
+		{"malformed date", doctor.ID, "date=next-monday", http.StatusBadRequest},
+		{"unknown timezone", doctor.ID, "tz=Mars/Olympus", http.StatusBadRequest},
+		{"not a doctor", patient.ID, "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/doctors/"+tt.doctorID+"/slots?"+tt.query, nil, api.auth(t, patient))
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/mailer"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// verificationResendLimiter caps how often the verification email can be requested for one address,
+// so the endpoint cannot be used to flood an inbox.
+var verificationResendLimiter = utils.NewRateLimiter(3, time.Hour)
+
+// issueVerificationToken sets a fresh email verification token on user, valid for
+// VERIFICATION_TOKEN_EXPIRY_HOURS, and returns the raw token to put in the link. Earlier links stop
+// working. The caller saves the user.
+func issueVerificationToken(cfg *config.Config, user *models.User) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	expiry := time.Now().Add(time.Duration(cfg.VerificationTokenExpiry) * time.Hour)
+	user.VerificationToken = hashToken(token)
+	user.VerificationTokenExpiry = &expiry
+	return token, nil
+}
+
+// sendVerificationEmail emails user their verification link. Failures are only logged.
+func sendVerificationEmail(cfg *config.Config, user models.User, token string) {
+	body := fmt.Sprintf("Hello %s,\n\nPlease confirm your email address here:\n%s\n\nThe link expires in %d hours.\n",
+		user.FirstName, frontendLink(cfg, "/verify-email", token), cfg.VerificationTokenExpiry)
+	if err := mailer.New(cfg.Mailer).Send(user.Email, "Confirm your email address", body); err != nil {
+		log.Printf("Failed to send verification email to %s: %v", user.Email, err)
+	}
+}
+
+// VerifyEmailRequest represents the request body for confirming an email address.
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail handles confirming an email address with the token from the verification link.
+// The token is single-use.
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	var user models.User
+	if err := reqDB(h.DB, c).Where("verification_token = ? AND verification_token_expiry > ?", hashToken(req.Token), time.Now()).
+		First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.BadRequest(c, "auth.verification_token_invalid")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	if err := reqDB(h.DB, c).Model(&user).Updates(map[string]interface{}{
+		"is_verified":               true,
+		"verification_token":        "",
+		"verification_token_expiry": nil,
+	}).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.verification_failed")
+		return
+	}
+
+	utils.Success(c, "Email address verified successfully", nil)
+}
+
+// ResendVerificationRequest represents the request body for asking for a new verification email.
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResendVerification handles emailing a new verification link to an unverified user. The answer is the
+// same whether or not the address belongs to an unverified user, so it cannot be used to find accounts.
+// Each address can ask a few times an hour.
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req ResendVerificationRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	email := strings.TrimSpace(req.Email)
+
+	if !verificationResendLimiter.Allow(strings.ToLower(email)) {
+		utils.TooManyRequests(c, "auth.verification_resend_limited")
+		return
+	}
+
+	var user models.User
+	err := reqDB(h.DB, c).Where("email = ? AND is_verified = ?", email, false).First(&user).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if err == nil {
+		token, err := issueVerificationToken(h.Cfg, &user)
+		if err != nil {
+			utils.InternalServerErrorWithDetail(c, "auth.verification_failed", err)
+			return
+		}
+		if err := reqDB(h.DB, c).Model(&user).Select("verification_token", "verification_token_expiry").Updates(&user).Error; err != nil {
+			utils.HandleDBError(c, err, "auth.verification_failed")
+			return
+		}
+		go sendVerificationEmail(h.Cfg, user, token)
+	}
+
+	utils.Success(c, "If the address belongs to an unverified account, a new verification email has been sent", nil)
+}
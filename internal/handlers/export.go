@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UserDataExport is the bundle returned by ExportData. It is built from the same response types the API
+// returns, so a column added to a model does not end up in exports unnoticed.
+type UserDataExport struct {
+	ExportedAt     time.Time                   `json:"exportedAt"`
+	Profile        models.UserSanitized        `json:"profile"`
+	Appointments   []dto.AppointmentResponse   `json:"appointments"`
+	MedicalRecords []dto.MedicalRecordResponse `json:"medicalRecords"`
+	Messages       []dto.MessageResponse       `json:"messages"`
+}
+
+// ExportData handles exporting all data belonging to the authenticated user as a downloadable JSON file.
+// Patients receive the appointments and records where they are the patient; doctors receive the ones they own.
+func (h *AuthHandler) ExportData(c *gin.Context) {
+	user, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		utils.InternalServerError(c, "auth.user_missing")
+		return
+	}
+
+	// Doctors own the appointments and records they created; everyone else is exported as the patient.
+	ownerColumn := "patient_id"
+	if user.Role == models.RoleDoctor {
+		ownerColumn = "doctor_id"
+	}
+
+	appointments := []models.Appointment{}
+	if err := reqDB(h.DB, c).Where(ownerColumn+" = ?", user.ID).Order("start_time asc").Find(&appointments).Error; err != nil {
+		utils.HandleDBError(c, err, "appointments.fetch_failed")
+		return
+	}
+	redactAppointmentsForRole(appointments, user.Role)
+
+	// Only attachment metadata is exported, so the file data column is never loaded.
+	records := []models.MedicalRecord{}
+	if err := reqDB(h.DB, c).Preload("Attachments", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "medical_record_id", "file_name", "file_type", "has_thumbnail", "created_at", "updated_at")
+	}).Where(ownerColumn+" = ?", user.ID).Order("record_date asc").Find(&records).Error; err != nil {
+		utils.HandleDBError(c, err, "records.fetch_failed")
+		return
+	}
+
+	var messages []models.Message
+	if err := reqDB(h.DB, c).Preload("Sender").Preload("Receiver").
+		Where("sender_id = ? OR receiver_id = ?", user.ID, user.ID).
+		Order("created_at asc").Find(&messages).Error; err != nil {
+		utils.HandleDBError(c, err, "messages.fetch_failed")
+		return
+	}
+
+	export := UserDataExport{
+		ExportedAt:     time.Now(),
+		Profile:        user.Sanitize(),
+		Appointments:   dto.NewAppointmentResponses(appointments),
+		MedicalRecords: dto.NewMedicalRecordResponses(records),
+		Messages:       dto.NewMessageResponses(messages),
+	}
+
+	// Stream the bundle straight to the response as a file download
+	fileName := fmt.Sprintf("medivuno-export-%s.json", time.Now().Format("2006-01-02"))
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	c.Status(http.StatusOK)
+	if err := json.NewEncoder(c.Writer).Encode(export); err != nil {
+		// Headers are already sent at this point, so the failure can only be logged
+		log.Printf("Failed to stream data export for user %s: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportDataRedactsPrivateNotesForPatients(t *testing.T) {
+	api := newTestAPI(t)
+	patient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+
+	start := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Minute)
+	api.create(t, &models.Appointment{
+		PatientID:    patient.ID,
+		DoctorID:     doctor.ID,
+		StartTime:    start,
+		EndTime:      start.Add(30 * time.Minute),
+		Status:       models.StatusConfirmed,
+		Notes:        "Bring previous results",
+		PrivateNotes: "Suspected hypertension",
+	})
+
+	tests := []struct {
+		name        string
+		user        *models.User
+		wantPrivate bool
+	}{
+		{"patient", patient, false},
+		{"doctor", doctor, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/auth/export", nil, api.auth(t, tt.user))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+			}
+
+			body := recorder.Body.String()
+			if !strings.Contains(body, "Bring previous results") {
+				t.Fatalf("export is missing the appointment: %s", body)
+			}
+			hasPrivate := strings.Contains(body, `"privateNotes"`) || strings.Contains(body, "Suspected hypertension")
+			if hasPrivate != tt.wantPrivate {
+				t.Errorf("private notes exported = %v, want %v: %s", hasPrivate, tt.wantPrivate, body)
+			}
+		})
+	}
+}
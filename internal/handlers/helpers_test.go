@@ -0,0 +1,188 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/handlers"
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/routes"
+	"healthcare-app-server/internal/slowlog"
+	"healthcare-app-server/internal/tenancy"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// testAPI is the full router wired to a fresh test database.
+type testAPI struct {
+	router *gin.Engine
+	db     *gorm.DB
+	cfg    *config.Config
+}
+
+// newTestAPI sets up the application routes on a fresh database, behind the global body size limit
+// and request timeout main installs. configure, when given, adjusts the configuration before the
+// routes are built; with MultiTenant set the database is scoped as main does.
+func newTestAPI(t *testing.T, configure ...func(*config.Config)) *testAPI {
+	t.Helper()
+
+	db := testutil.NewTestDB(t)
+	cfg := testutil.NewTestConfig()
+	for _, apply := range configure {
+		apply(cfg)
+	}
+	if cfg.MultiTenant {
+		if err := tenancy.Register(db); err != nil {
+			t.Fatalf("enabling multi-tenancy: %v", err)
+		}
+	}
+	router := testutil.NewRouter(cfg,
+		middleware.BodySizeLimit(cfg.MaxBodyBytes),
+		middleware.RequestTimeout(time.Duration(cfg.RequestTimeout)*time.Second))
+	router.MaxMultipartMemory = cfg.MaxMultipartMemory
+	routes.SetupRoutes(router, db, cfg, nil, jobs.NewScheduler(db, jobs.SystemClock, 1), slowlog.New(10))
+	return &testAPI{router: router, db: db, cfg: cfg}
+}
+
+// createUser saves a new user with role and returns it.
+func (api *testAPI) createUser(t *testing.T, role models.Role) *models.User {
+	t.Helper()
+
+	user := testutil.NewTestUser(role)
+	if err := api.db.Create(user).Error; err != nil {
+		t.Fatalf("creating %s user: %v", role, err)
+	}
+	return user
+}
+
+// auth returns the headers authenticating a request as user.
+func (api *testAPI) auth(t *testing.T, user *models.User) map[string]string {
+	t.Helper()
+	return map[string]string{"Authorization": testutil.BearerHeader(testutil.MintAccessToken(t, api.cfg, user))}
+}
+
+// create saves value, failing the test on error.
+func (api *testAPI) create(t *testing.T, value interface{}) {
+	t.Helper()
+	if err := api.db.Create(value).Error; err != nil {
+		t.Fatalf("creating %T: %v", value, err)
+	}
+}
+
+// testPassword is the password of users made by createUserWithPassword.
+const testPassword = "correct horse battery staple"
+
+// createUserWithPassword saves a new user with role who can log in with testPassword.
+func (api *testAPI) createUserWithPassword(t *testing.T, role models.Role) *models.User {
+	t.Helper()
+
+	user := testutil.NewTestUser(role)
+	if err := user.SetPassword(testPassword); err != nil {
+		t.Fatalf("hashing password: %v", err)
+	}
+	api.create(t, user)
+	return user
+}
+
+// login logs user in with testPassword and returns the issued tokens.
+func (api *testAPI) login(t *testing.T, user *models.User) handlers.LoginResponse {
+	t.Helper()
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/auth/login",
+		map[string]string{"email": user.Email, "password": testPassword}, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("login status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var response handlers.LoginResponse
+	decodeData(t, recorder, &response)
+	return response
+}
+
+// refresh presents refreshToken to the refresh endpoint.
+func (api *testAPI) refresh(t *testing.T, refreshToken string) *httptest.ResponseRecorder {
+	t.Helper()
+	return testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/auth/refresh-token",
+		map[string]string{"refreshToken": refreshToken}, nil)
+}
+
+// decodeData decodes the data field of the response envelope in recorder into v.
+func decodeData(t *testing.T, recorder *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decoding response %q: %v", recorder.Body.String(), err)
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		t.Fatalf("decoding data %s: %v", envelope.Data, err)
+	}
+}
+
+// captureSelects records the select list of every row query api.db runs on table until the test ends, and
+// returns a function reporting them. Count queries are left out.
+func (api *testAPI) captureSelects(t *testing.T, table string) func() []string {
+	t.Helper()
+
+	var mu sync.Mutex
+	var selects []string
+	err := api.db.Callback().Query().After("gorm:query").Register("test:capture_selects", func(tx *gorm.DB) {
+		sql := tx.Statement.SQL.String()
+		if tx.Statement.Table != table || !strings.HasPrefix(sql, "SELECT ") || strings.Contains(sql, "count(") {
+			return
+		}
+		list, _, _ := strings.Cut(strings.TrimPrefix(sql, "SELECT "), " FROM ")
+		mu.Lock()
+		defer mu.Unlock()
+		selects = append(selects, list)
+	})
+	if err != nil {
+		t.Fatalf("registering the query capture: %v", err)
+	}
+	t.Cleanup(func() { api.db.Callback().Query().Remove("test:capture_selects") })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), selects...)
+	}
+}
+
+// assertSelect fails unless selects holds exactly one select list, naming every column in want and none in omitted.
+func assertSelect(t *testing.T, selects []string, want, omitted []string) {
+	t.Helper()
+
+	if len(selects) != 1 {
+		t.Fatalf("captured selects %q, want exactly one", selects)
+	}
+	names := selectedNames(selects[0])
+	for _, column := range want {
+		if !names[column] {
+			t.Errorf("SELECT %s does not read %s", selects[0], column)
+		}
+	}
+	for _, column := range omitted {
+		if names[column] {
+			t.Errorf("SELECT %s reads the excluded column %s", selects[0], column)
+		}
+	}
+}
+
+// selectedNames returns the column names and aliases a select list reads, without quotes or table prefixes.
+func selectedNames(list string) map[string]bool {
+	names := map[string]bool{}
+	for _, name := range regexp.MustCompile("[A-Za-z_][A-Za-z_0-9]*").FindAllString(strings.NewReplacer("`", "", `"`, "").Replace(list), -1) {
+		names[name] = true
+	}
+	return names
+}
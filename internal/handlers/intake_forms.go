@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IntakeFormRequest represents the request body for submitting or revising an intake form.
+type IntakeFormRequest struct {
+	Symptoms        string `json:"symptoms" binding:"required,max=5000"`
+	SymptomDuration string `json:"symptomDuration" binding:"max=100"`
+	Medications     string `json:"medications" binding:"max=2000"`
+	Allergies       string `json:"allergies" binding:"max=2000"`
+	AdditionalNotes string `json:"additionalNotes" binding:"max=5000"`
+}
+
+// apply copies the request fields onto form and stamps the submission time.
+func (req IntakeFormRequest) apply(form *models.IntakeForm) {
+	form.Symptoms = req.Symptoms
+	form.SymptomDuration = req.SymptomDuration
+	form.Medications = req.Medications
+	form.Allergies = req.Allergies
+	form.AdditionalNotes = req.AdditionalNotes
+	form.SubmittedAt = time.Now()
+}
+
+// findIntakeAppointment loads the appointment named by the :id path parameter, writing the error response itself.
+func (h *AppointmentHandler) findIntakeAppointment(c *gin.Context) (models.Appointment, bool) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_appointment_id")
+		return models.Appointment{}, false
+	}
+
+	var appointment models.Appointment
+	if err := reqDB(h.DB, c).First(&appointment, "id = ?", appointmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.appointment_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return models.Appointment{}, false
+	}
+	return appointment, true
+}
+
+// checkIntakeEditable makes sure the requester is the appointment's patient and the visit has not started yet.
+func checkIntakeEditable(c *gin.Context, appointment models.Appointment) bool {
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if userRole != models.RolePatient || userID != appointment.PatientID {
+		utils.Forbidden(c, "intake.submit_forbidden")
+		return false
+	}
+	if !time.Now().Before(appointment.StartTime) || appointment.Status.Normalize() == models.StatusCancelled {
+		utils.BadRequest(c, "intake.closed")
+		return false
+	}
+	return true
+}
+
+// SubmitIntakeForm handles the appointment's patient filling in the intake questionnaire before the visit.
+func (h *AppointmentHandler) SubmitIntakeForm(c *gin.Context) {
+	appointment, ok := h.findIntakeAppointment(c)
+	if !ok {
+		return
+	}
+	if !checkIntakeEditable(c, appointment) {
+		return
+	}
+
+	var req IntakeFormRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	var existing int64
+	if err := reqDB(h.DB, c).Model(&models.IntakeForm{}).Where("appointment_id = ?", appointment.ID).Count(&existing).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if existing > 0 {
+		utils.Conflict(c, "intake.already_submitted")
+		return
+	}
+
+	form := models.IntakeForm{
+		AppointmentID: appointment.ID,
+		PatientID:     appointment.PatientID,
+	}
+	req.apply(&form)
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&form).Error; err != nil {
+			return err
+		}
+		return tx.Model(&appointment).Update("has_intake", true).Error
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "intake.save_failed")
+		return
+	}
+
+	utils.Created(c, "Intake form submitted successfully", form)
+}
+
+// UpdateIntakeForm handles the patient revising their intake form until the appointment starts.
+func (h *AppointmentHandler) UpdateIntakeForm(c *gin.Context) {
+	appointment, ok := h.findIntakeAppointment(c)
+	if !ok {
+		return
+	}
+	if !checkIntakeEditable(c, appointment) {
+		return
+	}
+
+	var req IntakeFormRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	var form models.IntakeForm
+	if err := reqDB(h.DB, c).First(&form, "appointment_id = ?", appointment.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "intake.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	req.apply(&form)
+	if err := reqDB(h.DB, c).Save(&form).Error; err != nil {
+		utils.HandleDBError(c, err, "intake.save_failed")
+		return
+	}
+
+	utils.Success(c, "Intake form updated successfully", form)
+}
+
+// GetIntakeForm handles fetching an appointment's intake form.
+// Accessible by the involved patient and doctor, or an admin.
+func (h *AppointmentHandler) GetIntakeForm(c *gin.Context) {
+	appointment, ok := h.findIntakeAppointment(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	isAdmin := userRole.IsAdmin()
+	if !isAdmin && userID != appointment.PatientID && userID != appointment.DoctorID {
+		utils.Forbidden(c, "intake.view_forbidden")
+		return
+	}
+
+	var form models.IntakeForm
+	if err := reqDB(h.DB, c).First(&form, "appointment_id = ?", appointment.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "intake.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	utils.Success(c, "Intake form fetched successfully", form)
+}
+
+// ConvertIntakeForm handles the appointment's doctor copying the intake form into a new consultation note.
+// A form can only be converted once.
+func (h *AppointmentHandler) ConvertIntakeForm(c *gin.Context) {
+	appointment, ok := h.findIntakeAppointment(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if userRole != models.RoleDoctor || userID != appointment.DoctorID {
+		utils.Forbidden(c, "intake.convert_forbidden")
+		return
+	}
+
+	var form models.IntakeForm
+	if err := reqDB(h.DB, c).First(&form, "appointment_id = ?", appointment.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "intake.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	if form.MedicalRecordID != nil {
+		utils.Conflict(c, "intake.already_converted")
+		return
+	}
+
+	record := models.MedicalRecord{
+		PatientID:  appointment.PatientID,
+		DoctorID:   appointment.DoctorID,
+		RecordType: models.RecordTypeConsultation,
+		RecordDate: appointment.StartTime,
+		Title:      "Intake form - " + appointment.StartTime.Format("2006-01-02"),
+		Summary:    form.Symptoms,
+		Details:    intakeFormDetails(form),
+	}
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return tx.Model(&form).Update("medical_record_id", record.ID).Error
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "records.create_failed")
+		return
+	}
+
+	utils.Created(c, "Intake form converted to a medical record successfully", dto.NewMedicalRecordResponse(record))
+}
+
+// intakeFormDetails renders the answers of form as the details text of a consultation note, skipping empty ones.
+func intakeFormDetails(form models.IntakeForm) string {
+	answers := []struct{ label, value string }{
+		{"Symptoms", form.Symptoms},
+		{"Duration", form.SymptomDuration},
+		{"Current medications", form.Medications},
+		{"Allergies", form.Allergies},
+		{"Additional notes", form.AdditionalNotes},
+	}
+
+	var lines []string
+	for _, answer := range answers {
+		if answer.value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", answer.label, answer.value))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
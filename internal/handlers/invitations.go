@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/mailer"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errInvitationUsed aborts the registration transaction when the invitation was accepted concurrently.
+var errInvitationUsed = errors.New("invitation already accepted")
+
+// CreateInvitationRequest represents the request body for inviting someone to register with a role.
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+	Role  string `json:"role" binding:"required,role"`
+	// Organization the account joins; super admins only, other admins invite into their own
+	OrganizationID string `json:"organizationId" binding:"omitempty,uuid"`
+}
+
+// CreateInvitation handles an admin inviting someone to register with any role, doctor and admin
+// included. The invitee is emailed a link valid for INVITATION_EXPIRY_HOURS; earlier pending
+// invitations of the same address stop working (admin).
+func (h *UserHandler) CreateInvitation(c *gin.Context) {
+	var req CreateInvitationRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	organizationID, ok := assignableOrganization(c, reqDB(h.DB, c), h.Cfg, req.OrganizationID, true)
+	if !ok {
+		return
+	}
+
+	var existing int64
+	if err := acrossOrganizations(h.DB, c).Model(&models.User{}).Where("email = ?", email).Count(&existing).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if existing > 0 {
+		utils.BadRequest(c, "users.email_taken")
+		return
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		utils.InternalServerErrorWithDetail(c, "invitations.create_failed", err)
+		return
+	}
+	adminID, _ := middleware.GetUserIDFromContext(c)
+	invitation := models.Invitation{
+		Email: email,
+		Role:  models.Role(req.Role).Normalize(),
+		// Left nil for the invitations of other admins, whom the scoped create puts in the admin's organization
+		OrganizationID: organizationID,
+		TokenHash:      hashToken(token),
+		ExpiresAt:      time.Now().UTC().Add(time.Duration(h.Cfg.InvitationExpiryHours) * time.Hour),
+		InvitedByID:    adminID,
+	}
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("email = ? AND accepted_at IS NULL", email).
+			Delete(&models.Invitation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&invitation).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, adminID, "invitation.created", auditEntityInvitation, invitation.ID, "role="+string(invitation.Role))
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "invitations.create_failed")
+		return
+	}
+
+	go sendRoleInvitation(h.Cfg, invitation, token)
+
+	utils.Created(c, "Invitation sent successfully", invitation)
+}
+
+// RegisterWithInviteRequest represents the request body for registering from an invitation link.
+// The email address and role come from the invitation.
+type RegisterWithInviteRequest struct {
+	Token     string `json:"token" binding:"required"`
+	FirstName string `json:"firstName" binding:"required,max=100"`
+	LastName  string `json:"lastName" binding:"required,max=100"`
+	Password  string `json:"password" binding:"required,min=8"`
+}
+
+// RegisterWithInvite handles registering the account an invitation was made for, with its role and organization.
+// The invitation is single-use, and following the emailed link verifies the address.
+func (h *AuthHandler) RegisterWithInvite(c *gin.Context) {
+	var req RegisterWithInviteRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	var invitation models.Invitation
+	if err := reqDB(h.DB, c).Where("token_hash = ? AND accepted_at IS NULL AND expires_at > ?", hashToken(req.Token), time.Now().UTC()).
+		First(&invitation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.BadRequest(c, "invitations.invalid_token")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	var existing int64
+	if err := reqDB(h.DB, c).Model(&models.User{}).Where("email = ?", invitation.Email).Count(&existing).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if existing > 0 {
+		utils.BadRequest(c, "users.email_taken")
+		return
+	}
+
+	user := models.User{
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		Email:      invitation.Email,
+		Role:       invitation.Role,
+		IsVerified: true,
+		// Registration is public, so nothing scopes it and the organization is set here
+		OrganizationID: invitation.OrganizationID,
+	}
+	if err := user.SetPassword(req.Password); err != nil {
+		utils.InternalServerErrorWithDetail(c, "auth.password_hash_failed", err)
+		return
+	}
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		// Only accepted while still pending, so one link cannot register two accounts
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Update("accepted_at", time.Now().UTC())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationUsed
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, user.ID, "invitation.accepted", auditEntityInvitation, invitation.ID, "role="+string(user.Role))
+	})
+	if errors.Is(err, errInvitationUsed) {
+		utils.BadRequest(c, "invitations.invalid_token")
+		return
+	}
+	if err != nil {
+		utils.HandleDBError(c, err, "users.create_failed")
+		return
+	}
+	if user.Role == models.RoleDoctor {
+		invalidateDoctorCache(c, h.Doctors)
+	}
+
+	utils.Created(c, "User registered successfully", user.Sanitize())
+}
+
+// sendRoleInvitation emails the invitee their registration link. Failures are only logged.
+func sendRoleInvitation(cfg *config.Config, invitation models.Invitation, token string) {
+	body := fmt.Sprintf("Hello,\n\nYou have been invited to join as %s. Create your account here:\n%s\n\nThe link expires in %d hours.\n",
+		invitation.Role, frontendLink(cfg, "/register-with-invite", token), cfg.InvitationExpiryHours)
+	if err := mailer.New(cfg.Mailer).Send(invitation.Email, "You have been invited", body); err != nil {
+		log.Printf("Failed to send invitation to %s: %v", invitation.Email, err)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/utils"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobHandler exposes the scheduled background jobs.
+type JobHandler struct {
+	DB        *gorm.DB
+	Scheduler *jobs.Scheduler
+}
+
+// NewJobHandler creates a new JobHandler.
+func NewJobHandler(db *gorm.DB, scheduler *jobs.Scheduler) *JobHandler {
+	return &JobHandler{DB: db, Scheduler: scheduler}
+}
+
+// GetJobs handles listing the scheduled jobs with their schedule, next and last run, and last error (admin).
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	statuses, err := h.Scheduler.Status(reqDB(h.DB, c))
+	if err != nil {
+		utils.HandleDBError(c, err, "jobs.fetch_failed")
+		return
+	}
+
+	utils.Success(c, "Jobs fetched successfully", statuses)
+}
+
+// RunJobNow handles starting a scheduled job right away instead of at its next run (admin). The job runs in
+// the background; its outcome shows in GET /admin/jobs.
+func (h *JobHandler) RunJobNow(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.Scheduler.RunNow(name); err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrUnknownJob):
+			utils.NotFound(c, "jobs.not_found")
+		case errors.Is(err, jobs.ErrJobRunning):
+			utils.Conflict(c, "jobs.already_running")
+		default:
+			utils.HandleDBError(c, err, "jobs.run_failed")
+		}
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	if err := recordAudit(reqDB(h.DB, c), userID, "job.run_now", auditEntityJob, name, ""); err != nil {
+		log.Printf("Failed to record the manual run of job %s: %v", name, err)
+	}
+
+	utils.Accepted(c, "Job started", nil)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	// failedLoginEventLimiter caps how many failed attempts are written per IP and email,
+	// so a brute-force run against one account cannot flood the login_events table.
+	failedLoginEventLimiter = utils.NewRateLimiter(10, time.Minute)
+	// failedLoginIPEventLimiter caps how many failed attempts are written per IP across all emails,
+	// so cycling through made-up addresses cannot flood it either.
+	failedLoginIPEventLimiter = utils.NewRateLimiter(50, time.Minute)
+)
+
+// recordLoginEvent stores a login attempt. Failed attempts are subject to failedLoginIPEventLimiter
+// and failedLoginEventLimiter. Errors are only logged, as auditing must never block the login flow itself.
+func recordLoginEvent(db *gorm.DB, c *gin.Context, userID *string, email string, success bool, failureReason string) {
+	if !success && !allowFailedLoginEvent(c.ClientIP(), email) {
+		return
+	}
+
+	event := models.LoginEvent{
+		UserID:        userID,
+		Email:         email,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     truncate(c.Request.UserAgent(), 512),
+		Success:       success,
+		FailureReason: failureReason,
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("Failed to record login event for %s: %v", email, err)
+	}
+}
+
+// allowFailedLoginEvent reports whether a failed attempt for email from ip may still be written. Once the
+// IP is throttled nothing more is counted against its emails.
+func allowFailedLoginEvent(ip, email string) bool {
+	return failedLoginIPEventLimiter.Allow(ip) && failedLoginEventLimiter.Allow(ip+"|"+strings.ToLower(email))
+}
+
+// recordSuccessfulLogin updates the user's last login columns and stores a successful login event.
+// Shared by every login flow (password login, and OAuth callbacks once they exist).
+func recordSuccessfulLogin(db *gorm.DB, c *gin.Context, user *models.User) {
+	now := time.Now()
+	ip := c.ClientIP()
+	if err := db.Model(user).UpdateColumns(map[string]interface{}{
+		"last_login_at": now,
+		"last_login_ip": ip,
+	}).Error; err != nil {
+		log.Printf("Failed to update last login for user %s: %v", user.ID, err)
+	} else {
+		user.LastLoginAt = &now
+		user.LastLoginIP = ip
+	}
+
+	userID := user.ID
+	recordLoginEvent(db, c, &userID, user.Email, true, "")
+}
+
+// rehashPassword replaces user's password hash with one made at the configured bcrypt cost. Failures are
+// logged rather than returned, so they never block the login that triggered the upgrade.
+func rehashPassword(db *gorm.DB, user *models.User, password string) {
+	if err := user.SetPassword(password); err != nil {
+		log.Printf("Failed to rehash password for user %s: %v", user.ID, err)
+		return
+	}
+	if err := db.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("password", user.Password).Error; err != nil {
+		log.Printf("Failed to save rehashed password for user %s: %v", user.ID, err)
+	}
+}
+
+// truncate shortens s to at most max bytes.
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+// fetchLoginHistory returns a page of a user's login events, most recent first, honouring the `page` and `limit` query parameters.
+func fetchLoginHistory(db *gorm.DB, c *gin.Context, userID string) ([]models.LoginEvent, utils.PaginationMeta, bool) {
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return nil, utils.PaginationMeta{}, false
+	}
+
+	query := db.Model(&models.LoginEvent{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.login_history_failed")
+		return nil, utils.PaginationMeta{}, false
+	}
+
+	var events []models.LoginEvent
+	if err := query.Order("created_at desc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&events).Error; err != nil {
+		utils.HandleDBError(c, err, "auth.login_history_failed")
+		return nil, utils.PaginationMeta{}, false
+	}
+	return events, pagination.Meta(total), true
+}
+
+// GetLoginHistory handles fetching the authenticated user's own recent login events.
+func (h *AuthHandler) GetLoginHistory(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	events, meta, ok := fetchLoginHistory(reqDB(h.DB, c), c, userID)
+	if !ok {
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Login history fetched successfully", events, meta)
+}
+
+// GetUserLoginHistory handles fetching the recent login events of any user (admin).
+func (h *UserHandler) GetUserLoginHistory(c *gin.Context) {
+	userID := c.Param("id")
+
+	var user models.User
+	if err := reqDB(h.DB, c).First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.user_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	events, meta, ok := fetchLoginHistory(reqDB(h.DB, c), c, user.ID)
+	if !ok {
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Login history fetched successfully", events, meta)
+
+}
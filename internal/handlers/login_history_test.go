@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAllowFailedLoginEventCapsEachIPAcrossEmails(t *testing.T) {
+	const ip = "203.0.113.10"
+	for i := 0; i < 50; i++ {
+		if !allowFailedLoginEvent(ip, fmt.Sprintf("unknown%d@example.test", i)) {
+			t.Fatalf("attempt %d from a fresh IP was throttled", i+1)
+		}
+	}
+	if allowFailedLoginEvent(ip, "another@example.test") {
+		t.Error("a new email from a throttled IP was still recorded")
+	}
+	if !allowFailedLoginEvent("203.0.113.11", "another@example.test") {
+		t.Error("another IP was throttled")
+	}
+}
+
+func TestAllowFailedLoginEventCapsEachEmail(t *testing.T) {
+	const ip = "203.0.113.20"
+	for i := 0; i < 10; i++ {
+		if !allowFailedLoginEvent(ip, "Target@example.test") {
+			t.Fatalf("attempt %d was throttled", i+1)
+		}
+	}
+	if allowFailedLoginEvent(ip, "target@example.test") {
+		t.Error("the same email in another case was not throttled")
+	}
+}
@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt" // Added for logging
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/dto"
 	"healthcare-app-server/internal/middleware"
 	"healthcare-app-server/internal/models"
 	"healthcare-app-server/internal/utils"
+	"healthcare-app-server/internal/webhooks"
 	"io/ioutil" // Added for ioutil.ReadAll
-	"net/http"  // Added for http.StatusOK and http.StatusNotImplemented
-	"strings"   // Import for strings.EqualFold
+	"log"
+	"net/http" // Added for http.StatusOK and http.StatusNotImplemented
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,23 +24,71 @@ import (
 
 // MedicalRecordHandler handles medical record related requests.
 type MedicalRecordHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Cfg      *config.Config
+	Webhooks *webhooks.Dispatcher // Receives the medicalrecord.created event
 }
 
 // NewMedicalRecordHandler creates a new MedicalRecordHandler.
-func NewMedicalRecordHandler(db *gorm.DB) *MedicalRecordHandler {
-	return &MedicalRecordHandler{DB: db}
+func NewMedicalRecordHandler(db *gorm.DB, cfg *config.Config, webhooks *webhooks.Dispatcher) *MedicalRecordHandler {
+	return &MedicalRecordHandler{DB: db, Cfg: cfg, Webhooks: webhooks}
+}
+
+// preloadAttachmentMetadata preloads record attachments without their file data.
+func preloadAttachmentMetadata(db *gorm.DB) *gorm.DB {
+	return db.Select(models.AttachmentMetadataColumns)
+}
+
+// orderDiagnoses preloads record diagnoses in code order.
+func orderDiagnoses(db *gorm.DB) *gorm.DB {
+	return db.Order("code asc")
+}
+
+// canViewPatientRecords reports whether the requester may read a patient's medical records:
+// any doctor, or the patient themselves.
+func canViewPatientRecords(role models.Role, userID, patientID string) bool {
+	isDoctor := role == models.RoleDoctor
+	isPatientOwner := role == models.RolePatient && userID == patientID
+	return isDoctor || isPatientOwner
+}
+
+// canModifyRecord reports whether the requester may change a record: the doctor who created it or an admin.
+func canModifyRecord(role models.Role, userID, recordDoctorID string) bool {
+	isAdmin := role.IsAdmin()
+	isCreatorDoctor := role == models.RoleDoctor && userID == recordDoctorID
+	return isAdmin || isCreatorDoctor
+}
+
+// medicalRecordsETag derives an ETag from the records, their attachments and their diagnoses, so it
+// changes whenever a record is updated or an attachment or diagnosis is added or removed. variant
+// tells apart responses that show the same records differently.
+func medicalRecordsETag(records []models.MedicalRecord, variant ...string) string {
+	parts := append([]string{}, variant...)
+	for _, record := range records {
+		parts = append(parts, utils.ETagVersion(record.ID, record.UpdatedAt))
+		for _, attachment := range record.Attachments {
+			parts = append(parts, utils.ETagVersion(attachment.ID, attachment.UpdatedAt))
+		}
+		for _, diagnosis := range record.Diagnoses {
+			parts = append(parts, utils.ETagVersion(diagnosis.ID, diagnosis.UpdatedAt))
+		}
+	}
+	return utils.ComputeETag(parts...)
 }
 
 // CreateMedicalRecordRequest represents the request body for creating a medical record.
 type CreateMedicalRecordRequest struct {
-	PatientID  string                   `json:"patientId" binding:"required,uuid"`
-	RecordType models.MedicalRecordType `json:"recordType" binding:"required"`
+	PatientID string `json:"patientId" binding:"required,uuid"`
+	// Optional template; it fills every field below that the request leaves empty
+	TemplateID string                   `json:"templateId" binding:"omitempty,uuid"`
+	RecordType models.MedicalRecordType `json:"recordType" binding:"required_without=TemplateID,omitempty,record_type"`
 	RecordDate string                   `json:"recordDate" binding:"required"` // Changed from json:"date"
-	Title      string                   `json:"title" binding:"required"`
-	Department string                   `json:"department"`
-	Summary    string                   `json:"summary" binding:"required"`
-	Details    string                   `json:"details"`
+	Title      string                   `json:"title" binding:"required_without=TemplateID,max=255"`
+	Department string                   `json:"department" binding:"max=100"` // Free text; prefer DepartmentID
+	// Optional department whose name is stored as the record's department, instead of Department
+	DepartmentID string `json:"departmentId" binding:"omitempty,uuid"`
+	Summary      string `json:"summary" binding:"required_without=TemplateID"`
+	Details      string `json:"details"`
 	// Attachments will be handled separately or via multipart form
 }
 
@@ -47,28 +102,28 @@ func (h *MedicalRecordHandler) CreateMedicalRecord(c *gin.Context) {
 
 	doctorIDStr, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "Doctor ID not found in token")
+		utils.Unauthorized(c, "common.unauthenticated")
 		return
 	}
 	doctorID, err := uuid.Parse(doctorIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Doctor ID format in token")
+		utils.BadRequest(c, "common.invalid_token_subject")
 		return
 	}
 
 	patientID, err := uuid.Parse(req.PatientID)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Patient ID format")
+		utils.BadRequest(c, "common.invalid_patient_id")
 		return
 	}
 
 	// Verify patient exists
 	var patient models.User
-	if err := h.DB.Where("id = ? AND role = ?", patientID, models.RolePatient).First(&patient).Error; err != nil {
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", patientID, models.RolePatient).First(&patient).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Patient not found")
+			utils.NotFound(c, "common.patient_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error verifying patient: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
@@ -78,12 +133,54 @@ func (h *MedicalRecordHandler) CreateMedicalRecord(c *gin.Context) {
 		var err error
 		recordDate, err = time.Parse(time.RFC3339, req.RecordDate)
 		if err != nil {
-			utils.BadRequest(c, "Invalid date format. Please use ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ)")
+			utils.BadRequest(c, "records.invalid_date")
 			return
 		}
+		recordDate = recordDate.UTC()
 	} else {
-		recordDate = time.Now()
+		recordDate = time.Now().UTC()
+	}
+
+	// Resolved before the template is applied, so the chosen department wins over the template's
+	if req.DepartmentID != "" {
+		req.Department, err = departmentName(reqDB(h.DB, c), req.DepartmentID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				utils.NotFound(c, "departments.not_found")
+			} else {
+				utils.HandleDBError(c, err, "common.database_error")
+			}
+			return
+		}
+	}
+
+	if req.TemplateID != "" {
+		template, err := findUsableTemplate(reqDB(h.DB, c), req.TemplateID, doctorID.String())
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				utils.NotFound(c, "records.template_not_found")
+			} else {
+				utils.HandleDBError(c, err, "common.database_error")
+			}
+			return
+		}
+		applyTemplate(&req, template, patient, recordDate)
+
+		// The template must have supplied whatever the request left out
+		if req.RecordType == "" || req.Title == "" || req.Summary == "" {
+			utils.BadRequest(c, "records.template_incomplete")
+			return
+		}
 	}
+
+	// Sanitized after the template is applied, so template text is covered too
+	req.Summary = utils.SanitizeText(req.Summary)
+	req.Details = utils.SanitizeText(req.Details)
+	if strings.TrimSpace(req.Summary) == "" {
+		utils.BadRequest(c, "records.summary_empty")
+		return
+	}
+
 	record := models.MedicalRecord{
 		PatientID:  patientID.String(), // Convert UUID to string
 		DoctorID:   doctorID.String(),  // Convert UUID to string
@@ -95,22 +192,49 @@ func (h *MedicalRecordHandler) CreateMedicalRecord(c *gin.Context) {
 		Details:    req.Details,
 	}
 
-	if err := h.DB.Create(&record).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create medical record: "+err.Error())
+	if err := reqDB(h.DB, c).Create(&record).Error; err != nil {
+		utils.HandleDBError(c, err, "records.create_failed")
 		return
 	}
+	h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookMedicalRecordCreated, webhooks.NewMedicalRecordData(record))
+
+	utils.Created(c, "Medical record created successfully", dto.NewMedicalRecordResponse(record))
+}
 
-	utils.Created(c, "Medical record created successfully", record)
+// medicalRecordListFields are the fields the record list can be narrowed to with `fields`. The ETag
+// needs updated_at, so it is always selected.
+var medicalRecordListFields = utils.FieldSpec{
+	Required: []string{"id", "patientId", "doctorId"},
+	Columns:  []string{"id", "patient_id", "doctor_id", "updated_at"},
+	Optional: map[string][]string{
+		"recordType":  {"record_type"},
+		"recordDate":  {"record_date"},
+		"date":        {"record_date"},
+		"title":       {"title"},
+		"department":  {"department"},
+		"summary":     {"summary"},
+		"details":     {"details"},
+		"version":     {"version"},
+		"attachments": nil,
+		"diagnoses":   nil,
+		"createdAt":   {"created_at"},
+		"updatedAt":   {"updated_at"},
+	},
 }
 
+// summaryDetailsLength is how many characters of the details the record list returns with summaryOnly.
+const summaryDetailsLength = 200
+
 // GetMedicalRecordsForPatient handles fetching medical records for a specific patient.
-// Accessible by the patient themselves or doctors.
+// Accessible by the patient themselves or doctors. `fields` narrows the response to the listed
+// fields (the IDs are always returned) and summaryOnly=true cuts the details to their first
+// 200 characters, both in the query, for clients on slow connections.
 func (h *MedicalRecordHandler) GetMedicalRecordsForPatient(c *gin.Context) {
 	patientIDStr := c.Param("patientId")
 	_, err := uuid.Parse(patientIDStr) // Changed patientID to _ as it's not used before re-check
 	if err != nil {
 		fmt.Println("[DEBUG] Invalid Patient ID format from URL param:", patientIDStr) // Log the problematic param
-		utils.BadRequest(c, "Invalid Patient ID format from URL param: "+patientIDStr)
+		utils.BadRequest(c, "common.invalid_patient_id")
 		return
 	}
 
@@ -124,8 +248,7 @@ func (h *MedicalRecordHandler) GetMedicalRecordsForPatient(c *gin.Context) {
 	fmt.Printf("[DEBUG] GetMedicalRecordsForPatient: Requesting User Role: %s (Exists: %t)\n", string(requestingUserRole), userRoleExists)
 
 	// Authorization: Patient can see their own records, Doctors can see any patient\'s records
-	// Use strings.EqualFold for case-insensitive role comparison.
-	isDoctor := userRoleExists && strings.EqualFold(string(requestingUserRole), string(models.RoleDoctor))
+	isDoctor := userRoleExists && requestingUserRole == models.RoleDoctor
 	isSelf := userIDExists && requestingUserIDStr == patientIDStr
 
 	if isDoctor || isSelf {
@@ -134,7 +257,7 @@ func (h *MedicalRecordHandler) GetMedicalRecordsForPatient(c *gin.Context) {
 	} else {
 		// Not authorized
 		fmt.Printf("[DEBUG] GetMedicalRecordsForPatient: Authorization failed. Role: %s (IsDoctor: %t), RequestingID: %s, TargetPatientID: %s (IsSelf: %t). models.RoleDoctor is: %s\n", string(requestingUserRole), isDoctor, requestingUserIDStr, patientIDStr, isSelf, string(models.RoleDoctor))
-		utils.Forbidden(c, "You are not authorized to view these medical records")
+		utils.Forbidden(c, "records.list_forbidden")
 		return
 	}
 
@@ -144,17 +267,142 @@ func (h *MedicalRecordHandler) GetMedicalRecordsForPatient(c *gin.Context) {
 	parsedPatientID, err := uuid.Parse(patientIDStr)
 	if err != nil {
 		// This should ideally not happen if the first parse succeeded, but as a safeguard:
-		utils.InternalServerError(c, "Failed to parse patient ID for database query")
+		utils.InternalServerError(c, "common.internal_error")
+		return
+	}
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+	fields, ok := utils.ParseFields(c, medicalRecordListFields)
+	if !ok {
 		return
 	}
+	summaryOnly := false
+	if value := c.Query("summaryOnly"); value != "" {
+		if summaryOnly, err = strconv.ParseBool(value); err != nil {
+			utils.BadRequest(c, "records.invalid_summary_only")
+			return
+		}
+	}
+
+	query := reqDB(h.DB, c).Model(&models.MedicalRecord{}).Where("patient_id = ?", parsedPatientID)
+	// With strict access, doctors only see the records they wrote and those the patient consented to
+	if h.Cfg.StrictRecordAccess && isDoctor {
+		all, recordIDs, err := consentedRecords(reqDB(h.DB, c), requestingUserIDStr, patientIDStr)
+		if err != nil {
+			utils.HandleDBError(c, err, "records.fetch_failed")
+			return
+		}
+		if !all {
+			query = query.Where("doctor_id = ? OR id IN ?", requestingUserIDStr, recordIDs)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "records.fetch_failed")
+		return
+	}
+
+	if !fields.All() || summaryOnly {
+		columns := fields.Columns()
+		for i, column := range columns {
+			if column == "details" && summaryOnly {
+				columns[i] = fmt.Sprintf("SUBSTR(details, 1, %d) AS details", summaryDetailsLength)
+			}
+		}
+		query = query.Select(columns)
+	}
+	if fields.Has("attachments") {
+		query = query.Preload("Attachments", preloadAttachmentMetadata)
+	}
+	if fields.Has("diagnoses") {
+		query = query.Preload("Diagnoses", orderDiagnoses)
+	}
 
 	var records []models.MedicalRecord
-	if err := h.DB.Preload("Attachments").Where("patient_id = ?", parsedPatientID).Order("created_at desc").Find(&records).Error; err != nil {
-		utils.InternalServerError(c, "Failed to fetch medical records: "+err.Error())
+	if err := query.Order("created_at desc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&records).Error; err != nil {
+		utils.HandleDBError(c, err, "records.fetch_failed")
 		return
 	}
 
-	utils.Success(c, "Medical records fetched successfully", records)
+	// Different fields make a different response, so they are part of the ETag
+	if utils.CheckNotModified(c, medicalRecordsETag(records, c.Query("fields"), strconv.FormatBool(summaryOnly))) {
+		return
+	}
+
+	response, err := fields.Apply(dto.NewMedicalRecordResponses(records))
+	if err != nil {
+		utils.InternalServerError(c, "records.fetch_failed")
+		return
+	}
+	utils.SuccessWithMeta(c, "Medical records fetched successfully", response, pagination.Meta(total))
+
+}
+
+// GetAuthoredMedicalRecords handles a doctor listing the records they wrote, across all patients, newest
+// record date first and with each record's patient. Supports recordType, patientId, from and to (on the
+// record date), page and limit.
+func (h *MedicalRecordHandler) GetAuthoredMedicalRecords(c *gin.Context) {
+	doctorID, _ := middleware.GetUserIDFromContext(c)
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.MedicalRecord{}).Where("doctor_id = ?", doctorID)
+	if recordType := c.Query("recordType"); recordType != "" {
+		if !models.MedicalRecordType(recordType).IsValid() {
+			utils.BadRequest(c, "records.invalid_record_type_filter", utils.Params{"recordType": recordType})
+			return
+		}
+		query = query.Where("record_type = ?", recordType)
+	}
+	if patientID := c.Query("patientId"); patientID != "" {
+		if _, err := uuid.Parse(patientID); err != nil {
+			utils.BadRequest(c, "common.invalid_patient_id")
+			return
+		}
+		query = query.Where("patient_id = ?", patientID)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			utils.BadRequest(c, "records.invalid_from")
+			return
+		}
+		query = query.Where("record_date >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			utils.BadRequest(c, "records.invalid_to")
+			return
+		}
+		query = query.Where("record_date <= ?", toTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "records.fetch_failed")
+		return
+	}
+
+	var records []models.MedicalRecord
+	if err := query.Preload("Patient").Preload("Attachments", preloadAttachmentMetadata).Preload("Diagnoses", orderDiagnoses).
+		Order("record_date desc, created_at desc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&records).Error; err != nil {
+		utils.HandleDBError(c, err, "records.fetch_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Medical records fetched successfully", dto.NewMedicalRecordResponses(records), pagination.Meta(total))
 }
 
 // UploadMedicalRecordAttachment handles uploading attachment files for a specific medical record.
@@ -164,102 +412,150 @@ func (h *MedicalRecordHandler) UploadMedicalRecordAttachment(c *gin.Context) {
 	medicalRecordIDStr := c.Param("id")
 	medicalRecordID, err := uuid.Parse(medicalRecordIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Medical Record ID format from URL param: "+medicalRecordIDStr)
+		utils.BadRequest(c, "common.invalid_medical_record_id")
 		return
 	}
 
 	// Verify the medical record exists
 	var record models.MedicalRecord
-	if err := h.DB.First(&record, "id = ?", medicalRecordID).Error; err != nil {
+	if err := reqDB(h.DB, c).First(&record, "id = ?", medicalRecordID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Medical record not found")
+			utils.NotFound(c, "common.medical_record_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error verifying medical record: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
 
-	file, header, err := c.Request.FormFile("file") // "file" is the name of the form field
+	// c.FormFile honours the router's MaxMultipartMemory, so large uploads spill to temp files
+	header, err := c.FormFile("file") // "file" is the name of the form field
 	if err != nil {
-		utils.BadRequest(c, "Error retrieving file from form: "+err.Error())
+		if utils.IsRequestTooLarge(err) {
+			utils.PayloadTooLarge(c, "records.file_too_large")
+			return
+		}
+		utils.ErrorWithDetail(c, http.StatusBadRequest, "records.file_missing", err)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		utils.InternalServerErrorWithDetail(c, "records.file_read_failed", err)
 		return
 	}
 	defer file.Close()
 
 	fileData, err := ioutil.ReadAll(file)
 	if err != nil {
-		utils.InternalServerError(c, "Error reading file content: "+err.Error())
+		utils.InternalServerErrorWithDetail(c, "records.file_read_failed", err)
 		return
 	}
 
+	// Never trust the client's Content-Type: sniff the real type and require it to be allowed
+	// and to agree with the claimed one, so e.g. HTML cannot be stored as image/png
+	fileType := utils.DetectContentType(fileData)
+	if !h.isAllowedAttachmentType(fileType) {
+		utils.UnsupportedMediaType(c, "records.file_type_not_allowed", utils.Params{"type": fileType})
+		return
+	}
+	if claimed := utils.MediaType(header.Header.Get("Content-Type")); claimed != "" && claimed != "application/octet-stream" && claimed != fileType {
+		utils.UnsupportedMediaType(c, "records.file_type_mismatch", utils.Params{"claimed": claimed, "type": fileType})
+		return
+	}
+	contentHash := sha256.Sum256(fileData)
+	thumbnail := h.thumbnailFor(fileType, fileData)
+
 	// Create MedicalRecordAttachment entry
 	attachment := models.MedicalRecordAttachment{
 		MedicalRecordID: medicalRecordID.String(),
 		FileName:        header.Filename,
-		FileType:        header.Header.Get("Content-Type"),
+		FileType:        fileType,
 		FileData:        fileData,
+		ContentHash:     hex.EncodeToString(contentHash[:]),
+		ThumbnailData:   thumbnail,
+		HasThumbnail:    thumbnail != nil,
 	}
 
-	if err := h.DB.Create(&attachment).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create medical record attachment entry: "+err.Error())
+	if err := reqDB(h.DB, c).Create(&attachment).Error; err != nil {
+		utils.HandleDBError(c, err, "records.attachment_create_failed")
 		return
 	}
 
-	// Return a slimmed down version of the attachment, without the FileData
-	responseAttachment := struct {
-		ID              string    `json:"id"`
-		MedicalRecordID string    `json:"medicalRecordId"`
-		FileName        string    `json:"fileName"`
-		FileType        string    `json:"fileType"`
-		CreatedAt       time.Time `json:"createdAt"`
-	}{
-		ID:              attachment.ID,
-		MedicalRecordID: attachment.MedicalRecordID,
-		FileName:        attachment.FileName,
-		FileType:        attachment.FileType,
-		CreatedAt:       attachment.CreatedAt,
-	}
-
-	utils.Success(c, "File uploaded and linked to medical record successfully", responseAttachment)
+	utils.Success(c, "File uploaded and linked to medical record successfully", dto.NewAttachmentResponse(attachment))
 }
 
 // GetMedicalRecordAttachment handles retrieving a specific attachment by its ID and serving its file data.
 // Authorization should ensure the requesting user has rights to view the parent medical record.
 func (h *MedicalRecordHandler) GetMedicalRecordAttachment(c *gin.Context) {
+	attachment, ok := h.loadViewableAttachment(c)
+	if !ok {
+		return
+	}
+
+	// Attachments are immutable, so the stored content hash is a stable ETag. Older rows without
+	// a hash fall back to ID and timestamp so the blob never has to be read to answer a conditional request.
+	etag := `"` + attachment.ContentHash + `"`
+	if attachment.ContentHash == "" {
+		etag = utils.ComputeETag(utils.ETagVersion(attachment.ID, attachment.UpdatedAt))
+	}
+	if utils.CheckNotModified(c, etag) || utils.CheckNotModifiedSince(c, attachment.CreatedAt) {
+		return
+	}
+
+	var attachmentData models.MedicalRecordAttachment
+	if err := reqDB(h.DB, c).Select("id", "file_data").First(&attachmentData, "id = ?", attachment.ID).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	attachment.FileData = attachmentData.FileData
+
+	// Only types that cannot carry scripts are shown inline; rows stored before sniffing keep their claimed type
+	disposition := "attachment"
+	if utils.IsInlineSafeContentType(attachment.FileType) {
+		disposition = "inline"
+	}
+	c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
+	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, attachment.FileName))
+	c.Data(http.StatusOK, attachment.FileType, attachment.FileData)
+}
+
+// loadViewableAttachment loads the metadata of the attachment in the path and checks that the requester may
+// view it. When it returns false, the error response has been written.
+func (h *MedicalRecordHandler) loadViewableAttachment(c *gin.Context) (models.MedicalRecordAttachment, bool) {
 	attachmentIDStr := c.Param("attachmentId")
 	attachmentID, err := uuid.Parse(attachmentIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Attachment ID format: "+attachmentIDStr)
-		return
+		utils.BadRequest(c, "records.invalid_attachment_id")
+		return models.MedicalRecordAttachment{}, false
 	}
 
+	// Load metadata only; the file data is fetched once authorization and cache checks have passed
 	var attachment models.MedicalRecordAttachment
-	if err := h.DB.First(&attachment, "id = ?", attachmentID).Error; err != nil {
+	if err := reqDB(h.DB, c).Select(models.AttachmentMetadataColumns).First(&attachment, "id = ?", attachmentID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Attachment not found")
+			utils.NotFound(c, "records.attachment_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error fetching attachment: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
-		return
+		return attachment, false
 	}
 
 	// Authorization: Check if the user can access the parent medical record
 	var medicalRecord models.MedicalRecord
-	if err := h.DB.First(&medicalRecord, "id = ?", attachment.MedicalRecordID).Error; err != nil {
-		utils.InternalServerError(c, "Could not fetch parent medical record for authorization check.")
-		return
+	if err := reqDB(h.DB, c).First(&medicalRecord, "id = ?", attachment.MedicalRecordID).Error; err != nil {
+		utils.InternalServerError(c, "records.parent_record_unavailable")
+		return attachment, false
 	}
 
 	requestingUserIDStr, userIDExists := middleware.GetUserIDFromContext(c)
 	requestingUserRole, userRoleExists := middleware.GetUserRoleFromContext(c)
 
 	if !userIDExists || !userRoleExists {
-		utils.Unauthorized(c, "User information not found in token for authorization.")
-		return
+		utils.Unauthorized(c, "common.unauthenticated")
+		return attachment, false
 	}
 
-	isDoctor := strings.EqualFold(string(requestingUserRole), string(models.RoleDoctor))
-	isPatientOwner := strings.EqualFold(string(requestingUserRole), string(models.RolePatient)) && requestingUserIDStr == medicalRecord.PatientID
+	isDoctor := requestingUserRole == models.RoleDoctor
+	isPatientOwner := requestingUserRole == models.RolePatient && requestingUserIDStr == medicalRecord.PatientID
 	isRecordCreator := isDoctor && requestingUserIDStr == medicalRecord.DoctorID // Or any doctor if policy allows
 
 	// Allow if: user is a doctor (general access to records they can see), or patient owning the record.
@@ -272,19 +568,179 @@ func (h *MedicalRecordHandler) GetMedicalRecordAttachment(c *gin.Context) {
 		// or that general doctor access to any attachment (if record is accessible) is okay.
 		// A stricter check would re-verify access to medicalRecord.ID similar to GetMedicalRecordByID.
 		// For now, if not a doctor and not the patient owner, deny.
-		utils.Forbidden(c, "You are not authorized to view this attachment.")
+		utils.Forbidden(c, "records.attachment_forbidden")
+		return attachment, false
+	}
+	if isDoctor {
+		allowed, err := doctorCanViewRecord(reqDB(h.DB, c), h.Cfg, requestingUserIDStr, medicalRecord)
+		if err != nil {
+			utils.HandleDBError(c, err, "common.database_error")
+			return attachment, false
+		}
+		if !allowed {
+			utils.Forbidden(c, "records.consent_required")
+			return attachment, false
+		}
+	}
+
+	return attachment, true
+}
+
+// GetMedicalRecordAttachmentThumbnail handles serving the JPEG preview of an image attachment, under the
+// same authorization as the full download. Attachments without a preview are not found.
+func (h *MedicalRecordHandler) GetMedicalRecordAttachmentThumbnail(c *gin.Context) {
+	attachment, ok := h.loadViewableAttachment(c)
+	if !ok {
+		return
+	}
+	if !attachment.HasThumbnail {
+		utils.NotFound(c, "records.thumbnail_not_found")
 		return
 	}
 
-	c.Writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", attachment.FileName))
-	c.Data(http.StatusOK, attachment.FileType, attachment.FileData)
+	// Previews never change once stored, so they are cached like the file itself
+	etag := utils.ComputeETag(utils.ETagVersion(attachment.ID+"/thumbnail", attachment.UpdatedAt))
+	if attachment.ContentHash != "" {
+		etag = `"` + attachment.ContentHash + `-thumbnail"`
+	}
+	if utils.CheckNotModified(c, etag) || utils.CheckNotModifiedSince(c, attachment.CreatedAt) {
+		return
+	}
+
+	var thumbnail models.MedicalRecordAttachment
+	if err := reqDB(h.DB, c).Select("id", "thumbnail_data").First(&thumbnail, "id = ?", attachment.ID).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+
+	c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
+	c.Writer.Header().Set("Content-Disposition", "inline")
+	c.Data(http.StatusOK, "image/jpeg", thumbnail.ThumbnailData)
+}
+
+// thumbnailFor makes the preview stored with a new attachment, or returns nil when the file is not an image
+// or previews are disabled. An image that cannot be decoded is still accepted, just without a preview.
+func (h *MedicalRecordHandler) thumbnailFor(fileType string, fileData []byte) []byte {
+	if h.Cfg.AttachmentThumbnailSize <= 0 || !utils.IsThumbnailableType(fileType) {
+		return nil
+	}
+	thumbnail, err := utils.MakeThumbnail(fileData, h.Cfg.AttachmentThumbnailSize)
+	if err != nil {
+		log.Printf("Storing %s attachment without a thumbnail: %v", fileType, err)
+		return nil
+	}
+	return thumbnail
 }
 
-// DeleteMedicalRecord handles deleting a medical record.
+// isAllowedAttachmentType reports whether fileType is on the configured attachment allowlist.
+func (h *MedicalRecordHandler) isAllowedAttachmentType(fileType string) bool {
+	for _, allowed := range h.Cfg.AllowedAttachmentTypes {
+		if allowed == fileType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteMedicalRecord handles moving a medical record to the trash.
+// The record can be restored until the purge job removes it after RECORD_TRASH_RETENTION_DAYS.
 // Only accessible by the doctor who created it or an admin.
 func (h *MedicalRecordHandler) DeleteMedicalRecord(c *gin.Context) {
-	// Placeholder implementation - actual deletion logic needed
-	c.JSON(http.StatusNotImplemented, gin.H{"message": "DeleteMedicalRecord not yet implemented."})
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_medical_record_id")
+		return
+	}
+
+	var record models.MedicalRecord
+	if err := reqDB(h.DB, c).First(&record, "id = ?", recordID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.medical_record_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canModifyRecord(userRole, userID, record.DoctorID) {
+		utils.Forbidden(c, "records.delete_forbidden")
+		return
+	}
+
+	if err := reqDB(h.DB, c).Delete(&record).Error; err != nil {
+		utils.HandleDBError(c, err, "records.delete_failed")
+		return
+	}
+
+	utils.Success(c, "Medical record moved to trash successfully", nil)
+}
+
+// GetMedicalRecordTrash handles listing deleted medical records that can still be restored,
+// most recently deleted first. Doctors see the records they created, admins see all.
+func (h *MedicalRecordHandler) GetMedicalRecordTrash(c *gin.Context) {
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Unscoped().Model(&models.MedicalRecord{}).Where("deleted_at IS NOT NULL")
+	if !userRole.IsAdmin() {
+		query = query.Where("doctor_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "records.fetch_failed")
+		return
+	}
+
+	var records []models.MedicalRecord
+	if err := query.Order("deleted_at desc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&records).Error; err != nil {
+		utils.HandleDBError(c, err, "records.fetch_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Deleted medical records fetched successfully", dto.NewMedicalRecordResponses(records), pagination.Meta(total))
+}
+
+// RestoreMedicalRecord handles bringing a medical record back from the trash.
+// Only accessible by the doctor who created it or an admin.
+func (h *MedicalRecordHandler) RestoreMedicalRecord(c *gin.Context) {
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_medical_record_id")
+		return
+	}
+
+	var record models.MedicalRecord
+	if err := reqDB(h.DB, c).Unscoped().Where("deleted_at IS NOT NULL").First(&record, "id = ?", recordID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "records.not_in_trash")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canModifyRecord(userRole, userID, record.DoctorID) {
+		utils.Forbidden(c, "records.restore_forbidden")
+		return
+	}
+
+	if err := reqDB(h.DB, c).Unscoped().Model(&record).Update("deleted_at", nil).Error; err != nil {
+		utils.HandleDBError(c, err, "records.restore_failed")
+		return
+	}
+	record.DeletedAt = gorm.DeletedAt{}
+
+	utils.Success(c, "Medical record restored successfully", dto.NewMedicalRecordResponse(record))
 }
 
 // GetMedicalRecordByID handles fetching a single medical record by its ID.
@@ -293,66 +749,82 @@ func (h *MedicalRecordHandler) GetMedicalRecordByID(c *gin.Context) {
 	recordIDStr := c.Param("id")
 	recordID, err := uuid.Parse(recordIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Medical Record ID format")
+		utils.BadRequest(c, "common.invalid_medical_record_id")
 		return
 	}
 
 	var record models.MedicalRecord
-	if err := h.DB.Preload("Attachments").First(&record, "id = ?", recordID).Error; err != nil {
+	if err := reqDB(h.DB, c).Preload("Attachments", preloadAttachmentMetadata).Preload("Diagnoses", orderDiagnoses).
+		First(&record, "id = ?", recordID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Medical record not found")
+			utils.NotFound(c, "common.medical_record_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
 	requestingUserIDStr, _ := middleware.GetUserIDFromContext(c)
 	requestingUserRole, _ := middleware.GetUserRoleFromContext(c)
 
-	// Use strings.EqualFold for case-insensitive role comparison.
-	isDoctor := strings.EqualFold(string(requestingUserRole), string(models.RoleDoctor))
-	isPatientOwner := strings.EqualFold(string(requestingUserRole), string(models.RolePatient)) && requestingUserIDStr == record.PatientID
+	if !canViewPatientRecords(requestingUserRole, requestingUserIDStr, record.PatientID) {
+		utils.Forbidden(c, "records.view_forbidden")
+		return
+	}
+	if requestingUserRole == models.RoleDoctor {
+		allowed, err := doctorCanViewRecord(reqDB(h.DB, c), h.Cfg, requestingUserIDStr, record)
+		if err != nil {
+			utils.HandleDBError(c, err, "common.database_error")
+			return
+		}
+		if !allowed {
+			utils.Forbidden(c, "records.consent_required")
+			return
+		}
+	}
 
-	if !(isDoctor || isPatientOwner) {
-		utils.Forbidden(c, "You are not authorized to view this medical record")
+	if utils.CheckNotModified(c, medicalRecordsETag([]models.MedicalRecord{record})) {
 		return
 	}
 
-	utils.Success(c, "Medical record fetched successfully", record)
+	utils.Success(c, "Medical record fetched successfully", dto.NewMedicalRecordResponse(record))
 }
 
 // UpdateMedicalRecordRequest represents the request body for updating a medical record.
+// Only the fields present in the body are changed; an explicit empty string clears department or details,
+// while the fields a record cannot be without reject it.
 type UpdateMedicalRecordRequest struct {
-	RecordType models.MedicalRecordType `json:"recordType,omitempty"`
-	RecordDate string                   `json:"recordDate,omitempty"` // Added to allow date updates
-	Title      string                   `json:"title,omitempty"`
-	Department string                   `json:"department,omitempty"`
-	Summary    string                   `json:"summary,omitempty"`
-	Details    string                   `json:"details,omitempty"`
+	RecordType *models.MedicalRecordType `json:"recordType" binding:"omitempty,record_type"`
+	RecordDate *string                   `json:"recordDate"` // RFC3339
+	Title      *string                   `json:"title" binding:"omitempty,min=1,max=255"`
+	Department *string                   `json:"department" binding:"omitempty,max=100"`
+	Summary    *string                   `json:"summary" binding:"omitempty,min=1"`
+	Details    *string                   `json:"details"`
+	Version    int                       `json:"version" binding:"required,min=1"` // Version of the record the client last read
 }
 
 // UpdateMedicalRecord handles updating an existing medical record.
 // Only accessible by the doctor who created it or an admin.
+// The update is rejected with 409 if the record changed since the client read the given version.
 func (h *MedicalRecordHandler) UpdateMedicalRecord(c *gin.Context) {
 	recordIDStr := c.Param("id")
 	recordID, err := uuid.Parse(recordIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Medical Record ID format")
+		utils.BadRequest(c, "common.invalid_medical_record_id")
 		return
 	}
 
 	var req UpdateMedicalRecordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.BadRequest(c, "Invalid request payload: "+err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	var record models.MedicalRecord
-	if err := h.DB.First(&record, "id = ?", recordID).Error; err != nil {
+	if err := reqDB(h.DB, c).First(&record, "id = ?", recordID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Medical record not found")
+			utils.NotFound(c, "common.medical_record_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
@@ -361,43 +833,59 @@ func (h *MedicalRecordHandler) UpdateMedicalRecord(c *gin.Context) {
 	requestingUserRole, _ := middleware.GetUserRoleFromContext(c)
 
 	// Authorization: Only the creating doctor or an Admin can update.
-	isAdmin := strings.EqualFold(string(requestingUserRole), string(models.RoleAdmin))
-	isCreatorDoctor := strings.EqualFold(string(requestingUserRole), string(models.RoleDoctor)) && requestingUserIDStr == record.DoctorID
-
-	if !(isAdmin || isCreatorDoctor) {
-		utils.Forbidden(c, "You are not authorized to update this medical record")
+	if !canModifyRecord(requestingUserRole, requestingUserIDStr, record.DoctorID) {
+		utils.Forbidden(c, "records.update_forbidden")
 		return
 	}
 
 	// Apply updates
-	if req.RecordType != "" {
-		record.RecordType = req.RecordType
+	if req.RecordType != nil {
+		record.RecordType = *req.RecordType
 	}
-	if req.RecordDate != "" {
-		parsedDate, err := time.Parse(time.RFC3339, req.RecordDate)
+	if req.RecordDate != nil {
+		parsedDate, err := time.Parse(time.RFC3339, *req.RecordDate)
 		if err != nil {
-			utils.BadRequest(c, "Invalid date format for recordDate. Please use ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ)")
+			utils.BadRequest(c, "records.invalid_record_date")
 			return
 		}
-		record.RecordDate = parsedDate
+		record.RecordDate = parsedDate.UTC()
 	}
-	if req.Title != "" {
-		record.Title = req.Title
+	if req.Title != nil {
+		record.Title = *req.Title
 	}
-	if req.Department != "" {
-		record.Department = req.Department
+	if req.Department != nil {
+		record.Department = *req.Department
 	}
-	if req.Summary != "" {
-		record.Summary = req.Summary
+	if req.Summary != nil {
+		record.Summary = utils.SanitizeText(*req.Summary)
+		if strings.TrimSpace(record.Summary) == "" {
+			utils.BadRequest(c, "records.summary_empty")
+			return
+		}
+	}
+	if req.Details != nil {
+		record.Details = utils.SanitizeText(*req.Details)
 	}
-	if req.Details != "" {
-		record.Details = req.Details
+
+	if record.Version != req.Version {
+		utils.Conflict(c, "records.version_conflict")
+		return
 	}
+	record.Version++
 
-	if err := h.DB.Save(&record).Error; err != nil {
-		utils.InternalServerError(c, "Failed to update medical record: "+err.Error())
+	// The version condition makes the check and the write atomic, so a concurrent update cannot slip in between
+	result := reqDB(h.DB, c).Model(&record).
+		Where("version = ?", req.Version).
+		Select("record_type", "record_date", "title", "department", "summary", "details", "version", "updated_at").
+		Updates(&record)
+	if result.Error != nil {
+		utils.HandleDBError(c, result.Error, "records.update_failed")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Conflict(c, "records.version_conflict")
 		return
 	}
 
-	utils.Success(c, "Medical record updated successfully", record)
+	utils.Success(c, "Medical record updated successfully", dto.NewMedicalRecordResponse(record))
 }
@@ -0,0 +1,491 @@
+package handlers_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"healthcare-app-server/internal/i18n"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordFixture is a medical record with one attachment, written by doctor for patient.
+type recordFixture struct {
+	patient, doctor *models.User
+	record          models.MedicalRecord
+	attachment      models.MedicalRecordAttachment
+}
+
+func newRecordFixture(t *testing.T, api *testAPI) recordFixture {
+	t.Helper()
+
+	fixture := recordFixture{patient: api.createUser(t, models.RolePatient), doctor: api.createUser(t, models.RoleDoctor)}
+	fixture.record = models.MedicalRecord{
+		PatientID:  fixture.patient.ID,
+		DoctorID:   fixture.doctor.ID,
+		RecordType: models.RecordTypeLabResult,
+		RecordDate: time.Now().UTC().Truncate(time.Second),
+		Title:      "Blood panel",
+	}
+	api.create(t, &fixture.record)
+
+	data := []byte("glucose 5.1 mmol/L\n")
+	hash := sha256.Sum256(data)
+	fixture.attachment = models.MedicalRecordAttachment{
+		MedicalRecordID: fixture.record.ID,
+		FileName:        "results.txt",
+		FileType:        "text/plain",
+		FileData:        data,
+		ContentHash:     hex.EncodeToString(hash[:]),
+	}
+	api.create(t, &fixture.attachment)
+	return fixture
+}
+
+func TestMedicalRecordGETsHonourIfNoneMatch(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+
+	paths := map[string]string{
+		"record":     "/api/v1/medical-records/" + fixture.record.ID,
+		"records":    "/api/v1/medical-records/patient/" + fixture.patient.ID,
+		"attachment": "/api/v1/medical-records/attachments/" + fixture.attachment.ID,
+	}
+	for name, path := range paths {
+		t.Run(name, func(t *testing.T) {
+			headers := api.auth(t, fixture.patient)
+			first := testutil.PerformRequest(t, api.router, http.MethodGet, path, nil, headers)
+			if first.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", first.Code, first.Body.String())
+			}
+			etag := first.Header().Get("ETag")
+			if etag == "" {
+				t.Fatal("no ETag header")
+			}
+
+			headers["If-None-Match"] = etag
+			matched := testutil.PerformRequest(t, api.router, http.MethodGet, path, nil, headers)
+			if matched.Code != http.StatusNotModified {
+				t.Fatalf("matching If-None-Match status = %d, want %d", matched.Code, http.StatusNotModified)
+			}
+			if matched.Body.Len() != 0 {
+				t.Errorf("304 carried a body: %q", matched.Body.String())
+			}
+
+			headers["If-None-Match"] = `"stale"`
+			mismatched := testutil.PerformRequest(t, api.router, http.MethodGet, path, nil, headers)
+			if mismatched.Code != http.StatusOK {
+				t.Errorf("mismatching If-None-Match status = %d, want %d", mismatched.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestMedicalRecordETagChangesWhenTheRecordIsUpdated(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+	path := "/api/v1/medical-records/" + fixture.record.ID
+	headers := api.auth(t, fixture.patient)
+
+	before := testutil.PerformRequest(t, api.router, http.MethodGet, path, nil, headers).Header().Get("ETag")
+	if err := api.db.Model(&fixture.record).Updates(map[string]interface{}{
+		"title":      "Blood panel (corrected)",
+		"updated_at": fixture.record.UpdatedAt.Add(time.Second),
+	}).Error; err != nil {
+		t.Fatalf("updating record: %v", err)
+	}
+
+	headers["If-None-Match"] = before
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, path, nil, headers)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status after the update = %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if after := recorder.Header().Get("ETag"); after == before {
+		t.Errorf("ETag %s did not change with the record", after)
+	}
+}
+
+func TestMedicalRecordAttachmentHonoursIfModifiedSince(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+	path := "/api/v1/medical-records/attachments/" + fixture.attachment.ID
+
+	tests := []struct {
+		name       string
+		since      time.Time
+		wantStatus int
+	}{
+		{"not modified since", fixture.attachment.CreatedAt.Add(time.Minute), http.StatusNotModified},
+		{"modified since", fixture.attachment.CreatedAt.Add(-time.Minute), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := api.auth(t, fixture.patient)
+			headers["If-Modified-Since"] = tt.since.UTC().Format(http.TimeFormat)
+			recorder := testutil.PerformRequest(t, api.router, http.MethodGet, path, nil, headers)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUpdateMedicalRecordRejectsStaleVersion(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+	admin := api.createUser(t, models.RoleAdmin)
+	path := "/api/v1/medical-records/" + fixture.record.ID
+
+	// Both editors read the record at version 1; the doctor saves first
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPut, path,
+		map[string]interface{}{"title": "Doctor's title", "version": 1}, api.auth(t, fixture.doctor))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("first update status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	var updated struct {
+		Version int `json:"version"`
+	}
+	decodeData(t, recorder, &updated)
+	if updated.Version != 2 {
+		t.Errorf("version after first update = %d, want 2", updated.Version)
+	}
+
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPut, path,
+		map[string]interface{}{"title": "Admin's title", "version": 1}, api.auth(t, admin))
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("stale update status = %d, want %d: %s", recorder.Code, http.StatusConflict, recorder.Body.String())
+	}
+	if response := testutil.DecodeResponse(t, recorder); response.Error != i18n.Translate("en", "records.version_conflict", nil) {
+		t.Errorf("error = %q", response.Error)
+	}
+
+	var stored models.MedicalRecord
+	if err := api.db.First(&stored, "id = ?", fixture.record.ID).Error; err != nil {
+		t.Fatalf("reloading record: %v", err)
+	}
+	if stored.Title != "Doctor's title" || stored.Version != 2 {
+		t.Errorf("stored title %q version %d, want the first update to stand", stored.Title, stored.Version)
+	}
+}
+
+func TestMedicalRecordDateRoundTripsAsUTC(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+	recordDate := time.Date(2026, 3, 2, 23, 30, 0, 0, time.FixedZone("+05:30", 5*60*60+30*60))
+	wantDate := "2026-03-02T18:00:00Z"
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/medical-records", map[string]string{
+		"patientId":  fixture.patient.ID,
+		"recordType": string(models.RecordTypeLabResult),
+		"recordDate": recordDate.Format(time.RFC3339),
+		"title":      "Blood panel",
+		"summary":    "Normal",
+	}, api.auth(t, fixture.doctor))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body.String())
+	}
+	var created struct {
+		ID         string `json:"id"`
+		RecordDate string `json:"recordDate"`
+	}
+	decodeData(t, recorder, &created)
+	if created.RecordDate != wantDate {
+		t.Errorf("created recordDate = %q, want %q", created.RecordDate, wantDate)
+	}
+
+	// An update from a zone west of UTC crosses back over midnight
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPut, "/api/v1/medical-records/"+created.ID, map[string]interface{}{
+		"recordDate": "2026-03-02T21:15:00-04:00",
+		"version":    1,
+	}, api.auth(t, fixture.doctor))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+
+	recorder = testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/medical-records/"+created.ID, nil, api.auth(t, fixture.patient))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("get status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	var fetched struct {
+		RecordDate string `json:"recordDate"`
+	}
+	decodeData(t, recorder, &fetched)
+	if fetched.RecordDate != "2026-03-03T01:15:00Z" {
+		t.Errorf("fetched recordDate = %q, want 2026-03-03T01:15:00Z", fetched.RecordDate)
+	}
+}
+
+func TestUpdateMedicalRecordPatchSemantics(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+		check      func(t *testing.T, record models.MedicalRecord)
+	}{
+		{
+			name:       "absent fields are left unchanged",
+			body:       map[string]interface{}{"summary": "Revised"},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, record models.MedicalRecord) {
+				if record.Summary != "Revised" || record.Department != "Cardiology" || record.Details != "Fasting sample" ||
+					record.Title != "Blood panel" || record.RecordType != models.RecordTypeLabResult {
+					t.Errorf("record = %+v, want only the summary changed", record)
+				}
+			},
+		},
+		{
+			name:       "explicit empty strings clear optional fields",
+			body:       map[string]interface{}{"department": "", "details": ""},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, record models.MedicalRecord) {
+				if record.Department != "" || record.Details != "" {
+					t.Errorf("department %q details %q, want both cleared", record.Department, record.Details)
+				}
+				if record.Summary != "Normal" || record.Title != "Blood panel" {
+					t.Errorf("record = %+v, want the other fields unchanged", record)
+				}
+			},
+		},
+		{name: "unknown record type", body: map[string]interface{}{"recordType": "Horoscope"}, wantStatus: http.StatusBadRequest},
+		{name: "empty record type", body: map[string]interface{}{"recordType": ""}, wantStatus: http.StatusBadRequest},
+		{name: "empty title", body: map[string]interface{}{"title": ""}, wantStatus: http.StatusBadRequest},
+		{name: "blank summary", body: map[string]interface{}{"summary": "   "}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			fixture := newRecordFixture(t, api)
+			if err := api.db.Model(&fixture.record).Updates(map[string]interface{}{
+				"department": "Cardiology", "summary": "Normal", "details": "Fasting sample",
+			}).Error; err != nil {
+				t.Fatalf("filling record: %v", err)
+			}
+			var before models.MedicalRecord
+			if err := api.db.First(&before, "id = ?", fixture.record.ID).Error; err != nil {
+				t.Fatalf("loading record: %v", err)
+			}
+
+			tt.body["version"] = before.Version
+			recorder := testutil.PerformRequest(t, api.router, http.MethodPut, "/api/v1/medical-records/"+fixture.record.ID, tt.body, api.auth(t, fixture.doctor))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+
+			var after models.MedicalRecord
+			if err := api.db.First(&after, "id = ?", fixture.record.ID).Error; err != nil {
+				t.Fatalf("reloading record: %v", err)
+			}
+			if tt.check != nil {
+				tt.check(t, after)
+				return
+			}
+			// A rejected update changes nothing
+			if after.Version != before.Version || after.RecordType != before.RecordType || after.Title != before.Title || after.Summary != before.Summary {
+				t.Errorf("record changed by a rejected update: %+v", after)
+			}
+		})
+	}
+}
+
+func TestMedicalRecordWritesNeutralizeScriptPayloads(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/medical-records", map[string]string{
+		"patientId":  fixture.patient.ID,
+		"recordType": string(models.RecordTypeConsultation),
+		"recordDate": time.Now().UTC().Format(time.RFC3339),
+		"title":      "Follow-up",
+		"summary":    `Stable<script>alert("summary")</script>`,
+		"details":    `<a href="javascript:alert(1)">See notes</a>`,
+	}, api.auth(t, fixture.doctor))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body.String())
+	}
+	var created struct {
+		ID      string `json:"id"`
+		Summary string `json:"summary"`
+		Details string `json:"details"`
+	}
+	decodeData(t, recorder, &created)
+	if created.Summary != "Stable" || created.Details != "See notes" {
+		t.Errorf("created summary %q details %q, want the markup removed", created.Summary, created.Details)
+	}
+
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPut, "/api/v1/medical-records/"+created.ID, map[string]interface{}{
+		"details": `Improving<style>body{display:none}</style>`,
+		"version": 1,
+	}, api.auth(t, fixture.doctor))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+
+	var stored models.MedicalRecord
+	if err := api.db.First(&stored, "id = ?", created.ID).Error; err != nil {
+		t.Fatalf("reloading record: %v", err)
+	}
+	if stored.Summary != "Stable" || stored.Details != "Improving" {
+		t.Errorf("stored summary %q details %q, want the markup removed", stored.Summary, stored.Details)
+	}
+
+	// A summary that is nothing but markup is empty once sanitized
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPut, "/api/v1/medical-records/"+created.ID, map[string]interface{}{
+		"summary": "<script>alert(1)</script>",
+		"version": 2,
+	}, api.auth(t, fixture.doctor))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("markup-only summary status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+	}
+}
+
+func TestGetMedicalRecordsForPatientSelectsOnlyRequestedFields(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+	longDetails := strings.Repeat("d", 500)
+	if err := api.db.Model(&fixture.record).Updates(map[string]interface{}{"summary": "Normal", "details": longDetails}).Error; err != nil {
+		t.Fatalf("filling record: %v", err)
+	}
+	path := "/api/v1/medical-records/patient/" + fixture.patient.ID
+
+	t.Run("fields", func(t *testing.T) {
+		selects := api.captureSelects(t, "medical_records")
+		recorder := testutil.PerformRequest(t, api.router, http.MethodGet, path+"?fields=title,recordDate", nil, api.auth(t, fixture.patient))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+		}
+		assertSelect(t, selects(), []string{"id", "patient_id", "doctor_id", "title", "record_date"},
+			[]string{"summary", "details", "department", "record_type"})
+
+		var records []map[string]interface{}
+		decodeData(t, recorder, &records)
+		if len(records) != 1 {
+			t.Fatalf("got %d records, want 1", len(records))
+		}
+		for _, key := range []string{"summary", "details", "attachments", "department"} {
+			if _, ok := records[0][key]; ok {
+				t.Errorf("response has the excluded field %s", key)
+			}
+		}
+		if records[0]["id"] != fixture.record.ID || records[0]["title"] != "Blood panel" {
+			t.Errorf("record = %v, want its ID and title", records[0])
+		}
+	})
+
+	t.Run("summaryOnly", func(t *testing.T) {
+		selects := api.captureSelects(t, "medical_records")
+		recorder := testutil.PerformRequest(t, api.router, http.MethodGet, path+"?summaryOnly=true", nil, api.auth(t, fixture.patient))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+		}
+		captured := selects()
+		assertSelect(t, captured, []string{"summary"}, nil)
+		// Details are only read cut down, under their own name
+		if withoutCut := strings.Replace(captured[0], "SUBSTR(details, 1, 200) AS details", "", 1); withoutCut == captured[0] || selectedNames(withoutCut)["details"] {
+			t.Errorf("SELECT %s reads the full details", captured[0])
+		}
+
+		var records []struct {
+			Details string `json:"details"`
+		}
+		decodeData(t, recorder, &records)
+		if len(records) != 1 || records[0].Details != longDetails[:200] {
+			t.Errorf("details = %d characters, want the first 200", len(records[0].Details))
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		recorder := testutil.PerformRequest(t, api.router, http.MethodGet, path+"?fields=title,password", nil, api.auth(t, fixture.patient))
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+		}
+		if response := testutil.DecodeResponse(t, recorder); !strings.Contains(response.Error, "password") || !strings.Contains(response.Error, "summary") {
+			t.Errorf("error = %q, want it to name the field and the valid options", response.Error)
+		}
+	})
+}
+
+// pngHeader is the signature and IHDR chunk of a 1x1 PNG, enough for content sniffing.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00\x90wS\xde")
+
+// uploadAttachment posts data as a multipart file part with the given claimed content type.
+func (api *testAPI) uploadAttachment(t *testing.T, user *models.User, recordID, fileName, contentType string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+fileName+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatalf("creating form file: %v", err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/medical-records/"+recordID+"/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", testutil.BearerHeader(testutil.MintAccessToken(t, api.cfg, user)))
+	recorder := httptest.NewRecorder()
+	api.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestUploadAttachmentRejectsContentThatContradictsItsClaimedType(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+
+	recorder := api.uploadAttachment(t, fixture.doctor, fixture.record.ID, "x.png", "image/png", []byte("<html><script>alert(1)</script></html>"))
+	if recorder.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusUnsupportedMediaType, recorder.Body.String())
+	}
+
+	var count int64
+	api.db.Model(&models.MedicalRecordAttachment{}).Where("medical_record_id = ?", fixture.record.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("attachments = %d, want only the fixture's", count)
+	}
+}
+
+func TestUploadAttachmentStoresTheSniffedType(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+
+	recorder := api.uploadAttachment(t, fixture.doctor, fixture.record.ID, "scan.png", "application/octet-stream", pngHeader)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+
+	var stored models.MedicalRecordAttachment
+	if err := api.db.Where("medical_record_id = ? AND file_name = ?", fixture.record.ID, "scan.png").First(&stored).Error; err != nil {
+		t.Fatalf("loading uploaded attachment: %v", err)
+	}
+	if stored.FileType != "image/png" {
+		t.Errorf("file type = %q, want image/png", stored.FileType)
+	}
+}
+
+func TestAttachmentDownloadForcesAttachmentForUnsafeTypes(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+
+	// The fixture's text/plain attachment is not on the inline-safe list
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/medical-records/attachments/"+fixture.attachment.ID, nil, api.auth(t, fixture.patient))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want nosniff", got)
+	}
+	if got := recorder.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment;") {
+		t.Errorf("Content-Disposition = %q, want attachment", got)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/realtime"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// markMessagesRead marks the messages among messageIDs that readerID received and has not read yet as read,
+// and returns the receipts for their senders. Every way of reading a message goes through here, REST and
+// realtime alike, so the status, the read time and the receipts never disagree.
+func markMessagesRead(db *gorm.DB, readerID string, messageIDs []string) ([]realtime.Receipt, error) {
+	var unread []models.Message
+	if err := db.Select("id", "sender_id", "conversation_id").
+		Where("id IN ? AND receiver_id = ? AND status <> ?", messageIDs, readerID, models.MessageStatusRead).
+		Order("created_at asc").
+		Find(&unread).Error; err != nil {
+		return nil, err
+	}
+	if len(unread) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(unread))
+	for i, message := range unread {
+		ids[i] = message.ID
+	}
+	readAt := time.Now().UTC()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Message{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":  models.MessageStatusRead,
+			"read_at": readAt,
+		}).Error; err != nil {
+			return err
+		}
+		// Bring the reader's unread counts of the conversations involved down to match
+		recounted := make(map[string]bool)
+		for _, message := range unread {
+			if recounted[message.ConversationID] {
+				continue
+			}
+			recounted[message.ConversationID] = true
+			if err := models.RecountUnread(tx, message.ConversationID, readerID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// One receipt per conversation; a reader's messages in a conversation all come from the same sender
+	var receipts []realtime.Receipt
+	index := make(map[string]int)
+	for _, message := range unread {
+		i, ok := index[message.ConversationID]
+		if !ok {
+			i = len(receipts)
+			index[message.ConversationID] = i
+			receipts = append(receipts, realtime.Receipt{
+				SenderID:       message.SenderID,
+				ConversationID: message.ConversationID,
+				ReadAt:         readAt,
+			})
+		}
+		receipts[i].MessageIDs = append(receipts[i].MessageIDs, message.ID)
+	}
+	return receipts, nil
+}
+
+// applyReceipts sets the status and read time recorded by receipts on message, if it is among them.
+func applyReceipts(receipts []realtime.Receipt, message *models.Message) {
+	for _, receipt := range receipts {
+		for _, id := range receipt.MessageIDs {
+			if id == message.ID {
+				readAt := receipt.ReadAt
+				message.Status = models.MessageStatusRead
+				message.ReadAt = &readAt
+				return
+			}
+		}
+	}
+}
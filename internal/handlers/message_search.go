@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/encryption"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	messageSearchMinLength = 2    // Shortest search term, so a single letter does not match every message
+	messageSearchMaxLength = 200  // Longest search term
+	messageSearchScanLimit = 5000 // Most recent messages searched in Go when content may be encrypted
+	messageSnippetContext  = 60   // Characters of context kept on each side of a match in its snippet
+)
+
+// MessageSearchMatch tells where the search term was found in a message. Offsets and lengths count
+// characters, not bytes.
+type MessageSearchMatch struct {
+	Field         string `json:"field"`         // "subject" or "content"
+	Offset        int    `json:"offset"`        // Where the match starts in the field
+	Length        int    `json:"length"`        // 0 when a full-text match found no literal occurrence of the term
+	Snippet       string `json:"snippet"`       // The match with some context around it
+	SnippetOffset int    `json:"snippetOffset"` // Where the match starts in the snippet
+}
+
+// MessageSearchResult is a message found by SearchMessages.
+type MessageSearchResult struct {
+	dto.MessageResponse
+	Match MessageSearchMatch `json:"match"`
+}
+
+// SearchMessages handles searching the subjects and content of the current user's messages for `q`,
+// newest first. `withUser` narrows the search to the conversation with one user, and `from`/`to` to
+// when the messages were sent. Only messages the user sent or received are ever searched. Archived
+// messages are included, and found messages are not marked read.
+//
+// The term is matched with LIKE, which the sender/receiver indexes narrow to the user's own messages,
+// or with the FULLTEXT index when MESSAGE_SEARCH_FULLTEXT is on. While encryption keys are configured
+// content may be stored encrypted, so the user's most recent messages are decrypted and searched here
+// instead.
+func (h *MessageHandler) SearchMessages(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	term := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(term) < messageSearchMinLength || utf8.RuneCountInString(term) > messageSearchMaxLength {
+		utils.BadRequest(c, "messages.invalid_search_query", utils.Params{"min": strconv.Itoa(messageSearchMinLength), "max": strconv.Itoa(messageSearchMaxLength)})
+		return
+	}
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.Message{}).Where("sender_id = ? OR receiver_id = ?", userID, userID)
+	if withUser := c.Query("withUser"); withUser != "" {
+		otherUserID, err := uuid.Parse(withUser)
+		if err != nil {
+			utils.BadRequest(c, "messages.invalid_with_user")
+			return
+		}
+		// Added to the condition above, so it narrows the user's messages and never reaches anyone else's
+		query = query.Where("(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
+			userID, otherUserID, otherUserID, userID)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			utils.BadRequest(c, "messages.invalid_from")
+			return
+		}
+		query = query.Where("created_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			utils.BadRequest(c, "messages.invalid_to")
+			return
+		}
+		query = query.Where("created_at <= ?", toTime)
+	}
+
+	var (
+		messages []models.Message
+		total    int64
+		err      error
+	)
+	if encryption.Active() != nil {
+		messages, total, err = searchMessagesInGo(reqDB(h.DB, c), query, term, pagination)
+	} else {
+		messages, total, err = h.searchMessagesInSQL(query, term, pagination)
+	}
+	if err != nil {
+		utils.HandleDBError(c, err, "messages.search_failed")
+		return
+	}
+
+	results := make([]MessageSearchResult, 0, len(messages))
+	for _, message := range messages {
+		results = append(results, MessageSearchResult{MessageResponse: dto.NewMessageResponse(message), Match: findMessageMatch(message, term)})
+	}
+	utils.SuccessWithMeta(c, "Messages fetched successfully", results, pagination.Meta(total))
+}
+
+// searchMessagesInSQL returns one page of the messages of query matching term, and how many match.
+func (h *MessageHandler) searchMessagesInSQL(query *gorm.DB, term string, pagination utils.Pagination) ([]models.Message, int64, error) {
+	if h.Cfg.MessageSearchFullText && query.Dialector.Name() == "mysql" {
+		query = query.Where("MATCH(subject, content) AGAINST (?)", term)
+	} else {
+		pattern := "%" + escapeLike(term) + "%"
+		query = query.Where("subject LIKE ? OR content LIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var messages []models.Message
+	err := query.Preload("Sender").Preload("Receiver").
+		Order("created_at desc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&messages).Error
+	return messages, total, err
+}
+
+// searchMessagesInGo decrypts the most recent messageSearchScanLimit messages of query and returns one
+// page of those matching term, and how many match.
+func searchMessagesInGo(db *gorm.DB, query *gorm.DB, term string, pagination utils.Pagination) ([]models.Message, int64, error) {
+	var candidates []models.Message
+	if err := query.Select("id", "subject", "content").
+		Order("created_at desc").Limit(messageSearchScanLimit).
+		Find(&candidates).Error; err != nil {
+		return nil, 0, err
+	}
+
+	needle := []rune(term)
+	var matched []string
+	for _, candidate := range candidates {
+		if indexFold([]rune(candidate.Subject), needle) >= 0 || indexFold([]rune(candidate.Content), needle) >= 0 {
+			matched = append(matched, candidate.ID)
+		}
+	}
+	total := int64(len(matched))
+	if pagination.Offset >= len(matched) {
+		return []models.Message{}, total, nil
+	}
+	page := matched[pagination.Offset:min(pagination.Offset+pagination.Limit, len(matched))]
+
+	var messages []models.Message
+	err := db.Preload("Sender").Preload("Receiver").
+		Where("id IN ?", page).
+		Order("created_at desc").
+		Find(&messages).Error
+	return messages, total, err
+}
+
+// findMessageMatch finds term in the message's subject, then its content, ignoring case. A full-text
+// search can match on words of the term apart; then the first word found is reported. When nothing
+// is found, the match points at the start of the content with a length of 0.
+func findMessageMatch(message models.Message, term string) MessageSearchMatch {
+	needles := append([]string{term}, strings.Fields(term)...)
+	for _, needle := range needles {
+		for _, field := range []struct{ name, value string }{{"subject", message.Subject}, {"content", message.Content}} {
+			if offset := indexFold([]rune(field.value), []rune(needle)); offset >= 0 {
+				return newMessageSearchMatch(field.name, field.value, offset, utf8.RuneCountInString(needle))
+			}
+		}
+	}
+	return newMessageSearchMatch("content", message.Content, 0, 0)
+}
+
+// newMessageSearchMatch builds the match of length characters at offset in the field's value.
+func newMessageSearchMatch(field, value string, offset, length int) MessageSearchMatch {
+	runes := []rune(value)
+	start := max(offset-messageSnippetContext, 0)
+	end := min(offset+length+messageSnippetContext, len(runes))
+	return MessageSearchMatch{
+		Field:         field,
+		Offset:        offset,
+		Length:        length,
+		Snippet:       string(runes[start:end]),
+		SnippetOffset: offset - start,
+	}
+}
+
+// indexFold returns the character offset of the first case-insensitive occurrence of needle in s, or
+// -1. Runes are folded one by one, so offsets stay those of the original text.
+func indexFold(s, needle []rune) int {
+	if len(needle) == 0 {
+		return -1
+	}
+	for i := 0; i+len(needle) <= len(s); i++ {
+		found := true
+		for j, r := range needle {
+			if unicode.ToLower(s[i+j]) != unicode.ToLower(r) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+	return -1
+}
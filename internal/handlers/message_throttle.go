@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// messageLimitEventLimiter caps the audit events written for one sender hitting the message limits,
+// so a spam run shows up in the audit log without flooding it.
+var messageLimitEventLimiter = utils.NewRateLimiter(1, 10*time.Minute)
+
+// messageThrottle limits how many messages each user sends per minute and per hour. Doctors and admins
+// have their own, usually higher, limits. A nil limiter means that limit is off.
+type messageThrottle struct {
+	perMinute, perHour           *utils.RateLimiter
+	staffPerMinute, staffPerHour *utils.RateLimiter
+}
+
+// newMessageThrottle creates the limiters configured in cfg.
+func newMessageThrottle(cfg *config.Config) *messageThrottle {
+	limiter := func(limit int, window time.Duration) *utils.RateLimiter {
+		if limit <= 0 {
+			return nil
+		}
+		return utils.NewRateLimiter(limit, window)
+	}
+	return &messageThrottle{
+		perMinute:      limiter(cfg.MessageRatePerMinute, time.Minute),
+		perHour:        limiter(cfg.MessageRatePerHour, time.Hour),
+		staffPerMinute: limiter(cfg.StaffMessageRatePerMinute, time.Minute),
+		staffPerHour:   limiter(cfg.StaffMessageRatePerHour, time.Hour),
+	}
+}
+
+// allow counts a message of senderID and reports whether it fits in the sender's limits, and if it
+// does not, the window whose limit was hit.
+func (t *messageThrottle) allow(senderID string, role models.Role) (time.Duration, bool) {
+	perMinute, perHour := t.perMinute, t.perHour
+	if role == models.RoleDoctor || role.IsAdmin() {
+		perMinute, perHour = t.staffPerMinute, t.staffPerHour
+	}
+	if perMinute != nil && !perMinute.Allow(senderID) {
+		return time.Minute, false
+	}
+	if perHour != nil && !perHour.Allow(senderID) {
+		return time.Hour, false
+	}
+	return 0, true
+}
+
+// throttleMessage answers 429 with Retry-After when the sender is over their message limits, writing an
+// audit event so admins can spot abuse. It reports whether the message may be sent.
+func (h *MessageHandler) throttleMessage(c *gin.Context, senderID string, role models.Role) bool {
+	window, ok := h.throttle.allow(senderID, role)
+	if ok {
+		return true
+	}
+	if messageLimitEventLimiter.Allow(senderID) {
+		details := "window=" + window.String()
+		if err := recordAudit(reqDB(h.DB, c), senderID, "message.rate_limited", auditEntityUser, senderID, details); err != nil {
+			log.Printf("Failed to record message rate limit of %s: %v", senderID, err)
+		}
+	}
+	c.Header("Retry-After", strconv.Itoa(int(window.Seconds())))
+	utils.TooManyRequests(c, "messages.rate_limited")
+	return false
+}
+
+// isDuplicateMessage reports whether senderID sent content to receiverID within the last
+// MESSAGE_DUPLICATE_WINDOW_SECONDS. Content may be encrypted at rest, so it is compared after loading.
+func (h *MessageHandler) isDuplicateMessage(db *gorm.DB, senderID, receiverID, content string) (bool, error) {
+	if h.Cfg.MessageDuplicateWindow <= 0 {
+		return false, nil
+	}
+	since := time.Now().Add(-time.Duration(h.Cfg.MessageDuplicateWindow) * time.Second)
+	var recent []models.Message
+	if err := db.Select("id", "content").
+		Where("sender_id = ? AND receiver_id = ? AND created_at >= ?", senderID, receiverID, since).
+		Find(&recent).Error; err != nil {
+		return false, err
+	}
+	for _, message := range recent {
+		if message.Content == content {
+			return true, nil
+		}
+	}
+	return false, nil
+}
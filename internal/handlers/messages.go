@@ -1,12 +1,20 @@
 package handlers
 
 import (
-	"fmt"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/metrics"
 	"healthcare-app-server/internal/middleware"
 	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/realtime"
 	"healthcare-app-server/internal/utils"
+	"healthcare-app-server/internal/webhooks"
+	"log"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -15,20 +23,23 @@ import (
 
 // MessageHandler handles messaging related requests.
 type MessageHandler struct {
-	DB *gorm.DB
-	// Potentially add a WebSocket upgrader here if using WebSockets for real-time
+	DB       *gorm.DB
+	Cfg      *config.Config
+	Webhooks *webhooks.Dispatcher // Receives the message.sent event
+	Realtime *realtime.Hub        // Sends read receipts to connected senders
+	throttle *messageThrottle     // Per-sender message limits
 }
 
 // NewMessageHandler creates a new MessageHandler.
-func NewMessageHandler(db *gorm.DB) *MessageHandler {
-	return &MessageHandler{DB: db}
+func NewMessageHandler(db *gorm.DB, cfg *config.Config, webhooks *webhooks.Dispatcher, hub *realtime.Hub) *MessageHandler {
+	return &MessageHandler{DB: db, Cfg: cfg, Webhooks: webhooks, Realtime: hub, throttle: newMessageThrottle(cfg)}
 }
 
 // SendMessageRequest represents the request body for sending a message.
 type SendMessageRequest struct {
 	RecipientID     string `json:"recipientId" binding:"required,uuid"`
-	Content         string `json:"content" binding:"required"`
-	Subject         string `json:"subject"`
+	Content         string `json:"content" binding:"required"` // At most MESSAGE_MAX_LENGTH characters
+	Subject         string `json:"subject"`                    // At most MESSAGE_MAX_SUBJECT_LENGTH characters
 	ParentMessageID string `json:"parentMessageId"`
 }
 
@@ -38,46 +49,53 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 	if !utils.BindAndValidate(c, &req) {
 		return
 	}
+	req.Content = utils.SanitizeText(req.Content)
+	req.Subject = utils.SanitizeText(req.Subject)
+	if strings.TrimSpace(req.Content) == "" {
+		utils.BadRequest(c, "messages.content_empty")
+		return
+	}
+	if utf8.RuneCountInString(req.Content) > h.Cfg.MaxMessageLength {
+		utils.BadRequest(c, "messages.content_too_long", utils.Params{"max": strconv.Itoa(h.Cfg.MaxMessageLength)})
+		return
+	}
+	if utf8.RuneCountInString(req.Subject) > h.Cfg.MaxMessageSubjectLength {
+		utils.BadRequest(c, "messages.subject_too_long", utils.Params{"max": strconv.Itoa(h.Cfg.MaxMessageSubjectLength)})
+		return
+	}
 
-	senderIDStr, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.Unauthorized(c, "Sender ID not found in token")
+	sender, ok := middleware.GetUserFromContext(c)
+	if !ok {
+		utils.InternalServerError(c, "auth.user_missing")
 		return
 	}
-	senderID, err := uuid.Parse(senderIDStr)
+	senderID, err := uuid.Parse(sender.ID)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Sender ID format in token")
+		utils.BadRequest(c, "common.invalid_token_subject")
 		return
 	}
 
 	recipientID, err := uuid.Parse(req.RecipientID)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Recipient ID format")
+		utils.BadRequest(c, "messages.invalid_recipient_id")
 		return
 	}
 
 	if senderID == recipientID {
-		utils.BadRequest(c, "Cannot send a message to yourself.")
+		utils.BadRequest(c, "messages.self_message")
 		return
 	}
 
 	// Verify recipient exists
 	var recipient models.User
-	if err := h.DB.First(&recipient, "id = ?", recipientID).Error; err != nil {
+	if err := reqDB(h.DB, c).First(&recipient, "id = ?", recipientID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Recipient user not found")
+			utils.NotFound(c, "messages.recipient_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error verifying recipient: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
-	// Verify sender exists (though middleware should ensure this)
-	var sender models.User
-	if err := h.DB.First(&sender, "id = ?", senderID).Error; err != nil {
-		utils.NotFound(c, "Sender user not found") // Should not happen if auth middleware is correct
-		return
-	}
-
 	// Authorization: Who can message whom?
 	// For now, let's assume:
 	// - Patients can message Doctors.
@@ -88,29 +106,55 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 	senderRole, _ := middleware.GetUserRoleFromContext(c)
 	recipientRole := recipient.Role
 
-	// Convert roles to lowercase for case-insensitive comparison
-	senderRoleLower := strings.ToLower(string(senderRole))
-	recipientRoleLower := strings.ToLower(string(recipientRole))
-
-	// Log roles for debugging
-	fmt.Printf("Sender Role: %s, Recipient Role: %s\n", senderRoleLower, recipientRoleLower)
-
 	// Authorization logic for messaging
 	allowedToMessage := false
-	if (strings.Contains(senderRoleLower, "patient") && strings.Contains(recipientRoleLower, "doctor")) ||
-		(strings.Contains(senderRoleLower, "doctor") && strings.Contains(recipientRoleLower, "patient")) {
+	if (senderRole == models.RolePatient && recipientRole == models.RoleDoctor) ||
+		(senderRole == models.RoleDoctor && recipientRole == models.RolePatient) {
 		allowedToMessage = true
 	}
 	// Add more rules if Admins can message, or Doctor-to-Doctor, Patient-to-Patient allowed
-	if strings.Contains(senderRoleLower, "admin") || strings.Contains(recipientRoleLower, "admin") {
+	if senderRole.IsAdmin() || recipientRole.IsAdmin() {
 		allowedToMessage = true
 	}
 
 	if !allowedToMessage {
-		fmt.Printf("Message denied: Sender Role=%s, Recipient Role=%s\n", senderRole, recipientRole)
-		utils.Forbidden(c, "You are not authorized to send a message to this user.")
+		utils.Forbidden(c, "messages.send_forbidden")
+		return
+	}
+
+	if !h.throttleMessage(c, sender.ID, senderRole) {
+		return
+	}
+	duplicate, err := h.isDuplicateMessage(reqDB(h.DB, c), sender.ID, recipient.ID, req.Content)
+	if err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
 		return
 	}
+	if duplicate {
+		utils.Conflict(c, "messages.duplicate")
+		return
+	}
+
+	// Optionally, patients may only message doctors who have treated them or contacted them first.
+	// Doctors and admins are never restricted.
+	if h.Cfg.RestrictPatientMessaging && senderRole == models.RolePatient && recipientRole == models.RoleDoctor {
+		related, err := hasCareRelationship(reqDB(h.DB, c), sender.ID, recipient.ID)
+		if err == nil && !related {
+			var doctorMessages int64
+			err = reqDB(h.DB, c).Model(&models.Message{}).
+				Where("sender_id = ? AND receiver_id = ?", recipient.ID, sender.ID).
+				Limit(1).Count(&doctorMessages).Error
+			related = doctorMessages > 0
+		}
+		if err != nil {
+			utils.HandleDBError(c, err, "common.database_error")
+			return
+		}
+		if !related {
+			utils.Forbidden(c, "messages.no_care_relationship")
+			return
+		}
+	}
 
 	message := models.Message{
 		SenderID:   senderID.String(),    // Convert UUID to string
@@ -127,130 +171,374 @@ func (h *MessageHandler) SendMessage(c *gin.Context) {
 		}
 	}
 
-	if err := h.DB.Create(&message).Error; err != nil {
-		utils.InternalServerError(c, "Failed to send message: "+err.Error())
+	if err := reqDB(h.DB, c).Create(&message).Error; err != nil {
+		utils.HandleDBError(c, err, "messages.send_failed")
 		return
 	}
 
+	// A new message brings an archived conversation back for the receiver; the sender's state is left alone
+	if err := reqDB(h.DB, c).Model(&models.ConversationState{}).
+		Where("user_id = ? AND partner_id = ? AND archived = ?", message.ReceiverID, message.SenderID, true).
+		Update("archived", false).Error; err != nil {
+		log.Printf("Failed to unarchive conversation of %s with %s: %v", message.ReceiverID, message.SenderID, err)
+	}
+
 	// Here you might trigger a real-time event (e.g., WebSocket push)
+	h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookMessageSent, webhooks.NewMessageData(message))
+	metrics.MessagesSent.Inc()
+
+	utils.Created(c, "Message sent successfully", dto.NewMessageResponse(message))
+}
 
-	utils.Created(c, "Message sent successfully", message)
+// messageListFields are the fields the message list can be narrowed to with `fields`. Marking the
+// page read needs the status, so it is always selected.
+var messageListFields = utils.FieldSpec{
+	Required: []string{"id", "senderId", "receiverId", "conversationId"},
+	Columns:  []string{"id", "sender_id", "receiver_id", "conversation_id", "status"},
+	Optional: map[string][]string{
+		"parentId":   {"parent_id"},
+		"subject":    {"subject"},
+		"content":    {"content"},
+		"status":     {"status"},
+		"readAt":     {"read_at"},
+		"archivedAt": {"archived_at"},
+		"sender":     nil,
+		"receiver":   nil,
+		"createdAt":  {"created_at"},
+		"updatedAt":  {"updated_at"},
+	},
 }
 
 // GetMessagesForUser handles fetching messages for the logged-in user (conversation list or specific conversation).
+// `fields` narrows the response to the listed fields; the IDs are always returned.
 // This could be complex depending on how conversations are structured.
 // A simple approach: get all messages where the user is sender or recipient.
 func (h *MessageHandler) GetMessagesForUser(c *gin.Context) {
 	userIDStr, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "User not authenticated")
+		utils.Unauthorized(c, "common.unauthenticated")
 		return
 	}
 	userID, _ := uuid.Parse(userIDStr) // Assume valid UUID from token
 
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+	fields, ok := utils.ParseFields(c, messageListFields)
+	if !ok {
+		return
+	}
+
 	// Optional: Get messages with a specific other user (conversation view)
 	otherUserIDStr := c.Query("withUser")
 	var messages []models.Message
 
-	query := h.DB.Preload("Sender").Preload("Receiver").Order("created_at asc")
+	query := reqDB(h.DB, c).Model(&models.Message{})
 
 	if otherUserIDStr != "" {
 		otherUserID, err := uuid.Parse(otherUserIDStr)
 		if err != nil {
-			utils.BadRequest(c, "Invalid 'withUser' ID format")
+			utils.BadRequest(c, "messages.invalid_with_user")
+			return
+		}
+		// Messages sent before conversations were stored, and not yet reached by the backfill, are found
+		// by their sender and receiver instead
+		var conversation models.Conversation
+		err = reqDB(h.DB, c).Select("id").First(&conversation, "id = ?", models.ConversationKey(userID.String(), otherUserID.String())).Error
+		switch err {
+		case nil:
+			query = query.Where("conversation_id = ?", conversation.ID)
+		case gorm.ErrRecordNotFound:
+			query = query.Where("(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
+				userID, otherUserID, otherUserID, userID)
+		default:
+			utils.HandleDBError(c, err, "messages.fetch_failed")
 			return
 		}
-		query = query.Where("(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
-			userID, otherUserID, otherUserID, userID)
 	} else {
 		// Get all messages involving the user (can be a lot, consider pagination)
 		query = query.Where("sender_id = ? OR receiver_id = ?", userID, userID)
 	}
 
-	if err := query.Find(&messages).Error; err != nil {
-		utils.InternalServerError(c, "Failed to fetch messages: "+err.Error())
+	includeArchived, ok := parseIncludeArchived(c)
+	if !ok {
+		return
+	}
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "messages.fetch_failed")
+		return
+	}
+
+	if !fields.All() {
+		query = query.Select(fields.Columns())
+	}
+	if fields.Has("sender") {
+		query = query.Preload("Sender")
+	}
+	if fields.Has("receiver") {
+		query = query.Preload("Receiver")
+	}
+	if err := query.Order("created_at asc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&messages).Error; err != nil {
+		utils.HandleDBError(c, err, "messages.fetch_failed")
 		return
 	} // Mark messages as "read" if the current user is the recipient
 	// This is a simplified approach. A more robust system would track read status per user per message.
-	for i, msg := range messages {
+	var unread []string
+	for _, msg := range messages {
 		if msg.ReceiverID == userID.String() && msg.Status == models.MessageStatusSent {
-			messages[i].Status = models.MessageStatusRead
-			h.DB.Model(&messages[i]).Update("status", models.MessageStatusRead) // Update in DB
+			unread = append(unread, msg.ID)
+		}
+	}
+	if len(unread) > 0 {
+		receipts, err := markMessagesRead(reqDB(h.DB, c), userID.String(), unread)
+		if err != nil {
+			log.Printf("Failed to mark messages read for user %s: %v", userID, err)
+		} else {
+			h.Realtime.SendReceipts(userID.String(), receipts)
+			for i := range messages {
+				applyReceipts(receipts, &messages[i])
+			}
 		}
 	}
 
-	utils.Success(c, "Messages fetched successfully", messages)
+	response, err := fields.Apply(dto.NewMessageResponses(messages))
+	if err != nil {
+		utils.InternalServerError(c, "messages.fetch_failed")
+		return
+	}
+	utils.SuccessWithMeta(c, "Messages fetched successfully", response, pagination.Meta(total))
 }
 
-// GetConversations handles fetching a list of conversations for the user.
-// A conversation is typically defined by unique pairs of (user, other_user).
+// GetConversations handles fetching a list of conversations for the user, most recently active first.
+// Conversations are stored rows kept up to date as messages are sent and read, so this is one indexed query.
 func (h *MessageHandler) GetConversations(c *gin.Context) {
-	userIDStr, exists := middleware.GetUserIDFromContext(c)
+	userID, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "User not authenticated")
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
 		return
 	}
-	userID, _ := uuid.Parse(userIDStr)
 
-	// This query is a bit more complex. It aims to get the latest message for each conversation partner.
-	// One way to do this is to get all distinct users the current user has messaged or received messages from,
-	// then fetch the latest message for each of those pairs.
-	// This is a simplified version and might need optimization for performance on large datasets.
+	includeArchived, ok := parseIncludeArchived(c)
+	if !ok {
+		return
+	}
 
-	var conversationPartners []struct {
-		PartnerID uuid.UUID `gorm:"column:partner_id"`
+	query := reqDB(h.DB, c).Model(&models.Conversation{})
+	if includeArchived {
+		query = query.Where("conversations.participant_a_id = ? OR conversations.participant_b_id = ?", userID, userID)
+	} else {
+		// Conversations the user archived drop out, and so do those whose messages were all archived for age
+		query = query.
+			Where("(conversations.participant_a_id = ? AND conversations.archived_a = ?) OR (conversations.participant_b_id = ? AND conversations.archived_b = ?)",
+				userID, false, userID, false).
+			Joins("JOIN messages ON messages.id = conversations.last_message_id AND messages.archived_at IS NULL")
 	}
 
-	// Find all distinct users the current user has interacted with
-	// This subquery logic might need to be adjusted based on exact SQL dialect and GORM capabilities for complex distinct pairs
-	err := h.DB.Raw(`
-		SELECT DISTINCT partner_id FROM (
-			SELECT receiver_id as partner_id FROM messages WHERE sender_id = ?
-			UNION
-			SELECT sender_id as partner_id FROM messages WHERE receiver_id = ?
-		) AS partners
-	`, userID, userID).Scan(&conversationPartners).Error
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "messages.fetch_conversations_failed")
+		return
+	}
 
-	if err != nil {
-		utils.InternalServerError(c, "Failed to fetch conversation partners: "+err.Error())
+	var conversations []models.Conversation
+	if err := query.Preload("LastMessage.Sender").Preload("LastMessage.Receiver").
+		Order("conversations.last_message_at desc, conversations.id").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&conversations).Error; err != nil {
+		utils.HandleDBError(c, err, "messages.fetch_conversations_failed")
 		return
 	}
 
+	// The user's mute settings for the conversations on the page
+	conversationIDs := make([]string, len(conversations))
+	for i, conversation := range conversations {
+		conversationIDs[i] = conversation.ID
+	}
+	var states []models.ConversationState
+	if err := reqDB(h.DB, c).Where("user_id = ? AND conversation_id IN ?", userID, conversationIDs).Find(&states).Error; err != nil {
+		utils.HandleDBError(c, err, "messages.fetch_conversations_failed")
+		return
+	}
+	mutedByConversation := make(map[string]bool, len(states))
+	for _, state := range states {
+		mutedByConversation[state.ConversationID] = state.Muted
+	}
+
 	type ConversationPreview struct {
-		Partner     models.UserSanitized `json:"partner"`
-		LastMessage models.Message       `json:"lastMessage"`
-		UnreadCount int64                `json:"unreadCount"`
+		ConversationID string               `json:"conversationId"`
+		Subject        string               `json:"subject"`
+		Partner        models.UserSanitized `json:"partner"`
+		LastMessage    dto.MessageResponse  `json:"lastMessage"`
+		UnreadCount    int                  `json:"unreadCount"`
+		Archived       bool                 `json:"archived"`
+		Muted          bool                 `json:"muted"`
 	}
 	var previews []ConversationPreview
 
-	for _, cp := range conversationPartners {
-		var partnerUser models.User
-		if err := h.DB.First(&partnerUser, "id = ?", cp.PartnerID).Error; err != nil {
+	for _, conversation := range conversations {
+		lastMessage := conversation.LastMessage
+		if lastMessage == nil {
+			continue // Last message not visible, e.g. outside the user's organization
+		}
+		partnerUser := lastMessage.Receiver
+		if lastMessage.SenderID != userID {
+			partnerUser = lastMessage.Sender
+		}
+		if partnerUser.ID == "" {
 			continue // Skip if partner user not found
 		}
 
-		var lastMessage models.Message
-		err := h.DB.Preload("Sender").Preload("Receiver").
-			Where("(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
-				userID, cp.PartnerID, cp.PartnerID, userID).
-			Order("created_at desc").First(&lastMessage).Error
-		if err != nil {
-			continue // Skip if no message found (should not happen if they are a partner)
+		previews = append(previews, ConversationPreview{
+			ConversationID: conversation.ID,
+			Subject:        conversation.Subject,
+			Partner:        partnerUser.Sanitize(),
+			LastMessage:    dto.NewMessageResponse(*lastMessage),
+			UnreadCount:    conversation.UnreadFor(userID),
+			Archived:       conversation.ArchivedFor(userID),
+			Muted:          mutedByConversation[conversation.ID],
+		})
+	}
+
+	utils.SuccessWithMeta(c, "Conversations fetched successfully", previews, pagination.Meta(total))
+}
+
+// hasCareRelationship reports whether the patient has an appointment or medical record with the doctor.
+func hasCareRelationship(db *gorm.DB, patientID, doctorID string) (bool, error) {
+	var appointments int64
+	if err := db.Model(&models.Appointment{}).
+		Where("patient_id = ? AND doctor_id = ?", patientID, doctorID).
+		Limit(1).Count(&appointments).Error; err != nil {
+		return false, err
+	}
+	if appointments > 0 {
+		return true, nil
+	}
+
+	var records int64
+	if err := db.Model(&models.MedicalRecord{}).
+		Where("patient_id = ? AND doctor_id = ?", patientID, doctorID).
+		Limit(1).Count(&records).Error; err != nil {
+		return false, err
+	}
+	return records > 0, nil
+}
+
+// parseIncludeArchived reads the optional `includeArchived` query flag, which defaults to false.
+// On an invalid value it writes a 400 response and returns false.
+func parseIncludeArchived(c *gin.Context) (bool, bool) {
+	value := c.Query("includeArchived")
+	if value == "" {
+		return false, true
+	}
+	includeArchived, err := strconv.ParseBool(value)
+	if err != nil {
+		utils.BadRequest(c, "messages.invalid_include_archived")
+		return false, false
+	}
+	return includeArchived, true
+}
+
+// UpdateConversationStateRequest represents the request body for archiving or muting a conversation.
+// Omitted fields are left unchanged.
+type UpdateConversationStateRequest struct {
+	Archived *bool `json:"archived"`
+	Muted    *bool `json:"muted"`
+}
+
+// UpdateConversationState handles the current user archiving/unarchiving or muting/unmuting their
+// conversation with a partner. The settings are the user's own; the partner's view is unaffected.
+// Archiving only hides the conversation from the list and never deletes messages.
+func (h *MessageHandler) UpdateConversationState(c *gin.Context) {
+	var req UpdateConversationStateRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	if req.Archived == nil && req.Muted == nil {
+		utils.BadRequest(c, "messages.conversation_state_required")
+		return
+	}
+
+	h.saveConversationState(c, req)
+}
+
+// ArchiveConversation handles the current user archiving their conversation with a partner.
+// Kept for existing clients; it is the same as UpdateConversationState with archived set to true.
+func (h *MessageHandler) ArchiveConversation(c *gin.Context) {
+	archived := true
+	h.saveConversationState(c, UpdateConversationStateRequest{Archived: &archived})
+}
+
+// saveConversationState applies req to the current user's state for the conversation with the
+// partner in the :partnerId path parameter, creating the state on first use.
+func (h *MessageHandler) saveConversationState(c *gin.Context, req UpdateConversationStateRequest) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	partnerID, err := uuid.Parse(c.Param("partnerId"))
+	if err != nil {
+		utils.BadRequest(c, "messages.invalid_conversation_user_id")
+		return
+	}
+	if partnerID.String() == userID {
+		utils.BadRequest(c, "messages.self_conversation")
+		return
+	}
+
+	var partner models.User
+	if err := reqDB(h.DB, c).Select("id").First(&partner, "id = ?", partnerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.user_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
 		}
+		return
+	}
 
-		var unreadCount int64
-		h.DB.Model(&models.Message{}).
-			Where("sender_id = ? AND receiver_id = ? AND status = ?", cp.PartnerID, userID, models.MessageStatusSent).
-			Count(&unreadCount)
+	state := models.ConversationState{
+		UserID:         userID,
+		PartnerID:      partner.ID,
+		ConversationID: models.ConversationKey(userID, partner.ID),
+	}
+	if err := reqDB(h.DB, c).Where("user_id = ? AND partner_id = ?", userID, partner.ID).FirstOrInit(&state).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if req.Archived != nil {
+		state.Archived = *req.Archived
+	}
+	if req.Muted != nil {
+		state.Muted = *req.Muted
+	}
 
-		previews = append(previews, ConversationPreview{
-			Partner:     partnerUser.Sanitize(),
-			LastMessage: lastMessage,
-			UnreadCount: unreadCount,
-		})
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&state).Error; err != nil {
+			return err
+		}
+		// The conversation list reads the archive setting from the conversation
+		return models.SetConversationArchived(tx, userID, partner.ID, state.Archived)
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "messages.conversation_state_update_failed")
+		return
 	}
 
-	utils.Success(c, "Conversations fetched successfully", previews)
+	utils.Success(c, "Conversation updated successfully", state)
 }
 
 // MarkMessageAsRead handles marking a specific message as read.
@@ -259,44 +547,89 @@ func (h *MessageHandler) MarkMessageAsRead(c *gin.Context) {
 	messageIDStr := c.Param("messageId")
 	messageID, err := uuid.Parse(messageIDStr)
 	if err != nil {
-		utils.BadRequest(c, "Invalid Message ID format")
+		utils.BadRequest(c, "messages.invalid_message_id")
 		return
 	}
 
 	userIDStr, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "User not authenticated")
+		utils.Unauthorized(c, "common.unauthenticated")
 		return
 	}
 	userID, _ := uuid.Parse(userIDStr)
 
 	var message models.Message
-	if err := h.DB.First(&message, "id = ?", messageID).Error; err != nil {
+	if err := reqDB(h.DB, c).First(&message, "id = ?", messageID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Message not found")
+			utils.NotFound(c, "messages.not_found")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
 	// Only the recipient can mark a message as read
 	if message.ReceiverID != userID.String() {
-		utils.Forbidden(c, "You are not authorized to mark this message as read.")
+		utils.Forbidden(c, "messages.mark_read_forbidden")
 		return
 	}
 
 	if message.Status == models.MessageStatusRead {
-		utils.Success(c, "Message already marked as read", message)
+		utils.Success(c, "Message already marked as read", dto.NewMessageResponse(message))
 		return
 	}
 
-	message.Status = models.MessageStatusRead
-	if err := h.DB.Save(&message).Error; err != nil {
-		utils.InternalServerError(c, "Failed to update message status: "+err.Error())
+	receipts, err := markMessagesRead(reqDB(h.DB, c), userID.String(), []string{message.ID})
+	if err != nil {
+		utils.HandleDBError(c, err, "messages.status_update_failed")
 		return
 	}
+	h.Realtime.SendReceipts(userID.String(), receipts)
+	applyReceipts(receipts, &message)
 
-	utils.Success(c, "Message marked as read successfully", message)
+	utils.Success(c, "Message marked as read successfully", dto.NewMessageResponse(message))
+}
+
+// GetMessage handles fetching a single message, e.g. for a link from a notification.
+// Only the sender and the recipient may see it; a recipient fetching an unread message marks it as read,
+// like the message list does.
+func (h *MessageHandler) GetMessage(c *gin.Context) {
+	messageID, err := uuid.Parse(c.Param("messageId"))
+	if err != nil {
+		utils.BadRequest(c, "messages.invalid_message_id")
+		return
+	}
+
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	var message models.Message
+	if err := reqDB(h.DB, c).Preload("Sender").Preload("Receiver").First(&message, "id = ?", messageID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "messages.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	if message.SenderID != userID && message.ReceiverID != userID {
+		utils.Forbidden(c, "messages.view_forbidden")
+		return
+	}
+
+	if message.ReceiverID == userID && message.Status == models.MessageStatusSent {
+		receipts, err := markMessagesRead(reqDB(h.DB, c), userID, []string{message.ID})
+		if err != nil {
+			utils.HandleDBError(c, err, "messages.status_update_failed")
+			return
+		}
+		h.Realtime.SendReceipts(userID, receipts)
+		applyReceipts(receipts, &message)
+	}
+
+	utils.Success(c, "Message fetched successfully", dto.NewMessageResponse(message))
 }
 
 // NewMessagesRequest represents the query params for getting new messages
@@ -308,32 +641,52 @@ type NewMessagesRequest struct {
 func (h *MessageHandler) GetNewMessages(c *gin.Context) {
 	var req NewMessagesRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		utils.BadRequest(c, "Invalid request: "+err.Error())
+		utils.ErrorWithDetail(c, http.StatusBadRequest, "common.invalid_request", err)
 		return
 	}
 
 	userID, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "User ID not found in context")
+		utils.Unauthorized(c, "common.unauthenticated")
 		return
 	}
 
 	// Parse the since timestamp
 	sinceTime, err := time.Parse(time.RFC3339, req.Since)
 	if err != nil {
-		utils.BadRequest(c, "Invalid timestamp format. Use RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)")
+		utils.BadRequest(c, "messages.invalid_since")
 		return
 	}
 
-	// Get messages received after the specified time
-	var messages []models.Message
-	if err := h.DB.Preload("Sender").Preload("Receiver").
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	// Get messages received after the specified time. This is the in-app notification feed, so messages
+	// from partners the user muted are left out; they are still delivered and show up in the conversation.
+	mutedPartners := reqDB(h.DB, c).Model(&models.ConversationState{}).
+		Select("partner_id").
+		Where("user_id = ? AND muted = ?", userID, true)
+	query := reqDB(h.DB, c).Model(&models.Message{}).
 		Where("(receiver_id = ? OR sender_id = ?) AND created_at > ?", userID, userID, sinceTime).
+		Where("NOT (receiver_id = ? AND sender_id IN (?))", userID, mutedPartners)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "messages.fetch_failed")
+		return
+	}
+
+	var messages []models.Message
+	if err := query.Preload("Sender").Preload("Receiver").
 		Order("created_at DESC").
+		Offset(pagination.Offset).Limit(pagination.Limit).
 		Find(&messages).Error; err != nil {
-		utils.InternalServerError(c, "Failed to fetch messages: "+err.Error())
+		utils.HandleDBError(c, err, "messages.fetch_failed")
 		return
 	}
 
-	utils.Success(c, "New messages fetched successfully", messages)
+	utils.SuccessWithMeta(c, "New messages fetched successfully", dto.NewMessageResponses(messages), pagination.Meta(total))
+
 }
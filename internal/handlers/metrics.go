@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/cache"
+	"healthcare-app-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler reports runtime counters of the server.
+type MetricsHandler struct {
+	Doctors *cache.Loader
+}
+
+// NewMetricsHandler creates a new MetricsHandler.
+func NewMetricsHandler(doctors *cache.Loader) *MetricsHandler {
+	return &MetricsHandler{Doctors: doctors}
+}
+
+// MetricsResponse holds the counters reported by GetMetrics.
+type MetricsResponse struct {
+	DoctorCache cache.Stats `json:"doctorCache"`
+}
+
+// GetMetrics handles reporting the server's counters since startup (admin).
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	utils.Success(c, "Metrics fetched successfully", MetricsResponse{
+		DoctorCache: h.Doctors.Stats(),
+	})
+}
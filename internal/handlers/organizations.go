@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/tenancy"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrganizationHandler handles the organizations (clinics) of a multi-tenant deployment.
+type OrganizationHandler struct {
+	DB *gorm.DB
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler.
+func NewOrganizationHandler(db *gorm.DB) *OrganizationHandler {
+	return &OrganizationHandler{DB: db}
+}
+
+// CreateOrganizationRequest represents the request body for creating an organization.
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+// CreateOrganization handles creating an organization with a fresh invite code (super admin).
+// Its first admin is then created with POST /users and the organization's ID.
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req CreateOrganizationRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	organization := models.Organization{Name: strings.TrimSpace(req.Name)}
+	if err := organization.RotateInviteCode(); err != nil {
+		utils.InternalServerErrorWithDetail(c, "organizations.create_failed", err)
+		return
+	}
+	if err := reqDB(h.DB, c).Create(&organization).Error; err != nil {
+		utils.HandleDBError(c, err, "organizations.create_failed")
+		return
+	}
+
+	utils.Created(c, "Organization created successfully", organization)
+}
+
+// GetOrganizations handles listing every organization by name (super admin).
+func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	var total int64
+	if err := reqDB(h.DB, c).Model(&models.Organization{}).Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "organizations.fetch_failed")
+		return
+	}
+
+	var organizations []models.Organization
+	if err := reqDB(h.DB, c).Order("name asc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&organizations).Error; err != nil {
+		utils.HandleDBError(c, err, "organizations.fetch_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Organizations fetched successfully", organizations, pagination.Meta(total))
+}
+
+// RotateOrganizationInviteCode handles replacing the invite code of any organization (super admin).
+func (h *OrganizationHandler) RotateOrganizationInviteCode(c *gin.Context) {
+	organizationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "organizations.invalid_id")
+		return
+	}
+	h.rotateInviteCode(c, organizationID.String())
+}
+
+// GetOwnOrganization handles an admin fetching their own organization, including the invite code they
+// hand out to patients and doctors (admin, MULTI_TENANT only).
+func (h *OrganizationHandler) GetOwnOrganization(c *gin.Context) {
+	organizationID, ok := tenancy.OrganizationID(c.Request.Context())
+	if !ok {
+		utils.NotFound(c, "organizations.none")
+		return
+	}
+
+	var organization models.Organization
+	if err := reqDB(h.DB, c).First(&organization, "id = ?", organizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "organizations.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	utils.Success(c, "Organization fetched successfully", organization)
+}
+
+// RotateOwnInviteCode handles an admin replacing their organization's invite code, e.g. after it leaked
+// (admin, MULTI_TENANT only). Users who already registered are unaffected.
+func (h *OrganizationHandler) RotateOwnInviteCode(c *gin.Context) {
+	organizationID, ok := tenancy.OrganizationID(c.Request.Context())
+	if !ok {
+		utils.NotFound(c, "organizations.none")
+		return
+	}
+	h.rotateInviteCode(c, organizationID)
+}
+
+// rotateInviteCode gives the organization a new invite code and responds with the organization.
+func (h *OrganizationHandler) rotateInviteCode(c *gin.Context, organizationID string) {
+	var organization models.Organization
+	if err := reqDB(h.DB, c).First(&organization, "id = ?", organizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "organizations.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	if err := organization.RotateInviteCode(); err != nil {
+		utils.InternalServerErrorWithDetail(c, "organizations.update_failed", err)
+		return
+	}
+	if err := reqDB(h.DB, c).Model(&organization).Update("invite_code", organization.InviteCode).Error; err != nil {
+		utils.HandleDBError(c, err, "organizations.update_failed")
+		return
+	}
+
+	utils.Success(c, "Invite code rotated successfully", organization)
+}
+
+// acrossOrganizations returns db bound to the request context but seeing every organization, for the checks
+// that must, such as whether an email address is taken by anyone.
+func acrossOrganizations(db *gorm.DB, c *gin.Context) *gorm.DB {
+	return db.WithContext(tenancy.Unscoped(c.Request.Context()))
+}
+
+// isPlatformAdmin reports whether an admin manages data shared by every organization, such as global record
+// templates: any admin of a single-tenant deployment, and super admins of a multi-tenant one.
+func isPlatformAdmin(c *gin.Context, role models.Role) bool {
+	_, scoped := tenancy.OrganizationID(c.Request.Context())
+	return role.IsAdmin() && !scoped
+}
+
+// assignableOrganization checks an organizationId an admin sent when creating or moving a user and returns
+// it, or nil when none was sent. Only super admins choose organizations; the users other admins create join
+// the admin's own organization. With MULTI_TENANT on, a super admin must choose one for every new user, as
+// users outside all organizations cannot sign in. It writes the error response when it returns false.
+func assignableOrganization(c *gin.Context, db *gorm.DB, cfg *config.Config, organizationID string, creating bool) (*string, bool) {
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if organizationID == "" {
+		if creating && cfg.MultiTenant && userRole == models.RoleSuperAdmin {
+			utils.BadRequest(c, "organizations.required")
+			return nil, false
+		}
+		return nil, true
+	}
+	if userRole != models.RoleSuperAdmin {
+		utils.Forbidden(c, "organizations.assign_forbidden")
+		return nil, false
+	}
+
+	var organization models.Organization
+	if err := db.First(&organization, "id = ?", organizationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "organizations.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return nil, false
+	}
+	return &organization.ID, true
+}
+
+// backfillOrganizations moves the rows of users who just joined an organization into it, in the background.
+func backfillOrganizations(db *gorm.DB) {
+	go func() {
+		if updated, err := jobs.BackfillOrganizations(db); err != nil {
+			log.Printf("Failed to backfill organizations: %v", err)
+		} else if updated > 0 {
+			log.Printf("Backfilled the organization of %d rows", updated)
+		}
+	}()
+}
+
+// ensureUserInOrganization checks, for requests scoped to an organization, that userID belongs to it and
+// answers 404 with notFoundKey otherwise, so other organizations' IDs look like unknown ones. Use it before
+// reading data keyed by a user that has no organization of its own, such as prescriptions or vitals.
+func ensureUserInOrganization(c *gin.Context, db *gorm.DB, userID, notFoundKey string) bool {
+	if _, scoped := tenancy.OrganizationID(c.Request.Context()); !scoped {
+		return true
+	}
+	var count int64
+	if err := db.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return false
+	}
+	if count == 0 {
+		utils.NotFound(c, notFoundKey)
+		return false
+	}
+	return true
+}
+
+// scopeToOrganizationUsers limits query, for requests scoped to an organization, to rows whose column
+// references one of its users. Use it for listings of data that has no organization of its own.
+func scopeToOrganizationUsers(c *gin.Context, db, query *gorm.DB, column string) *gorm.DB {
+	if _, scoped := tenancy.OrganizationID(c.Request.Context()); !scoped {
+		return query
+	}
+	return query.Where(column+" IN (?)", db.Model(&models.User{}).Select("id"))
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// hashToken returns the form a password reset or invitation token is stored in, so a database leak
+// does not hand out working links.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns a fresh 256-bit token for an emailed link.
+func randomToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// issuePasswordResetToken sets a fresh reset token valid for ttl on user and returns the raw token
+// to put in the link. The caller saves the user.
+func issuePasswordResetToken(user *models.User, ttl time.Duration) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	expiry := time.Now().Add(ttl)
+	user.ResetToken = hashToken(token)
+	user.ResetTokenExpiry = &expiry
+	return token, nil
+}
+
+// frontendLink returns the client app URL of path carrying token, for links sent to users by email.
+func frontendLink(cfg *config.Config, path, token string) string {
+	return cfg.FrontendURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// passwordSetLink returns the client URL where the owner of token chooses a new password.
+func passwordSetLink(cfg *config.Config, token string) string {
+	return frontendLink(cfg, "/reset-password", token)
+}
+
+// ResetPasswordRequest represents the request body for setting a password with a reset token.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// ResetPassword handles setting a new password from a reset or invitation link.
+// The token is single-use; following the emailed link also verifies the address,
+// and every existing session of the user is revoked.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	var user models.User
+	if err := reqDB(h.DB, c).Where("reset_token = ? AND reset_token_expiry > ?", hashToken(req.Token), time.Now()).
+		First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.BadRequest(c, "auth.reset_token_invalid")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	if err := user.SetPassword(req.Password); err != nil {
+		utils.InternalServerErrorWithDetail(c, "auth.password_hash_failed", err)
+		return
+	}
+	user.ResetToken = ""
+	user.ResetTokenExpiry = nil
+	user.IsVerified = true
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).Where("user_id = ?", user.ID).Update("is_revoked", true).Error
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "auth.password_reset_failed")
+		return
+	}
+
+	utils.Success(c, "Password has been set successfully", nil)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
+	"healthcare-app-server/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TransferPatientRequest represents the request body for moving a patient's care from one doctor to another.
+type TransferPatientRequest struct {
+	FromDoctorID  string `json:"fromDoctorId" binding:"required,uuid"`
+	ToDoctorID    string `json:"toDoctorId" binding:"required,uuid"`
+	Note          string `json:"note" binding:"max=500"` // Why the care moves; included in the messages and record notes
+	AddRecordNote bool   `json:"addRecordNote"`          // Append a care-transfer note to the source doctor's records of the patient
+}
+
+// TransferPatientResult summarises a care transfer.
+type TransferPatientResult struct {
+	Moved          []dto.AppointmentResponse `json:"moved"`
+	Unmoved        []UnmovedAppointment      `json:"unmoved"`
+	NotedRecordIDs []string                  `json:"notedRecordIds"`
+}
+
+// TransferPatient handles moving one patient's care from one doctor to another (admin). The patient's future
+// appointments with the source doctor move to the target doctor where they fit the target's booking limits
+// and time off, recorded as AppointmentReassignments like ReassignDoctorAppointments; the others stay and are
+// returned with the reason. With addRecordNote, a care-transfer note is appended to the details of every
+// record the source doctor wrote for the patient, bumping its version; the records keep their author.
+// The patient and both doctors are messaged. Everything happens in one transaction.
+func (h *AppointmentHandler) TransferPatient(c *gin.Context) {
+	var req TransferPatientRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	if req.FromDoctorID == req.ToDoctorID {
+		utils.BadRequest(c, "appointments.reassign_same_doctor")
+		return
+	}
+	note := utils.SanitizeText(strings.TrimSpace(req.Note))
+
+	patientID, err := uuid.Parse(c.Param("patientId"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_patient_id")
+		return
+	}
+	var patient models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", patientID, models.RolePatient).First(&patient).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.patient_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	// The source doctor may already have been demoted, so only the target must still be a doctor
+	var fromDoctor models.User
+	if err := reqDB(h.DB, c).First(&fromDoctor, "id = ?", req.FromDoctorID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	var toDoctor models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", req.ToDoctorID, models.RoleDoctor).First(&toDoctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "appointments.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	adminID, _ := middleware.GetUserIDFromContext(c)
+	now := time.Now().UTC()
+	result := TransferPatientResult{Moved: []dto.AppointmentResponse{}, Unmoved: []UnmovedAppointment{}, NotedRecordIDs: []string{}}
+
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		var appointments []models.Appointment
+		if err := tx.Preload("Patient").Preload("AppointmentType").
+			Where("patient_id = ? AND doctor_id = ? AND status IN ? AND start_time > ?",
+				patient.ID, fromDoctor.ID, scheduling.BlockingStatuses, now).
+			Order("start_time asc").
+			Find(&appointments).Error; err != nil {
+			return err
+		}
+
+		for _, appointment := range appointments {
+			reason, err := reassignConflict(tx, toDoctor.ID, appointment)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				result.Unmoved = append(result.Unmoved, UnmovedAppointment{Appointment: dto.NewAppointmentResponse(appointment), Reason: reason})
+				continue
+			}
+
+			if err := tx.Model(&appointment).Update("doctor_id", toDoctor.ID).Error; err != nil {
+				return err
+			}
+			appointment.DoctorID = toDoctor.ID
+			if err := tx.Create(&models.AppointmentReassignment{
+				AppointmentID:  appointment.ID,
+				FromDoctorID:   fromDoctor.ID,
+				ToDoctorID:     toDoctor.ID,
+				ReassignedByID: adminID,
+			}).Error; err != nil {
+				return err
+			}
+			result.Moved = append(result.Moved, dto.NewAppointmentResponse(appointment))
+		}
+
+		if req.AddRecordNote {
+			var records []models.MedicalRecord
+			if err := tx.Select("id", "details").
+				Where("patient_id = ? AND doctor_id = ?", patient.ID, fromDoctor.ID).
+				Order("record_date asc").
+				Find(&records).Error; err != nil {
+				return err
+			}
+			recordNote := fmt.Sprintf("Care transferred to Dr. %s %s on %s.", toDoctor.FirstName, toDoctor.LastName, now.Format("2006-01-02"))
+			if note != "" {
+				recordNote += " " + note
+			}
+			for _, record := range records {
+				details := recordNote
+				if record.Details != "" {
+					details = record.Details + "\n\n" + recordNote
+				}
+				if err := tx.Model(&models.MedicalRecord{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+					"details": details,
+					"version": gorm.Expr("version + 1"),
+				}).Error; err != nil {
+					return err
+				}
+				result.NotedRecordIDs = append(result.NotedRecordIDs, record.ID)
+			}
+		}
+
+		for _, message := range careTransferMessages(adminID, patient, fromDoctor, toDoctor, note, len(result.Moved)) {
+			if err := tx.Create(message).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "appointments.transfer_failed")
+		return
+	}
+
+	utils.Success(c, "Patient care transferred successfully", result)
+}
+
+// careTransferMessages are the messages telling the patient and both doctors about a care transfer.
+// The patient hears from their new doctor; the doctors hear from the admin who made the transfer.
+func careTransferMessages(adminID string, patient, fromDoctor, toDoctor models.User, note string, moved int) []*models.Message {
+	reason := ""
+	if note != "" {
+		reason = "\n\n" + note
+	}
+	return []*models.Message{
+		{
+			SenderID:   toDoctor.ID,
+			ReceiverID: patient.ID,
+			Subject:    "Your care has been transferred",
+			Content: fmt.Sprintf("Your care has moved from Dr. %s %s to Dr. %s %s. %d upcoming appointment(s) are now with Dr. %s.%s",
+				fromDoctor.FirstName, fromDoctor.LastName, toDoctor.FirstName, toDoctor.LastName, moved, toDoctor.LastName, reason),
+			Status: models.MessageStatusSent,
+		},
+		{
+			SenderID:   adminID,
+			ReceiverID: fromDoctor.ID,
+			Subject:    "A patient has been transferred",
+			Content: fmt.Sprintf("The care of %s %s has moved to Dr. %s %s.%s",
+				patient.FirstName, patient.LastName, toDoctor.FirstName, toDoctor.LastName, reason),
+			Status: models.MessageStatusSent,
+		},
+		{
+			SenderID:   adminID,
+			ReceiverID: toDoctor.ID,
+			Subject:    "A patient has been transferred to you",
+			Content: fmt.Sprintf("The care of %s %s has moved to you from Dr. %s %s, including %d upcoming appointment(s).%s",
+				patient.FirstName, patient.LastName, fromDoctor.FirstName, fromDoctor.LastName, moved, reason),
+			Status: models.MessageStatusSent,
+		},
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PolicyHandler handles terms of service and privacy policy documents and their acceptance.
+type PolicyHandler struct {
+	DB *gorm.DB
+}
+
+// NewPolicyHandler creates a new PolicyHandler.
+func NewPolicyHandler(db *gorm.DB) *PolicyHandler {
+	return &PolicyHandler{DB: db}
+}
+
+// CreatePolicyDocumentRequest represents the request body for publishing a policy version.
+// A document needs its content, a URL where it is published, or both.
+type CreatePolicyDocumentRequest struct {
+	Type        string `json:"type" binding:"required,oneof=terms_of_service privacy_policy"`
+	Version     string `json:"version" binding:"required,max=50"`
+	Content     string `json:"content" binding:"required_without=URL"`
+	URL         string `json:"url" binding:"omitempty,max=500,http_url_or_empty"`
+	EffectiveAt string `json:"effectiveAt"` // RFC3339 or YYYY-MM-DD; defaults to now, which publishes it at once
+}
+
+// UpdatePolicyDocumentRequest represents the request body for correcting a policy version nobody has
+// accepted yet. Only the fields present in the body are changed.
+type UpdatePolicyDocumentRequest struct {
+	Version     *string `json:"version" binding:"omitempty,min=1,max=50"`
+	Content     *string `json:"content"`
+	URL         *string `json:"url" binding:"omitempty,max=500,http_url_or_empty"`
+	EffectiveAt *string `json:"effectiveAt"`
+}
+
+// AcceptPoliciesRequest represents the request body for accepting the policy versions in effect.
+type AcceptPoliciesRequest struct {
+	// Version accepted of each policy type, e.g. {"terms_of_service": "2", "privacy_policy": "3"}
+	AcceptedPolicyVersions map[string]string `json:"acceptedPolicyVersions" binding:"required"`
+}
+
+// PolicyAcceptanceStatus tells whether a user accepted the version of a policy type in effect.
+type PolicyAcceptanceStatus struct {
+	Type        models.PolicyType `json:"type"`
+	Version     string            `json:"version"`
+	EffectiveAt time.Time         `json:"effectiveAt"`
+	Accepted    bool              `json:"accepted"`
+	AcceptedAt  *time.Time        `json:"acceptedAt,omitempty"`
+}
+
+// GetCurrentPolicies handles listing the policy versions in effect, for signup forms. It is public.
+func (h *PolicyHandler) GetCurrentPolicies(c *gin.Context) {
+	documents, err := models.CurrentPolicies(reqDB(h.DB, c), time.Now().UTC())
+	if err != nil {
+		utils.HandleDBError(c, err, "policies.fetch_failed")
+		return
+	}
+	utils.Success(c, "Policies fetched successfully", documents)
+}
+
+// GetPolicyDocuments handles listing every policy version, newest first per type (admin).
+func (h *PolicyHandler) GetPolicyDocuments(c *gin.Context) {
+	var documents []models.PolicyDocument
+	if err := reqDB(h.DB, c).Order("type asc, effective_at desc").Find(&documents).Error; err != nil {
+		utils.HandleDBError(c, err, "policies.fetch_failed")
+		return
+	}
+	utils.Success(c, "Policies fetched successfully", documents)
+}
+
+// CreatePolicyDocument handles publishing a policy version (admin). Once its effective date has passed,
+// users who have not accepted it are held to POLICY_ENFORCEMENT.
+func (h *PolicyHandler) CreatePolicyDocument(c *gin.Context) {
+	var req CreatePolicyDocumentRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	effectiveAt := time.Now().UTC()
+	if req.EffectiveAt != "" {
+		parsed, err := parseDateParam(req.EffectiveAt)
+		if err != nil {
+			utils.BadRequest(c, "policies.invalid_effective_at")
+			return
+		}
+		effectiveAt = parsed.UTC()
+	}
+
+	document := models.PolicyDocument{
+		Type:        models.PolicyType(req.Type),
+		Version:     strings.TrimSpace(req.Version),
+		Content:     req.Content,
+		URL:         req.URL,
+		EffectiveAt: effectiveAt,
+	}
+	if err := reqDB(h.DB, c).Create(&document).Error; err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.Conflict(c, "policies.version_taken")
+		} else {
+			utils.HandleDBError(c, err, "policies.create_failed")
+		}
+		return
+	}
+
+	utils.Created(c, "Policy published successfully", document)
+}
+
+// loadUnacceptedPolicyDocument loads the policy document of the :id parameter for a change, which is only
+// allowed while nobody has accepted it. On failure it writes the response and returns false.
+func (h *PolicyHandler) loadUnacceptedPolicyDocument(c *gin.Context) (models.PolicyDocument, bool) {
+	var document models.PolicyDocument
+	documentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "policies.invalid_id")
+		return document, false
+	}
+	if err := reqDB(h.DB, c).First(&document, "id = ?", documentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "policies.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return document, false
+	}
+
+	var acceptances int64
+	if err := reqDB(h.DB, c).Model(&models.UserPolicyAcceptance{}).
+		Where("policy_document_id = ?", document.ID).
+		Limit(1).Count(&acceptances).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return document, false
+	}
+	if acceptances > 0 {
+		// The acceptances are proof of what users agreed to; publish a new version instead
+		utils.Conflict(c, "policies.already_accepted")
+		return document, false
+	}
+	return document, true
+}
+
+// UpdatePolicyDocument handles correcting a policy version nobody has accepted yet (admin).
+func (h *PolicyHandler) UpdatePolicyDocument(c *gin.Context) {
+	var req UpdatePolicyDocumentRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	document, ok := h.loadUnacceptedPolicyDocument(c)
+	if !ok {
+		return
+	}
+
+	if req.Version != nil {
+		document.Version = strings.TrimSpace(*req.Version)
+	}
+	if req.Content != nil {
+		document.Content = *req.Content
+	}
+	if req.URL != nil {
+		document.URL = *req.URL
+	}
+	if req.EffectiveAt != nil {
+		effectiveAt, err := parseDateParam(*req.EffectiveAt)
+		if err != nil {
+			utils.BadRequest(c, "policies.invalid_effective_at")
+			return
+		}
+		document.EffectiveAt = effectiveAt.UTC()
+	}
+	if document.Content == "" && document.URL == "" {
+		utils.BadRequest(c, "policies.content_required")
+		return
+	}
+
+	if err := reqDB(h.DB, c).Save(&document).Error; err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.Conflict(c, "policies.version_taken")
+		} else {
+			utils.HandleDBError(c, err, "policies.update_failed")
+		}
+		return
+	}
+
+	utils.Success(c, "Policy updated successfully", document)
+}
+
+// DeletePolicyDocument handles withdrawing a policy version nobody has accepted yet (admin).
+func (h *PolicyHandler) DeletePolicyDocument(c *gin.Context) {
+	document, ok := h.loadUnacceptedPolicyDocument(c)
+	if !ok {
+		return
+	}
+	if err := reqDB(h.DB, c).Delete(&document).Error; err != nil {
+		utils.HandleDBError(c, err, "policies.delete_failed")
+		return
+	}
+	utils.Success(c, "Policy deleted successfully", nil)
+}
+
+// AcceptPolicies handles the current user accepting the policy versions in effect. Every one of them
+// must be named with its current version; ones accepted before are left as they were.
+func (h *PolicyHandler) AcceptPolicies(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+	var req AcceptPoliciesRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	documents, ok := checkAcceptedPolicies(c, reqDB(h.DB, c), req.AcceptedPolicyVersions)
+	if !ok {
+		return
+	}
+	if err := recordPolicyAcceptances(reqDB(h.DB, c), userID, c.ClientIP(), documents); err != nil {
+		utils.HandleDBError(c, err, "policies.accept_failed")
+		return
+	}
+
+	statuses, err := policyAcceptanceStatuses(reqDB(h.DB, c), userID)
+	if err != nil {
+		utils.HandleDBError(c, err, "policies.fetch_failed")
+		return
+	}
+	utils.Success(c, "Policies accepted successfully", statuses)
+}
+
+// checkAcceptedPolicies returns the policy documents in effect once accepted names the current version of
+// each, as sent at signup and by AcceptPolicies. Otherwise it writes a 400 listing the required versions
+// and returns false.
+func checkAcceptedPolicies(c *gin.Context, db *gorm.DB, accepted map[string]string) ([]models.PolicyDocument, bool) {
+	documents, err := models.CurrentPolicies(db, time.Now().UTC())
+	if err != nil {
+		utils.HandleDBError(c, err, "policies.fetch_failed")
+		return nil, false
+	}
+
+	required := make(map[string]string, len(documents))
+	mismatch := false
+	for _, document := range documents {
+		required[string(document.Type)] = document.Version
+		if strings.TrimSpace(accepted[string(document.Type)]) != document.Version {
+			mismatch = true
+		}
+	}
+	if mismatch {
+		utils.ErrorWithData(c, http.StatusBadRequest, "policies.versions_mismatch", gin.H{"requiredPolicyVersions": required})
+		return nil, false
+	}
+	return documents, true
+}
+
+// recordPolicyAcceptances records userID accepting documents from ip now. Documents the user accepted
+// before keep their first acceptance.
+func recordPolicyAcceptances(db *gorm.DB, userID, ip string, documents []models.PolicyDocument) error {
+	if len(documents) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	acceptances := make([]models.UserPolicyAcceptance, 0, len(documents))
+	for _, document := range documents {
+		acceptances = append(acceptances, models.UserPolicyAcceptance{
+			UserID:           userID,
+			PolicyDocumentID: document.ID,
+			PolicyType:       document.Type,
+			Version:          document.Version,
+			AcceptedAt:       now,
+			IPAddress:        ip,
+		})
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&acceptances).Error
+}
+
+// policyAcceptanceStatuses returns, for each policy version in effect, whether userID accepted it.
+func policyAcceptanceStatuses(db *gorm.DB, userID string) ([]PolicyAcceptanceStatus, error) {
+	documents, err := models.CurrentPolicies(db, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]PolicyAcceptanceStatus, 0, len(documents))
+	if len(documents) == 0 {
+		return statuses, nil
+	}
+
+	documentIDs := make([]string, 0, len(documents))
+	for _, document := range documents {
+		documentIDs = append(documentIDs, document.ID)
+	}
+	var acceptances []models.UserPolicyAcceptance
+	if err := db.Where("user_id = ? AND policy_document_id IN ?", userID, documentIDs).Find(&acceptances).Error; err != nil {
+		return nil, err
+	}
+	acceptedAt := make(map[string]time.Time, len(acceptances))
+	for _, acceptance := range acceptances {
+		acceptedAt[acceptance.PolicyDocumentID] = acceptance.AcceptedAt
+	}
+
+	for _, document := range documents {
+		status := PolicyAcceptanceStatus{Type: document.Type, Version: document.Version, EffectiveAt: document.EffectiveAt}
+		if at, ok := acceptedAt[document.ID]; ok {
+			status.Accepted = true
+			status.AcceptedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
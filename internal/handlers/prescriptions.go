@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PrescriptionHandler handles prescription related requests.
+// Prescriptions follow the same authorization rules as medical records.
+type PrescriptionHandler struct {
+	DB *gorm.DB
+}
+
+// NewPrescriptionHandler creates a new PrescriptionHandler.
+func NewPrescriptionHandler(db *gorm.DB) *PrescriptionHandler {
+	return &PrescriptionHandler{DB: db}
+}
+
+// CreatePrescriptionRequest represents the request body for issuing a prescription.
+type CreatePrescriptionRequest struct {
+	PatientID       string     `json:"patientId" binding:"required,uuid"`
+	MedicalRecordID string     `json:"medicalRecordId" binding:"omitempty,uuid"` // Optional record the prescription was issued in
+	MedicationName  string     `json:"medicationName" binding:"required,max=255"`
+	Dosage          string     `json:"dosage" binding:"required,max=100"`
+	Frequency       string     `json:"frequency" binding:"required,max=100"`
+	DurationDays    int        `json:"durationDays" binding:"min=0"`
+	Refills         int        `json:"refills" binding:"min=0"`
+	IssueDate       *time.Time `json:"issueDate"` // Defaults to now
+	Notes           string     `json:"notes" binding:"max=5000"`
+}
+
+// UpdatePrescriptionStatusRequest represents the request body for marking a prescription active or expired.
+type UpdatePrescriptionStatusRequest struct {
+	Status models.PrescriptionStatus `json:"status" binding:"required,oneof=active expired"`
+}
+
+// CreatePrescription handles a doctor issuing a prescription to a patient.
+func (h *PrescriptionHandler) CreatePrescription(c *gin.Context) {
+	var req CreatePrescriptionRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	doctorID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	var patient models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", req.PatientID, models.RolePatient).First(&patient).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.patient_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	prescription := models.Prescription{
+		PatientID:      patient.ID,
+		DoctorID:       doctorID,
+		MedicationName: req.MedicationName,
+		Dosage:         req.Dosage,
+		Frequency:      req.Frequency,
+		DurationDays:   req.DurationDays,
+		Refills:        req.Refills,
+		IssueDate:      time.Now(),
+		Status:         models.PrescriptionStatusActive,
+		Notes:          req.Notes,
+	}
+	if req.IssueDate != nil {
+		prescription.IssueDate = *req.IssueDate
+	}
+
+	// A linked record must belong to the same patient
+	if req.MedicalRecordID != "" {
+		var record models.MedicalRecord
+		if err := reqDB(h.DB, c).Select("id", "patient_id").First(&record, "id = ?", req.MedicalRecordID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				utils.NotFound(c, "common.medical_record_not_found")
+			} else {
+				utils.HandleDBError(c, err, "common.database_error")
+			}
+			return
+		}
+		if record.PatientID != patient.ID {
+			utils.BadRequest(c, "prescriptions.record_patient_mismatch")
+			return
+		}
+		prescription.MedicalRecordID = &record.ID
+	}
+
+	if err := reqDB(h.DB, c).Create(&prescription).Error; err != nil {
+		utils.HandleDBError(c, err, "prescriptions.create_failed")
+		return
+	}
+
+	utils.Created(c, "Prescription created successfully", prescription)
+}
+
+// GetPrescriptionsForPatient handles listing a patient's prescriptions, optionally filtered by ?status=active|expired.
+// Accessible by the patient themselves or doctors.
+func (h *PrescriptionHandler) GetPrescriptionsForPatient(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patientId"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_patient_id")
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canViewPatientRecords(userRole, userID, patientID.String()) {
+		utils.Forbidden(c, "prescriptions.view_forbidden")
+		return
+	}
+	if !ensureUserInOrganization(c, reqDB(h.DB, c), patientID.String(), "common.patient_not_found") {
+		return
+	}
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.Prescription{}).Where("patient_id = ?", patientID)
+	if status := c.Query("status"); status != "" {
+		if status != string(models.PrescriptionStatusActive) && status != string(models.PrescriptionStatusExpired) {
+			utils.BadRequest(c, "prescriptions.invalid_status_filter")
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "prescriptions.fetch_failed")
+		return
+	}
+
+	var prescriptions []models.Prescription
+	if err := query.Order("issue_date desc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&prescriptions).Error; err != nil {
+		utils.HandleDBError(c, err, "prescriptions.fetch_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Prescriptions fetched successfully", prescriptions, pagination.Meta(total))
+
+}
+
+// UpdatePrescriptionStatus handles marking a prescription active or expired.
+// Only the prescribing doctor or an admin can change it.
+func (h *PrescriptionHandler) UpdatePrescriptionStatus(c *gin.Context) {
+	prescriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "prescriptions.invalid_id")
+		return
+	}
+
+	var req UpdatePrescriptionStatusRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	var prescription models.Prescription
+	if err := reqDB(h.DB, c).First(&prescription, "id = ?", prescriptionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "prescriptions.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	if !ensureUserInOrganization(c, reqDB(h.DB, c), prescription.PatientID, "prescriptions.not_found") {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canModifyRecord(userRole, userID, prescription.DoctorID) {
+		utils.Forbidden(c, "prescriptions.update_forbidden")
+		return
+	}
+
+	prescription.Status = req.Status
+	if err := reqDB(h.DB, c).Save(&prescription).Error; err != nil {
+		utils.HandleDBError(c, err, "prescriptions.update_failed")
+		return
+	}
+
+	utils.Success(c, "Prescription status updated successfully", prescription)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/realtime"
+	"healthcare-app-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+// RealtimeHandler handles the realtime messaging WebSocket.
+type RealtimeHandler struct {
+	Hub *realtime.Hub
+}
+
+// NewRealtimeHandler creates a new RealtimeHandler.
+func NewRealtimeHandler(hub *realtime.Hub) *RealtimeHandler {
+	return &RealtimeHandler{Hub: hub}
+}
+
+// NewRealtimeHub creates the hub that connects messaging clients, backed by the messages in db.
+func NewRealtimeHub(db *gorm.DB) *realtime.Hub {
+	return realtime.NewHub(messageStore{db: db})
+}
+
+// Connect handles upgrading the request to the realtime WebSocket, see package realtime for the events.
+// The connection is authenticated like any other request; browsers, which cannot set the Authorization
+// header on a WebSocket, use the access token cookie (ACCESS_TOKEN_COOKIE). Cross-site upgrades are
+// already refused by the CORS middleware.
+func (h *RealtimeHandler) Connect(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			h.Hub.Serve(userID, realtime.NewWebSocketConn(ws))
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// messageStore is the realtime.Store over the messages table. It does not use a request context:
+// connections outlive the request that opened them.
+type messageStore struct {
+	db *gorm.DB
+}
+
+func (s messageStore) Partners(userID string) ([]string, error) {
+	var partners []string
+	err := s.db.Raw(`SELECT DISTINCT CASE WHEN sender_id = ? THEN receiver_id ELSE sender_id END
+		FROM messages WHERE sender_id = ? OR receiver_id = ?`, userID, userID, userID).
+		Scan(&partners).Error
+	return partners, err
+}
+
+func (s messageStore) ConversationPartner(userID, conversationID string) (string, error) {
+	var message models.Message
+	err := s.db.Select("sender_id", "receiver_id").
+		Where("conversation_id = ? AND (sender_id = ? OR receiver_id = ?)", conversationID, userID, userID).
+		Take(&message).Error
+	if err == gorm.ErrRecordNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if message.SenderID == userID {
+		return message.ReceiverID, nil
+	}
+	return message.SenderID, nil
+}
+
+func (s messageStore) MarkRead(userID string, messageIDs []string) ([]realtime.Receipt, error) {
+	return markMessagesRead(s.db, userID, messageIDs)
+}
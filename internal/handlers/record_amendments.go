@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errAmendmentDecided aborts the decision transaction when the request was decided concurrently.
+var errAmendmentDecided = errors.New("amendment request already decided")
+
+// CreateAmendmentRequestRequest represents the request body for a patient asking to correct a record.
+type CreateAmendmentRequestRequest struct {
+	RequestedChange string `json:"requestedChange" binding:"required,max=5000"`
+}
+
+// AmendmentRecordChanges are the record fields a doctor changes when accepting an amendment request.
+type AmendmentRecordChanges struct {
+	Title      *string `json:"title" binding:"omitempty,min=1,max=255"`
+	Department *string `json:"department" binding:"omitempty,max=100"`
+	Summary    *string `json:"summary"`
+	Details    *string `json:"details"`
+}
+
+// DecideAmendmentRequestRequest represents the request body for accepting or rejecting an amendment request.
+type DecideAmendmentRequestRequest struct {
+	Status   models.AmendmentStatus `json:"status" binding:"required,oneof=accepted rejected"`
+	Response string                 `json:"response" binding:"max=5000"` // Required when rejecting
+	// Changes applied to the record together with the acceptance; the record's version is bumped
+	RecordChanges *AmendmentRecordChanges `json:"recordChanges"`
+}
+
+// loadAmendableRecord loads the record in the :id path parameter, writing the error response itself on failure.
+func (h *MedicalRecordHandler) loadAmendableRecord(c *gin.Context) (models.MedicalRecord, bool) {
+	var record models.MedicalRecord
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_medical_record_id")
+		return record, false
+	}
+	if err := reqDB(h.DB, c).First(&record, "id = ?", recordID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.medical_record_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return record, false
+	}
+	return record, true
+}
+
+// CreateAmendmentRequest handles the record's patient asking for a correction to it. The record's doctor is messaged.
+func (h *MedicalRecordHandler) CreateAmendmentRequest(c *gin.Context) {
+	record, ok := h.loadAmendableRecord(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if userRole != models.RolePatient || userID != record.PatientID {
+		utils.Forbidden(c, "records.amendment_forbidden")
+		return
+	}
+
+	var req CreateAmendmentRequestRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	requestedChange := utils.SanitizeText(strings.TrimSpace(req.RequestedChange))
+	if requestedChange == "" {
+		utils.BadRequest(c, "records.amendment_change_empty")
+		return
+	}
+
+	amendment := models.RecordAmendmentRequest{
+		MedicalRecordID: record.ID,
+		PatientID:       record.PatientID,
+		RequestedChange: requestedChange,
+		Status:          models.AmendmentStatusPending,
+	}
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&amendment).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "record_amendment.requested", auditEntityRecordAmendment, amendment.ID, "record="+record.ID)
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "records.amendment_create_failed")
+		return
+	}
+
+	notifyAmendment(reqDB(h.DB, c), userID, record.DoctorID, "Record amendment requested",
+		fmt.Sprintf("Your patient asked for a correction to the medical record %q. Please review the request.", record.Title))
+
+	utils.Created(c, "Amendment request created successfully", amendment)
+}
+
+// GetAmendmentRequests handles listing a record's amendment requests, newest first. The record's patient,
+// the record's doctor and admins can see them.
+func (h *MedicalRecordHandler) GetAmendmentRequests(c *gin.Context) {
+	record, ok := h.loadAmendableRecord(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	isPatient := userRole == models.RolePatient && userID == record.PatientID
+	if !isPatient && !canModifyRecord(userRole, userID, record.DoctorID) {
+		utils.Forbidden(c, "records.amendment_view_forbidden")
+		return
+	}
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.RecordAmendmentRequest{}).Where("medical_record_id = ?", record.ID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "records.amendment_fetch_failed")
+		return
+	}
+
+	var amendments []models.RecordAmendmentRequest
+	if err := query.Order("created_at desc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&amendments).Error; err != nil {
+		utils.HandleDBError(c, err, "records.amendment_fetch_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Amendment requests fetched successfully", amendments, pagination.Meta(total))
+}
+
+// DecideAmendmentRequest handles the record's doctor or an admin accepting or rejecting a pending amendment
+// request. Record changes sent with an acceptance are applied in the same transaction and bump the record's
+// version. The patient is messaged.
+func (h *MedicalRecordHandler) DecideAmendmentRequest(c *gin.Context) {
+	amendmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "records.invalid_amendment_id")
+		return
+	}
+
+	var req DecideAmendmentRequestRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	if req.RecordChanges != nil && req.Status != models.AmendmentStatusAccepted {
+		utils.BadRequest(c, "records.amendment_changes_accept_only")
+		return
+	}
+	response := utils.SanitizeText(strings.TrimSpace(req.Response))
+	if req.Status == models.AmendmentStatusRejected && response == "" {
+		utils.BadRequest(c, "records.amendment_reason_required")
+		return
+	}
+	changes := map[string]interface{}{}
+	if req.RecordChanges != nil {
+		if req.RecordChanges.Title != nil {
+			changes["title"] = *req.RecordChanges.Title
+		}
+		if req.RecordChanges.Department != nil {
+			changes["department"] = *req.RecordChanges.Department
+		}
+		if req.RecordChanges.Summary != nil {
+			summary := utils.SanitizeText(*req.RecordChanges.Summary)
+			if strings.TrimSpace(summary) == "" {
+				utils.BadRequest(c, "records.summary_empty")
+				return
+			}
+			changes["summary"] = summary
+		}
+		if req.RecordChanges.Details != nil {
+			changes["details"] = utils.SanitizeText(*req.RecordChanges.Details)
+		}
+	}
+
+	var amendment models.RecordAmendmentRequest
+	if err := reqDB(h.DB, c).First(&amendment, "id = ?", amendmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "records.amendment_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	// Loading the record also hides requests on records of other organizations, and of deleted records
+	var record models.MedicalRecord
+	if err := reqDB(h.DB, c).First(&record, "id = ?", amendment.MedicalRecordID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "records.amendment_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canModifyRecord(userRole, userID, record.DoctorID) {
+		utils.Forbidden(c, "records.amendment_decide_forbidden")
+		return
+	}
+	if amendment.Status != models.AmendmentStatusPending {
+		utils.Conflict(c, "records.amendment_already_decided")
+		return
+	}
+
+	now := time.Now().UTC()
+	amendment.Status = req.Status
+	amendment.Response = response
+	amendment.RespondedByID = &userID
+	amendment.RespondedAt = &now
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		// Only decided while still pending, so two reviewers answering at once cannot both succeed
+		result := tx.Model(&models.RecordAmendmentRequest{}).
+			Where("id = ? AND status = ?", amendment.ID, models.AmendmentStatusPending).
+			Updates(map[string]interface{}{
+				"status":          amendment.Status,
+				"response":        amendment.Response,
+				"responded_by_id": userID,
+				"responded_at":    now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAmendmentDecided
+		}
+
+		details := "record=" + record.ID
+		if len(changes) > 0 {
+			changes["version"] = gorm.Expr("version + 1")
+			if err := tx.Model(&record).Updates(changes).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.MedicalRecord{}).Where("id = ?", record.ID).Pluck("version", &record.Version).Error; err != nil {
+				return err
+			}
+			amendment.AppliedVersion = &record.Version
+			if err := tx.Model(&amendment).Update("applied_version", record.Version).Error; err != nil {
+				return err
+			}
+			details += fmt.Sprintf(" version=%d", record.Version)
+		}
+		return recordAudit(tx, userID, "record_amendment."+string(amendment.Status), auditEntityRecordAmendment, amendment.ID, details)
+	})
+	if errors.Is(err, errAmendmentDecided) {
+		utils.Conflict(c, "records.amendment_already_decided")
+		return
+	}
+	if err != nil {
+		utils.HandleDBError(c, err, "records.amendment_update_failed")
+		return
+	}
+
+	content := fmt.Sprintf("Your request to correct the medical record %q was accepted.", record.Title)
+	if amendment.Status == models.AmendmentStatusRejected {
+		content = fmt.Sprintf("Your request to correct the medical record %q was rejected: %s", record.Title, amendment.Response)
+	} else if amendment.Response != "" {
+		content += "\n\n" + amendment.Response
+	}
+	notifyAmendment(reqDB(h.DB, c), userID, amendment.PatientID, "Record amendment "+string(amendment.Status), content)
+
+	utils.Success(c, "Amendment request updated successfully", amendment)
+}
+
+// notifyAmendment messages the other party of an amendment request about a change to it. Failures are logged.
+func notifyAmendment(db *gorm.DB, senderID, receiverID, subject, content string) {
+	message := models.Message{
+		SenderID:   senderID,
+		ReceiverID: receiverID,
+		Subject:    subject,
+		Content:    content,
+		Status:     models.MessageStatusSent,
+	}
+	if err := db.Create(&message).Error; err != nil {
+		log.Printf("Failed to notify %s about a record amendment request: %v", receiverID, err)
+	}
+}
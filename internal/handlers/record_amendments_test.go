@@ -0,0 +1,85 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/i18n"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"testing"
+)
+
+func TestAmendmentRequestsOnlyForTheRecordsPatient(t *testing.T) {
+	api := newTestAPI(t)
+	fixture := newRecordFixture(t, api)
+	otherPatient := api.createUser(t, models.RolePatient)
+	path := "/api/v1/medical-records/" + fixture.record.ID + "/amendment-requests"
+	body := map[string]string{"requestedChange": "My blood type is listed wrong"}
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		wantStatus int
+	}{
+		{"another patient", otherPatient, http.StatusForbidden},
+		{"the record's doctor", fixture.doctor, http.StatusForbidden},
+		{"an admin", api.createUser(t, models.RoleAdmin), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, api.router, http.MethodPost, path, body, api.auth(t, tt.user))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+
+	want := i18n.Translate("en", "records.amendment_forbidden", nil)
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, path, body, api.auth(t, otherPatient))
+	if response := testutil.DecodeResponse(t, recorder); response.Error != want {
+		t.Errorf("error = %q, want %q", response.Error, want)
+	}
+
+	// Nothing was filed and the doctor was not bothered
+	var amendments int64
+	api.db.Model(&models.RecordAmendmentRequest{}).Count(&amendments)
+	if amendments != 0 {
+		t.Fatalf("%d amendment requests filed by others, want none", amendments)
+	}
+	var messages int64
+	api.db.Model(&models.Message{}).Where("receiver_id = ?", fixture.doctor.ID).Count(&messages)
+	if messages != 0 {
+		t.Errorf("doctor received %d messages about refused requests", messages)
+	}
+
+	// The record's own patient can file one, and the other patient cannot see it
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPost, path, body, api.auth(t, fixture.patient))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("own patient status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body.String())
+	}
+	var filed models.RecordAmendmentRequest
+	decodeData(t, recorder, &filed)
+	if filed.PatientID != fixture.patient.ID || filed.Status != models.AmendmentStatusPending {
+		t.Errorf("filed amendment = %+v", filed)
+	}
+
+	recorder = testutil.PerformRequest(t, api.router, http.MethodGet, path, nil, api.auth(t, otherPatient))
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("another patient listing status = %d, want %d: %s", recorder.Code, http.StatusForbidden, recorder.Body.String())
+	}
+}
+
+func TestAmendmentRequestsForMissingRecords(t *testing.T) {
+	api := newTestAPI(t)
+	patient := api.createUser(t, models.RolePatient)
+	body := map[string]string{"requestedChange": "Wrong date"}
+
+	for path, wantStatus := range map[string]int{
+		"/api/v1/medical-records/00000000-0000-0000-0000-000000000000/amendment-requests": http.StatusNotFound,
+		"/api/v1/medical-records/not-a-uuid/amendment-requests":                           http.StatusBadRequest,
+	} {
+		recorder := testutil.PerformRequest(t, api.router, http.MethodPost, path, body, api.auth(t, patient))
+		if recorder.Code != wantStatus {
+			t.Errorf("POST %s status = %d, want %d: %s", path, recorder.Code, wantStatus, recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AddRecordDiagnosisRequest represents the request body for adding a coded diagnosis to a record.
+type AddRecordDiagnosisRequest struct {
+	Code        string `json:"code" binding:"required,icd10"` // ICD-10 code, with or without the dot
+	Description string `json:"description" binding:"max=255"`
+}
+
+// AddRecordDiagnosis handles adding an ICD-10 diagnosis to a medical record. Only the record's doctor
+// and admins can add one; a code the record already has is a conflict.
+func (h *MedicalRecordHandler) AddRecordDiagnosis(c *gin.Context) {
+	record, ok := h.loadAmendableRecord(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canModifyRecord(userRole, userID, record.DoctorID) {
+		utils.Forbidden(c, "records.diagnosis_forbidden")
+		return
+	}
+
+	var req AddRecordDiagnosisRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	code, _ := utils.NormalizeICD10(req.Code)
+
+	diagnosis := models.RecordDiagnosis{
+		MedicalRecordID: record.ID,
+		Code:            code,
+		Description:     utils.SanitizeText(strings.TrimSpace(req.Description)),
+		AddedByID:       userID,
+	}
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&diagnosis).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "record_diagnosis.added", auditEntityRecordDiagnosis, diagnosis.ID, "record="+record.ID+" code="+code)
+	})
+	if err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.Conflict(c, "records.diagnosis_exists")
+		} else {
+			utils.HandleDBError(c, err, "records.diagnosis_add_failed")
+		}
+		return
+	}
+
+	utils.Created(c, "Diagnosis added successfully", dto.NewDiagnosisResponse(diagnosis))
+}
+
+// RemoveRecordDiagnosis handles removing the diagnosis with the :code path parameter from a medical
+// record. Only the record's doctor and admins can remove one.
+func (h *MedicalRecordHandler) RemoveRecordDiagnosis(c *gin.Context) {
+	record, ok := h.loadAmendableRecord(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canModifyRecord(userRole, userID, record.DoctorID) {
+		utils.Forbidden(c, "records.diagnosis_forbidden")
+		return
+	}
+
+	code, valid := utils.NormalizeICD10(c.Param("code"))
+	if !valid {
+		utils.BadRequest(c, "records.invalid_diagnosis_code")
+		return
+	}
+
+	var diagnosis models.RecordDiagnosis
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("medical_record_id = ? AND code = ?", record.ID, code).First(&diagnosis).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&diagnosis).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "record_diagnosis.removed", auditEntityRecordDiagnosis, diagnosis.ID, "record="+record.ID+" code="+code)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "records.diagnosis_not_found")
+		} else {
+			utils.HandleDBError(c, err, "records.diagnosis_remove_failed")
+		}
+		return
+	}
+
+	utils.Success(c, "Diagnosis removed successfully", nil)
+}
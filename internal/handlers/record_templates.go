@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecordTemplateHandler handles medical record template requests.
+// Doctors manage their own templates, admins manage global ones.
+type RecordTemplateHandler struct {
+	DB *gorm.DB
+}
+
+// NewRecordTemplateHandler creates a new RecordTemplateHandler.
+func NewRecordTemplateHandler(db *gorm.DB) *RecordTemplateHandler {
+	return &RecordTemplateHandler{DB: db}
+}
+
+// CreateRecordTemplateRequest represents the request body for creating a record template.
+type CreateRecordTemplateRequest struct {
+	Name            string                   `json:"name" binding:"required,max=100"`
+	RecordType      models.MedicalRecordType `json:"recordType" binding:"required,record_type"`
+	TitlePattern    string                   `json:"titlePattern" binding:"max=255"`
+	Department      string                   `json:"department" binding:"max=100"`
+	SummarySkeleton string                   `json:"summarySkeleton"`
+	DetailsSkeleton string                   `json:"detailsSkeleton"`
+}
+
+// UpdateRecordTemplateRequest represents the request body for updating a record template.
+// Only the fields present in the body are changed.
+type UpdateRecordTemplateRequest struct {
+	Name            *string                   `json:"name" binding:"omitempty,min=1,max=100"`
+	RecordType      *models.MedicalRecordType `json:"recordType" binding:"omitempty,record_type"`
+	TitlePattern    *string                   `json:"titlePattern" binding:"omitempty,max=255"`
+	Department      *string                   `json:"department" binding:"omitempty,max=100"`
+	SummarySkeleton *string                   `json:"summarySkeleton"`
+	DetailsSkeleton *string                   `json:"detailsSkeleton"`
+}
+
+// templateScope restricts a query to the templates the user may use: their own plus global ones.
+func templateScope(db *gorm.DB, userID string) *gorm.DB {
+	return db.Where("owner_doctor_id = ? OR owner_doctor_id IS NULL", userID)
+}
+
+// findUsableTemplate loads a template the user may apply to a new record.
+// It returns gorm.ErrRecordNotFound for templates that exist but belong to another doctor.
+func findUsableTemplate(db *gorm.DB, templateID, userID string) (models.RecordTemplate, error) {
+	var template models.RecordTemplate
+	err := templateScope(db, userID).Where("id = ?", templateID).First(&template).Error
+	return template, err
+}
+
+// applyTemplate fills every field of req left empty from the template. Fields given in the request always win.
+func applyTemplate(req *CreateMedicalRecordRequest, template models.RecordTemplate, patient models.User, recordDate time.Time) {
+	if req.RecordType == "" {
+		req.RecordType = template.RecordType
+	}
+	if req.Title == "" {
+		req.Title = strings.NewReplacer(
+			"{patientName}", strings.TrimSpace(patient.FirstName+" "+patient.LastName),
+			"{date}", recordDate.Format("2006-01-02"),
+		).Replace(template.TitlePattern)
+	}
+	if req.Department == "" {
+		req.Department = template.Department
+	}
+	if req.Summary == "" {
+		req.Summary = template.SummarySkeleton
+	}
+	if req.Details == "" {
+		req.Details = template.DetailsSkeleton
+	}
+}
+
+// canManageTemplate reports whether the user may edit or delete the template:
+// doctors their own templates, admins the global ones. Global templates are shared by every organization,
+// so with MULTI_TENANT on only super admins manage them.
+func canManageTemplate(c *gin.Context, role models.Role, userID string, template models.RecordTemplate) bool {
+	if template.OwnerDoctorID == nil {
+		return isPlatformAdmin(c, role)
+	}
+	return role == models.RoleDoctor && *template.OwnerDoctorID == userID
+}
+
+// GetRecordTemplates handles listing the caller's own templates plus the global ones,
+// optionally filtered by ?recordType=. Own templates are listed first.
+func (h *RecordTemplateHandler) GetRecordTemplates(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	query := templateScope(reqDB(h.DB, c), userID)
+	if recordType := c.Query("recordType"); recordType != "" {
+		if !models.MedicalRecordType(recordType).IsValid() {
+			utils.BadRequest(c, "records.invalid_record_type_filter", utils.Params{"recordType": recordType})
+			return
+		}
+		query = query.Where("record_type = ?", recordType)
+	}
+
+	var templates []models.RecordTemplate
+	if err := query.Order("owner_doctor_id IS NULL, name asc").Find(&templates).Error; err != nil {
+		utils.HandleDBError(c, err, "records.fetch_templates_failed")
+		return
+	}
+
+	utils.Success(c, "Record templates fetched successfully", templates)
+}
+
+// CreateRecordTemplate handles creating a template: a private one for doctors, a global one for admins.
+func (h *RecordTemplateHandler) CreateRecordTemplate(c *gin.Context) {
+	var req CreateRecordTemplateRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if userRole.IsAdmin() && !isPlatformAdmin(c, userRole) {
+		utils.Forbidden(c, "records.template_forbidden")
+		return
+	}
+
+	template := models.RecordTemplate{
+		Name:            strings.TrimSpace(req.Name),
+		RecordType:      req.RecordType,
+		TitlePattern:    req.TitlePattern,
+		Department:      req.Department,
+		SummarySkeleton: req.SummarySkeleton,
+		DetailsSkeleton: req.DetailsSkeleton,
+	}
+	if !userRole.IsAdmin() {
+		template.OwnerDoctorID = &userID
+	}
+
+	if err := reqDB(h.DB, c).Create(&template).Error; err != nil {
+		utils.HandleDBError(c, err, "records.create_template_failed")
+		return
+	}
+
+	utils.Created(c, "Record template created successfully", template)
+}
+
+// findManagedTemplate loads the template in the :templateId path parameter and writes an error
+// response unless the current user may manage it.
+func (h *RecordTemplateHandler) findManagedTemplate(c *gin.Context) (models.RecordTemplate, bool) {
+	var template models.RecordTemplate
+	templateID, err := uuid.Parse(c.Param("templateId"))
+	if err != nil {
+		utils.BadRequest(c, "records.invalid_template_id")
+		return template, false
+	}
+
+	if err := reqDB(h.DB, c).First(&template, "id = ?", templateID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "records.template_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return template, false
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canManageTemplate(c, userRole, userID, template) {
+		utils.Forbidden(c, "records.template_forbidden")
+		return template, false
+	}
+	return template, true
+}
+
+// UpdateRecordTemplate handles updating a template. Records already created from it are unchanged.
+func (h *RecordTemplateHandler) UpdateRecordTemplate(c *gin.Context) {
+	var req UpdateRecordTemplateRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	template, ok := h.findManagedTemplate(c)
+	if !ok {
+		return
+	}
+
+	if req.Name != nil {
+		template.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.RecordType != nil {
+		template.RecordType = *req.RecordType
+	}
+	if req.TitlePattern != nil {
+		template.TitlePattern = *req.TitlePattern
+	}
+	if req.Department != nil {
+		template.Department = *req.Department
+	}
+	if req.SummarySkeleton != nil {
+		template.SummarySkeleton = *req.SummarySkeleton
+	}
+	if req.DetailsSkeleton != nil {
+		template.DetailsSkeleton = *req.DetailsSkeleton
+	}
+
+	if err := reqDB(h.DB, c).Save(&template).Error; err != nil {
+		utils.HandleDBError(c, err, "records.update_template_failed")
+		return
+	}
+
+	utils.Success(c, "Record template updated successfully", template)
+}
+
+// DeleteRecordTemplate handles deleting a template. Records created from it keep their copied values.
+func (h *RecordTemplateHandler) DeleteRecordTemplate(c *gin.Context) {
+	template, ok := h.findManagedTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := reqDB(h.DB, c).Delete(&template).Error; err != nil {
+		utils.HandleDBError(c, err, "records.delete_template_failed")
+		return
+	}
+
+	utils.Success(c, "Record template deleted successfully", nil)
+}
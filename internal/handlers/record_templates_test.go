@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"testing"
+)
+
+// newRecordTemplate saves a template owned by doctor with every field set.
+func newRecordTemplate(t *testing.T, api *testAPI, doctor *models.User) models.RecordTemplate {
+	t.Helper()
+
+	template := models.RecordTemplate{
+		OwnerDoctorID:   &doctor.ID,
+		Name:            "Follow-up",
+		RecordType:      models.RecordTypeConsultation,
+		TitlePattern:    "Follow-up: {patientName} on {date}",
+		Department:      "Cardiology",
+		SummarySkeleton: "Presenting complaint:",
+		DetailsSkeleton: "Examination:\nPlan:",
+	}
+	api.create(t, &template)
+	return template
+}
+
+// createRecordFromTemplate posts a record for patient with the given fields and returns the created record.
+func createRecordFromTemplate(t *testing.T, api *testAPI, doctor *models.User, fields map[string]string) dto.MedicalRecordResponse {
+	t.Helper()
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/medical-records", fields, api.auth(t, doctor))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var record dto.MedicalRecordResponse
+	decodeData(t, recorder, &record)
+	return record
+}
+
+func TestCreateMedicalRecordFromTemplate(t *testing.T) {
+	api := newTestAPI(t)
+	doctor := api.createUser(t, models.RoleDoctor)
+	patient := api.createUser(t, models.RolePatient)
+	template := newRecordTemplate(t, api, doctor)
+
+	t.Run("request fields beat the template", func(t *testing.T) {
+		record := createRecordFromTemplate(t, api, doctor, map[string]string{
+			"patientId":  patient.ID,
+			"templateId": template.ID,
+			"recordDate": "2026-03-02T10:00:00Z",
+			"recordType": string(models.RecordTypeLabResult),
+			"title":      "Lipid panel",
+			"department": "Internal medicine",
+			"summary":    "LDL slightly raised",
+			"details":    "Repeat in 3 months",
+		})
+		if record.RecordType != models.RecordTypeLabResult || record.Title != "Lipid panel" || record.Department != "Internal medicine" ||
+			record.Summary != "LDL slightly raised" || record.Details != "Repeat in 3 months" {
+			t.Errorf("record = %+v, want every field from the request", record)
+		}
+	})
+
+	t.Run("template fills the fields left empty", func(t *testing.T) {
+		record := createRecordFromTemplate(t, api, doctor, map[string]string{
+			"patientId":  patient.ID,
+			"templateId": template.ID,
+			"recordDate": "2026-03-02T10:00:00Z",
+			"summary":    "Chest pain resolved",
+		})
+		if record.Summary != "Chest pain resolved" {
+			t.Errorf("summary = %q, want the request's", record.Summary)
+		}
+		wantTitle := "Follow-up: " + patient.FirstName + " " + patient.LastName + " on 2026-03-02"
+		if record.RecordType != template.RecordType || record.Title != wantTitle || record.Department != template.Department ||
+			record.Details != template.DetailsSkeleton {
+			t.Errorf("record = %+v, want the rest from the template with the title %q", record, wantTitle)
+		}
+	})
+}
+
+func TestDeletingARecordTemplateKeepsItsRecords(t *testing.T) {
+	api := newTestAPI(t)
+	doctor := api.createUser(t, models.RoleDoctor)
+	patient := api.createUser(t, models.RolePatient)
+	template := newRecordTemplate(t, api, doctor)
+	record := createRecordFromTemplate(t, api, doctor, map[string]string{
+		"patientId": patient.ID, "templateId": template.ID, "recordDate": "2026-03-02T10:00:00Z",
+	})
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodDelete, "/api/v1/medical-records/templates/"+template.ID, nil, api.auth(t, doctor))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("delete status = %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var stored models.MedicalRecord
+	if err := api.db.First(&stored, "id = ?", record.ID).Error; err != nil {
+		t.Fatalf("loading the record: %v", err)
+	}
+	if stored.Summary != template.SummarySkeleton || stored.Details != template.DetailsSkeleton {
+		t.Errorf("record = %+v, want the template's text kept", stored)
+	}
+}
+
+func TestAnotherDoctorsTemplateCannotBeUsed(t *testing.T) {
+	api := newTestAPI(t)
+	owner := api.createUser(t, models.RoleDoctor)
+	other := api.createUser(t, models.RoleDoctor)
+	patient := api.createUser(t, models.RolePatient)
+	template := newRecordTemplate(t, api, owner)
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/medical-records", map[string]string{
+		"patientId": patient.ID, "templateId": template.ID, "recordDate": "2026-03-02T10:00:00Z",
+	}, api.auth(t, other))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d: %s", recorder.Code, http.StatusNotFound, recorder.Body.String())
+	}
+}
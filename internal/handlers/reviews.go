@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/cache"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReviewHandler handles doctor review related requests.
+type ReviewHandler struct {
+	DB      *gorm.DB
+	Cfg     *config.Config
+	Doctors *cache.Loader // Cached doctor listings, which show ratings
+}
+
+// NewReviewHandler creates a new ReviewHandler.
+func NewReviewHandler(db *gorm.DB, cfg *config.Config, doctors *cache.Loader) *ReviewHandler {
+	return &ReviewHandler{DB: db, Cfg: cfg, Doctors: doctors}
+}
+
+// ReviewRequest represents the request body for creating or editing a review.
+type ReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Comment string `json:"comment" binding:"max=2000"`
+}
+
+// DoctorReviewRequest represents the request body for reviewing a doctor directly. The review is filed
+// against AppointmentID when given, otherwise against the patient's most recent completed appointment
+// with the doctor that has no review yet.
+type DoctorReviewRequest struct {
+	ReviewRequest
+	AppointmentID string `json:"appointmentId" binding:"omitempty,uuid"`
+}
+
+// ReviewResponse represents a review with the reviewing patient's sanitized details.
+type ReviewResponse struct {
+	models.Review
+	Patient models.UserSanitized `json:"patient"`
+}
+
+// DoctorRatingSummary holds the aggregate rating of a doctor.
+type DoctorRatingSummary struct {
+	DoctorID      string  `json:"-"`
+	AverageRating float64 `json:"averageRating"`
+	ReviewCount   int64   `json:"reviewCount"`
+}
+
+// loadReviewableAppointment fetches the appointment from the :id param and verifies the requester
+// is its patient. It sends the error response itself and returns ok=false on failure.
+func (h *ReviewHandler) loadReviewableAppointment(c *gin.Context) (appointment models.Appointment, userID string, ok bool) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_appointment_id")
+		return appointment, "", false
+	}
+
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return appointment, "", false
+	}
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	if err := reqDB(h.DB, c).First(&appointment, "id = ?", appointmentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.appointment_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return appointment, "", false
+	}
+
+	// Only the appointment's patient may review it; this also stops doctors reviewing themselves
+	if userRole != models.RolePatient || appointment.PatientID != userID || appointment.DoctorID == userID {
+		utils.Forbidden(c, "reviews.patient_only")
+		return appointment, "", false
+	}
+
+	return appointment, userID, true
+}
+
+// CreateReview handles a patient reviewing the doctor of one of their completed appointments.
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
+	var req ReviewRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	appointment, patientID, ok := h.loadReviewableAppointment(c)
+	if !ok {
+		return
+	}
+
+	if !strings.EqualFold(string(appointment.Status), string(models.StatusCompleted)) {
+		utils.BadRequest(c, "reviews.completed_only")
+		return
+	}
+	h.createReview(c, appointment, patientID, req)
+}
+
+// CreateDoctorReview handles a patient reviewing a doctor they had a completed appointment with. It
+// resolves which appointment the review is for and files it like CreateReview.
+func (h *ReviewHandler) CreateDoctorReview(c *gin.Context) {
+	doctorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_doctor_id")
+		return
+	}
+	var req DoctorReviewRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if userRole != models.RolePatient || userID == doctorID.String() {
+		utils.Forbidden(c, "reviews.patient_only")
+		return
+	}
+
+	query := reqDB(h.DB, c).Where("patient_id = ? AND doctor_id = ? AND status = ?", userID, doctorID, models.StatusCompleted)
+	if req.AppointmentID != "" {
+		query = query.Where("id = ?", req.AppointmentID)
+	}
+	var appointments []models.Appointment
+	if err := query.Order("start_time desc").Find(&appointments).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	if len(appointments) == 0 {
+		utils.Forbidden(c, "reviews.no_completed_appointment")
+		return
+	}
+
+	appointmentIDs := make([]string, len(appointments))
+	for i, appointment := range appointments {
+		appointmentIDs[i] = appointment.ID
+	}
+	var reviewedIDs []string
+	if err := reqDB(h.DB, c).Model(&models.Review{}).Where("appointment_id IN ?", appointmentIDs).
+		Pluck("appointment_id", &reviewedIDs).Error; err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return
+	}
+	reviewed := make(map[string]bool, len(reviewedIDs))
+	for _, id := range reviewedIDs {
+		reviewed[id] = true
+	}
+	for _, appointment := range appointments {
+		if !reviewed[appointment.ID] {
+			h.createReview(c, appointment, userID, req.ReviewRequest)
+			return
+		}
+	}
+	utils.Conflict(c, "reviews.already_reviewed")
+}
+
+// createReview saves patientID's review of the completed appointment and writes the response.
+func (h *ReviewHandler) createReview(c *gin.Context, appointment models.Appointment, patientID string, req ReviewRequest) {
+	review := models.Review{
+		AppointmentID: appointment.ID,
+		PatientID:     patientID,
+		DoctorID:      appointment.DoctorID,
+		Rating:        req.Rating,
+		Comment:       req.Comment,
+	}
+
+	// The unique index on appointment_id guarantees one review per appointment, even under concurrent requests
+	if err := reqDB(h.DB, c).Create(&review).Error; err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.Conflict(c, "reviews.already_reviewed")
+		} else {
+			utils.HandleDBError(c, err, "reviews.create_failed")
+		}
+		return
+	}
+	invalidateDoctorCache(c, h.Doctors)
+
+	utils.Created(c, "Review created successfully", review)
+}
+
+// UpdateReview handles a patient editing their review within the configured edit window.
+func (h *ReviewHandler) UpdateReview(c *gin.Context) {
+	var req ReviewRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	appointment, _, ok := h.loadReviewableAppointment(c)
+	if !ok {
+		return
+	}
+
+	var review models.Review
+	if err := reqDB(h.DB, c).First(&review, "appointment_id = ?", appointment.ID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "reviews.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	editDeadline := review.CreatedAt.Add(time.Duration(h.Cfg.ReviewEditWindowHours) * time.Hour)
+	if time.Now().After(editDeadline) {
+		utils.Forbidden(c, "reviews.edit_window_expired")
+		return
+	}
+
+	review.Rating = req.Rating
+	review.Comment = req.Comment
+	if err := reqDB(h.DB, c).Save(&review).Error; err != nil {
+		utils.HandleDBError(c, err, "reviews.update_failed")
+		return
+	}
+	invalidateDoctorCache(c, h.Doctors)
+
+	utils.Success(c, "Review updated successfully", review)
+}
+
+// GetDoctorReviews handles fetching the paginated reviews of a doctor along with their aggregate rating.
+// Accessible by all authenticated users.
+func (h *ReviewHandler) GetDoctorReviews(c *gin.Context) {
+	doctorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_doctor_id")
+		return
+	}
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	var doctor models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", doctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	ratings, err := doctorRatingSummaries(reqDB(h.DB, c), []string{doctor.ID})
+	if err != nil {
+		utils.HandleDBError(c, err, "reviews.fetch_failed")
+		return
+	}
+	summary := ratings[doctor.ID]
+
+	var reviews []models.Review
+	if err := reqDB(h.DB, c).Preload("Patient").Where("doctor_id = ?", doctor.ID).
+		Order("created_at desc").Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&reviews).Error; err != nil {
+		utils.HandleDBError(c, err, "reviews.fetch_failed")
+		return
+	}
+
+	responses := make([]ReviewResponse, len(reviews))
+	for i, review := range reviews {
+		responses[i] = ReviewResponse{Review: review, Patient: review.Patient.Sanitize()}
+	}
+
+	utils.SuccessWithMeta(c, "Reviews fetched successfully", gin.H{
+		"averageRating": summary.AverageRating,
+		"reviewCount":   summary.ReviewCount,
+		"reviews":       responses,
+	}, pagination.Meta(summary.ReviewCount))
+
+}
+
+// DeleteReview handles removing an abusive review (admin).
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	reviewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "reviews.invalid_id")
+		return
+	}
+
+	result := scopeToOrganizationUsers(c, reqDB(h.DB, c), reqDB(h.DB, c), "doctor_id").Delete(&models.Review{}, "id = ?", reviewID)
+	if result.Error != nil {
+		utils.HandleDBError(c, result.Error, "reviews.delete_failed")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.NotFound(c, "reviews.not_found")
+		return
+	}
+	invalidateDoctorCache(c, h.Doctors)
+
+	utils.Success(c, "Review deleted successfully", nil)
+}
+
+// doctorRatingSummaries computes the average rating and review count for each doctor in a single grouped query.
+// Doctors without reviews get a zero-valued summary.
+func doctorRatingSummaries(db *gorm.DB, doctorIDs []string) (map[string]DoctorRatingSummary, error) {
+	summaries := make(map[string]DoctorRatingSummary, len(doctorIDs))
+	if len(doctorIDs) == 0 {
+		return summaries, nil
+	}
+
+	var rows []DoctorRatingSummary
+	if err := db.Model(&models.Review{}).
+		Select("doctor_id, AVG(rating) AS average_rating, COUNT(*) AS review_count").
+		Where("doctor_id IN ?", doctorIDs).
+		Group("doctor_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		summaries[row.DoctorID] = row
+	}
+	return summaries, nil
+}
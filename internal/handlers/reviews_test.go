@@ -0,0 +1,49 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCreateDoctorReview(t *testing.T) {
+	api := newTestAPI(t)
+	patient := api.createUser(t, models.RolePatient)
+	otherPatient := api.createUser(t, models.RolePatient)
+	doctor := api.createUser(t, models.RoleDoctor)
+
+	start := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Minute)
+	api.create(t, &models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: models.StatusCompleted})
+	api.create(t, &models.Appointment{PatientID: otherPatient.ID, DoctorID: doctor.ID, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: models.StatusCancelled})
+	path := "/api/v1/doctors/" + doctor.ID + "/reviews"
+	review := map[string]interface{}{"rating": 5, "comment": "Very thorough"}
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		wantStatus int
+	}{
+		{"patient with a completed appointment", patient, http.StatusCreated},
+		{"second review of the only appointment", patient, http.StatusConflict},
+		{"patient without a completed appointment", otherPatient, http.StatusForbidden},
+		{"doctor reviewing themselves", doctor, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, api.router, http.MethodPost, path, review, api.auth(t, tt.user))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+
+	var reviews []models.Review
+	if err := api.db.Find(&reviews).Error; err != nil {
+		t.Fatalf("loading reviews: %v", err)
+	}
+	if len(reviews) != 1 || reviews[0].PatientID != patient.ID || reviews[0].DoctorID != doctor.ID || reviews[0].Rating != 5 {
+		t.Errorf("reviews = %+v, want one 5-star review by the patient", reviews)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/slowlog"
+	"healthcare-app-server/internal/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlowQueryHandler exposes the slow query and request log.
+type SlowQueryHandler struct {
+	Log *slowlog.Log
+}
+
+// NewSlowQueryHandler creates a new SlowQueryHandler.
+func NewSlowQueryHandler(log *slowlog.Log) *SlowQueryHandler {
+	return &SlowQueryHandler{Log: log}
+}
+
+// GetSlowQueries handles listing the most recent slow queries and requests, newest first, at most
+// ?limit= of them (admin). Only the last SLOW_LOG_SIZE entries since startup are kept.
+func (h *SlowQueryHandler) GetSlowQueries(c *gin.Context) {
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			utils.BadRequest(c, "common.invalid_limit")
+			return
+		}
+	}
+
+	utils.Success(c, "Slow queries fetched successfully", h.Log.Recent(limit))
+}
@@ -0,0 +1,192 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clinic is an organization with one user of each role and a patient's appointment, record and message.
+type clinic struct {
+	organization           models.Organization
+	admin, doctor, patient *models.User
+	appointment            models.Appointment
+	record                 models.MedicalRecord
+	message                models.Message
+}
+
+func newClinic(t *testing.T, api *testAPI, name string) clinic {
+	t.Helper()
+
+	cl := clinic{organization: models.Organization{Name: name, InviteCode: "invite-" + name}}
+	api.create(t, &cl.organization)
+	member := func(role models.Role) *models.User {
+		user := testutil.NewTestUser(role)
+		user.OrganizationID = &cl.organization.ID
+		api.create(t, user)
+		return user
+	}
+	cl.admin, cl.doctor, cl.patient = member(models.RoleAdmin), member(models.RoleDoctor), member(models.RolePatient)
+
+	start := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Hour)
+	cl.appointment = models.Appointment{OrganizationID: &cl.organization.ID, PatientID: cl.patient.ID, DoctorID: cl.doctor.ID,
+		StartTime: start, EndTime: start.Add(30 * time.Minute), Status: models.StatusConfirmed, Reason: "Checkup"}
+	api.create(t, &cl.appointment)
+	cl.record = models.MedicalRecord{OrganizationID: &cl.organization.ID, PatientID: cl.patient.ID, DoctorID: cl.doctor.ID,
+		RecordType: models.RecordTypeLabResult, RecordDate: start, Title: name + " blood panel", Summary: "Normal"}
+	api.create(t, &cl.record)
+	cl.message = models.Message{OrganizationID: &cl.organization.ID, SenderID: cl.doctor.ID, ReceiverID: cl.patient.ID,
+		Content: name + " results are in", Status: models.MessageStatusSent}
+	api.create(t, &cl.message)
+	return cl
+}
+
+func newMultiTenantAPI(t *testing.T) (*testAPI, clinic, clinic) {
+	t.Helper()
+
+	api := newTestAPI(t, func(cfg *config.Config) { cfg.MultiTenant = true })
+	return api, newClinic(t, api, "north"), newClinic(t, api, "south")
+}
+
+func TestCrossTenantAccessIsRefused(t *testing.T) {
+	api, north, south := newMultiTenantAPI(t)
+	startTime := time.Now().UTC().Add(96 * time.Hour).Truncate(time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"doctor reads another clinic's record", north.doctor, http.MethodGet, "/api/v1/medical-records/" + south.record.ID, nil},
+		{"admin reads another clinic's record", north.admin, http.MethodGet, "/api/v1/medical-records/" + south.record.ID, nil},
+		{"doctor updates another clinic's record", north.doctor, http.MethodPut, "/api/v1/medical-records/" + south.record.ID,
+			map[string]interface{}{"title": "Hijacked", "version": 1}},
+		{"admin deletes another clinic's record", north.admin, http.MethodDelete, "/api/v1/medical-records/" + south.record.ID, nil},
+		{"doctor reads another clinic's appointment", north.doctor, http.MethodGet, "/api/v1/appointments/" + south.appointment.ID, nil},
+		{"admin cancels another clinic's appointment", north.admin, http.MethodPatch, "/api/v1/appointments/" + south.appointment.ID + "/status",
+			map[string]string{"status": string(models.StatusCancelled)}},
+		{"admin reads another clinic's user", north.admin, http.MethodGet, "/api/v1/users/" + south.patient.ID, nil},
+		{"admin updates another clinic's user", north.admin, http.MethodPut, "/api/v1/users/" + south.patient.ID,
+			map[string]string{"firstName": "Hijacked"}},
+		{"patient reads another clinic's message", north.patient, http.MethodGet, "/api/v1/messages/" + south.message.ID, nil},
+		{"patient books another clinic's doctor", north.patient, http.MethodPost, "/api/v1/appointments",
+			map[string]string{"doctorId": south.doctor.ID, "patientId": north.patient.ID, "startTime": startTime, "reason": "Checkup"}},
+		{"doctor books another clinic's patient", north.doctor, http.MethodPost, "/api/v1/appointments",
+			map[string]string{"doctorId": north.doctor.ID, "patientId": south.patient.ID, "startTime": startTime, "reason": "Checkup"}},
+		{"doctor writes a record for another clinic's patient", north.doctor, http.MethodPost, "/api/v1/medical-records",
+			map[string]string{"patientId": south.patient.ID, "recordType": string(models.RecordTypeLabResult),
+				"recordDate": startTime, "title": "Blood panel", "summary": "Normal"}},
+		{"doctor messages another clinic's patient", north.doctor, http.MethodPost, "/api/v1/messages/send",
+			map[string]string{"recipientId": south.patient.ID, "content": "Hello"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, api.router, tt.method, tt.path, tt.body, api.auth(t, tt.user))
+			if recorder.Code != http.StatusNotFound && recorder.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 404 or 403: %s", recorder.Code, recorder.Body.String())
+			}
+			if strings.Contains(recorder.Body.String(), "south") {
+				t.Errorf("response leaks the other clinic's data: %s", recorder.Body.String())
+			}
+		})
+	}
+
+	// Nothing in the other clinic changed
+	var record models.MedicalRecord
+	if err := api.db.First(&record, "id = ?", south.record.ID).Error; err != nil || record.Title != "south blood panel" {
+		t.Errorf("south record = %q, %v; want it untouched", record.Title, err)
+	}
+	var appointment models.Appointment
+	if err := api.db.First(&appointment, "id = ?", south.appointment.ID).Error; err != nil || appointment.Status != models.StatusConfirmed {
+		t.Errorf("south appointment status = %q, %v; want it untouched", appointment.Status, err)
+	}
+	var patient models.User
+	if err := api.db.First(&patient, "id = ?", south.patient.ID).Error; err != nil || patient.FirstName == "Hijacked" {
+		t.Errorf("south patient = %q, %v; want it untouched", patient.FirstName, err)
+	}
+	var messages int64
+	api.db.Model(&models.Message{}).Where("receiver_id = ?", south.patient.ID).Count(&messages)
+	if messages != 1 {
+		t.Errorf("south patient has %d messages, want only their own clinic's", messages)
+	}
+}
+
+func TestTenantListsOnlyShowTheOwnClinic(t *testing.T) {
+	api, north, south := newMultiTenantAPI(t)
+
+	tests := []struct {
+		name string
+		user *models.User
+		path string
+	}{
+		{"admin lists users", north.admin, "/api/v1/users"},
+		{"patient lists doctors", north.patient, "/api/v1/users/doctors"},
+		{"admin lists appointments", north.admin, "/api/v1/appointments"},
+		{"doctor lists another clinic's patient's records", north.doctor, "/api/v1/medical-records/patient/" + south.patient.ID},
+		{"admin lists another clinic's patient's appointments", north.admin, "/api/v1/appointments/patient/" + south.patient.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, api.router, http.MethodGet, tt.path, nil, api.auth(t, tt.user))
+			if recorder.Code >= http.StatusInternalServerError {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body.String())
+			}
+			body := recorder.Body.String()
+			for _, id := range []string{south.admin.ID, south.doctor.ID, south.patient.ID, south.appointment.ID, south.record.ID} {
+				if strings.Contains(body, id) {
+					t.Errorf("response contains %s from the other clinic: %s", id, body)
+				}
+			}
+		})
+	}
+}
+
+func TestTenantAccessWithinTheOwnClinic(t *testing.T) {
+	api, north, south := newMultiTenantAPI(t)
+	superAdmin := api.createUser(t, models.RoleSuperAdmin)
+
+	tests := []struct {
+		name string
+		user *models.User
+		path string
+	}{
+		{"doctor reads their clinic's record", north.doctor, "/api/v1/medical-records/" + north.record.ID},
+		{"admin reads their clinic's appointment", north.admin, "/api/v1/appointments/" + north.appointment.ID},
+		{"super admin reads any clinic's appointment", superAdmin, "/api/v1/appointments/" + south.appointment.ID},
+		{"super admin reads any clinic's user", superAdmin, "/api/v1/users/" + south.patient.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, api.router, http.MethodGet, tt.path, nil, api.auth(t, tt.user))
+			if recorder.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestTenantTokensWithoutOrganizationAreRefused(t *testing.T) {
+	api, north, _ := newMultiTenantAPI(t)
+	orphan := api.createUser(t, models.RoleAdmin)
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/medical-records/"+north.record.ID, nil, api.auth(t, orphan))
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d: %s", recorder.Code, http.StatusForbidden, recorder.Body.String())
+	}
+}
+
+func TestTenancyIsOffByDefault(t *testing.T) {
+	api := newTestAPI(t)
+	north, south := newClinic(t, api, "north"), newClinic(t, api, "south")
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodGet, "/api/v1/appointments/"+south.appointment.ID, nil, api.auth(t, north.admin))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d without MULTI_TENANT: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/dto"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
+	"healthcare-app-server/internal/utils"
+	"healthcare-app-server/internal/webhooks"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TimeOffRequest represents the request body for adding or changing a time-off block.
+type TimeOffRequest struct {
+	StartTime time.Time `json:"startTime" binding:"required"`
+	EndTime   time.Time `json:"endTime" binding:"required"`
+	Reason    string    `json:"reason" binding:"max=255"`
+}
+
+// TimeOffResult is a saved time-off block together with the appointments a forced save cancelled.
+type TimeOffResult struct {
+	TimeOff               models.TimeOff            `json:"timeOff"`
+	CancelledAppointments []dto.AppointmentResponse `json:"cancelledAppointments"`
+}
+
+// findTimeOffDoctor loads the doctor named by the :id path parameter and makes sure the requester
+// is that doctor or an admin. It writes the error response itself.
+func (h *DoctorProfileHandler) findTimeOffDoctor(c *gin.Context) (models.User, bool) {
+	doctor, ok := h.findDoctor(c)
+	if !ok {
+		return doctor, false
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !userRole.IsAdmin() && userID != doctor.ID {
+		utils.Forbidden(c, "doctors.time_off_forbidden")
+		return doctor, false
+	}
+	return doctor, true
+}
+
+// findTimeOff loads the doctor's time-off block named by the :timeOffId path parameter, writing the error response itself.
+func (h *DoctorProfileHandler) findTimeOff(c *gin.Context, doctorID string) (models.TimeOff, bool) {
+	var timeOff models.TimeOff
+	timeOffID, err := uuid.Parse(c.Param("timeOffId"))
+	if err != nil {
+		utils.BadRequest(c, "doctors.invalid_time_off_id")
+		return timeOff, false
+	}
+	if err := reqDB(h.DB, c).Where("id = ? AND doctor_id = ?", timeOffID, doctorID).First(&timeOff).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "doctors.time_off_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return timeOff, false
+	}
+	return timeOff, true
+}
+
+// GetTimeOff handles listing a doctor's time-off blocks, soonest first.
+// Only the doctor themselves or an admin may see them.
+func (h *DoctorProfileHandler) GetTimeOff(c *gin.Context) {
+	doctor, ok := h.findTimeOffDoctor(c)
+	if !ok {
+		return
+	}
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+	query := reqDB(h.DB, c).Model(&models.TimeOff{}).Where("doctor_id = ?", doctor.ID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "doctors.fetch_time_off_failed")
+		return
+	}
+
+	var blocks []models.TimeOff
+	if err := query.Order("start_time asc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&blocks).Error; err != nil {
+		utils.HandleDBError(c, err, "doctors.fetch_time_off_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Time off fetched successfully", blocks, pagination.Meta(total))
+}
+
+// CreateTimeOff handles adding a time-off block to a doctor's calendar.
+// Only the doctor themselves or an admin may add one. See saveTimeOff for the conflict rules.
+func (h *DoctorProfileHandler) CreateTimeOff(c *gin.Context) {
+	var req TimeOffRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	doctor, ok := h.findTimeOffDoctor(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	timeOff := models.TimeOff{DoctorID: doctor.ID, CreatedByID: userID}
+	result, ok := h.saveTimeOff(c, doctor, &timeOff, req)
+	if !ok {
+		return
+	}
+
+	utils.Created(c, "Time off created successfully", result)
+}
+
+// UpdateTimeOff handles moving or re-describing one of a doctor's time-off blocks.
+// Only the doctor themselves or an admin may change it. See saveTimeOff for the conflict rules.
+func (h *DoctorProfileHandler) UpdateTimeOff(c *gin.Context) {
+	var req TimeOffRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	doctor, ok := h.findTimeOffDoctor(c)
+	if !ok {
+		return
+	}
+	timeOff, ok := h.findTimeOff(c, doctor.ID)
+	if !ok {
+		return
+	}
+
+	result, ok := h.saveTimeOff(c, doctor, &timeOff, req)
+	if !ok {
+		return
+	}
+
+	utils.Success(c, "Time off updated successfully", result)
+}
+
+// DeleteTimeOff handles removing one of a doctor's time-off blocks. Appointments cancelled
+// when the block was forced in stay cancelled.
+func (h *DoctorProfileHandler) DeleteTimeOff(c *gin.Context) {
+	doctor, ok := h.findTimeOffDoctor(c)
+	if !ok {
+		return
+	}
+	timeOff, ok := h.findTimeOff(c, doctor.ID)
+	if !ok {
+		return
+	}
+
+	if err := reqDB(h.DB, c).Delete(&timeOff).Error; err != nil {
+		utils.HandleDBError(c, err, "doctors.delete_time_off_failed")
+		return
+	}
+
+	utils.Success(c, "Time off deleted successfully", nil)
+}
+
+// saveTimeOff validates req and stores it on timeOff. The block must end after it starts and must not
+// overlap the doctor's other blocks. If it covers appointments that are still going to happen, the
+// conflicts are returned with a 409 unless the request has force=true, in which case those appointments
+// are cancelled and their patients messaged in the same transaction. It writes error responses itself.
+func (h *DoctorProfileHandler) saveTimeOff(c *gin.Context, doctor models.User, timeOff *models.TimeOff, req TimeOffRequest) (TimeOffResult, bool) {
+	force := false
+	if forceStr := c.Query("force"); forceStr != "" {
+		var err error
+		if force, err = strconv.ParseBool(forceStr); err != nil {
+			utils.BadRequest(c, "doctors.invalid_force_flag")
+			return TimeOffResult{}, false
+		}
+	}
+
+	if !req.EndTime.After(req.StartTime) {
+		utils.BadRequest(c, "doctors.invalid_time_off_range")
+		return TimeOffResult{}, false
+	}
+
+	overlapping, err := scheduling.OverlappingTimeOff(reqDB(h.DB, c), doctor.ID, req.StartTime, req.EndTime, timeOff.ID)
+	if err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return TimeOffResult{}, false
+	}
+	if len(overlapping) > 0 {
+		utils.Conflict(c, "doctors.overlapping_time_off")
+		return TimeOffResult{}, false
+	}
+
+	conflicts, err := scheduling.ConflictingAppointments(reqDB(h.DB, c), doctor.ID, req.StartTime, req.EndTime, "")
+	if err != nil {
+		utils.HandleDBError(c, err, "common.database_error")
+		return TimeOffResult{}, false
+	}
+	if len(conflicts) > 0 && !force {
+		utils.ErrorWithData(c, http.StatusConflict, "doctors.time_off_conflicts", gin.H{
+			"conflicts": dto.NewAppointmentResponses(conflicts),
+		})
+		return TimeOffResult{}, false
+	}
+
+	timeOff.StartTime = req.StartTime
+	timeOff.EndTime = req.EndTime
+	timeOff.Reason = strings.TrimSpace(req.Reason)
+
+	err = reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(timeOff).Error; err != nil {
+			return err
+		}
+		for i := range conflicts {
+			conflicts[i].Status = models.StatusCancelled
+			if err := tx.Model(&conflicts[i]).Update("status", models.StatusCancelled).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(timeOffCancellationMessage(doctor, conflicts[i])).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "doctors.save_time_off_failed")
+		return TimeOffResult{}, false
+	}
+	for _, appointment := range conflicts {
+		h.Webhooks.Publish(reqDB(h.DB, c), models.WebhookAppointmentCancelled, webhooks.NewAppointmentData(appointment))
+	}
+
+	return TimeOffResult{
+		TimeOff:               *timeOff,
+		CancelledAppointments: dto.NewAppointmentResponses(conflicts),
+	}, true
+}
+
+// timeOffCancellationMessage is the message telling a patient their appointment was cancelled by the doctor's time off.
+func timeOffCancellationMessage(doctor models.User, appointment models.Appointment) *models.Message {
+	return &models.Message{
+		SenderID:   doctor.ID,
+		ReceiverID: appointment.PatientID,
+		Subject:    "Your appointment has been cancelled",
+		Content: fmt.Sprintf("Dr. %s %s is unavailable on %s, so your appointment has been cancelled. Please book another time.",
+			doctor.FirstName, doctor.LastName, appointment.StartTime.UTC().Format("2006-01-02 15:04 MST")),
+		Status: models.MessageStatusSent,
+	}
+}
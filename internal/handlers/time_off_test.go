@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/handlers"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCreateTimeOffForceCancelsConflictingAppointments(t *testing.T) {
+	api := newTestAPI(t)
+	doctor := api.createUser(t, models.RoleDoctor)
+	patient := api.createUser(t, models.RolePatient)
+	start := time.Now().UTC().Add(7 * 24 * time.Hour).Truncate(time.Hour)
+
+	confirmed := models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start.Add(time.Hour), EndTime: start.Add(90 * time.Minute), Status: models.StatusConfirmed}
+	completed := models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start.Add(2 * time.Hour), EndTime: start.Add(150 * time.Minute), Status: models.StatusCompleted}
+	outside := models.Appointment{PatientID: patient.ID, DoctorID: doctor.ID, StartTime: start.Add(48 * time.Hour), EndTime: start.Add(48*time.Hour + 30*time.Minute), Status: models.StatusConfirmed}
+	for _, appointment := range []*models.Appointment{&confirmed, &completed, &outside} {
+		api.create(t, appointment)
+	}
+
+	path := "/api/v1/doctors/" + doctor.ID + "/time-off"
+	body := map[string]interface{}{"startTime": start, "endTime": start.Add(8 * time.Hour), "reason": "Conference"}
+
+	// Without force the conflicts come back and nothing changes
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, path, body, api.auth(t, doctor))
+	if recorder.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusConflict, recorder.Body.String())
+	}
+	var conflicts struct {
+		Conflicts []struct {
+			ID string `json:"id"`
+		} `json:"conflicts"`
+	}
+	decodeData(t, recorder, &conflicts)
+	if len(conflicts.Conflicts) != 1 || conflicts.Conflicts[0].ID != confirmed.ID {
+		t.Fatalf("conflicts = %+v, want only %s", conflicts.Conflicts, confirmed.ID)
+	}
+	var count int64
+	api.db.Model(&models.TimeOff{}).Where("doctor_id = ?", doctor.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("%d time-off blocks saved without force", count)
+	}
+
+	// With force the block is saved and the conflicting appointment cancelled
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPost, path+"?force=true", body, api.auth(t, doctor))
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("forced status = %d, want %d: %s", recorder.Code, http.StatusCreated, recorder.Body.String())
+	}
+	var result handlers.TimeOffResult
+	decodeData(t, recorder, &result)
+	if result.TimeOff.ID == "" || result.TimeOff.Reason != "Conference" {
+		t.Errorf("time off = %+v", result.TimeOff)
+	}
+	if len(result.CancelledAppointments) != 1 || result.CancelledAppointments[0].ID != confirmed.ID {
+		t.Fatalf("cancelled = %+v, want only %s", result.CancelledAppointments, confirmed.ID)
+	}
+
+	wantStatus := map[string]models.AppointmentStatus{
+		confirmed.ID: models.StatusCancelled,
+		completed.ID: models.StatusCompleted,
+		outside.ID:   models.StatusConfirmed,
+	}
+	for id, want := range wantStatus {
+		var stored models.Appointment
+		if err := api.db.First(&stored, "id = ?", id).Error; err != nil {
+			t.Fatalf("reloading appointment %s: %v", id, err)
+		}
+		if stored.Status != want {
+			t.Errorf("appointment %s status = %s, want %s", id, stored.Status, want)
+		}
+	}
+
+	// The patient is told about the cancellation
+	var messages []models.Message
+	if err := api.db.Where("sender_id = ? AND receiver_id = ?", doctor.ID, patient.ID).Find(&messages).Error; err != nil {
+		t.Fatalf("loading messages: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Errorf("%d cancellation messages sent, want 1", len(messages))
+	}
+
+	// The block now stops new bookings
+	recorder = testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/appointments", map[string]interface{}{
+		"doctorId":  doctor.ID,
+		"patientId": patient.ID,
+		"startTime": start.Add(3 * time.Hour),
+		"reason":    "Checkup",
+	}, api.auth(t, patient))
+	if recorder.Code != http.StatusConflict {
+		t.Errorf("booking inside time off status = %d, want %d: %s", recorder.Code, http.StatusConflict, recorder.Body.String())
+	}
+}
+
+func TestCreateTimeOffRejectsInvalidForceFlag(t *testing.T) {
+	api := newTestAPI(t)
+	doctor := api.createUser(t, models.RoleDoctor)
+	start := time.Now().UTC().Add(7 * 24 * time.Hour).Truncate(time.Hour)
+
+	recorder := testutil.PerformRequest(t, api.router, http.MethodPost, "/api/v1/doctors/"+doctor.ID+"/time-off?force=maybe",
+		map[string]interface{}{"startTime": start, "endTime": start.Add(time.Hour)}, api.auth(t, doctor))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusBadRequest, recorder.Body.String())
+	}
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/mailer"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outcomes reported for each row of a bulk user import.
+const (
+	importStatusCreated    = "created"
+	importStatusDuplicate  = "skipped_duplicate"
+	importStatusInvalid    = "invalid"
+	importStatusFailed     = "failed"
+	importStatusNotCreated = "not_created" // Valid row of an atomic import that was rejected as a whole
+)
+
+// ImportUserRow is one user of a bulk import, given as a JSON object or a CSV line.
+type ImportUserRow struct {
+	FirstName   string `json:"firstName" binding:"required,max=100"`
+	LastName    string `json:"lastName" binding:"required,max=100"`
+	Email       string `json:"email" binding:"required,email,max=255"`
+	Role        string `json:"role" binding:"required,oneof=patient doctor"`
+	PhoneNumber string `json:"phone" binding:"omitempty,max=30"`
+	DateOfBirth string `json:"dob" binding:"omitempty,datetime=2006-01-02"`
+}
+
+// ImportRowResult reports what happened to one row. Row is the 1-based position in the batch, not counting a CSV header.
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	UserID string `json:"userId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportUsersResult summarizes a bulk import.
+type ImportUsersResult struct {
+	Atomic  bool              `json:"atomic"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Invalid int               `json:"invalid"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// count tallies the row outcomes into the summary fields.
+func (r *ImportUsersResult) count() {
+	for _, row := range r.Rows {
+		switch row.Status {
+		case importStatusCreated:
+			r.Created++
+		case importStatusDuplicate:
+			r.Skipped++
+		case importStatusInvalid:
+			r.Invalid++
+		case importStatusFailed:
+			r.Failed++
+		}
+	}
+}
+
+// importCSVColumns are the accepted CSV header names, matched case-insensitively.
+var importCSVColumns = []string{"firstName", "lastName", "email", "role", "phone", "dob"}
+
+// invitation is the email sent to an imported user so they can choose a password.
+type invitation struct {
+	email     string
+	firstName string
+	token     string
+}
+
+// ImportUsers handles creating many patient and doctor accounts at once (admin).
+// The body is a JSON array of ImportUserRow, or CSV with a header row when sent as text/csv.
+// Every row is validated and reported on its own; existing emails are skipped. With atomic=true
+// nothing is created unless every row is valid. Created users get a random password and are
+// emailed a link to set their own. Super admins choose the users' organization with organizationId.
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	atomic := false
+	if atomicStr := c.Query("atomic"); atomicStr != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(atomicStr); err != nil {
+			utils.BadRequest(c, "users.invalid_atomic_flag")
+			return
+		}
+	}
+
+	if organizationIDStr := c.Query("organizationId"); organizationIDStr != "" {
+		if _, err := uuid.Parse(organizationIDStr); err != nil {
+			utils.BadRequest(c, "organizations.invalid_id")
+			return
+		}
+	}
+	organizationID, ok := assignableOrganization(c, reqDB(h.DB, c), h.Cfg, c.Query("organizationId"), true)
+	if !ok {
+		return
+	}
+
+	rows, ok := parseImportRows(c)
+	if !ok {
+		return
+	}
+	if len(rows) == 0 {
+		utils.BadRequest(c, "users.import_empty")
+		return
+	}
+	if len(rows) > h.Cfg.MaxImportRows {
+		utils.BadRequest(c, "users.import_too_many_rows", utils.Params{"max": strconv.Itoa(h.Cfg.MaxImportRows)})
+		return
+	}
+
+	result := ImportUsersResult{Atomic: atomic, Rows: make([]ImportRowResult, len(rows))}
+	users := make([]*models.User, len(rows)) // nil for rows that are not created
+	tokens := make([]string, len(rows))
+	seen := make(map[string]bool, len(rows))
+	emails := make([]string, 0, len(rows))
+
+	for i := range rows {
+		row := &rows[i]
+		row.FirstName = strings.TrimSpace(row.FirstName)
+		row.LastName = strings.TrimSpace(row.LastName)
+		row.Email = strings.ToLower(strings.TrimSpace(row.Email))
+		row.Role = strings.ToLower(strings.TrimSpace(row.Role))
+		row.PhoneNumber = strings.TrimSpace(row.PhoneNumber)
+		row.DateOfBirth = strings.TrimSpace(row.DateOfBirth)
+
+		result.Rows[i] = ImportRowResult{Row: i + 1, Email: row.Email}
+		if err := binding.Validator.ValidateStruct(row); err != nil {
+			result.Rows[i].Status = importStatusInvalid
+			result.Rows[i].Error = utils.FormatValidationError(c, err)
+			continue
+		}
+		if seen[row.Email] {
+			result.Rows[i].Status = importStatusDuplicate
+			continue
+		}
+		seen[row.Email] = true
+		emails = append(emails, row.Email)
+	}
+
+	registered := make(map[string]bool)
+	if len(emails) > 0 {
+		var existing []string
+		if err := acrossOrganizations(h.DB, c).Model(&models.User{}).Where("email IN ?", emails).Pluck("email", &existing).Error; err != nil {
+			utils.HandleDBError(c, err, "users.import_failed")
+			return
+		}
+		for _, email := range existing {
+			registered[strings.ToLower(email)] = true
+		}
+	}
+
+	invitationTTL := time.Duration(h.Cfg.InvitationExpiryHours) * time.Hour
+	for i, row := range rows {
+		if result.Rows[i].Status != "" {
+			continue
+		}
+		if registered[row.Email] {
+			result.Rows[i].Status = importStatusDuplicate
+			continue
+		}
+
+		user := &models.User{
+			FirstName:   row.FirstName,
+			LastName:    row.LastName,
+			Email:       row.Email,
+			Role:        models.Role(row.Role),
+			PhoneNumber: row.PhoneNumber,
+			// Left nil for the imports of other admins, whom the scoped create puts in the admin's organization
+			OrganizationID: organizationID,
+		}
+		if row.DateOfBirth != "" {
+			dob, _ := time.Parse("2006-01-02", row.DateOfBirth) // Format checked by the datetime rule
+			user.DateOfBirth = &dob
+		}
+		if err := user.SetRandomPassword(); err != nil {
+			utils.InternalServerErrorWithDetail(c, "auth.password_hash_failed", err)
+			return
+		}
+		token, err := issuePasswordResetToken(user, invitationTTL)
+		if err != nil {
+			utils.InternalServerErrorWithDetail(c, "users.import_failed", err)
+			return
+		}
+		users[i], tokens[i] = user, token
+	}
+
+	if atomic {
+		if !h.createImportAtomically(c, &result, users) {
+			return
+		}
+	} else {
+		h.createImportRows(c, &result, users)
+	}
+
+	var invitations []invitation
+	importedDoctor := false
+	for i, user := range users {
+		if user != nil && result.Rows[i].Status == importStatusCreated {
+			invitations = append(invitations, invitation{email: user.Email, firstName: user.FirstName, token: tokens[i]})
+			importedDoctor = importedDoctor || user.Role == models.RoleDoctor
+		}
+	}
+	if importedDoctor {
+		invalidateDoctorCache(c, h.Doctors)
+	}
+	if len(invitations) > 0 {
+		go sendInvitations(h.Cfg, invitations)
+	}
+
+	result.count()
+	utils.Success(c, "Users imported successfully", result)
+}
+
+// createImportAtomically creates all users in one transaction, or none if any row is invalid.
+// It writes the error response itself and returns false when nothing was created.
+func (h *UserHandler) createImportAtomically(c *gin.Context, result *ImportUsersResult, users []*models.User) bool {
+	rejected := false
+	for _, row := range result.Rows {
+		if row.Status == importStatusInvalid {
+			rejected = true
+		}
+	}
+	if rejected {
+		for i, user := range users {
+			if user != nil {
+				result.Rows[i].Status = importStatusNotCreated
+			}
+		}
+		result.count()
+		utils.ErrorWithData(c, http.StatusUnprocessableEntity, "users.import_rejected", result)
+		return false
+	}
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		for _, user := range users {
+			if user == nil {
+				continue
+			}
+			if err := tx.Create(user).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "users.import_failed")
+		return false
+	}
+
+	for i, user := range users {
+		if user != nil {
+			result.Rows[i].Status = importStatusCreated
+			result.Rows[i].UserID = user.ID
+		}
+	}
+	return true
+}
+
+// createImportRows creates each user on its own, so one failing row does not affect the others.
+func (h *UserHandler) createImportRows(c *gin.Context, result *ImportUsersResult, users []*models.User) {
+	for i, user := range users {
+		if user == nil {
+			continue
+		}
+		if err := reqDB(h.DB, c).Create(user).Error; err != nil {
+			if utils.IsDuplicateKeyError(err) {
+				// Registered by someone else since the duplicate check
+				result.Rows[i].Status = importStatusDuplicate
+			} else {
+				log.Printf("Failed to import user %s: %v", user.Email, err)
+				result.Rows[i].Status = importStatusFailed
+				result.Rows[i].Error = utils.T(c, "users.create_failed")
+			}
+			continue
+		}
+		result.Rows[i].Status = importStatusCreated
+		result.Rows[i].UserID = user.ID
+	}
+}
+
+// parseImportRows reads the import batch from a JSON array or, for text/csv, from CSV with a header row.
+// It writes the error response itself and returns false if the body cannot be read.
+func parseImportRows(c *gin.Context) ([]ImportUserRow, bool) {
+	if c.ContentType() != "text/csv" {
+		// Decoded without gin's binding so one invalid row does not reject the whole batch
+		var rows []ImportUserRow
+		if err := json.NewDecoder(c.Request.Body).Decode(&rows); err != nil {
+			utils.BindError(c, err)
+			return nil, false
+		}
+		return rows, true
+	}
+
+	reader := csv.NewReader(c.Request.Body)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, true
+	}
+	if err != nil {
+		utils.BindError(c, err)
+		return nil, false
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		for _, column := range importCSVColumns {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				columns[column] = i
+			}
+		}
+	}
+	for _, required := range []string{"firstName", "lastName", "email", "role"} {
+		if _, ok := columns[required]; !ok {
+			utils.BadRequest(c, "users.import_missing_column", utils.Params{"column": required})
+			return nil, false
+		}
+	}
+
+	reader.FieldsPerRecord = len(header)
+	var rows []ImportUserRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			utils.BindError(c, err)
+			return nil, false
+		}
+		field := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return record[i]
+			}
+			return ""
+		}
+		rows = append(rows, ImportUserRow{
+			FirstName:   field("firstName"),
+			LastName:    field("lastName"),
+			Email:       field("email"),
+			Role:        field("role"),
+			PhoneNumber: field("phone"),
+			DateOfBirth: field("dob"),
+		})
+	}
+	return rows, true
+}
+
+// sendInvitations emails each imported user a link to choose their password. Failures are only logged.
+func sendInvitations(cfg *config.Config, invitations []invitation) {
+	m := mailer.New(cfg.Mailer)
+	for _, invite := range invitations {
+		body := fmt.Sprintf("Hello %s,\n\nAn account has been created for you. Choose your password here:\n%s\n\nThe link expires in %d hours.\n",
+			invite.firstName, passwordSetLink(cfg, invite.token), cfg.InvitationExpiryHours)
+		if err := m.Send(invite.email, "Your account has been created", body); err != nil {
+			log.Printf("Failed to send invitation to %s: %v", invite.email, err)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"healthcare-app-server/internal/cache"
+	"healthcare-app-server/internal/config"
 	"healthcare-app-server/internal/middleware"
 	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
 	"healthcare-app-server/internal/utils"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -12,21 +17,25 @@ import (
 
 // UserHandler handles user-related requests (typically admin operations).
 type UserHandler struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Cfg     *config.Config
+	Doctors *cache.Loader // Cached doctor listings and profiles, invalidated when a doctor changes
 }
 
 // NewUserHandler creates a new UserHandler.
-func NewUserHandler(db *gorm.DB) *UserHandler {
-	return &UserHandler{DB: db}
+func NewUserHandler(db *gorm.DB, cfg *config.Config, doctors *cache.Loader) *UserHandler {
+	return &UserHandler{DB: db, Cfg: cfg, Doctors: doctors}
 }
 
 // CreateUserRequest represents the request body for creating a user by an admin.
 type CreateUserRequest struct {
-	FirstName string `json:"firstName" binding:"required"`
-	LastName  string `json:"lastName" binding:"required"`
-	Email     string `json:"email" binding:"required,email"`
+	FirstName string `json:"firstName" binding:"required,max=100"`
+	LastName  string `json:"lastName" binding:"required,max=100"`
+	Email     string `json:"email" binding:"required,email,max=255"`
 	Password  string `json:"password" binding:"required,min=8"`
-	Role      string `json:"role" binding:"required,oneof=PATIENT DOCTOR ADMIN"`
+	Role      string `json:"role" binding:"required,role"`
+	// Organization to create the user in; super admins only, other admins create users in their own
+	OrganizationID string `json:"organizationId" binding:"omitempty,uuid"`
 }
 
 // CreateUser handles creating a new user (admin).
@@ -36,12 +45,17 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	organizationID, ok := assignableOrganization(c, reqDB(h.DB, c), h.Cfg, req.OrganizationID, true)
+	if !ok {
+		return
+	}
+
 	var existingUser models.User
-	if err := h.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		utils.BadRequest(c, "User with this email already exists")
+	if err := acrossOrganizations(h.DB, c).Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
+		utils.BadRequest(c, "users.email_taken")
 		return
 	} else if err != gorm.ErrRecordNotFound {
-		utils.InternalServerError(c, "Database error: "+err.Error())
+		utils.HandleDBError(c, err, "common.database_error")
 		return
 	}
 
@@ -49,26 +63,113 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Email:     req.Email,
-		Role:      models.Role(req.Role),
+		Role:      models.Role(req.Role).Normalize(),
+		// Left nil for the users of other admins, whom the scoped create puts in the admin's organization
+		OrganizationID: organizationID,
 	}
 	if err := user.SetPassword(req.Password); err != nil {
-		utils.InternalServerError(c, "Failed to hash password: "+err.Error())
+		utils.InternalServerErrorWithDetail(c, "auth.password_hash_failed", err)
 		return
 	}
 
-	if err := h.DB.Create(&user).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create user: "+err.Error())
+	if err := reqDB(h.DB, c).Create(&user).Error; err != nil {
+		utils.HandleDBError(c, err, "users.create_failed")
 		return
 	}
+	if user.Role == models.RoleDoctor {
+		invalidateDoctorCache(c, h.Doctors)
+	}
 
 	utils.Created(c, "User created successfully", user.Sanitize())
 }
 
-// GetUsers handles fetching all users (admin).
+// userSortColumns maps the accepted `sort` query values to database columns.
+var userSortColumns = map[string]string{
+	"createdAt": "created_at",
+	"lastName":  "last_name",
+	"email":     "email",
+}
+
+// GetUsers handles fetching users with optional filters, sorting and pagination (admin).
+// Supported query parameters: q, role, isVerified, createdAfter, createdBefore, sort, order, page, limit.
 func (h *UserHandler) GetUsers(c *gin.Context) {
+	query := reqDB(h.DB, c).Model(&models.User{})
+
+	// Free-text search uses a prefix match so the indexes on email, first_name and last_name can be used.
+	// MySQL's default utf8mb4 collation makes LIKE case-insensitive.
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := escapeLike(q) + "%"
+		query = query.Where("email LIKE ? OR first_name LIKE ? OR last_name LIKE ?", pattern, pattern, pattern)
+	}
+
+	if role := c.Query("role"); role != "" {
+		normalizedRole := models.Role(role).Normalize()
+		if !normalizedRole.IsValid() {
+			utils.BadRequest(c, "users.invalid_role_filter", utils.Params{"role": role})
+			return
+		}
+		query = query.Where("role = ?", normalizedRole)
+	}
+
+	if isVerifiedStr := c.Query("isVerified"); isVerifiedStr != "" {
+		isVerified, err := strconv.ParseBool(isVerifiedStr)
+		if err != nil {
+			utils.BadRequest(c, "users.invalid_is_verified")
+			return
+		}
+		query = query.Where("is_verified = ?", isVerified)
+	}
+
+	if createdAfterStr := c.Query("createdAfter"); createdAfterStr != "" {
+		createdAfter, err := parseDateParam(createdAfterStr)
+		if err != nil {
+			utils.BadRequest(c, "users.invalid_created_after")
+			return
+		}
+		query = query.Where("created_at >= ?", createdAfter)
+	}
+
+	if createdBeforeStr := c.Query("createdBefore"); createdBeforeStr != "" {
+		createdBefore, err := parseDateParam(createdBeforeStr)
+		if err != nil {
+			utils.BadRequest(c, "users.invalid_created_before")
+			return
+		}
+		query = query.Where("created_at < ?", createdBefore)
+	}
+
+	sortColumn := "created_at"
+	if sortParam := c.Query("sort"); sortParam != "" {
+		column, ok := userSortColumns[sortParam]
+		if !ok {
+			utils.BadRequest(c, "users.invalid_sort")
+			return
+		}
+		sortColumn = column
+	}
+	sortOrder := "desc"
+	if orderParam := strings.ToLower(c.Query("order")); orderParam != "" {
+		if orderParam != "asc" && orderParam != "desc" {
+			utils.BadRequest(c, "users.invalid_order")
+			return
+		}
+		sortOrder = orderParam
+	}
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "users.fetch_failed")
+		return
+	}
+
 	var users []models.User
-	if err := h.DB.Find(&users).Error; err != nil {
-		utils.InternalServerError(c, "Failed to fetch users: "+err.Error())
+	if err := query.Order(sortColumn + " " + sortOrder).Offset(pagination.Offset).Limit(pagination.Limit).Find(&users).Error; err != nil {
+		utils.HandleDBError(c, err, "users.fetch_failed")
 		return
 	}
 
@@ -77,7 +178,20 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 		sanitizedUsers[i] = u.Sanitize()
 	}
 
-	utils.Success(c, "Users fetched successfully", sanitizedUsers)
+	utils.SuccessWithMeta(c, "Users fetched successfully", sanitizedUsers, pagination.Meta(total))
+}
+
+// escapeLike escapes the LIKE wildcard characters in user-supplied search input.
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// parseDateParam parses a query parameter given either as RFC3339 or as a plain YYYY-MM-DD date.
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
 }
 
 // GetUserByID handles fetching a single user by ID (admin).
@@ -85,11 +199,11 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 	userID := c.Param("id")
 
 	var user models.User
-	if err := h.DB.First(&user, "id = ?", userID).Error; err != nil {
+	if err := reqDB(h.DB, c).First(&user, "id = ?", userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "User not found")
+			utils.NotFound(c, "common.user_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
@@ -97,11 +211,20 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 }
 
 // UpdateUserRequest represents the request body for updating a user by an admin.
+// Only the fields present in the body are changed.
 type UpdateUserRequest struct {
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
-	Email     string `json:"email,omitempty"` // Allow email update, ensure uniqueness
-	Role      string `json:"role,omitempty,oneof=PATIENT DOCTOR ADMIN"`
+	FirstName *string `json:"firstName" binding:"omitempty,min=1,max=100"`
+	LastName  *string `json:"lastName" binding:"omitempty,min=1,max=100"`
+	Email     *string `json:"email" binding:"omitempty,email,max=255"` // Must not belong to another user
+	Role      *string `json:"role" binding:"omitempty,role"`
+	// Stored in E.164 form, e.g. +48123456789; "" clears it, as it does the other optional fields
+	PhoneNumber *string `json:"phoneNumber" binding:"omitempty,phone"`
+	Address     *string `json:"address" binding:"omitempty,max=255"`
+	// YYYY-MM-DD, in the past and at least MINIMUM_AGE_YEARS ago
+	DateOfBirth  *string `json:"dateOfBirth" binding:"omitempty,birthdate"`
+	ProfileImage *string `json:"profileImage" binding:"omitempty,max=500,http_url_or_empty"`
+	// Organization to move the user to; super admins only. Their rows without an organization follow them
+	OrganizationID *string `json:"organizationId" binding:"omitempty,uuid"`
 	// Password should be updated via a separate "change password" endpoint for security
 }
 
@@ -111,42 +234,62 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 	var req UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil { // Use ShouldBindJSON for partial updates
-		utils.BadRequest(c, "Invalid request payload: "+err.Error())
+		utils.BindError(c, err)
 		return
 	}
 
 	var user models.User
-	if err := h.DB.First(&user, "id = ?", userID).Error; err != nil {
-		utils.NotFound(c, "User not found")
+	if err := reqDB(h.DB, c).First(&user, "id = ?", userID).Error; err != nil {
+		utils.NotFound(c, "common.user_not_found")
 		return
 	}
 
-	if req.FirstName != "" {
-		user.FirstName = req.FirstName
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
 	}
-	if req.LastName != "" {
-		user.LastName = req.LastName
+	if req.LastName != nil {
+		user.LastName = *req.LastName
 	}
-	if req.Email != "" && req.Email != user.Email {
+	applyProfileFields(&user, req.PhoneNumber, req.Address, req.DateOfBirth, req.ProfileImage)
+	if req.Email != nil && *req.Email != user.Email {
 		// Check if new email is already taken
 		var existingUser models.User
-		if err := h.DB.Where("email = ? AND id != ?", req.Email, user.ID).First(&existingUser).Error; err == nil {
-			utils.BadRequest(c, "New email is already in use")
+		if err := acrossOrganizations(h.DB, c).Where("email = ? AND id != ?", *req.Email, user.ID).First(&existingUser).Error; err == nil {
+			utils.BadRequest(c, "users.new_email_taken")
 			return
 		} else if err != gorm.ErrRecordNotFound {
-			utils.InternalServerError(c, "Database error checking email: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 			return
 		}
-		user.Email = req.Email
+		user.Email = *req.Email
 	}
-	if req.Role != "" {
-		user.Role = models.Role(req.Role)
+	wasDoctor := user.Role == models.RoleDoctor
+	if req.Role != nil {
+		user.Role = models.Role(*req.Role).Normalize()
+	}
+	movedOrganization := false
+	if req.OrganizationID != nil {
+		organizationID, ok := assignableOrganization(c, reqDB(h.DB, c), h.Cfg, *req.OrganizationID, false)
+		if !ok {
+			return
+		}
+		if organizationID != nil && (user.OrganizationID == nil || *user.OrganizationID != *organizationID) {
+			user.OrganizationID = organizationID
+			movedOrganization = true
+		}
 	}
 
-	if err := h.DB.Save(&user).Error; err != nil {
-		utils.InternalServerError(c, "Failed to update user: "+err.Error())
+	if err := reqDB(h.DB, c).Save(&user).Error; err != nil {
+		utils.HandleDBError(c, err, "users.update_failed")
 		return
 	}
+	if wasDoctor || user.Role == models.RoleDoctor || movedOrganization {
+		invalidateDoctorCache(c, h.Doctors)
+	}
+	if movedOrganization {
+		// Only rows without an organization follow the user; rows already in another one stay there
+		backfillOrganizations(h.DB)
+	}
 
 	utils.Success(c, "User updated successfully", user.Sanitize())
 }
@@ -157,75 +300,278 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 	// Optional: Check if user exists before attempting delete
 	var user models.User
-	if err := h.DB.First(&user, "id = ?", userID).Error; err != nil {
+	if err := reqDB(h.DB, c).First(&user, "id = ?", userID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "User not found")
+			utils.NotFound(c, "common.user_not_found")
 		} else {
-			utils.InternalServerError(c, "Database error: "+err.Error())
+			utils.HandleDBError(c, err, "common.database_error")
 		}
 		return
 	}
 
 	// Consider soft delete or handling related records (e.g., appointments)
-	if err := h.DB.Delete(&models.User{}, "id = ?", userID).Error; err != nil {
-		utils.InternalServerError(c, "Failed to delete user: "+err.Error())
+	if err := reqDB(h.DB, c).Delete(&models.User{}, "id = ?", userID).Error; err != nil {
+		utils.HandleDBError(c, err, "users.delete_failed")
 		return
 	}
+	if user.Role == models.RoleDoctor {
+		invalidateDoctorCache(c, h.Doctors)
+	}
 
 	utils.Success(c, "User deleted successfully", nil)
 }
 
-// GetDoctors handles fetching all users with the doctor role.
+// DoctorListItem is a sanitized doctor together with their aggregate review rating.
+type DoctorListItem struct {
+	models.UserSanitized
+	AverageRating float64 `json:"averageRating"`
+	ReviewCount   int64   `json:"reviewCount"`
+}
+
+// defaultOpeningMinutes is the free time a doctor needs on the day for ?availableOn when no duration is given.
+const defaultOpeningMinutes = 30
+
+// GetDoctors handles fetching a page of users with the doctor role.
 // This endpoint will be accessible to patients for booking appointments.
+// Pages are cached for DOCTOR_CACHE_TTL_SECONDS and dropped whenever a doctor or their reviews change.
+// `availableOn` (YYYY-MM-DD) keeps only doctors with a free stretch of `duration` minutes (default 30)
+// on that UTC day, see scheduling.DoctorsWithOpenings; those pages follow bookings and are never cached.
 func (h *UserHandler) GetDoctors(c *gin.Context) {
-	var doctors []models.User
-	if err := h.DB.Where("role = ?", models.RoleDoctor).Find(&doctors).Error; err != nil {
-		utils.InternalServerError(c, "Failed to fetch doctors: "+err.Error())
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
 		return
 	}
 
-	sanitizedDoctors := make([]models.UserSanitized, len(doctors))
+	if availableOn := c.Query("availableOn"); availableOn != "" {
+		day, err := time.Parse("2006-01-02", availableOn)
+		if err != nil {
+			utils.BadRequest(c, "users.invalid_available_on")
+			return
+		}
+		minutes := defaultOpeningMinutes
+		if durationStr := c.Query("duration"); durationStr != "" {
+			minutes, err = strconv.Atoi(durationStr)
+			if err != nil || minutes < 5 || minutes > 480 {
+				utils.BadRequest(c, "users.invalid_opening_duration")
+				return
+			}
+		}
+
+		doctorIDs, err := scheduling.DoctorsWithOpenings(reqDB(h.DB, c), day, time.Duration(minutes)*time.Minute, time.Now().UTC())
+		if err != nil {
+			utils.HandleDBError(c, err, "users.fetch_doctors_failed")
+			return
+		}
+		page, err := loadDoctorListPage(reqDB(h.DB, c), pagination, doctorIDs)
+		if err != nil {
+			utils.HandleDBError(c, err, "users.fetch_doctors_failed")
+			return
+		}
+		utils.SuccessWithMeta(c, "Doctors fetched successfully", page.Items, pagination.Meta(page.Total))
+		return
+	}
+
+	var page doctorListPage
+	err := h.Doctors.Load(c.Request.Context(), doctorListCacheKey(c, pagination), &page, func() (interface{}, error) {
+		return loadDoctorListPage(reqDB(h.DB, c), pagination, nil)
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "users.fetch_doctors_failed")
+		return
+	}
+
+	utils.SuccessWithMeta(c, "Doctors fetched successfully", page.Items, pagination.Meta(page.Total))
+}
+
+// loadDoctorListPage reads a page of doctors, sanitized and with their review ratings, from the database.
+// A non-nil onlyIDs restricts the page to those doctors.
+func loadDoctorListPage(db *gorm.DB, pagination utils.Pagination, onlyIDs []string) (doctorListPage, error) {
+	query := db.Model(&models.User{}).Where("role = ?", models.RoleDoctor)
+	if onlyIDs != nil {
+		query = query.Where("id IN ?", onlyIDs)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return doctorListPage{}, err
+	}
+
+	var doctors []models.User
+	if err := query.Order("last_name asc, first_name asc").Offset(pagination.Offset).Limit(pagination.Limit).Find(&doctors).Error; err != nil {
+		return doctorListPage{}, err
+	}
+
+	doctorIDs := make([]string, len(doctors))
 	for i, doctor := range doctors {
-		sanitizedDoctors[i] = doctor.Sanitize()
+		doctorIDs[i] = doctor.ID
 	}
+	ratings, err := doctorRatingSummaries(db, doctorIDs)
+	if err != nil {
+		return doctorListPage{}, err
+	}
+
+	doctorItems := make([]DoctorListItem, len(doctors))
+	for i, doctor := range doctors {
+		rating := ratings[doctor.ID]
+		doctorItems[i] = DoctorListItem{
+			UserSanitized: doctor.Sanitize(),
+			AverageRating: rating.AverageRating,
+			ReviewCount:   rating.ReviewCount,
+		}
+	}
+
+	return doctorListPage{Items: doctorItems, Total: total}, nil
+}
 
-	utils.Success(c, "Doctors fetched successfully", sanitizedDoctors)
+// PatientListItem is a sanitized patient together with the date of their latest interaction with the requesting doctor.
+type PatientListItem struct {
+	models.UserSanitized
+	LastInteractionAt *time.Time `json:"lastInteractionAt"`
 }
 
-// GetDoctorPatients handles fetching all patients.
-// This endpoint is accessible to doctors and admins.
+// interactionRow is a user row joined with the date of their latest interaction with the requesting user.
+type interactionRow struct {
+	models.User
+	LastInteractionAt *time.Time
+}
+
+// GetDoctorPatients handles fetching a page of patients.
+// Doctors only see patients they have an appointment or medical record with, unless they pass
+// `all=true` and DOCTORS_CAN_LIST_ALL_PATIENTS allows it. Admins always see every patient.
+// Supported query parameters: q, all, page, limit.
 func (h *UserHandler) GetDoctorPatients(c *gin.Context) {
-	_, exists := middleware.GetUserIDFromContext(c)
+	userID, exists := middleware.GetUserIDFromContext(c)
 	if !exists {
-		utils.Unauthorized(c, "User not authenticated")
+		utils.Unauthorized(c, "common.unauthenticated")
 		return
 	}
 
 	userRole, _ := middleware.GetUserRoleFromContext(c)
-	userRoleLower := strings.ToLower(string(userRole))
 
 	// Only doctors and admins can access this endpoint
-	if userRoleLower != "doctor" && userRoleLower != "admin" {
-		utils.Forbidden(c, "Only doctors and admins can view patient lists")
+	if userRole != models.RoleDoctor && !userRole.IsAdmin() {
+		utils.Forbidden(c, "users.patient_list_forbidden")
 		return
 	}
 
-	var patients []models.User
-	var err error
+	listAll := userRole.IsAdmin()
+	if allStr := c.Query("all"); allStr != "" && !listAll {
+		all, err := strconv.ParseBool(allStr)
+		if err != nil {
+			utils.BadRequest(c, "users.invalid_all_flag")
+			return
+		}
+		if all && !h.Cfg.DoctorsCanListAllPatients {
+			utils.Forbidden(c, "users.all_patients_forbidden")
+			return
+		}
+		listAll = all
+	}
 
-	// Doctors and Admins should see all patients
-	err = h.DB.Where("role = ?", models.RolePatient).Find(&patients).Error
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
 
-	if err != nil {
-		utils.InternalServerError(c, "Failed to fetch patients: "+err.Error())
+	// Latest past appointment or medical record between each patient and the requesting user.
+	// Future appointments still make someone "my patient" but do not count as an interaction yet.
+	interactions := reqDB(h.DB, c).Raw(`SELECT patient_id, MAX(interaction_at) AS last_interaction_at FROM (
+		SELECT patient_id, CASE WHEN start_time <= ? THEN start_time END AS interaction_at FROM appointments WHERE doctor_id = ?
+		UNION ALL
+		SELECT patient_id, created_at AS interaction_at FROM medical_records WHERE doctor_id = ? AND deleted_at IS NULL
+	) AS doctor_interactions GROUP BY patient_id`, time.Now(), userID, userID)
+
+	query := reqDB(h.DB, c).Model(&models.User{}).
+		Joins("LEFT JOIN (?) AS interactions ON interactions.patient_id = users.id", interactions).
+		Where("users.role = ?", models.RolePatient)
+	if !listAll {
+		query = query.Where("interactions.patient_id IS NOT NULL")
+	}
+
+	// Same prefix match as GetUsers
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := escapeLike(q) + "%"
+		query = query.Where("users.email LIKE ? OR users.first_name LIKE ? OR users.last_name LIKE ?", pattern, pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "users.fetch_patients_failed")
+		return
+	}
+
+	// Most recent interaction first, patients never seen by the requesting user last
+	var rows []interactionRow
+	if err := query.Select("users.*, interactions.last_interaction_at").
+		Order("interactions.last_interaction_at IS NULL, interactions.last_interaction_at desc, users.last_name asc, users.first_name asc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Scan(&rows).Error; err != nil {
+		utils.HandleDBError(c, err, "users.fetch_patients_failed")
 		return
 	}
 
 	// Sanitize patient data before sending
-	sanitizedPatients := make([]models.UserSanitized, len(patients))
-	for i, patient := range patients {
-		sanitizedPatients[i] = patient.Sanitize()
+	patientItems := make([]PatientListItem, len(rows))
+	for i, row := range rows {
+		patientItems[i] = PatientListItem{
+			UserSanitized:     row.User.Sanitize(),
+			LastInteractionAt: row.LastInteractionAt,
+		}
+	}
+
+	utils.SuccessWithMeta(c, "Patients fetched successfully", patientItems, pagination.Meta(total))
+}
+
+// MyDoctorListItem is a sanitized doctor together with the date of their latest interaction with the requesting patient.
+type MyDoctorListItem struct {
+	models.UserSanitized
+	LastInteractionAt *time.Time `json:"lastInteractionAt"`
+}
+
+// GetMyDoctors handles a patient listing the doctors they have an appointment or medical record with,
+// most recent interaction first, for instance to pick who to message. It is the patient's side of
+// GetDoctorPatients, with the same notion of an interaction. Supports page and limit.
+func (h *UserHandler) GetMyDoctors(c *gin.Context) {
+	userID, _ := middleware.GetUserIDFromContext(c)
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	// UNION drops the rows both sides have in common before grouping; future appointments count as no interaction yet
+	interactions := reqDB(h.DB, c).Raw(`SELECT doctor_id, MAX(interaction_at) AS last_interaction_at FROM (
+		SELECT doctor_id, CASE WHEN start_time <= ? THEN start_time END AS interaction_at FROM appointments WHERE patient_id = ?
+		UNION
+		SELECT doctor_id, created_at AS interaction_at FROM medical_records WHERE patient_id = ? AND deleted_at IS NULL
+	) AS patient_interactions GROUP BY doctor_id`, time.Now(), userID, userID)
+
+	query := reqDB(h.DB, c).Model(&models.User{}).
+		Joins("JOIN (?) AS interactions ON interactions.doctor_id = users.id", interactions).
+		Where("users.role = ?", models.RoleDoctor)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "users.fetch_my_doctors_failed")
+		return
+	}
+
+	var rows []interactionRow
+	if err := query.Select("users.*, interactions.last_interaction_at").
+		Order("interactions.last_interaction_at IS NULL, interactions.last_interaction_at desc, users.last_name asc, users.first_name asc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Scan(&rows).Error; err != nil {
+		utils.HandleDBError(c, err, "users.fetch_my_doctors_failed")
+		return
+	}
+
+	doctorItems := make([]MyDoctorListItem, len(rows))
+	for i, row := range rows {
+		doctorItems[i] = MyDoctorListItem{
+			UserSanitized:     row.User.Sanitize(),
+			LastInteractionAt: row.LastInteractionAt,
+		}
 	}
 
-	utils.Success(c, "Patients fetched successfully", sanitizedPatients)
+	utils.SuccessWithMeta(c, "Doctors fetched successfully", doctorItems, pagination.Meta(total))
 }
@@ -0,0 +1,65 @@
+package handlers_test
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateUserPatchSemantics(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+		want       func(user models.User) bool
+	}{
+		{
+			name:       "absent fields are left unchanged",
+			body:       map[string]interface{}{"firstName": "Jan"},
+			wantStatus: http.StatusOK,
+			want: func(user models.User) bool {
+				return user.FirstName == "Jan" && user.Role == models.RoleDoctor && user.Address == "Main St 1"
+			},
+		},
+		{
+			name:       "explicit empty strings clear optional fields",
+			body:       map[string]interface{}{"address": ""},
+			wantStatus: http.StatusOK,
+			want: func(user models.User) bool {
+				return user.Address == "" && user.Role == models.RoleDoctor
+			},
+		},
+		{name: "unknown role", body: map[string]interface{}{"role": "surgeon"}, wantStatus: http.StatusBadRequest},
+		{name: "empty role", body: map[string]interface{}{"role": ""}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t)
+			admin := api.createUser(t, models.RoleAdmin)
+			user := api.createUser(t, models.RoleDoctor)
+			if err := api.db.Model(user).Update("address", "Main St 1").Error; err != nil {
+				t.Fatalf("filling user: %v", err)
+			}
+
+			recorder := testutil.PerformRequest(t, api.router, http.MethodPut, "/api/v1/users/"+user.ID, tt.body, api.auth(t, admin))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+
+			var stored models.User
+			if err := api.db.First(&stored, "id = ?", user.ID).Error; err != nil {
+				t.Fatalf("reloading user: %v", err)
+			}
+			want := tt.want
+			if want == nil {
+				// A rejected update changes nothing
+				want = func(user models.User) bool { return user.Role == models.RoleDoctor && user.Address == "Main St 1" }
+			}
+			if !want(stored) {
+				t.Errorf("stored user = %q, role %q, address %q", stored.FirstName, stored.Role, stored.Address)
+			}
+		})
+	}
+}
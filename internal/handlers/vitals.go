@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxVitalPoints caps how many measurements one GetVitals call returns; the most recent are kept.
+const maxVitalPoints = 1000
+
+// vitalClockSkew is how far in the future a measurement time may be, to allow for client clocks running ahead.
+const vitalClockSkew = 5 * time.Minute
+
+// VitalHandler handles vitals: structured measurements such as blood pressure or weight.
+// They are read under the same rules as medical records; admins may read them too.
+type VitalHandler struct {
+	DB *gorm.DB
+}
+
+// NewVitalHandler creates a new VitalHandler.
+func NewVitalHandler(db *gorm.DB) *VitalHandler {
+	return &VitalHandler{DB: db}
+}
+
+// RecordVitalRequest represents the request body for recording a measurement.
+type RecordVitalRequest struct {
+	Type           models.VitalType `json:"type" binding:"required,vital_type"`
+	Value          float64          `json:"value" binding:"gt=0"`
+	SecondaryValue *float64         `json:"secondaryValue" binding:"omitempty,gt=0"` // Required for blood_pressure (diastolic), rejected otherwise
+	Unit           string           `json:"unit"`                                    // Defaults to the type's first unit
+	RecordedAt     *time.Time       `json:"recordedAt"`                              // Defaults to now
+	Notes          string           `json:"notes" binding:"max=500"`
+}
+
+// VitalSeries is one type's measurements, oldest first, ready to chart.
+type VitalSeries struct {
+	Type   models.VitalType `json:"type"`
+	Points []models.Vital   `json:"points"`
+}
+
+// VitalsResponse is a patient's measurements grouped by type.
+type VitalsResponse struct {
+	Series []VitalSeries `json:"series"`
+	// Set when more than maxVitalPoints measurements matched and only the most recent are returned
+	Truncated bool `json:"truncated"`
+}
+
+// canViewVitals reports whether the requester may read a patient's vitals: the record rules, plus admins.
+func canViewVitals(role models.Role, userID, patientID string) bool {
+	return canViewPatientRecords(role, userID, patientID) || role.IsAdmin()
+}
+
+// RecordVital handles recording a measurement for the patient in the path. Patients record their own;
+// doctors record any patient's, e.g. during a visit.
+func (h *VitalHandler) RecordVital(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patientId"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_patient_id")
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	isSelf := userRole == models.RolePatient && userID == patientID.String()
+	if !isSelf && userRole != models.RoleDoctor {
+		utils.Forbidden(c, "vitals.record_forbidden")
+		return
+	}
+
+	var req RecordVitalRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	units := req.Type.Units()
+	unit := strings.TrimSpace(req.Unit)
+	if unit == "" {
+		unit = units[0]
+	}
+	validUnit := false
+	for _, allowed := range units {
+		if unit == allowed {
+			validUnit = true
+			break
+		}
+	}
+	if !validUnit {
+		utils.BadRequest(c, "vitals.invalid_unit", utils.Params{"type": string(req.Type), "units": strings.Join(units, ", ")})
+		return
+	}
+	if (req.Type == models.VitalBloodPressure) != (req.SecondaryValue != nil) {
+		utils.BadRequest(c, "vitals.secondary_value_blood_pressure_only")
+		return
+	}
+
+	recordedAt := time.Now().UTC()
+	if req.RecordedAt != nil {
+		if req.RecordedAt.After(recordedAt.Add(vitalClockSkew)) {
+			utils.BadRequest(c, "vitals.recorded_in_future")
+			return
+		}
+		recordedAt = req.RecordedAt.UTC()
+	}
+
+	var patient models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", patientID, models.RolePatient).First(&patient).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "common.patient_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	vital := models.Vital{
+		PatientID:      patient.ID,
+		Type:           req.Type,
+		Value:          req.Value,
+		SecondaryValue: req.SecondaryValue,
+		Unit:           unit,
+		RecordedAt:     recordedAt,
+		RecordedByID:   userID,
+		Notes:          utils.SanitizeText(strings.TrimSpace(req.Notes)),
+	}
+	if err := reqDB(h.DB, c).Create(&vital).Error; err != nil {
+		utils.HandleDBError(c, err, "vitals.create_failed")
+		return
+	}
+
+	utils.Created(c, "Vital recorded successfully", vital)
+}
+
+// GetVitals handles fetching a patient's measurements as time series, one per type, optionally limited to
+// ?type= and to ?from= / ?to= (RFC3339 or YYYY-MM-DD). At most maxVitalPoints measurements are returned.
+func (h *VitalHandler) GetVitals(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patientId"))
+	if err != nil {
+		utils.BadRequest(c, "common.invalid_patient_id")
+		return
+	}
+
+	userID, _ := middleware.GetUserIDFromContext(c)
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+	if !canViewVitals(userRole, userID, patientID.String()) {
+		utils.Forbidden(c, "vitals.view_forbidden")
+		return
+	}
+	if !ensureUserInOrganization(c, reqDB(h.DB, c), patientID.String(), "common.patient_not_found") {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.Vital{}).Where("patient_id = ?", patientID)
+	if vitalType := c.Query("type"); vitalType != "" {
+		if !models.VitalType(vitalType).IsValid() {
+			utils.BadRequest(c, "vitals.invalid_type_filter", utils.Params{"type": vitalType})
+			return
+		}
+		query = query.Where("type = ?", vitalType)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from)
+		if err != nil {
+			utils.BadRequest(c, "vitals.invalid_from")
+			return
+		}
+		query = query.Where("recorded_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to)
+		if err != nil {
+			utils.BadRequest(c, "vitals.invalid_to")
+			return
+		}
+		query = query.Where("recorded_at <= ?", toTime)
+	}
+
+	// One extra row tells whether anything was cut off
+	var vitals []models.Vital
+	if err := query.Order("recorded_at desc").Limit(maxVitalPoints + 1).Find(&vitals).Error; err != nil {
+		utils.HandleDBError(c, err, "vitals.fetch_failed")
+		return
+	}
+	response := VitalsResponse{Series: []VitalSeries{}}
+	if len(vitals) > maxVitalPoints {
+		vitals = vitals[:maxVitalPoints]
+		response.Truncated = true
+	}
+
+	// Walk oldest first, so every series comes out in chart order
+	seriesIndex := make(map[models.VitalType]int)
+	for i := len(vitals) - 1; i >= 0; i-- {
+		vital := vitals[i]
+		index, ok := seriesIndex[vital.Type]
+		if !ok {
+			index = len(response.Series)
+			seriesIndex[vital.Type] = index
+			response.Series = append(response.Series, VitalSeries{Type: vital.Type, Points: []models.Vital{}})
+		}
+		response.Series[index].Points = append(response.Series[index].Points, vital)
+	}
+
+	utils.Success(c, "Vitals fetched successfully", response)
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/scheduling"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WaitlistHandler handles waitlist related requests.
+type WaitlistHandler struct {
+	DB *gorm.DB
+}
+
+// NewWaitlistHandler creates a new WaitlistHandler.
+func NewWaitlistHandler(db *gorm.DB) *WaitlistHandler {
+	return &WaitlistHandler{DB: db}
+}
+
+// JoinWaitlistRequest represents the request body for joining a doctor's waitlist.
+type JoinWaitlistRequest struct {
+	DoctorID    string    `json:"doctorId" binding:"required,uuid"`
+	DesiredFrom time.Time `json:"desiredFrom" binding:"required"`
+	DesiredTo   time.Time `json:"desiredTo" binding:"required"`
+}
+
+// WaitlistEntryResponse represents a waitlist entry with the waiting patient's sanitized details.
+type WaitlistEntryResponse struct {
+	models.WaitlistEntry
+	Patient models.UserSanitized `json:"patient"`
+}
+
+// JoinWaitlist handles a patient queueing for cancellations with a doctor in a desired date range.
+func (h *WaitlistHandler) JoinWaitlist(c *gin.Context) {
+	var req JoinWaitlistRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+
+	patientID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+
+	if !req.DesiredTo.After(req.DesiredFrom) {
+		utils.BadRequest(c, "waitlist.invalid_range")
+		return
+	}
+	if !req.DesiredTo.After(time.Now()) {
+		utils.BadRequest(c, "waitlist.range_in_past")
+		return
+	}
+
+	var doctor models.User
+	if err := reqDB(h.DB, c).Where("id = ? AND role = ?", req.DoctorID, models.RoleDoctor).First(&doctor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "appointments.doctor_not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+
+	entry := models.WaitlistEntry{
+		PatientID:   patientID,
+		DoctorID:    doctor.ID,
+		DesiredFrom: req.DesiredFrom,
+		DesiredTo:   req.DesiredTo,
+	}
+	if err := reqDB(h.DB, c).Create(&entry).Error; err != nil {
+		utils.HandleDBError(c, err, "waitlist.join_failed")
+		return
+	}
+
+	utils.Created(c, "Joined the waitlist successfully", entry)
+}
+
+// GetWaitlist handles listing waitlist entries.
+// Doctors see their own waitlist, admins see all entries (optionally filtered by ?doctorId=), patients see their own entries.
+func (h *WaitlistHandler) GetWaitlist(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.WaitlistEntry{})
+	switch {
+	case userRole.IsAdmin():
+		query = scopeToOrganizationUsers(c, reqDB(h.DB, c), query, "patient_id")
+		if doctorID := c.Query("doctorId"); doctorID != "" {
+			if _, err := uuid.Parse(doctorID); err != nil {
+				utils.BadRequest(c, "common.invalid_doctor_id")
+				return
+			}
+			query = query.Where("doctor_id = ?", doctorID)
+		}
+	case userRole == models.RoleDoctor:
+		query = query.Where("doctor_id = ?", userID)
+	default:
+		query = query.Where("patient_id = ?", userID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "waitlist.fetch_failed")
+		return
+	}
+
+	var entries []models.WaitlistEntry
+	if err := query.Preload("Patient").Order("created_at asc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&entries).Error; err != nil {
+		utils.HandleDBError(c, err, "waitlist.fetch_failed")
+		return
+	}
+
+	responses := make([]WaitlistEntryResponse, len(entries))
+	for i, entry := range entries {
+		responses[i] = WaitlistEntryResponse{WaitlistEntry: entry, Patient: entry.Patient.Sanitize()}
+	}
+
+	utils.SuccessWithMeta(c, "Waitlist fetched successfully", responses, pagination.Meta(total))
+
+}
+
+// LeaveWaitlist handles removing a waitlist entry. Only the entry's patient or an admin may remove it.
+func (h *WaitlistHandler) LeaveWaitlist(c *gin.Context) {
+	entryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "waitlist.invalid_id")
+		return
+	}
+
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		utils.Unauthorized(c, "common.unauthenticated")
+		return
+	}
+	userRole, _ := middleware.GetUserRoleFromContext(c)
+
+	var entry models.WaitlistEntry
+	if err := reqDB(h.DB, c).First(&entry, "id = ?", entryID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "waitlist.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return
+	}
+	if !ensureUserInOrganization(c, reqDB(h.DB, c), entry.PatientID, "waitlist.not_found") {
+		return
+	}
+
+	if entry.PatientID != userID && !userRole.IsAdmin() {
+		utils.Forbidden(c, "waitlist.leave_forbidden")
+		return
+	}
+
+	if err := reqDB(h.DB, c).Delete(&entry).Error; err != nil {
+		utils.HandleDBError(c, err, "waitlist.leave_failed")
+		return
+	}
+
+	utils.Success(c, "Left the waitlist successfully", nil)
+}
+
+// notifyWaitlistOfOpenSlot messages every patient waiting for the doctor whose desired range overlaps
+// the freed [slotStart, slotEnd) slot. Each entry is notified at most once. Failures are logged, not returned,
+// so a cancellation never fails because of a notification.
+func notifyWaitlistOfOpenSlot(db *gorm.DB, doctorID string, slotStart, slotEnd time.Time) {
+	// A slot freed inside the doctor's time off cannot be booked, so there is nothing to announce
+	onTimeOff, err := scheduling.IsDuringTimeOff(db, doctorID, slotStart, slotEnd)
+	if err != nil {
+		log.Printf("waitlist: failed to check time off for doctor %s: %v", doctorID, err)
+		return
+	}
+	if onTimeOff {
+		return
+	}
+
+	var entries []models.WaitlistEntry
+	if err := db.Preload("Doctor").
+		Where("doctor_id = ? AND notified_at IS NULL", doctorID).
+		Where("desired_from < ? AND desired_to > ?", slotEnd, slotStart).
+		Order("created_at asc").
+		Find(&entries).Error; err != nil {
+		log.Printf("waitlist: failed to load entries for doctor %s: %v", doctorID, err)
+		return
+	}
+
+	for _, entry := range entries {
+		message := models.Message{
+			SenderID:   entry.DoctorID,
+			ReceiverID: entry.PatientID,
+			Subject:    "An appointment slot has opened",
+			Content: fmt.Sprintf("A slot with Dr. %s %s is now free on %s. Book it soon, other patients are waiting too.",
+				entry.Doctor.FirstName, entry.Doctor.LastName, slotStart.UTC().Format("2006-01-02 15:04 MST")),
+			Status: models.MessageStatusSent,
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&message).Error; err != nil {
+				return err
+			}
+			return tx.Model(&entry).Update("notified_at", time.Now()).Error
+		})
+		if err != nil {
+			log.Printf("waitlist: failed to notify entry %s: %v", entry.ID, err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookHandler handles the management of webhook subscriptions (admin).
+type WebhookHandler struct {
+	DB *gorm.DB
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(db *gorm.DB) *WebhookHandler {
+	return &WebhookHandler{DB: db}
+}
+
+// CreateWebhookRequest represents the request body for subscribing an endpoint to events.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1,dive,webhook_event"`
+}
+
+// UpdateWebhookRequest represents the request body for changing a subscription. Setting active
+// to true re-enables a subscription that was disabled for failing and clears its failure count.
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url" binding:"omitempty,url,max=2048"`
+	EventTypes []string `json:"eventTypes" binding:"omitempty,min=1,dive,webhook_event"`
+	Active     *bool    `json:"active"`
+}
+
+// WebhookResponse is a subscription as returned by the API.
+type WebhookResponse struct {
+	models.WebhookSubscription
+	EventTypes []string `json:"eventTypes"`
+	// Only returned when the subscription is created; receivers verify deliveries with it
+	Secret string `json:"secret,omitempty"`
+}
+
+func newWebhookResponse(subscription models.WebhookSubscription) WebhookResponse {
+	return WebhookResponse{WebhookSubscription: subscription, EventTypes: subscription.Events()}
+}
+
+// CreateWebhook handles subscribing an endpoint to events. The signing secret is generated here and
+// returned once; it cannot be read back later.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	if !isWebhookURL(req.URL) {
+		utils.BadRequest(c, "webhooks.invalid_url")
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		utils.InternalServerErrorWithDetail(c, "webhooks.create_failed", err)
+		return
+	}
+
+	adminID, _ := middleware.GetUserIDFromContext(c)
+	subscription := models.WebhookSubscription{
+		URL:         req.URL,
+		Secret:      hex.EncodeToString(secret),
+		EventTypes:  joinWebhookEvents(req.EventTypes),
+		Active:      true,
+		CreatedByID: adminID,
+	}
+	if err := reqDB(h.DB, c).Create(&subscription).Error; err != nil {
+		utils.HandleDBError(c, err, "webhooks.create_failed")
+		return
+	}
+
+	response := newWebhookResponse(subscription)
+	response.Secret = subscription.Secret
+	utils.Created(c, "Webhook created successfully", response)
+}
+
+// GetWebhooks handles listing the subscriptions, newest first.
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	var total int64
+	if err := reqDB(h.DB, c).Model(&models.WebhookSubscription{}).Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "webhooks.fetch_failed")
+		return
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := reqDB(h.DB, c).Order("created_at desc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&subscriptions).Error; err != nil {
+		utils.HandleDBError(c, err, "webhooks.fetch_failed")
+		return
+	}
+
+	responses := make([]WebhookResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		responses[i] = newWebhookResponse(subscription)
+	}
+	utils.SuccessWithMeta(c, "Webhooks fetched successfully", responses, pagination.Meta(total))
+}
+
+// GetWebhook handles fetching one subscription.
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	subscription, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+	utils.Success(c, "Webhook fetched successfully", newWebhookResponse(subscription))
+}
+
+// UpdateWebhook handles changing a subscription's URL, events or active flag.
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var req UpdateWebhookRequest
+	if !utils.BindAndValidate(c, &req) {
+		return
+	}
+	subscription, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	if req.URL != nil {
+		if !isWebhookURL(*req.URL) {
+			utils.BadRequest(c, "webhooks.invalid_url")
+			return
+		}
+		subscription.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		subscription.EventTypes = joinWebhookEvents(req.EventTypes)
+	}
+	if req.Active != nil {
+		if *req.Active && !subscription.Active {
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = nil
+		}
+		subscription.Active = *req.Active
+	}
+
+	if err := reqDB(h.DB, c).Save(&subscription).Error; err != nil {
+		utils.HandleDBError(c, err, "webhooks.update_failed")
+		return
+	}
+	utils.Success(c, "Webhook updated successfully", newWebhookResponse(subscription))
+}
+
+// DeleteWebhook handles removing a subscription together with its delivery log.
+// Deliveries still queued for it are dropped.
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	subscription, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	err := reqDB(h.DB, c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", subscription.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&subscription).Error
+	})
+	if err != nil {
+		utils.HandleDBError(c, err, "webhooks.delete_failed")
+		return
+	}
+	utils.Success(c, "Webhook deleted successfully", nil)
+}
+
+// GetWebhookDeliveries handles listing a subscription's deliveries, newest first, optionally filtered by
+// ?status=pending|succeeded|failed.
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	subscription, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+	pagination, ok := utils.ParsePagination(c)
+	if !ok {
+		return
+	}
+
+	query := reqDB(h.DB, c).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscription.ID)
+	if status := c.Query("status"); status != "" {
+		switch models.WebhookDeliveryStatus(status) {
+		case models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+			query = query.Where("status = ?", status)
+		default:
+			utils.BadRequest(c, "webhooks.invalid_status_filter", utils.Params{"status": status})
+			return
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.HandleDBError(c, err, "webhooks.fetch_deliveries_failed")
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("created_at desc").
+		Offset(pagination.Offset).Limit(pagination.Limit).
+		Find(&deliveries).Error; err != nil {
+		utils.HandleDBError(c, err, "webhooks.fetch_deliveries_failed")
+		return
+	}
+	utils.SuccessWithMeta(c, "Webhook deliveries fetched successfully", deliveries, pagination.Meta(total))
+}
+
+// findWebhook loads the subscription named by the :id path parameter, writing the error response itself.
+func (h *WebhookHandler) findWebhook(c *gin.Context) (models.WebhookSubscription, bool) {
+	var subscription models.WebhookSubscription
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "webhooks.invalid_id")
+		return subscription, false
+	}
+	if err := reqDB(h.DB, c).First(&subscription, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.NotFound(c, "webhooks.not_found")
+		} else {
+			utils.HandleDBError(c, err, "common.database_error")
+		}
+		return subscription, false
+	}
+	return subscription, true
+}
+
+// isWebhookURL reports whether raw is an absolute http or https URL.
+func isWebhookURL(raw string) bool {
+	parsed, err := url.Parse(raw)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// joinWebhookEvents returns events as stored in WebhookSubscription.EventTypes, without duplicates.
+func joinWebhookEvents(events []string) string {
+	seen := make(map[string]bool, len(events))
+	unique := make([]string, 0, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	return strings.Join(unique, ",")
+}
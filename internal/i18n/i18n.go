@@ -0,0 +1,145 @@
+// Package i18n provides the message catalogs used to localize API responses.
+// Catalogs are flat JSON objects mapping a message key to its text, embedded per locale from locales/<locale>.json.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is used when neither the request nor the configuration selects a supported locale.
+const DefaultLocale = "en"
+
+// LocaleContextKey is the gin context key under which the request's locale is stored.
+const LocaleContextKey = "locale"
+
+// Params holds the values substituted for {name} placeholders in a message.
+type Params map[string]string
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs maps locale -> message key -> message text.
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: reading embedded locales: %v", err))
+	}
+
+	loaded := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: reading %s: %v", entry.Name(), err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: parsing %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// IsSupported reports whether a catalog exists for locale.
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// HasKey reports whether key is defined in the default catalog.
+func HasKey(key string) bool {
+	_, ok := catalogs[DefaultLocale][key]
+	return ok
+}
+
+// Translate returns the text of key in locale, falling back to the default locale.
+// Unknown keys are returned unchanged, so plain (untranslated) messages still work.
+func Translate(locale, key string, params Params) string {
+	message, ok := catalogs[locale][key]
+	if !ok {
+		if message, ok = catalogs[DefaultLocale][key]; !ok {
+			message = key
+		}
+	}
+	if len(params) == 0 {
+		return message
+	}
+
+	replacements := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(message)
+}
+
+// MatchAcceptLanguage picks the supported locale the client prefers most according to an
+// Accept-Language header (e.g. "pl-PL,pl;q=0.9,en;q=0.8"), or fallback if none match.
+func MatchAcceptLanguage(header, fallback string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, field := range fields[1:] {
+			if q, found := strings.CutPrefix(strings.TrimSpace(field), "q="); found {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].quality > preferences[j].quality })
+
+	for _, p := range preferences {
+		if IsSupported(p.tag) {
+			return p.tag
+		}
+		// Fall back to the primary language subtag, e.g. "pl-pl" -> "pl"
+		if primary, _, found := strings.Cut(p.tag, "-"); found && IsSupported(primary) {
+			return primary
+		}
+	}
+	return fallback
+}
+
+// CheckCatalogs verifies that every locale defines exactly the same message keys,
+// so a message can never silently fall back to another language.
+func CheckCatalogs() error {
+	var problems []string
+	for locale, messages := range catalogs {
+		for otherLocale, otherMessages := range catalogs {
+			if locale == otherLocale {
+				continue
+			}
+			for key := range messages {
+				if _, ok := otherMessages[key]; !ok {
+					problems = append(problems, fmt.Sprintf("%q is in %s but missing from %s", key, locale, otherLocale))
+				}
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("i18n: locale catalogs differ: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
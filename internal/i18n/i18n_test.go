@@ -0,0 +1,54 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCatalogsHaveTheSameKeys(t *testing.T) {
+	if len(catalogs) < 2 {
+		t.Fatalf("loaded %d catalogs, want at least en and pl", len(catalogs))
+	}
+	if err := CheckCatalogs(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCatalogsUseTheSamePlaceholders(t *testing.T) {
+	placeholder := regexp.MustCompile(`\{[a-zA-Z]+\}`)
+	placeholders := func(message string) string {
+		found := placeholder.FindAllString(message, -1)
+		sort.Strings(found)
+		return strings.Join(found, ",")
+	}
+
+	for key, message := range catalogs[DefaultLocale] {
+		want := placeholders(message)
+		for locale, messages := range catalogs {
+			if got := placeholders(messages[key]); got != want {
+				t.Errorf("%s in %s has placeholders %q, want %q as in %s", key, locale, got, want, DefaultLocale)
+			}
+		}
+	}
+}
+
+func TestMatchAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"pl", "pl"},
+		{"pl-PL,pl;q=0.9,en;q=0.8", "pl"},
+		{"de-DE,en;q=0.5,pl;q=0.7", "pl"},
+		{"de, fr", "en"},
+		{"pl;q=0", "en"},
+	}
+	for _, tt := range tests {
+		if got := MatchAcceptLanguage(tt.header, DefaultLocale); got != tt.want {
+			t.Errorf("MatchAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"healthcare-app-server/internal/models"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// appointmentPurgeInterval is how often appointments past the retention period are deleted.
+	appointmentPurgeInterval = 24 * time.Hour
+	// appointmentPurgeBatchSize is how many appointments are deleted per transaction, so no purge holds long locks.
+	appointmentPurgeBatchSize = 500
+)
+
+// purgeableAppointmentStatuses are the final statuses; appointments still going to happen are never purged.
+var purgeableAppointmentStatuses = []models.AppointmentStatus{
+	models.StatusCompleted, models.StatusCancelled, models.StatusNoShow,
+}
+
+// appointmentPurgeMu keeps the daily purge and purges started by admins from running at the same time.
+var appointmentPurgeMu sync.Mutex
+
+// StartAppointmentPurge deletes finished appointments that started longer than retention ago,
+// once at startup and then daily. A non-positive retention disables the job.
+func StartAppointmentPurge(db *gorm.DB, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(appointmentPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := PurgeOldAppointments(db, time.Now().Add(-retention)); err != nil {
+				log.Printf("Failed to purge old appointments: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// PurgeOldAppointments permanently deletes completed, cancelled and no-show appointments that started
+// before cutoff, together with their intake forms, and returns how many were deleted. Appointments
+// whose intake form was attached to a medical record are kept, as the record still refers to that visit,
+// and so are reviewed appointments, whose reviews count towards the doctor's rating. Appointments are
+// deleted in batches of appointmentPurgeBatchSize, each in its own transaction, so the purge can be
+// stopped at any point and simply run again.
+func PurgeOldAppointments(db *gorm.DB, cutoff time.Time) (int64, error) {
+	appointmentPurgeMu.Lock()
+	defer appointmentPurgeMu.Unlock()
+
+	var purged int64
+	for {
+		var ids []string
+		if err := db.Model(&models.Appointment{}).
+			Where("status IN ? AND start_time < ?", purgeableAppointmentStatuses, cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM intake_forms WHERE intake_forms.appointment_id = appointments.id AND intake_forms.medical_record_id IS NOT NULL)").
+			Where("NOT EXISTS (SELECT 1 FROM reviews WHERE reviews.appointment_id = appointments.id)").
+			Order("start_time asc").Limit(appointmentPurgeBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		var deleted int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("appointment_id IN ?", ids).Delete(&models.IntakeForm{}).Error; err != nil {
+				return err
+			}
+			result := tx.Where("id IN ?", ids).Delete(&models.Appointment{})
+			deleted = result.RowsAffected
+			return result.Error
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged += deleted
+		log.Printf("Appointment purge: deleted %d appointments so far", purged)
+
+		if len(ids) < appointmentPurgeBatchSize {
+			break
+		}
+	}
+	if purged > 0 {
+		log.Printf("Permanently deleted %d appointments older than %s", purged, cutoff.UTC().Format("2006-01-02"))
+	}
+	return purged, nil
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"healthcare-app-server/internal/mailer"
+	"healthcare-app-server/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AppointmentReminders emails patients a reminder of their confirmed appointments starting within Lead.
+// Each appointment is reminded once; rescheduling it clears the reminder so the new time is reminded too.
+type AppointmentReminders struct {
+	DB     *gorm.DB
+	Mailer *mailer.Mailer
+	Lead   time.Duration
+}
+
+// Name implements Job.
+func (AppointmentReminders) Name() string { return "appointment_reminders" }
+
+// Run implements Job. A reminder whose email fails is sent again by the retry; the others are not.
+func (j AppointmentReminders) Run(ctx context.Context, now time.Time) error {
+	db := j.DB.WithContext(ctx)
+	var upcoming []models.Appointment
+	if err := db.Preload("Patient").Preload("Doctor").
+		Where("status = ? AND reminder_sent_at IS NULL AND start_time > ? AND start_time <= ?",
+			models.StatusConfirmed, now, now.Add(j.Lead)).
+		Order("start_time asc").
+		Find(&upcoming).Error; err != nil {
+		return err
+	}
+
+	var sent, failed int
+	for _, appointment := range upcoming {
+		// Mark the reminder before sending; the update only succeeds for one server
+		claim := db.Model(&models.Appointment{}).
+			Where("id = ? AND reminder_sent_at IS NULL", appointment.ID).
+			UpdateColumn("reminder_sent_at", now)
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		body := fmt.Sprintf("Hello %s,\n\nThis is a reminder of your appointment with Dr. %s %s on %s.\n\n"+
+			"If you cannot make it, please cancel or reschedule it in the app.\n",
+			appointment.Patient.FirstName, appointment.Doctor.FirstName, appointment.Doctor.LastName,
+			appointment.StartTime.UTC().Format("2006-01-02 15:04 MST"))
+		if err := j.Mailer.Send(appointment.Patient.Email, "Appointment reminder", body); err != nil {
+			log.Printf("Failed to send the reminder of appointment %s: %v", appointment.ID, err)
+			failed++
+			if err := db.Model(&models.Appointment{}).Where("id = ?", appointment.ID).
+				UpdateColumn("reminder_sent_at", nil).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		log.Printf("Sent %d appointment reminders", sent)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d appointment reminders could not be sent", failed, sent+failed)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"healthcare-app-server/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// appointmentSweepInterval is how often ended appointments are looked for.
+const appointmentSweepInterval = 15 * time.Minute
+
+// StartAppointmentSweep moves confirmed appointments that ended more than `after` ago to
+// needs_review, or to completed when autoComplete is set, once at startup and then periodically.
+// A non-positive after disables the job.
+func StartAppointmentSweep(db *gorm.DB, after time.Duration, autoComplete bool) {
+	if after <= 0 {
+		return
+	}
+
+	target := models.StatusNeedsReview
+	if autoComplete {
+		target = models.StatusCompleted
+	}
+
+	go func() {
+		ticker := time.NewTicker(appointmentSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			swept, err := SweepEndedAppointments(db, time.Now().Add(-after), target)
+			if err != nil {
+				log.Printf("Failed to sweep ended appointments: %v", err)
+			} else if swept > 0 {
+				log.Printf("Marked %d ended appointments as %s", swept, target)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// SweepEndedAppointments sets target on every confirmed appointment that ended before cutoff
+// and returns how many were updated. Appointments without an end time are judged by their start.
+func SweepEndedAppointments(db *gorm.DB, cutoff time.Time, target models.AppointmentStatus) (int64, error) {
+	result := db.Model(&models.Appointment{}).
+		Where("status = ?", models.StatusConfirmed).
+		Where("(end_time > start_time AND end_time < ?) OR (end_time <= start_time AND start_time < ?)", cutoff, cutoff).
+		Update("status", target)
+	return result.RowsAffected, result.Error
+}
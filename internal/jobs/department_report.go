@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DepartmentMatch is a free-text department value stored on medical records or record templates,
+// with the department it matches.
+type DepartmentMatch struct {
+	Table string
+	Value string
+	Rows  int64
+	// Canonical is the name of the department Value matches in any letter case, or "" when none does.
+	Canonical string
+}
+
+// departmentValue is a distinct department value of a table and how many rows hold it.
+type departmentValue struct {
+	Value string
+	Count int64
+}
+
+// departmentTables are the tables whose department column is free text.
+var departmentTables = []string{"medical_records", "record_templates"}
+
+// DepartmentReport matches the distinct department values of medical records and record templates,
+// soft-deleted records included, to the departments case-insensitively, and returns the values that
+// are not exactly a department's name. With fix, values that only differ in letter case are rewritten
+// to the department's name; unmatched values are only reported, for an admin to create the department
+// or correct the rows. Updates bypass the models, so updated_at is left alone.
+func DepartmentReport(db *gorm.DB, fix bool) ([]DepartmentMatch, error) {
+	var departments []models.Department
+	if err := db.Select("name").Find(&departments).Error; err != nil {
+		return nil, fmt.Errorf("department report: loading departments: %w", err)
+	}
+	canonical := make(map[string]string, len(departments))
+	for _, department := range departments {
+		canonical[strings.ToLower(department.Name)] = department.Name
+	}
+
+	var matches []DepartmentMatch
+	for _, table := range departmentTables {
+		var values []departmentValue
+		if err := db.Table(table).Select("department AS value, COUNT(*) AS count").
+			Where("department <> ''").Group("department").Order("department asc").
+			Scan(&values).Error; err != nil {
+			return nil, fmt.Errorf("department report: loading %s: %w", table, err)
+		}
+
+		for _, value := range values {
+			name := canonical[strings.ToLower(strings.TrimSpace(value.Value))]
+			if name == value.Value {
+				continue
+			}
+			matches = append(matches, DepartmentMatch{Table: table, Value: value.Value, Rows: value.Count, Canonical: name})
+			if !fix || name == "" {
+				continue
+			}
+			if err := db.Table(table).Where("department = ?", value.Value).Update("department", name).Error; err != nil {
+				return nil, fmt.Errorf("department report: fixing %s %q: %w", table, value.Value, err)
+			}
+		}
+	}
+	return matches, nil
+}
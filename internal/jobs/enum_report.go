@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/models"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// EnumIssue is a stored row whose enum column does not hold one of the defined values exactly.
+type EnumIssue struct {
+	Table  string
+	Column string
+	ID     string
+	Value  string
+	// Canonical is the defined value Value stands for, such as the same value in another letter case,
+	// or "" when Value is unknown.
+	Canonical string
+}
+
+// enumColumnRow is one row of an enum column, read without the model.
+type enumColumnRow struct {
+	ID    string
+	Value string
+}
+
+// enumColumn is an enum column checked by EnumReport. canonical returns the defined value
+// matching value in any letter case, or "" when there is none.
+type enumColumn struct {
+	table, column string
+	canonical     func(value string) string
+}
+
+var enumColumns = []enumColumn{
+	{"users", "role", func(value string) string {
+		if role := models.Role(value).Normalize(); role.IsValid() {
+			return string(role)
+		}
+		return ""
+	}},
+	{"appointments", "status", func(value string) string {
+		if status := models.AppointmentStatus(value).Normalize(); status.IsValid() {
+			return string(status)
+		}
+		return ""
+	}},
+	{"medical_records", "record_type", canonicalRecordType},
+	{"record_templates", "record_type", canonicalRecordType},
+}
+
+func canonicalRecordType(value string) string {
+	for _, recordType := range models.MedicalRecordTypes {
+		if strings.EqualFold(value, string(recordType)) {
+			return string(recordType)
+		}
+	}
+	return ""
+}
+
+// EnumReport walks the enum columns (user roles, appointment statuses and record types) in ID order,
+// batchSize rows at a time, and returns every row whose value is not exactly a defined one, including
+// soft-deleted rows. With fix, values that only differ in letter case are rewritten to the defined
+// form; unknown values are only reported, as there is no safe guess for them.
+func EnumReport(db *gorm.DB, fix bool, batchSize int) ([]EnumIssue, error) {
+	var issues []EnumIssue
+	for _, column := range enumColumns {
+		found, err := enumReportColumn(db, column, fix, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	return issues, nil
+}
+
+// enumReportColumn checks one enum column. Updates bypass the model, so updated_at is left alone.
+func enumReportColumn(db *gorm.DB, column enumColumn, fix bool, batchSize int) ([]EnumIssue, error) {
+	var issues []EnumIssue
+	var checked, fixed int64
+	lastID := ""
+	for {
+		var rows []enumColumnRow
+		if err := db.Table(column.table).Select("id, "+column.column+" AS value").
+			Where("id > ?", lastID).Order("id asc").Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("enum report: loading %s: %w", column.table, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			canonical := column.canonical(row.Value)
+			if canonical == row.Value {
+				continue
+			}
+			issues = append(issues, EnumIssue{Table: column.table, Column: column.column, ID: row.ID, Value: row.Value, Canonical: canonical})
+			if !fix || canonical == "" {
+				continue
+			}
+			if err := db.Table(column.table).Where("id = ?", row.ID).Update(column.column, canonical).Error; err != nil {
+				return nil, fmt.Errorf("enum report: fixing %s %s: %w", column.table, row.ID, err)
+			}
+			fixed++
+		}
+
+		checked += int64(len(rows))
+		lastID = rows[len(rows)-1].ID
+		log.Printf("Enum report: %s.%s: %d rows checked, %d fixed", column.table, column.column, checked, fixed)
+	}
+	return issues, nil
+}
@@ -0,0 +1,46 @@
+// Package jobs contains background tasks the server runs alongside the HTTP API.
+package jobs
+
+import (
+	"healthcare-app-server/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// messageArchiveInterval is how often old messages are looked for.
+const messageArchiveInterval = time.Hour
+
+// StartMessageArchiver archives messages older than maxAge once at startup and then every hour.
+// Archiving only sets archived_at, so no data is deleted and clearing the column restores a message.
+// A non-positive maxAge disables the job.
+func StartMessageArchiver(db *gorm.DB, maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(messageArchiveInterval)
+		defer ticker.Stop()
+
+		for {
+			archived, err := ArchiveMessagesOlderThan(db, time.Now().Add(-maxAge))
+			if err != nil {
+				log.Printf("Failed to archive old messages: %v", err)
+			} else if archived > 0 {
+				log.Printf("Archived %d messages older than %s", archived, maxAge)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ArchiveMessagesOlderThan marks every unarchived message created before cutoff as archived
+// and returns how many messages were archived.
+func ArchiveMessagesOlderThan(db *gorm.DB, cutoff time.Time) (int64, error) {
+	result := db.Model(&models.Message{}).
+		Where("archived_at IS NULL AND created_at < ?", cutoff).
+		UpdateColumn("archived_at", time.Now())
+	return result.RowsAffected, result.Error
+}
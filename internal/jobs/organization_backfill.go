@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// organizationOwners maps each table scoped by organization to the column naming the user whose
+// organization its rows belong to; it matches the models' TenantOwnerID methods.
+var organizationOwners = []struct {
+	table, ownerColumn string
+}{
+	{"appointments", "patient_id"},
+	{"medical_records", "patient_id"},
+	{"messages", "receiver_id"},
+	{"announcements", "sender_id"},
+	{"audit_events", "actor_id"},
+}
+
+// BackfillOrganizations gives rows without an organization, created before MULTI_TENANT was turned on or
+// before their user joined an organization, the organization of the user they belong to. Rows whose user
+// has no organization yet are left alone, so it can run again whenever users are assigned. It returns how
+// many rows were updated.
+func BackfillOrganizations(db *gorm.DB) (int64, error) {
+	var updated int64
+	for _, owner := range organizationOwners {
+		result := db.Exec(fmt.Sprintf(
+			`UPDATE %[1]s SET organization_id = (SELECT users.organization_id FROM users WHERE users.id = %[1]s.%[2]s)
+			WHERE organization_id IS NULL AND %[2]s IN (SELECT id FROM users WHERE organization_id IS NOT NULL)`,
+			owner.table, owner.ownerColumn))
+		if result.Error != nil {
+			return updated, fmt.Errorf("backfilling organizations of %s: %w", owner.table, result.Error)
+		}
+		updated += result.RowsAffected
+	}
+	return updated, nil
+}
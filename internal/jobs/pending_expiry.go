@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// pendingExpiryInterval is how often unconfirmed pending appointments are looked for.
+const pendingExpiryInterval = 15 * time.Minute
+
+// unconfirmedPendingNote is stored as the notes of a pending appointment cancelled for lack of confirmation.
+const unconfirmedPendingNote = "Cancelled automatically: the appointment was not confirmed in time."
+
+// StartPendingExpiry cancels pending appointments nobody has confirmed within `window` of booking,
+// once at startup and then periodically. A non-positive window disables the job.
+func StartPendingExpiry(db *gorm.DB, window time.Duration) {
+	if window <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(pendingExpiryInterval)
+		defer ticker.Stop()
+
+		for {
+			now := time.Now()
+			expired, err := ExpirePendingAppointments(db, now.Add(-window), now)
+			if err != nil {
+				log.Printf("Failed to expire pending appointments: %v", err)
+			} else if expired > 0 {
+				log.Printf("Cancelled %d unconfirmed pending appointments", expired)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ExpirePendingAppointments cancels every pending appointment created before cutoff, or whose start time has
+// passed by now, messages its patient, and returns how many were cancelled. Each appointment is cancelled
+// only while it is still pending, so one confirmed at the same moment is left alone.
+func ExpirePendingAppointments(db *gorm.DB, cutoff, now time.Time) (int64, error) {
+	var pending []models.Appointment
+	if err := db.Preload("Doctor").
+		Where("status = ? AND (created_at < ? OR start_time < ?)", models.StatusPending, cutoff, now).
+		Find(&pending).Error; err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	for _, appointment := range pending {
+		result := db.Model(&models.Appointment{}).
+			Where("id = ? AND status = ?", appointment.ID, models.StatusPending).
+			Updates(map[string]interface{}{"status": models.StatusCancelled, "notes": unconfirmedPendingNote})
+		if result.Error != nil {
+			return expired, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		expired++
+
+		message := models.Message{
+			SenderID:   appointment.DoctorID,
+			ReceiverID: appointment.PatientID,
+			Subject:    "Appointment not confirmed",
+			Content: fmt.Sprintf("Your appointment with Dr. %s %s on %s was not confirmed in time and has been cancelled.",
+				appointment.Doctor.FirstName, appointment.Doctor.LastName, appointment.StartTime.UTC().Format("2006-01-02 15:04 MST")),
+			Status: models.MessageStatusSent,
+		}
+		if err := db.Create(&message).Error; err != nil {
+			log.Printf("Failed to notify patient %s of unconfirmed appointment %s: %v", appointment.PatientID, appointment.ID, err)
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// proposalExpiryInterval is how often unanswered appointment proposals are looked for.
+const proposalExpiryInterval = 15 * time.Minute
+
+// expiredProposalNote is stored as the notes of an expired proposal.
+const expiredProposalNote = "Cancelled automatically: the patient did not confirm the proposed appointment in time."
+
+// StartProposalExpiry cancels appointment proposals the patient has not confirmed within `window`,
+// once at startup and then periodically. A non-positive window disables the job.
+func StartProposalExpiry(db *gorm.DB, window time.Duration) {
+	if window <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(proposalExpiryInterval)
+		defer ticker.Stop()
+
+		for {
+			now := time.Now()
+			expired, err := ExpireProposals(db, now.Add(-window), now)
+			if err != nil {
+				log.Printf("Failed to expire appointment proposals: %v", err)
+			} else if expired > 0 {
+				log.Printf("Cancelled %d unconfirmed appointment proposals", expired)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ExpireProposals cancels every proposed appointment created before cutoff, or whose start time has
+// passed by now, messages its patient, and returns how many were cancelled. Each proposal is cancelled
+// only while it is still proposed, so one the patient confirms at the same moment is left alone.
+func ExpireProposals(db *gorm.DB, cutoff, now time.Time) (int64, error) {
+	var proposals []models.Appointment
+	if err := db.Preload("Doctor").
+		Where("status = ? AND (created_at < ? OR start_time < ?)", models.StatusProposed, cutoff, now).
+		Find(&proposals).Error; err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	for _, proposal := range proposals {
+		result := db.Model(&models.Appointment{}).
+			Where("id = ? AND status = ?", proposal.ID, models.StatusProposed).
+			Updates(map[string]interface{}{"status": models.StatusCancelled, "notes": expiredProposalNote})
+		if result.Error != nil {
+			return expired, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		expired++
+
+		message := models.Message{
+			SenderID:   proposal.DoctorID,
+			ReceiverID: proposal.PatientID,
+			Subject:    "Proposed appointment expired",
+			Content: fmt.Sprintf("The appointment proposed for you with Dr. %s %s on %s was not confirmed in time and has been cancelled.",
+				proposal.Doctor.FirstName, proposal.Doctor.LastName, proposal.StartTime.UTC().Format("2006-01-02 15:04 MST")),
+			Status: models.MessageStatusSent,
+		}
+		if err := db.Create(&message).Error; err != nil {
+			log.Printf("Failed to notify patient %s of expired proposal %s: %v", proposal.PatientID, proposal.ID, err)
+		}
+	}
+	return expired, nil
+}
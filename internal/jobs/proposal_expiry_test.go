@@ -0,0 +1,87 @@
+package jobs_test
+
+import (
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func createProposal(t *testing.T, db *gorm.DB, patient, doctor *models.User, createdAt, start time.Time, status models.AppointmentStatus) models.Appointment {
+	t.Helper()
+
+	appointment := models.Appointment{
+		BaseModel: models.BaseModel{CreatedAt: createdAt},
+		PatientID: patient.ID,
+		DoctorID:  doctor.ID,
+		StartTime: start,
+		EndTime:   start.Add(30 * time.Minute),
+		Status:    status,
+		Reason:    "Follow-up",
+	}
+	if err := db.Create(&appointment).Error; err != nil {
+		t.Fatalf("creating appointment: %v", err)
+	}
+	return appointment
+}
+
+func TestExpireProposals(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	patient, doctor := testutil.NewTestUser(models.RolePatient), testutil.NewTestUser(models.RoleDoctor)
+	for _, user := range []*models.User{patient, doctor} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("creating user: %v", err)
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	window := 48 * time.Hour
+	nextWeek := now.Add(7 * 24 * time.Hour)
+	unanswered := createProposal(t, db, patient, doctor, now.Add(-window-time.Hour), nextWeek, models.StatusProposed)
+	startedAlready := createProposal(t, db, patient, doctor, now.Add(-time.Hour), now.Add(-time.Minute), models.StatusProposed)
+	fresh := createProposal(t, db, patient, doctor, now.Add(-time.Hour), nextWeek, models.StatusProposed)
+	accepted := createProposal(t, db, patient, doctor, now.Add(-window-time.Hour), nextWeek, models.StatusConfirmed)
+
+	expired, err := jobs.ExpireProposals(db, now.Add(-window), now)
+	if err != nil {
+		t.Fatalf("expiring proposals: %v", err)
+	}
+	if expired != 2 {
+		t.Errorf("expired %d proposals, want 2", expired)
+	}
+
+	wantStatus := map[string]models.AppointmentStatus{
+		unanswered.ID:     models.StatusCancelled,
+		startedAlready.ID: models.StatusCancelled,
+		fresh.ID:          models.StatusProposed,
+		accepted.ID:       models.StatusConfirmed,
+	}
+	for id, want := range wantStatus {
+		var stored models.Appointment
+		if err := db.First(&stored, "id = ?", id).Error; err != nil {
+			t.Fatalf("reloading appointment: %v", err)
+		}
+		if stored.Status != want {
+			t.Errorf("appointment %s status = %s, want %s", id, stored.Status, want)
+		}
+		if want == models.StatusCancelled && stored.Notes == "" {
+			t.Errorf("expired appointment %s has no note saying why", id)
+		}
+	}
+
+	// The patient is told about each expired proposal, once
+	var subjects []string
+	if err := db.Model(&models.Message{}).Where("sender_id = ? AND receiver_id = ?", doctor.ID, patient.ID).Pluck("subject", &subjects).Error; err != nil {
+		t.Fatalf("loading messages: %v", err)
+	}
+	if len(subjects) != 2 || subjects[0] != "Proposed appointment expired" {
+		t.Errorf("messages to the patient = %v, want two expiry notices", subjects)
+	}
+
+	if expired, err := jobs.ExpireProposals(db, now.Add(-window), now); err != nil || expired != 0 {
+		t.Errorf("second run expired %d, %v; want nothing left to expire", expired, err)
+	}
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"healthcare-app-server/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordPurgeInterval is how often the medical record trash is emptied of expired records.
+const recordPurgeInterval = time.Hour
+
+// StartRecordPurge permanently deletes medical records that have been in the trash longer than
+// retention, once at startup and then every hour. A non-positive retention disables the job.
+func StartRecordPurge(db *gorm.DB, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(recordPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := PurgeDeletedRecords(db, time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("Failed to purge deleted medical records: %v", err)
+			} else if purged > 0 {
+				log.Printf("Permanently deleted %d medical records from the trash", purged)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// PurgeDeletedRecords permanently deletes medical records soft-deleted before cutoff, together
+// with their attachments and diagnoses, and returns how many records were removed. Prescriptions
+// and intake forms that referenced a purged record keep existing without the link.
+func PurgeDeletedRecords(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		if err := tx.Unscoped().Model(&models.MedicalRecord{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.Prescription{}).Where("medical_record_id IN ?", ids).
+			UpdateColumn("medical_record_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.IntakeForm{}).Where("medical_record_id IN ?", ids).
+			UpdateColumn("medical_record_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("medical_record_id IN ?", ids).Delete(&models.MedicalRecordAttachment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("medical_record_id IN ?", ids).Delete(&models.RecordDiagnosis{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.MedicalRecord{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
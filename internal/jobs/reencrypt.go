@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"fmt"
+	"healthcare-app-server/internal/encryption"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// encryptedColumnRow is one row of an encrypted column, read without the model's serializer
+// so the stored bytes can be inspected.
+type encryptedColumnRow struct {
+	ID    string
+	Value []byte
+}
+
+// Reencrypt brings every encrypted column in line with the current settings: attachment files, their previews and,
+// when ENCRYPT_MESSAGES is on, message content are sealed with the primary key, whether they were
+// plaintext or sealed with an older key. With ENCRYPT_MESSAGES off, encrypted message content is
+// decrypted again. Rows are processed batchSize at a time and progress is logged after each batch.
+// Rows that are already up to date are skipped, so an interrupted run can simply be restarted.
+func Reencrypt(db *gorm.DB, ring *encryption.KeyRing, encryptMessages bool, batchSize int) error {
+	rewriteBlob := func(value []byte) (interface{}, bool, error) {
+		if ring.IsCurrent(value) {
+			return nil, false, nil
+		}
+		plaintext, err := ring.Decrypt(value)
+		if err != nil {
+			return nil, false, err
+		}
+		sealed, err := ring.Encrypt(plaintext)
+		return sealed, true, err
+	}
+	if err := reencryptColumn(db, "medical_record_attachments", "file_data", batchSize, rewriteBlob); err != nil {
+		return err
+	}
+	// Attachments that are not images have no preview
+	err := reencryptColumn(db, "medical_record_attachments", "thumbnail_data", batchSize, func(value []byte) (interface{}, bool, error) {
+		if value == nil {
+			return nil, false, nil
+		}
+		return rewriteBlob(value)
+	})
+	if err != nil {
+		return err
+	}
+
+	return reencryptColumn(db, "messages", "content", batchSize, func(value []byte) (interface{}, bool, error) {
+		content := string(value)
+		if encryptMessages && ring.IsCurrentString(content) || !encryptMessages && !encryption.IsEncryptedString(content) {
+			return nil, false, nil
+		}
+		plaintext, err := ring.DecryptString(content)
+		if err != nil {
+			return nil, false, err
+		}
+		if !encryptMessages {
+			return plaintext, true, nil
+		}
+		sealed, err := ring.EncryptString(plaintext)
+		return sealed, true, err
+	})
+}
+
+// reencryptColumn walks table in ID order and stores rewrite's result for every row it reports as changed.
+// Updates bypass the model, so neither the serializer nor updated_at is involved.
+func reencryptColumn(db *gorm.DB, table, column string, batchSize int, rewrite func([]byte) (interface{}, bool, error)) error {
+	var checked, rewritten int64
+	lastID := ""
+	for {
+		var rows []encryptedColumnRow
+		if err := db.Table(table).Select("id, "+column+" AS value").
+			Where("id > ?", lastID).Order("id asc").Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return fmt.Errorf("reencrypt: loading %s: %w", table, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			value, changed, err := rewrite(row.Value)
+			if err != nil {
+				log.Printf("ALERT: reencrypt: failed to rewrite %s.%s of %s: %v", table, column, row.ID, err)
+				return err
+			}
+			if !changed {
+				continue
+			}
+			if err := db.Table(table).Where("id = ?", row.ID).UpdateColumn(column, value).Error; err != nil {
+				return fmt.Errorf("reencrypt: saving %s %s: %w", table, row.ID, err)
+			}
+			rewritten++
+		}
+		checked += int64(len(rows))
+		lastID = rows[len(rows)-1].ID
+		log.Printf("reencrypt: %s: %d rows checked, %d rewritten", table, checked, rewritten)
+	}
+
+	log.Printf("reencrypt: %s done: %d rows checked, %d rewritten", table, checked, rewritten)
+	return nil
+}
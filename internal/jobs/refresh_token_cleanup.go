@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"healthcare-app-server/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenCleanup deletes refresh tokens that have expired. Revoked tokens are kept until they expire,
+// because presenting a revoked token is how the reuse of a rotated token is detected.
+type RefreshTokenCleanup struct {
+	DB *gorm.DB
+}
+
+// Name implements Job.
+func (RefreshTokenCleanup) Name() string { return "refresh_token_cleanup" }
+
+// Run implements Job.
+func (j RefreshTokenCleanup) Run(ctx context.Context, now time.Time) error {
+	result := j.DB.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Deleted %d expired refresh tokens", result.RowsAffected)
+	}
+	return nil
+}
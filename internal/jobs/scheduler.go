@@ -0,0 +1,323 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"healthcare-app-server/internal/models"
+	"log"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Job is a background task run by a Scheduler.
+type Job interface {
+	// Name identifies the job in the job_runs table, the admin API and the logs; it must stay the same
+	// across releases.
+	Name() string
+	// Run does one round of work. now is the time the run started; ctx is cancelled once the run has
+	// held its lock for too long.
+	Run(ctx context.Context, now time.Time) error
+}
+
+// Clock tells the Scheduler the time. Tests replace it to move time forward without waiting.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now().UTC() }
+
+// SystemClock is the wall clock, in UTC.
+var SystemClock Clock = systemClock{}
+
+const (
+	schedulerPollInterval = 30 * time.Second // How often due jobs are looked for when RunNow does not wake the worker
+	jobLockLease          = 30 * time.Minute // Longest a run may take before another server may start the job again
+	jobRetryBase          = time.Minute      // Wait before the first retry; it doubles with every further attempt
+	jobRetryMax           = time.Hour        // Longest wait between two attempts
+)
+
+// Errors returned by Scheduler.RunNow.
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// scheduledJob is a registered job with its schedule.
+type scheduledJob struct {
+	job      Job
+	schedule Schedule
+}
+
+// JobStatus is a registered job's schedule and the state of its runs, as shown to admins.
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Running        bool       `json:"running"`
+	NextRunAt      *time.Time `json:"nextRunAt,omitempty"`
+	LastRunAt      *time.Time `json:"lastRunAt,omitempty"`
+	LastSuccessAt  *time.Time `json:"lastSuccessAt,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	LastDurationMs int64      `json:"lastDurationMs"`
+	Attempts       int        `json:"attempts"` // Failed attempts in a row of the current run
+}
+
+// Scheduler runs registered jobs on their schedules. Run state lives in the job_runs table, so several
+// servers can share the database and each run happens on one of them only. A failed run is retried with
+// a jittered, growing delay until maxAttempts attempts have failed; then the job waits for its next
+// scheduled run. A panicking job counts as a failed attempt and does not take the server down.
+type Scheduler struct {
+	db          *gorm.DB
+	clock       Clock
+	maxAttempts int
+	jobs        map[string]scheduledJob
+	names       []string // In registration order
+	wake        chan struct{}
+
+	mu          sync.Mutex
+	runsCreated bool
+}
+
+// NewScheduler creates a Scheduler that retries a failing run up to maxAttempts attempts in total.
+// Nothing runs until Start is called.
+func NewScheduler(db *gorm.DB, clock Clock, maxAttempts int) *Scheduler {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &Scheduler{
+		db:          db,
+		clock:       clock,
+		maxAttempts: maxAttempts,
+		jobs:        map[string]scheduledJob{},
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Register adds job to run on schedule. Jobs are registered before Start; names must be unique.
+func (s *Scheduler) Register(job Job, schedule Schedule) error {
+	name := job.Name()
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("job %s registered twice", name)
+	}
+	s.jobs[name] = scheduledJob{job: job, schedule: schedule}
+	s.names = append(s.names, name)
+	return nil
+}
+
+// Start runs due jobs in the background. A job that has never run is due straight away.
+func (s *Scheduler) Start() {
+	if len(s.names) == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(schedulerPollInterval)
+		defer ticker.Stop()
+
+		for {
+			s.RunDue()
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// RunDue runs every registered job that is due and not running elsewhere, and waits for them to finish.
+// The background worker calls it on every round; tests call it directly after moving their clock.
+func (s *Scheduler) RunDue() {
+	if err := s.createRuns(); err != nil {
+		log.Printf("jobs: failed to create job runs: %v", err)
+		return
+	}
+
+	now := s.clock.Now()
+	var due []models.JobRun
+	if err := s.db.Where("name IN ? AND next_run_at <= ?", s.names, now).Find(&due).Error; err != nil {
+		log.Printf("jobs: failed to load due jobs: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, run := range due {
+		scheduled, ok := s.jobs[run.Name]
+		if !ok {
+			continue
+		}
+		// Lock the job before running it; the update only succeeds for one server
+		claim := s.db.Model(&models.JobRun{}).
+			Where("id = ? AND next_run_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", run.ID, now, now).
+			UpdateColumns(map[string]interface{}{"locked_until": now.Add(jobLockLease), "last_run_at": now})
+		if claim.Error != nil {
+			log.Printf("jobs: failed to lock %s: %v", run.Name, claim.Error)
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(run models.JobRun) {
+			defer wg.Done()
+			s.execute(scheduled, run, now)
+		}(run)
+	}
+	wg.Wait()
+}
+
+// createRuns inserts the missing job_runs rows of the registered jobs, due now, once per Scheduler.
+// Rows left by an earlier start keep their next run.
+func (s *Scheduler) createRuns() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runsCreated {
+		return nil
+	}
+
+	now := s.clock.Now()
+	for _, name := range s.names {
+		run := models.JobRun{Name: name, NextRunAt: now}
+		if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&run).Error; err != nil {
+			return err
+		}
+	}
+	s.runsCreated = true
+	return nil
+}
+
+// execute runs one attempt of a locked job and stores the outcome, unlocking the job.
+func (s *Scheduler) execute(scheduled scheduledJob, run models.JobRun, startedAt time.Time) {
+	err := runRecovered(scheduled.job, startedAt)
+	finishedAt := s.clock.Now()
+	nextScheduled := scheduled.schedule.Next(finishedAt)
+
+	updates := map[string]interface{}{
+		"locked_until":  nil,
+		"last_duration": finishedAt.Sub(startedAt).Milliseconds(),
+	}
+	if err == nil {
+		updates["next_run_at"] = nextScheduled
+		updates["last_success_at"] = finishedAt
+		updates["last_error"] = ""
+		updates["attempts"] = 0
+	} else {
+		attempts := run.Attempts + 1
+		log.Printf("jobs: %s failed (attempt %d of %d): %v", run.Name, attempts, s.maxAttempts, err)
+		updates["last_error"] = truncate(err.Error(), 1000)
+		retryAt := finishedAt.Add(retryDelay(attempts))
+		if attempts < s.maxAttempts && retryAt.Before(nextScheduled) {
+			updates["next_run_at"] = retryAt
+			updates["attempts"] = attempts
+		} else {
+			// Out of retries, or the next scheduled run comes first and does the work anyway
+			updates["next_run_at"] = nextScheduled
+			updates["attempts"] = 0
+		}
+	}
+
+	if err := s.db.Model(&models.JobRun{}).Where("id = ?", run.ID).UpdateColumns(updates).Error; err != nil {
+		log.Printf("jobs: failed to record the run of %s: %v", run.Name, err)
+	}
+}
+
+// runRecovered runs job, turning a panic into an error.
+func runRecovered(job Job, now time.Time) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobLockLease)
+	defer cancel()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("jobs: %s panicked: %v\n%s", job.Name(), recovered, debug.Stack())
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return job.Run(ctx, now)
+}
+
+// RunNow makes the job called name due immediately and wakes the worker. It returns ErrUnknownJob for
+// a name that is not registered and ErrJobRunning while the job runs.
+func (s *Scheduler) RunNow(name string) error {
+	if _, ok := s.jobs[name]; !ok {
+		return ErrUnknownJob
+	}
+	if err := s.createRuns(); err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	var run models.JobRun
+	if err := s.db.Where("name = ?", name).First(&run).Error; err != nil {
+		return err
+	}
+	if run.LockedUntil != nil && run.LockedUntil.After(now) {
+		return ErrJobRunning
+	}
+	if run.NextRunAt.After(now) {
+		// Attempts stay as they are: a manual run that fails continues the current retries
+		if err := s.db.Model(&run).UpdateColumn("next_run_at", now).Error; err != nil {
+			return err
+		}
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default: // The worker is already woken
+	}
+	return nil
+}
+
+// Status returns every registered job with its run state, in registration order.
+func (s *Scheduler) Status(db *gorm.DB) ([]JobStatus, error) {
+	var runs []models.JobRun
+	if err := db.Where("name IN ?", s.names).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.JobRun, len(runs))
+	for _, run := range runs {
+		byName[run.Name] = run
+	}
+
+	now := s.clock.Now()
+	statuses := make([]JobStatus, 0, len(s.names))
+	for _, name := range s.names {
+		status := JobStatus{Name: name, Schedule: s.jobs[name].schedule.String()}
+		if run, ok := byName[name]; ok {
+			nextRunAt := run.NextRunAt
+			status.Running = run.LockedUntil != nil && run.LockedUntil.After(now)
+			status.NextRunAt = &nextRunAt
+			status.LastRunAt = run.LastRunAt
+			status.LastSuccessAt = run.LastSuccessAt
+			status.LastError = run.LastError
+			status.LastDurationMs = run.LastDuration
+			status.Attempts = run.Attempts
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// retryDelay returns the wait after the given number of failed attempts, with up to half of it again
+// added at random so servers that failed together do not retry in step.
+func retryDelay(attempts int) time.Duration {
+	delay := jobRetryBase
+	for i := 1; i < attempts && delay < jobRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > jobRetryMax {
+		delay = jobRetryMax
+	}
+	return delay + rand.N(delay/2)
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
@@ -0,0 +1,289 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/routes"
+	"healthcare-app-server/internal/slowlog"
+	"healthcare-app-server/internal/testutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeClock is a Clock that only moves when the test advances it.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+func (c *fakeClock) Advance(d time.Duration) { c.Set(c.Now().Add(d)) }
+
+// countingJob counts its runs and returns whatever run returns, or nil without it.
+type countingJob struct {
+	name string
+	run  func(ctx context.Context, now time.Time) error
+
+	mu   sync.Mutex
+	runs int
+}
+
+func (j *countingJob) Name() string { return j.name }
+
+func (j *countingJob) Run(ctx context.Context, now time.Time) error {
+	j.mu.Lock()
+	j.runs++
+	j.mu.Unlock()
+	if j.run == nil {
+		return nil
+	}
+	return j.run(ctx, now)
+}
+
+func (j *countingJob) Runs() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.runs
+}
+
+// newTestScheduler returns a scheduler on db driven by clock, with job registered to run every schedule.
+func newTestScheduler(t *testing.T, db *gorm.DB, clock jobs.Clock, maxAttempts int, job jobs.Job, schedule string) *jobs.Scheduler {
+	t.Helper()
+
+	parsed, err := jobs.ParseSchedule(schedule)
+	if err != nil {
+		t.Fatalf("parsing schedule: %v", err)
+	}
+	scheduler := jobs.NewScheduler(db, clock, maxAttempts)
+	if err := scheduler.Register(job, parsed); err != nil {
+		t.Fatalf("registering job: %v", err)
+	}
+	return scheduler
+}
+
+func loadJobRun(t *testing.T, db *gorm.DB, name string) models.JobRun {
+	t.Helper()
+
+	var run models.JobRun
+	if err := db.Where("name = ?", name).First(&run).Error; err != nil {
+		t.Fatalf("loading job run: %v", err)
+	}
+	return run
+}
+
+func TestSchedulerRestartKeepsTheStoredNextRun(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	start := clock.Now()
+
+	job := &countingJob{name: "hourly"}
+	newTestScheduler(t, db, clock, 1, job, "@every 1h").RunDue()
+	if job.Runs() != 1 {
+		t.Fatalf("runs = %d, want a never-run job to run straight away", job.Runs())
+	}
+	if run := loadJobRun(t, db, "hourly"); !run.NextRunAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("next run = %s, want %s", run.NextRunAt, start.Add(time.Hour))
+	}
+
+	// A restarted server picks the next run up from job_runs instead of running again
+	clock.Advance(10 * time.Minute)
+	restarted := &countingJob{name: "hourly"}
+	scheduler := newTestScheduler(t, db, clock, 1, restarted, "@every 1h")
+	scheduler.RunDue()
+	if restarted.Runs() != 0 {
+		t.Fatalf("runs after restart = %d, want 0 before the stored next run", restarted.Runs())
+	}
+
+	clock.Set(start.Add(time.Hour))
+	scheduler.RunDue()
+	if restarted.Runs() != 1 {
+		t.Errorf("runs at the stored next run = %d, want 1", restarted.Runs())
+	}
+}
+
+func TestSchedulerRetriesAFailingJobWithBackoff(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	job := &countingJob{name: "flaky", run: func(context.Context, time.Time) error { return errors.New("mail server unreachable") }}
+	scheduler := newTestScheduler(t, db, clock, 3, job, "@every 24h")
+
+	// Each retry waits the doubled base delay plus up to half of it again
+	for attempt, wantDelay := range []time.Duration{time.Minute, 2 * time.Minute} {
+		scheduler.RunDue()
+		run := loadJobRun(t, db, "flaky")
+		if run.Attempts != attempt+1 {
+			t.Fatalf("attempts = %d, want %d", run.Attempts, attempt+1)
+		}
+		if run.LastError != "mail server unreachable" {
+			t.Errorf("last error = %q", run.LastError)
+		}
+		delay := run.NextRunAt.Sub(clock.Now())
+		if delay < wantDelay || delay >= wantDelay*3/2 {
+			t.Errorf("attempt %d retries after %s, want between %s and %s", attempt+1, delay, wantDelay, wantDelay*3/2)
+		}
+
+		// Nothing runs before the retry is due
+		scheduler.RunDue()
+		if job.Runs() != attempt+1 {
+			t.Fatalf("runs = %d before the retry was due, want %d", job.Runs(), attempt+1)
+		}
+		clock.Set(run.NextRunAt)
+	}
+
+	// The last allowed attempt fails too, so the job waits for its next scheduled run
+	scheduler.RunDue()
+	if job.Runs() != 3 {
+		t.Fatalf("runs = %d, want 3", job.Runs())
+	}
+	run := loadJobRun(t, db, "flaky")
+	if run.Attempts != 0 {
+		t.Errorf("attempts = %d, want 0 once the retries ran out", run.Attempts)
+	}
+	if want := clock.Now().Add(24 * time.Hour); !run.NextRunAt.Equal(want) {
+		t.Errorf("next run = %s, want the scheduled %s", run.NextRunAt, want)
+	}
+	if run.LastSuccessAt != nil {
+		t.Errorf("last success = %s, want none", run.LastSuccessAt)
+	}
+}
+
+func TestSchedulerRecoversAPanickingJob(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	job := &countingJob{name: "broken", run: func(context.Context, time.Time) error { panic("nil map") }}
+	scheduler := newTestScheduler(t, db, clock, 2, job, "@every 1h")
+
+	scheduler.RunDue()
+
+	run := loadJobRun(t, db, "broken")
+	if !strings.Contains(run.LastError, "panic: nil map") {
+		t.Errorf("last error = %q, want the panic", run.LastError)
+	}
+	if run.Attempts != 1 {
+		t.Errorf("attempts = %d, want the panic to count as a failed attempt", run.Attempts)
+	}
+	if run.LockedUntil != nil {
+		t.Errorf("locked until %s, want the lock released", run.LockedUntil)
+	}
+}
+
+func TestSchedulerLocksAJobWhileItRuns(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+
+	// The job runs on its own goroutine, so it reads the row without failing the test itself
+	var duringRun models.JobRun
+	job := &countingJob{name: "locked"}
+	job.run = func(context.Context, time.Time) error {
+		return db.Where("name = ?", "locked").First(&duringRun).Error
+	}
+	scheduler := newTestScheduler(t, db, clock, 1, job, "@every 1h")
+
+	scheduler.RunDue()
+	if duringRun.LockedUntil == nil || !duringRun.LockedUntil.After(clock.Now()) {
+		t.Fatalf("locked until %v during the run, want a lease in the future", duringRun.LockedUntil)
+	}
+	if run := loadJobRun(t, db, "locked"); run.LockedUntil != nil {
+		t.Errorf("locked until %s after the run, want the lock released", run.LockedUntil)
+	}
+
+	// Another server holds the lock: the due job is skipped and cannot be started by hand
+	lockedUntil := clock.Now().Add(2 * time.Hour)
+	if err := db.Model(&models.JobRun{}).Where("name = ?", "locked").
+		UpdateColumns(map[string]interface{}{"locked_until": lockedUntil, "next_run_at": clock.Now().Add(time.Hour)}).Error; err != nil {
+		t.Fatalf("locking job: %v", err)
+	}
+	clock.Advance(time.Hour)
+	scheduler.RunDue()
+	if job.Runs() != 1 {
+		t.Errorf("runs = %d, want the locked job skipped", job.Runs())
+	}
+	if err := scheduler.RunNow("locked"); !errors.Is(err, jobs.ErrJobRunning) {
+		t.Errorf("RunNow = %v, want ErrJobRunning", err)
+	}
+
+	// Once the lease has passed, e.g. after the other server died, the job is picked up again
+	clock.Set(lockedUntil)
+	scheduler.RunDue()
+	if job.Runs() != 2 {
+		t.Errorf("runs = %d after the lease passed, want 2", job.Runs())
+	}
+}
+
+func TestRunNowRoute(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	cfg := testutil.NewTestConfig()
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	job := &countingJob{name: "report"}
+	scheduler := newTestScheduler(t, db, clock, 1, job, "@daily")
+	router := testutil.NewRouter(cfg)
+	routes.SetupRoutes(router, db, cfg, nil, scheduler, slowlog.New(10))
+
+	admin, patient := testutil.NewTestUser(models.RoleAdmin), testutil.NewTestUser(models.RolePatient)
+	for _, user := range []*models.User{admin, patient} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("creating user: %v", err)
+		}
+	}
+	auth := func(user *models.User) map[string]string {
+		return map[string]string{"Authorization": testutil.BearerHeader(testutil.MintAccessToken(t, cfg, user))}
+	}
+
+	// Run the job once so its next run lies in the future
+	scheduler.RunDue()
+	if job.Runs() != 1 {
+		t.Fatalf("runs = %d, want 1", job.Runs())
+	}
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		job        string
+		wantStatus int
+	}{
+		{"patients cannot start jobs", patient, "report", http.StatusForbidden},
+		{"unknown job", admin, "nightly-backup", http.StatusNotFound},
+		{"admin starts the job", admin, "report", http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, router, http.MethodPost, "/api/v1/admin/jobs/"+tt.job+"/run-now", nil, auth(tt.user))
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+
+	// The job is due now and runs on the next round, well before its daily run
+	if run := loadJobRun(t, db, "report"); run.NextRunAt.After(clock.Now()) {
+		t.Fatalf("next run = %s, want due now", run.NextRunAt)
+	}
+	scheduler.RunDue()
+	if job.Runs() != 2 {
+		t.Errorf("runs = %d after run-now, want 2", job.Runs())
+	}
+
+	var audits int64
+	db.Model(&models.AuditEvent{}).Where("action = ? AND entity_id = ?", "job.run_now", "report").Count(&audits)
+	if audits != 1 {
+		t.Errorf("audit events = %d, want the manual run recorded once", audits)
+	}
+}
@@ -1,35 +1,36 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"healthcare-app-server/internal/config"
 	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/tenancy"
 	"healthcare-app-server/internal/utils"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// AccessTokenCookie is the HTTP-only cookie the access token is delivered in when cfg.AccessTokenCookie is set.
+const AccessTokenCookie = "access_token"
+
 // AuthMiddleware creates a middleware for JWT authentication.
+// The Authorization header always takes precedence; only when it is absent, and cookie delivery
+// is enabled, is the token read from the access_token cookie instead.
 func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			utils.Unauthorized(c, "Authorization header required")
-			c.Abort()
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			utils.Unauthorized(c, "Invalid authorization header format")
+		tokenString, ok := accessTokenFromRequest(c, cfg)
+		if !ok {
 			c.Abort()
 			return
 		}
 
-		tokenString := parts[1]
-		claims, err := utils.ValidateToken(tokenString, cfg.JWTSecret)
+		claims, err := utils.ValidateAccessToken(tokenString, cfg)
 		if err != nil {
-			utils.Unauthorized(c, "Invalid token: "+err.Error())
+			rejectAccessToken(c, err)
 			c.Abort()
 			return
 		}
@@ -37,23 +38,78 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		// Set user information in context for downstream handlers
 		c.Set("userID", claims.UserID)
 		c.Set("userRole", claims.Role)
+		c.Set("tokenClaims", claims)
+
+		if cfg.MultiTenant && claims.Role != models.RoleSuperAdmin {
+			if claims.OrganizationID == "" {
+				utils.Forbidden(c, "tenancy.no_organization")
+				c.Abort()
+				return
+			}
+			scopeToOrganization(c, claims.OrganizationID)
+		}
 
 		c.Next()
 	}
 }
 
+// rejectAccessToken sends the 401 for a token ValidateAccessToken refused. Only an expired token gets
+// the token_expired code, which tells clients a refresh may help; the WWW-Authenticate header follows
+// RFC 6750 either way.
+func rejectAccessToken(c *gin.Context, err error) {
+	code, messageKey, description := utils.CodeInvalidToken, "auth.invalid_token", "The access token is invalid"
+	if errors.Is(err, utils.ErrTokenExpired) {
+		code, messageKey, description = utils.CodeTokenExpired, "auth.token_expired", "The access token expired"
+	}
+	c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, description))
+	utils.ErrorWithCode(c, http.StatusUnauthorized, code, messageKey, err)
+}
+
+// scopeToOrganization limits every query the request makes to the organization's data; see package tenancy.
+func scopeToOrganization(c *gin.Context, organizationID string) {
+	c.Request = c.Request.WithContext(tenancy.WithOrganization(c.Request.Context(), organizationID))
+	// A per-route RequestTimeout starts again from the original context, so that must carry the organization too
+	if original, exists := c.Get("originalRequestContext"); exists {
+		c.Set("originalRequestContext", tenancy.WithOrganization(original.(context.Context), organizationID))
+	}
+}
+
+// accessTokenFromRequest extracts the access token from the Authorization header or, failing that,
+// from the access token cookie. It writes the 401 response itself when no usable token is found.
+func accessTokenFromRequest(c *gin.Context, cfg *config.Config) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		if cfg.AccessTokenCookie {
+			if cookie, err := c.Cookie(AccessTokenCookie); err == nil && cookie != "" {
+				return cookie, true
+			}
+		}
+		c.Header("WWW-Authenticate", "Bearer")
+		utils.Unauthorized(c, "auth.header_required")
+		return "", false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_request"`)
+		utils.Unauthorized(c, "auth.header_invalid")
+		return "", false
+	}
+	return parts[1], true
+}
+
 // RoleAuthMiddleware creates a middleware for role-based authorization.
 // It should be used *after* AuthMiddleware.
 func RoleAuthMiddleware(allowedRoles ...models.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRoleFromContext, exists := c.Get("userRole")
 		if !exists {
-			utils.InternalServerError(c, "User role not found in context. AuthMiddleware might be missing.")
+			utils.InternalServerError(c, "auth.role_missing")
 			c.Abort()
 			return
 		}
 
-		// Assuming userRoleFromContext is a string (like "DOCTOR" from JWT claims)
+		// Set by AuthMiddleware from the token claims, already normalized
 		requestingUserRoleStr, ok := userRoleFromContext.(string)
 		if !ok {
 			// If it's already models.Role, convert to string for comparison
@@ -61,23 +117,24 @@ func RoleAuthMiddleware(allowedRoles ...models.Role) gin.HandlerFunc {
 				requestingUserRoleStr = string(roleFromContext)
 				ok = true // Mark as ok since we converted it
 			} else {
-				utils.InternalServerError(c, "User role in context is not of expected type (string or models.Role).")
+				utils.InternalServerError(c, "auth.role_invalid_type")
 				c.Abort()
 				return
 			}
 		}
 
 		isAllowed := false
-		for _, allowedRole := range allowedRoles { // allowedRole is models.Role (e.g., "doctor")
-			// Perform case-insensitive comparison
-			if strings.EqualFold(requestingUserRoleStr, string(allowedRole)) {
+		for _, allowedRole := range allowedRoles {
+			// Super admins have every admin right
+			if requestingUserRoleStr == string(allowedRole) ||
+				allowedRole == models.RoleAdmin && requestingUserRoleStr == string(models.RoleSuperAdmin) {
 				isAllowed = true
 				break
 			}
 		}
 
 		if !isAllowed {
-			utils.Forbidden(c, "You do not have permission to access this resource.")
+			utils.Forbidden(c, "auth.forbidden")
 			c.Abort()
 			return
 		}
@@ -86,6 +143,16 @@ func RoleAuthMiddleware(allowedRoles ...models.Role) gin.HandlerFunc {
 	}
 }
 
+// PlatformAdminMiddleware guards routes that act on the whole deployment rather than one organization,
+// such as webhooks and retention purges: with MULTI_TENANT on only super admins pass, otherwise admins too.
+// It should be used *after* AuthMiddleware.
+func PlatformAdminMiddleware(cfg *config.Config) gin.HandlerFunc {
+	if cfg.MultiTenant {
+		return RoleAuthMiddleware(models.RoleSuperAdmin)
+	}
+	return RoleAuthMiddleware(models.RoleAdmin)
+}
+
 // Helper function to get user ID from context
 func GetUserIDFromContext(c *gin.Context) (string, bool) {
 	userID, exists := c.Get("userID")
@@ -105,3 +172,13 @@ func GetUserRoleFromContext(c *gin.Context) (models.Role, bool) {
 	role, ok := userRole.(models.Role)
 	return role, ok
 }
+
+// Helper function to get the validated access token claims from context
+func GetClaimsFromContext(c *gin.Context) (*utils.Claims, bool) {
+	claims, exists := c.Get("tokenClaims")
+	if !exists {
+		return nil, false
+	}
+	tokenClaims, ok := claims.(*utils.Claims)
+	return tokenClaims, ok
+}
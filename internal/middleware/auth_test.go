@@ -0,0 +1,184 @@
+package middleware_test
+
+import (
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"healthcare-app-server/internal/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newAuthRouter serves /protected behind AuthMiddleware, echoing the authenticated user, and
+// /admin behind AuthMiddleware and RoleAuthMiddleware(admin).
+func newAuthRouter() *gin.Engine {
+	cfg := testutil.NewTestConfig()
+	router := testutil.NewRouter(cfg)
+	echo := func(c *gin.Context) {
+		userID, _ := middleware.GetUserIDFromContext(c)
+		role, _ := middleware.GetUserRoleFromContext(c)
+		utils.Success(c, "ok", gin.H{"userId": userID, "role": role})
+	}
+	router.GET("/protected", middleware.AuthMiddleware(cfg), echo)
+	router.GET("/admin", middleware.AuthMiddleware(cfg), middleware.RoleAuthMiddleware(models.RoleAdmin), echo)
+	router.GET("/doctors", middleware.AuthMiddleware(cfg), middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), echo)
+	return router
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	cfg := testutil.NewTestConfig()
+	router := newAuthRouter()
+	user := testutil.NewTestUser(models.RolePatient)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantCode      string
+	}{
+		{"valid token", testutil.BearerHeader(testutil.MintAccessToken(t, cfg, user)), http.StatusOK, ""},
+		{"lowercase scheme", "bearer " + testutil.MintAccessToken(t, cfg, user), http.StatusOK, ""},
+		{"expired token", testutil.BearerHeader(testutil.MintExpiredAccessToken(t, cfg, user)), http.StatusUnauthorized, utils.CodeTokenExpired},
+		{"garbage token", testutil.BearerHeader(testutil.GarbageToken), http.StatusUnauthorized, utils.CodeInvalidToken},
+		{"wrong secret", testutil.BearerHeader(testutil.MintAccessTokenWithSecret(t, cfg, user, "another_secret")), http.StatusUnauthorized, utils.CodeInvalidToken},
+		{"missing header", "", http.StatusUnauthorized, ""},
+		{"not a bearer header", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.authorization != "" {
+				headers["Authorization"] = tt.authorization
+			}
+			recorder := testutil.PerformRequest(t, router, http.MethodGet, "/protected", nil, headers)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+
+			response := testutil.DecodeResponse(t, recorder)
+			if tt.wantStatus == http.StatusOK {
+				data, _ := response.Data.(map[string]interface{})
+				if data["userId"] != user.ID || data["role"] != string(models.RolePatient) {
+					t.Errorf("context user = %v, want %s as %s", data, user.ID, models.RolePatient)
+				}
+				return
+			}
+			if response.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", response.Code, tt.wantCode)
+			}
+			if recorder.Header().Get("WWW-Authenticate") == "" {
+				t.Error("no WWW-Authenticate header on the 401")
+			}
+		})
+	}
+}
+
+func TestRoleAuthMiddleware(t *testing.T) {
+	cfg := testutil.NewTestConfig()
+	router := newAuthRouter()
+
+	tests := []struct {
+		name       string
+		role       models.Role
+		path       string
+		wantStatus int
+	}{
+		{"admin on an admin route", models.RoleAdmin, "/admin", http.StatusOK},
+		{"super admin on an admin route", models.RoleSuperAdmin, "/admin", http.StatusOK},
+		{"doctor on an admin route", models.RoleDoctor, "/admin", http.StatusForbidden},
+		{"patient on an admin route", models.RolePatient, "/admin", http.StatusForbidden},
+		{"doctor on a doctor route", models.RoleDoctor, "/doctors", http.StatusOK},
+		{"super admin on a doctor or admin route", models.RoleSuperAdmin, "/doctors", http.StatusOK},
+		{"patient on a doctor route", models.RolePatient, "/doctors", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := testutil.MintAccessToken(t, cfg, testutil.NewTestUser(tt.role))
+			recorder := testutil.PerformRequest(t, router, http.MethodGet, tt.path, nil, map[string]string{"Authorization": testutil.BearerHeader(token)})
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+		})
+	}
+}
+
+// mintLegacyAccessToken signs an access token the way tokens were issued before roles were normalized,
+// with role exactly as given.
+func mintLegacyAccessToken(t *testing.T, secret, userID, role string) string {
+	t.Helper()
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"role":    role,
+		"sub":     userID,
+		"iat":     now.Unix(),
+		"exp":     now.Add(15 * time.Minute).Unix(),
+	})
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("signing legacy token: %v", err)
+	}
+	return signed
+}
+
+func TestLegacyUppercaseRoleTokensStillWork(t *testing.T) {
+	cfg := testutil.NewTestConfig()
+	router := newAuthRouter()
+
+	tests := []struct {
+		role       string
+		path       string
+		wantStatus int
+		wantRole   models.Role
+	}{
+		{"ADMIN", "/admin", http.StatusOK, models.RoleAdmin},
+		{"Super_Admin", "/admin", http.StatusOK, models.RoleSuperAdmin},
+		{"DOCTOR", "/doctors", http.StatusOK, models.RoleDoctor},
+		{"PATIENT", "/protected", http.StatusOK, models.RolePatient},
+		{"PATIENT", "/doctors", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" on "+tt.path, func(t *testing.T) {
+			token := mintLegacyAccessToken(t, cfg.JWTSecret, "legacy-user", tt.role)
+			recorder := testutil.PerformRequest(t, router, http.MethodGet, tt.path, nil, map[string]string{"Authorization": testutil.BearerHeader(token)})
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			// Handlers only ever see the normalized role
+			data, _ := testutil.DecodeResponse(t, recorder).Data.(map[string]interface{})
+			if data["role"] != string(tt.wantRole) {
+				t.Errorf("context role = %v, want %s", data["role"], tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareWWWAuthenticateHints(t *testing.T) {
+	cfg := testutil.NewTestConfig()
+	router := newAuthRouter()
+	user := testutil.NewTestUser(models.RolePatient)
+
+	tests := []struct {
+		name, token, want string
+	}{
+		{"expired", testutil.MintExpiredAccessToken(t, cfg, user), `Bearer error="invalid_token", error_description="The access token expired"`},
+		{"garbage", testutil.GarbageToken, `Bearer error="invalid_token", error_description="The access token is invalid"`},
+		{"wrong secret", testutil.MintAccessTokenWithSecret(t, cfg, user, "another_secret"), `Bearer error="invalid_token", error_description="The access token is invalid"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, router, http.MethodGet, "/protected", nil, map[string]string{"Authorization": testutil.BearerHeader(tt.token)})
+			if got := recorder.Header().Get("WWW-Authenticate"); got != tt.want {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
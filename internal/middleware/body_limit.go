@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodySizeLimit caps the size of the request body at maxBytes.
+// It can be applied globally and again on individual routes: a per-route limit replaces
+// the global one (larger or smaller) because the original body is always re-wrapped.
+// Reading past the limit fails with *http.MaxBytesError, which utils.BindError maps to 413.
+func BodySizeLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		originalBody, exists := c.Get("originalRequestBody")
+		if !exists {
+			originalBody = c.Request.Body
+			c.Set("originalRequestBody", originalBody)
+		}
+		if body, ok := originalBody.(io.ReadCloser); ok && body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, body, maxBytes)
+		}
+
+		c.Next()
+	}
+}
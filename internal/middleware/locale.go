@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"healthcare-app-server/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale selects the response language from the Accept-Language header, falling back to defaultLocale.
+// The chosen locale is stored in the context for the response helpers in utils.
+func Locale(defaultLocale string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.MatchAcceptLanguage(c.GetHeader("Accept-Language"), defaultLocale)
+		c.Set(i18n.LocaleContextKey, locale)
+		c.Header("Content-Language", locale)
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PendingPoliciesHeader lists, under soft enforcement, the policy versions the user still has to accept,
+// as comma-separated type=version pairs.
+const PendingPoliciesHeader = "X-Pending-Policies"
+
+// RequirePolicyAcceptance creates a middleware that holds users to the terms of service and privacy policy
+// versions in effect. With enforcement "hard", a user who has not accepted one of them gets a 451 listing
+// the pending documents; with "soft" the request goes through and PendingPoliciesHeader lists them; with
+// "off" the middleware does nothing. Routes at the exempt paths, such as the one that records the
+// acceptance, are always served. It must run after AuthMiddleware and costs a query per request.
+func RequirePolicyAcceptance(db *gorm.DB, enforcement string, exempt ...string) gin.HandlerFunc {
+	exemptPaths := make(map[string]bool, len(exempt))
+	for _, path := range exempt {
+		exemptPaths[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if enforcement == "off" || exemptPaths[c.FullPath()] {
+			c.Next()
+			return
+		}
+		userID, exists := GetUserIDFromContext(c)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		pending, err := models.PendingPolicies(db.WithContext(c.Request.Context()), userID, time.Now().UTC())
+		if err != nil {
+			if enforcement == "soft" {
+				log.Printf("Failed to check the policy acceptance of user %s: %v", userID, err)
+				c.Next()
+				return
+			}
+			utils.HandleDBError(c, err, "common.database_error")
+			c.Abort()
+			return
+		}
+		if len(pending) == 0 {
+			c.Next()
+			return
+		}
+
+		if enforcement == "soft" {
+			versions := make([]string, 0, len(pending))
+			for _, document := range pending {
+				versions = append(versions, string(document.Type)+"="+document.Version)
+			}
+			c.Header(PendingPoliciesHeader, strings.Join(versions, ", "))
+			c.Next()
+			return
+		}
+		utils.ErrorWithData(c, http.StatusUnavailableForLegalReasons, "policies.acceptance_required", gin.H{"pendingPolicies": pending})
+		c.Abort()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery replaces gin's recovery middleware. A panicking handler is logged with its stack trace
+// and request ID, and the client gets the standard JSON error envelope, including the request ID
+// to quote to support, instead of an empty 500. Errors handlers attached with c.Error without writing
+// a response are answered the same way. It must run after RequestID.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// net/http uses this panic to abort a response on purpose; let it through
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			log.Printf("panic in request %s: %s %s: %v\n%s", utils.RequestID(c), c.Request.Method, c.Request.URL.Path, recovered, debug.Stack())
+
+			// Nothing can be sent to a client that went away or once the response has started
+			if err, ok := recovered.(error); (ok && isConnectionReset(err)) || c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			utils.InternalServerError(c, "common.internal_error")
+			c.Abort()
+		}()
+
+		c.Next()
+
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			utils.InternalServerErrorWithDetail(c, "common.internal_error", c.Errors.Last().Err)
+		}
+	}
+}
+
+// isConnectionReset reports whether err means the client closed the connection.
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
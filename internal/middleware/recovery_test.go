@@ -0,0 +1,90 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"healthcare-app-server/internal/i18n"
+	"healthcare-app-server/internal/testutil"
+	"healthcare-app-server/internal/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newPanicRouter is a test router with deliberately failing routes behind the standard middleware.
+func newPanicRouter() *gin.Engine {
+	router := testutil.NewRouter(testutil.NewTestConfig())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("deliberate test panic")
+	})
+	router.GET("/error", func(c *gin.Context) {
+		c.Error(errors.New("deliberate test error"))
+	})
+	router.GET("/ok", func(c *gin.Context) {
+		utils.Success(c, "ok", nil)
+	})
+	return router
+}
+
+func TestRecoveryAnswersWithTheJSONEnvelope(t *testing.T) {
+	router := newPanicRouter()
+	wantError := i18n.Translate("en", "common.internal_error", nil)
+
+	for _, path := range []string{"/panic", "/error"} {
+		t.Run(path, func(t *testing.T) {
+			recorder := testutil.PerformRequest(t, router, http.MethodGet, path, nil, map[string]string{utils.RequestIDHeader: "support-ref-123"})
+			if recorder.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusInternalServerError, recorder.Body.String())
+			}
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+				t.Errorf("content type = %q", contentType)
+			}
+
+			response := testutil.DecodeResponse(t, recorder)
+			if response.Status != http.StatusInternalServerError || response.Error != wantError {
+				t.Errorf("envelope = %+v", response)
+			}
+			if response.RequestID != "support-ref-123" || recorder.Header().Get(utils.RequestIDHeader) != "support-ref-123" {
+				t.Errorf("request ID in body %q and header %q, want support-ref-123", response.RequestID, recorder.Header().Get(utils.RequestIDHeader))
+			}
+		})
+	}
+}
+
+func TestRecoveryKeepsTheConnectionOpen(t *testing.T) {
+	server := httptest.NewServer(newPanicRouter())
+	defer server.Close()
+	client := server.Client()
+
+	for i, path := range []string{"/panic", "/ok"} {
+		reused := false
+		trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused }}
+		request, err := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatalf("building request: %v", err)
+		}
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+
+		var envelope utils.ResponseData
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			t.Fatalf("%s answered with a non-JSON body %q: %v", path, body, err)
+		}
+		// The request after the panic must go over the same keep-alive connection
+		if i > 0 && !reused {
+			t.Errorf("GET %s opened a new connection; the panic dropped the old one", path)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"healthcare-app-server/internal/utils"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// validRequestID limits client supplied request IDs to short, log-safe values.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID assigns every request an ID, reusing a well-formed X-Request-ID from the client (e.g. a proxy),
+// and echoes it in the response so errors can be correlated with server logs.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(utils.RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Set(utils.RequestIDContextKey, requestID)
+		c.Header(utils.RequestIDHeader, requestID)
+		c.Next()
+	}
+}
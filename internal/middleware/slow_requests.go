@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"healthcare-app-server/internal/slowlog"
+	"healthcare-app-server/internal/utils"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlowRequests tags the request context with the route and request ID, so slow queries can be traced back to
+// the request that made them, and records requests that took longer than threshold in slowLog with a warning.
+// A non-positive threshold only tags the context. It must run after RequestID and before RequestTimeout.
+func SlowRequests(slowLog *slowlog.Log, threshold time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath() // The pattern, never the IDs in the path
+		if c.FullPath() == "" {
+			route = c.Request.Method + " (unmatched)"
+		}
+		requestID := utils.RequestID(c)
+		c.Request = c.Request.WithContext(slowlog.WithRoute(c.Request.Context(), route, requestID))
+
+		started := time.Now()
+		c.Next()
+
+		elapsed := time.Since(started)
+		if threshold <= 0 || elapsed < threshold {
+			return
+		}
+		entry := slowlog.Entry{
+			Kind:       slowlog.KindRequest,
+			At:         time.Now().UTC(),
+			DurationMs: float64(elapsed.Microseconds()) / 1000,
+			Route:      route,
+			RequestID:  requestID,
+			Status:     c.Writer.Status(),
+		}
+		log.Printf("WARN slow request %.1fms status=%d route=%q request=%s", entry.DurationMs, entry.Status, route, requestID)
+		slowLog.Add(entry)
+	}
+}
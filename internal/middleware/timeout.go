@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeout bounds the request context by timeout, so database queries that handlers run with
+// c.Request.Context() are cancelled instead of hanging once the deadline passes.
+// Like BodySizeLimit it can be applied globally and again on individual routes: the per-route
+// timeout replaces the global one because it is always derived from the original context.
+func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		originalCtx, exists := c.Get("originalRequestContext")
+		if !exists {
+			originalCtx = c.Request.Context()
+			c.Set("originalRequestContext", originalCtx)
+		}
+
+		ctx, cancel := context.WithTimeout(originalCtx.(context.Context), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
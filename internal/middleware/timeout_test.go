@@ -0,0 +1,98 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"healthcare-app-server/internal/i18n"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"healthcare-app-server/internal/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// slowQueries makes every query on db take a minute unless its context ends first, like a
+// database that stopped answering.
+func slowQueries(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	err := db.Callback().Query().Before("gorm:query").Register("test:slow_query", func(tx *gorm.DB) {
+		select {
+		case <-tx.Statement.Context.Done():
+			tx.AddError(tx.Statement.Context.Err())
+		case <-time.After(time.Minute):
+		}
+	})
+	if err != nil {
+		t.Fatalf("registering the slow query hook: %v", err)
+	}
+}
+
+func TestRequestTimeoutCancelsSlowQueries(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	slowQueries(t, db)
+	router := testutil.NewRouter(testutil.NewTestConfig(), middleware.RequestTimeout(50*time.Millisecond))
+
+	var handlerErr error
+	router.GET("/slow", func(c *gin.Context) {
+		var count int64
+		if err := db.WithContext(c.Request.Context()).Model(&models.User{}).Count(&count).Error; err != nil {
+			handlerErr = c.Request.Context().Err()
+			utils.HandleDBError(c, err, "common.database_error")
+			return
+		}
+		utils.Success(c, "done", count)
+	})
+
+	started := time.Now()
+	recorder := testutil.PerformRequest(t, router, http.MethodGet, "/slow", nil, nil)
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("the request took %s, the query was not cancelled", elapsed)
+	}
+	if !errors.Is(handlerErr, context.DeadlineExceeded) {
+		t.Errorf("request context error = %v, want context.DeadlineExceeded", handlerErr)
+	}
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d: %s", recorder.Code, http.StatusServiceUnavailable, recorder.Body.String())
+	}
+	response := testutil.DecodeResponse(t, recorder)
+	if want := i18n.Translate("en", "common.request_timed_out", nil); response.Error != want {
+		t.Errorf("error = %q, want %q", response.Error, want)
+	}
+	if response.RequestID == "" {
+		t.Error("the timeout envelope has no request ID")
+	}
+}
+
+func TestRouteRequestTimeoutReplacesTheGlobalOne(t *testing.T) {
+	router := testutil.NewRouter(testutil.NewTestConfig(), middleware.RequestTimeout(20*time.Millisecond))
+	// Waits out 100ms unless the request context ends first
+	wait := func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			utils.ErrorWithDetail(c, http.StatusServiceUnavailable, "common.request_timed_out", c.Request.Context().Err())
+		case <-time.After(100 * time.Millisecond):
+			utils.Success(c, "done", nil)
+		}
+	}
+	router.GET("/default", wait)
+	router.GET("/upload", middleware.RequestTimeout(time.Second), wait)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/default", http.StatusServiceUnavailable},
+		{"/upload", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if recorder := testutil.PerformRequest(t, router, http.MethodGet, tt.path, nil, nil); recorder.Code != tt.wantStatus {
+			t.Errorf("%s status = %d, want %d", tt.path, recorder.Code, tt.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// LoadUser creates a middleware that loads the authenticated user's record once and stores it in the
+// context, where handlers read it with GetUserFromContext instead of querying it themselves.
+// It must run after AuthMiddleware. It costs a query per request, so only add it to routes whose
+// handlers need more than the ID and role carried by the token.
+func LoadUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserIDFromContext(c)
+		if !exists {
+			utils.Unauthorized(c, "common.unauthenticated")
+			c.Abort()
+			return
+		}
+
+		var user models.User
+		if err := db.WithContext(c.Request.Context()).First(&user, "id = ?", userID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				// The token outlived the account
+				utils.Unauthorized(c, "auth.user_not_found")
+			} else {
+				utils.HandleDBError(c, err, "common.database_error")
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("user", &user)
+		c.Next()
+	}
+}
+
+// GetUserFromContext returns the user loaded by LoadUser.
+func GetUserFromContext(c *gin.Context) (*models.User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		return nil, false
+	}
+	loaded, ok := user.(*models.User)
+	return loaded, ok
+}
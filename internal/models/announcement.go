@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// AnnouncementStatus represents the delivery state of a broadcast announcement
+type AnnouncementStatus string
+
+const (
+	AnnouncementStatusPending AnnouncementStatus = "pending"
+	AnnouncementStatusSent    AnnouncementStatus = "sent"
+	AnnouncementStatusFailed  AnnouncementStatus = "failed"
+)
+
+// Announcement records a broadcast sent by an admin to every user of a role.
+// It is delivered by fanning out one Message per recipient, so recipients read it in their
+// regular inbox and its read status is tracked per message.
+type Announcement struct {
+	BaseModel
+	SenderID       string             `gorm:"size:36;index;not null" json:"senderId"`
+	OrganizationID *string            `gorm:"size:36;index" json:"organizationId,omitempty"` // Clinic when MULTI_TENANT is on; only its users receive the announcement
+	TargetRole     string             `gorm:"size:20;not null" json:"targetRole"`            // patient, doctor or all
+	Subject        string             `gorm:"type:text" json:"subject"`
+	Content        string             `gorm:"type:text;not null" json:"content"`
+	Status         AnnouncementStatus `gorm:"size:20;default:'pending'" json:"status"`
+	RecipientCount int64              `gorm:"default:0" json:"recipientCount"`
+	DeliveredAt    *time.Time         `json:"deliveredAt,omitempty"`
+
+	// Relations
+	Sender User `gorm:"foreignKey:SenderID" json:"-"`
+}
+
+// TenantOwnerID makes an announcement created outside a request belong to its sender's organization.
+func (a *Announcement) TenantOwnerID() string {
+	return a.SenderID
+}
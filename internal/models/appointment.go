@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -13,21 +14,77 @@ const (
 	StatusCancelled   AppointmentStatus = "cancelled"
 	StatusCompleted   AppointmentStatus = "completed"
 	StatusRescheduled AppointmentStatus = "rescheduled"
+	StatusNoShow      AppointmentStatus = "no_show"
+	StatusNeedsReview AppointmentStatus = "needs_review" // Set by the sweep when a confirmed appointment ended without an outcome
+	StatusProposed    AppointmentStatus = "proposed"     // Booked for the patient by a doctor or admin, waiting for the patient to accept
 )
 
+// appointmentStatusTransitions lists the statuses each status may move to.
+// Completed, cancelled and no-show appointments are final.
+var appointmentStatusTransitions = map[AppointmentStatus][]AppointmentStatus{
+	StatusPending:     {StatusConfirmed, StatusCancelled, StatusRescheduled},
+	StatusConfirmed:   {StatusCompleted, StatusCancelled, StatusRescheduled, StatusNoShow, StatusNeedsReview},
+	StatusRescheduled: {StatusConfirmed, StatusCancelled, StatusRescheduled, StatusNoShow},
+	StatusNeedsReview: {StatusCompleted, StatusCancelled, StatusNoShow},
+	StatusProposed:    {StatusConfirmed, StatusCancelled},
+	StatusCompleted:   {},
+	StatusCancelled:   {},
+	StatusNoShow:      {},
+}
+
+// Normalize returns the status in the lowercase form the constants use,
+// as clients and older rows may send or hold it in uppercase.
+func (s AppointmentStatus) Normalize() AppointmentStatus {
+	return AppointmentStatus(strings.ToLower(string(s)))
+}
+
+// IsValid reports whether s is one of the defined statuses, in any letter case.
+func (s AppointmentStatus) IsValid() bool {
+	_, ok := appointmentStatusTransitions[s.Normalize()]
+	return ok
+}
+
+// CanTransitionTo reports whether an appointment in status s may move to next.
+func (s AppointmentStatus) CanTransitionTo(next AppointmentStatus) bool {
+	for _, allowed := range appointmentStatusTransitions[s.Normalize()] {
+		if allowed == next.Normalize() {
+			return true
+		}
+	}
+	return false
+}
+
 // Appointment represents a scheduled medical appointment
 type Appointment struct {
 	BaseModel
-	PatientID  string            `gorm:"size:36;index" json:"patientId"`
-	DoctorID   string            `gorm:"size:36;index" json:"doctorId"`
-	StartTime  time.Time         `json:"startTime"`
-	EndTime    time.Time         `json:"endTime"`
-	Status     AppointmentStatus `gorm:"size:20;default:'pending'" json:"status"`
-	Reason     string            `gorm:"size:255" json:"reason"`
-	Notes      string            `gorm:"type:text" json:"notes"`
-	IsFollowUp bool              `gorm:"default:false" json:"isFollowUp"`
+	PatientID      string            `gorm:"size:36;index" json:"patientId"`
+	DoctorID       string            `gorm:"size:36;index" json:"doctorId"`
+	OrganizationID *string           `gorm:"size:36;index" json:"organizationId,omitempty"` // Clinic when MULTI_TENANT is on, from the booking user or the patient
+	StartTime      time.Time         `json:"startTime"`
+	EndTime        time.Time         `json:"endTime"`
+	Status         AppointmentStatus `gorm:"size:20;default:'pending'" json:"status"`
+	Reason         string            `gorm:"size:255" json:"reason"`
+	Notes          string            `gorm:"type:text" json:"notes"`
+	// PrivateNotes are clinical observations visible only to doctors and admins; handlers clear them for patients
+	PrivateNotes string `gorm:"type:text" json:"privateNotes,omitempty"`
+	IsFollowUp   bool   `gorm:"default:false" json:"isFollowUp"`
+	// Optional category; Reason stays as supplemental free text
+	AppointmentTypeID *string `gorm:"size:36;index" json:"appointmentTypeId,omitempty"`
+	// Set when the patient submits an intake form, so doctors see which visits are prepared
+	HasIntake bool `gorm:"default:false" json:"hasIntake"`
+	// The user who booked it; nil for appointments booked before this was recorded
+	CreatedByID *string `gorm:"size:36;index" json:"createdById,omitempty"`
+	// When the patient was emailed a reminder; cleared when the appointment is rescheduled
+	ReminderSentAt *time.Time `json:"-"`
 
 	// Relations
-	Patient User `gorm:"foreignKey:PatientID" json:"-"`
-	Doctor  User `gorm:"foreignKey:DoctorID" json:"-"`
+	Patient         User             `gorm:"foreignKey:PatientID" json:"-"`
+	Doctor          User             `gorm:"foreignKey:DoctorID" json:"-"`
+	CreatedBy       *User            `gorm:"foreignKey:CreatedByID" json:"-"`
+	AppointmentType *AppointmentType `gorm:"foreignKey:AppointmentTypeID" json:"appointmentType,omitempty"`
+}
+
+// TenantOwnerID makes an appointment created outside a request belong to its patient's organization.
+func (a *Appointment) TenantOwnerID() string {
+	return a.PatientID
 }
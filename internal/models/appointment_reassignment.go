@@ -0,0 +1,11 @@
+package models
+
+// AppointmentReassignment records an appointment moved from one doctor to another by an admin,
+// e.g. when a doctor leaves the practice.
+type AppointmentReassignment struct {
+	BaseModel
+	AppointmentID  string `gorm:"size:36;index;not null" json:"appointmentId"`
+	FromDoctorID   string `gorm:"size:36;index;not null" json:"fromDoctorId"`
+	ToDoctorID     string `gorm:"size:36;index;not null" json:"toDoctorId"`
+	ReassignedByID string `gorm:"size:36;not null" json:"reassignedById"` // The admin who moved it
+}
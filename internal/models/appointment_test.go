@@ -0,0 +1,52 @@
+package models
+
+import "testing"
+
+// allAppointmentStatuses lists every status, so each pair of them is checked.
+var allAppointmentStatuses = []AppointmentStatus{
+	StatusPending, StatusConfirmed, StatusCancelled, StatusCompleted, StatusRescheduled,
+	StatusNoShow, StatusNeedsReview, StatusProposed,
+}
+
+func TestAppointmentStatusTransitions(t *testing.T) {
+	allowed := map[[2]AppointmentStatus]bool{
+		{StatusPending, StatusConfirmed}:       true,
+		{StatusPending, StatusCancelled}:       true,
+		{StatusPending, StatusRescheduled}:     true,
+		{StatusConfirmed, StatusCompleted}:     true,
+		{StatusConfirmed, StatusCancelled}:     true,
+		{StatusConfirmed, StatusRescheduled}:   true,
+		{StatusConfirmed, StatusNoShow}:        true,
+		{StatusConfirmed, StatusNeedsReview}:   true,
+		{StatusRescheduled, StatusConfirmed}:   true,
+		{StatusRescheduled, StatusCancelled}:   true,
+		{StatusRescheduled, StatusRescheduled}: true,
+		{StatusRescheduled, StatusNoShow}:      true,
+		{StatusNeedsReview, StatusCompleted}:   true,
+		{StatusNeedsReview, StatusCancelled}:   true,
+		{StatusNeedsReview, StatusNoShow}:      true,
+		{StatusProposed, StatusConfirmed}:      true,
+		{StatusProposed, StatusCancelled}:      true,
+	}
+
+	for _, from := range allAppointmentStatuses {
+		for _, to := range allAppointmentStatuses {
+			want := allowed[[2]AppointmentStatus{from, to}]
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s allowed = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestAppointmentStatusTransitionsIgnoreCase(t *testing.T) {
+	if !AppointmentStatus("PENDING").CanTransitionTo("Confirmed") {
+		t.Error("PENDING -> Confirmed was rejected")
+	}
+	if AppointmentStatus("COMPLETED").CanTransitionTo(StatusPending) {
+		t.Error("COMPLETED -> pending was allowed")
+	}
+	if AppointmentStatus("archived").CanTransitionTo(StatusConfirmed) {
+		t.Error("a transition from an unknown status was allowed")
+	}
+}
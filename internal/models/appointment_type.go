@@ -0,0 +1,12 @@
+package models
+
+// AppointmentType is an admin managed category of appointment (e.g. "Consultation", "Follow-up")
+// with the length new appointments of that type are booked for
+type AppointmentType struct {
+	BaseModel
+	Name                   string `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Description            string `gorm:"type:text" json:"description"`
+	DefaultDurationMinutes int    `gorm:"not null" json:"defaultDurationMinutes"`
+	Color                  string `gorm:"size:7" json:"color"` // Hex color used by the booking UI, e.g. "#4caf50"
+	Active                 bool   `gorm:"not null;index" json:"active"`
+}
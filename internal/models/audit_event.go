@@ -0,0 +1,21 @@
+package models
+
+// AuditEvent records a security- or compliance-relevant action: who did what to which entity.
+// It holds identifiers only, never record content, so it can be kept and shown to admins freely.
+type AuditEvent struct {
+	BaseModel
+	ActorID        *string `gorm:"size:36;index" json:"actorId,omitempty"`                           // Nil for actions taken by background jobs
+	OrganizationID *string `gorm:"size:36;index" json:"organizationId,omitempty"`                    // Clinic when MULTI_TENANT is on, from the actor
+	Action         string  `gorm:"size:100;index;not null" json:"action"`                            // e.g. record_amendment.requested
+	EntityType     string  `gorm:"size:50;index:idx_audit_events_entity;not null" json:"entityType"` // e.g. record_amendment
+	EntityID       string  `gorm:"size:36;index:idx_audit_events_entity;not null" json:"entityId"`
+	Details        string  `gorm:"size:500" json:"details,omitempty"` // Short machine-readable context, such as a new version number
+}
+
+// TenantOwnerID makes an event recorded outside a request belong to its actor's organization.
+func (e *AuditEvent) TenantOwnerID() string {
+	if e.ActorID == nil {
+		return ""
+	}
+	return *e.ActorID
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	_ "healthcare-app-server/internal/encryption" // Registers the serializers behind the encrypted columns
 	"time"
 
 	"github.com/google/uuid"
@@ -35,24 +36,79 @@ func InitDB(config DatabaseConfig) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := UseUTC(DB); err != nil {
+		return nil, err
+	}
 
+	if err := Migrate(DB); err != nil {
+		return nil, err
+	}
+	if config.MessageFullTextSearch {
+		if err := EnsureMessageFullTextIndex(DB); err != nil {
+			return nil, err
+		}
+	}
+
+	return DB, nil
+}
+
+// Migrate creates or updates the schema for all models and runs the data migrations.
+// It is shared by InitDB and test databases so both always have the same schema.
+func Migrate(db *gorm.DB) error {
 	// Auto migrate the database models
-	err = DB.AutoMigrate(
+	err := db.AutoMigrate(
+		&Organization{},
 		&User{},
+		&DoctorProfile{},
+		&DoctorAvailability{},
 		&RefreshToken{},
 		&MedicalRecord{},
 		&MedicalRecordAttachment{},
+		&RecordDiagnosis{},
+		&RecordAmendmentRequest{},
+		&ConsentGrant{},
+		&ConsentGrantRecord{},
+		&RecordTemplate{},
+		&Department{},
+		&DoctorDepartment{},
+		&AppointmentType{},
 		&Appointment{},
+		&TimeOff{},
+		&AppointmentReassignment{},
+		&IntakeForm{},
 		&Message{},
+		&ConversationState{},
+		&Conversation{},
+		&LoginEvent{},
+		&AuditEvent{},
+		&Invitation{},
+		&Review{},
+		&WaitlistEntry{},
+		&Prescription{},
+		&Vital{},
+		&Announcement{},
+		&WebhookSubscription{},
+		&WebhookDelivery{},
+		&JobRun{},
+		&PolicyDocument{},
+		&UserPolicyAcceptance{},
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return DB, nil
+	// Data migrations for columns added after rows already existed
+	if err := backfillConversationIDs(db); err != nil {
+		return err
+	}
+	if err := backfillConversations(db); err != nil {
+		return err
+	}
+	return normalizeRoles(db)
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	DSN string
+	DSN                   string
+	MessageFullTextSearch bool // Create the FULLTEXT index message search uses, see EnsureMessageFullTextIndex
 }
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ConsentScope is what a consent grant lets its doctor read.
+type ConsentScope string
+
+const (
+	ConsentScopeAllRecords ConsentScope = "all_records" // Every record of the patient, including later ones
+	ConsentScopeRecords    ConsentScope = "records"     // Only the records listed in the grant
+)
+
+// ConsentGrant is a patient's permission for a doctor to read their medical records written by other
+// doctors. It only matters with STRICT_RECORD_ACCESS, when doctors otherwise only see their own records.
+type ConsentGrant struct {
+	BaseModel
+	PatientID      string       `gorm:"size:36;not null;index:idx_consent_grants_patient_doctor" json:"patientId"`
+	DoctorID       string       `gorm:"size:36;not null;index:idx_consent_grants_patient_doctor;index" json:"doctorId"`
+	OrganizationID *string      `gorm:"size:36;index" json:"organizationId,omitempty"` // Clinic when MULTI_TENANT is on, from the patient
+	Scope          ConsentScope `gorm:"size:20;not null" json:"scope"`
+	ExpiresAt      *time.Time   `json:"expiresAt,omitempty"` // Nil for a grant that lasts until revoked
+	Revoked        bool         `gorm:"not null;default:false" json:"revoked"`
+	RevokedAt      *time.Time   `json:"revokedAt,omitempty"`
+
+	// Relations
+	Records []ConsentGrantRecord `gorm:"foreignKey:ConsentGrantID" json:"records,omitempty"` // With the records scope
+}
+
+// ConsentGrantRecord is a medical record covered by a consent grant with the records scope
+type ConsentGrantRecord struct {
+	BaseModel
+	ConsentGrantID  string `gorm:"size:36;not null;uniqueIndex:idx_consent_grant_record" json:"-"`
+	MedicalRecordID string `gorm:"size:36;not null;uniqueIndex:idx_consent_grant_record;index" json:"medicalRecordId"`
+}
+
+// IsActive reports whether the grant gives access at now: it is neither revoked nor expired.
+func (g *ConsentGrant) IsActive(now time.Time) bool {
+	return !g.Revoked && (g.ExpiresAt == nil || g.ExpiresAt.After(now))
+}
+
+// TenantOwnerID makes a grant created outside a request belong to its patient's organization.
+func (g *ConsentGrant) TenantOwnerID() string {
+	return g.PatientID
+}
@@ -0,0 +1,223 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Conversation is the thread of messages between two users. Its ID is the ConversationKey of the pair,
+// the value every one of its messages carries in Message.ConversationID. Creating a message updates its
+// conversation in the same transaction (see Message.AfterCreate), so the list of conversations is read
+// from here instead of being worked out from the messages.
+type Conversation struct {
+	ID        string    `gorm:"primaryKey;size:64" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// The two participants; A is the one whose ID sorts first, as in ConversationKey
+	ParticipantAID string    `gorm:"size:36;not null;index:idx_conversations_a_last" json:"participantAId"`
+	ParticipantBID string    `gorm:"size:36;not null;index:idx_conversations_b_last" json:"participantBId"`
+	Subject        string    `gorm:"type:text" json:"subject"` // Subject of the first message that had one
+	LastMessageAt  time.Time `gorm:"index:idx_conversations_a_last;index:idx_conversations_b_last" json:"lastMessageAt"`
+	LastMessageID  string    `gorm:"size:36" json:"lastMessageId"`
+	// Messages each participant received and has not read yet
+	UnreadA int `gorm:"not null;default:0" json:"-"`
+	UnreadB int `gorm:"not null;default:0" json:"-"`
+	// Whether each participant archived the conversation; a new message brings it back for its receiver
+	ArchivedA bool `gorm:"not null;default:false" json:"-"`
+	ArchivedB bool `gorm:"not null;default:false" json:"-"`
+
+	// Relations
+	LastMessage *Message `gorm:"foreignKey:LastMessageID" json:"-"`
+}
+
+// conversationParticipants orders a pair of user IDs the way ConversationKey does.
+func conversationParticipants(userA, userB string) (string, string) {
+	if strings.ToLower(userA) > strings.ToLower(userB) {
+		return userB, userA
+	}
+	return userA, userB
+}
+
+// IsParticipantA reports whether userID is the conversation's participant A.
+func (c Conversation) IsParticipantA(userID string) bool {
+	return c.ParticipantAID == userID
+}
+
+// PartnerID returns the other participant than userID.
+func (c Conversation) PartnerID(userID string) string {
+	if c.IsParticipantA(userID) {
+		return c.ParticipantBID
+	}
+	return c.ParticipantAID
+}
+
+// UnreadFor returns how many messages userID has not read in the conversation.
+func (c Conversation) UnreadFor(userID string) int {
+	if c.IsParticipantA(userID) {
+		return c.UnreadA
+	}
+	return c.UnreadB
+}
+
+// ArchivedFor reports whether userID archived the conversation.
+func (c Conversation) ArchivedFor(userID string) bool {
+	if c.IsParticipantA(userID) {
+		return c.ArchivedA
+	}
+	return c.ArchivedB
+}
+
+// participantColumn returns the column of the per-participant field (unread or archived) that belongs to
+// userID in the conversation between userID and partnerID.
+func participantColumn(field, userID, partnerID string) string {
+	if a, _ := conversationParticipants(userID, partnerID); a == userID {
+		return field + "_a"
+	}
+	return field + "_b"
+}
+
+// recordConversationMessage moves message's conversation on to it, creating the conversation with its
+// first message. The receiver gets one more unread message and sees the conversation again if they had
+// archived it.
+func recordConversationMessage(db *gorm.DB, message *Message) error {
+	a, b := conversationParticipants(message.SenderID, message.ReceiverID)
+	unreadColumn := participantColumn("unread", message.ReceiverID, message.SenderID)
+	archivedColumn := participantColumn("archived", message.ReceiverID, message.SenderID)
+	unread := 0
+	if message.Status != MessageStatusRead {
+		unread = 1
+	}
+
+	conversation := Conversation{
+		ID:             message.ConversationID,
+		ParticipantAID: a,
+		ParticipantBID: b,
+		Subject:        message.Subject,
+		LastMessageAt:  message.CreatedAt,
+		LastMessageID:  message.ID,
+	}
+	if unreadColumn == "unread_a" {
+		conversation.UnreadA = unread
+	} else {
+		conversation.UnreadB = unread
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_message_at": message.CreatedAt,
+			"last_message_id": message.ID,
+			"subject":         gorm.Expr("CASE WHEN subject = '' THEN ? ELSE subject END", message.Subject),
+			unreadColumn:      gorm.Expr(unreadColumn+" + ?", unread),
+			archivedColumn:    false,
+			"updated_at":      message.CreatedAt,
+		}),
+	}).Create(&conversation).Error
+}
+
+// RecountUnread sets the unread count of userID in the conversation conversationID from the messages,
+// after some of them were read. Messages whose conversation the backfill has not created yet are skipped.
+func RecountUnread(db *gorm.DB, conversationID, userID string) error {
+	var conversation Conversation
+	if err := db.Select("id", "participant_a_id").First(&conversation, "id = ?", conversationID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	var unread int64
+	if err := db.Model(&Message{}).
+		Where("conversation_id = ? AND receiver_id = ? AND status <> ?", conversationID, userID, MessageStatusRead).
+		Count(&unread).Error; err != nil {
+		return err
+	}
+	column := "unread_b"
+	if conversation.IsParticipantA(userID) {
+		column = "unread_a"
+	}
+	return db.Model(&conversation).UpdateColumn(column, unread).Error
+}
+
+// SetConversationArchived sets whether userID archived their conversation with partnerID. It does nothing
+// while the two have no messages yet.
+func SetConversationArchived(db *gorm.DB, userID, partnerID string, archived bool) error {
+	return db.Model(&Conversation{}).Where("id = ?", ConversationKey(userID, partnerID)).
+		UpdateColumn(participantColumn("archived", userID, partnerID), archived).Error
+}
+
+// syncConversation creates or rebuilds the conversation conversationID from its messages and the
+// participants' archive settings.
+func syncConversation(db *gorm.DB, conversationID string) error {
+	var last Message
+	if err := db.Select("id", "sender_id", "receiver_id", "created_at").
+		Where("conversation_id = ?", conversationID).
+		Order("created_at desc").
+		First(&last).Error; err != nil {
+		return err
+	}
+	a, b := conversationParticipants(last.SenderID, last.ReceiverID)
+
+	var subjects []string
+	if err := db.Model(&Message{}).Where("conversation_id = ? AND subject <> ?", conversationID, "").
+		Order("created_at asc").Limit(1).Pluck("subject", &subjects).Error; err != nil {
+		return err
+	}
+	conversation := Conversation{
+		ID:             conversationID,
+		ParticipantAID: a,
+		ParticipantBID: b,
+		LastMessageAt:  last.CreatedAt,
+		LastMessageID:  last.ID,
+	}
+	if len(subjects) > 0 {
+		conversation.Subject = subjects[0]
+	}
+
+	for _, side := range []struct {
+		userID, partnerID string
+		unread            *int
+		archived          *bool
+	}{
+		{a, b, &conversation.UnreadA, &conversation.ArchivedA},
+		{b, a, &conversation.UnreadB, &conversation.ArchivedB},
+	} {
+		var unread int64
+		if err := db.Model(&Message{}).
+			Where("conversation_id = ? AND receiver_id = ? AND status <> ?", conversationID, side.userID, MessageStatusRead).
+			Count(&unread).Error; err != nil {
+			return err
+		}
+		*side.unread = int(unread)
+
+		var archived int64
+		if err := db.Model(&ConversationState{}).
+			Where("user_id = ? AND partner_id = ? AND archived = ?", side.userID, side.partnerID, true).
+			Count(&archived).Error; err != nil {
+			return err
+		}
+		*side.archived = archived > 0
+	}
+
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&conversation).Error
+}
+
+// backfillConversations creates the conversations of messages sent before conversations were stored.
+// It is a no-op once every message's conversation exists.
+func backfillConversations(db *gorm.DB) error {
+	var conversationIDs []string
+	if err := db.Model(&Message{}).Distinct("conversation_id").
+		Where("conversation_id <> ? AND conversation_id NOT IN (?)", "", db.Model(&Conversation{}).Select("id")).
+		Pluck("conversation_id", &conversationIDs).Error; err != nil {
+		return err
+	}
+	for _, conversationID := range conversationIDs {
+		if err := syncConversation(db, conversationID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package models
+
+// ConversationState holds one user's own settings for their conversation with a partner.
+// Each participant has a separate row, so archiving or muting never affects the other side.
+type ConversationState struct {
+	BaseModel
+	UserID         string `gorm:"size:36;not null;uniqueIndex:idx_conversation_state_user_partner" json:"userId"`
+	PartnerID      string `gorm:"size:36;not null;uniqueIndex:idx_conversation_state_user_partner" json:"partnerId"`
+	ConversationID string `gorm:"size:64;index" json:"conversationId"` // Same key as Message.ConversationID, see ConversationKey
+	// Kept in step with the user's side of the Conversation, which the conversation list reads
+	Archived bool `gorm:"not null;default:false" json:"archived"`
+	Muted    bool `gorm:"not null;default:false" json:"muted"` // Suppresses new-message notifications, not the messages
+}
@@ -0,0 +1,21 @@
+package models
+
+// Department is an admin managed hospital department (e.g. "Cardiology") whose name is the canonical
+// value of the department field of medical records
+type Department struct {
+	BaseModel
+	Name        string `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	Active      bool   `gorm:"not null;index" json:"active"`
+}
+
+// DoctorDepartment assigns a doctor to a department; a doctor can work in several
+type DoctorDepartment struct {
+	BaseModel
+	DoctorID     string `gorm:"size:36;not null;uniqueIndex:idx_doctor_department" json:"doctorId"`
+	DepartmentID string `gorm:"size:36;not null;uniqueIndex:idx_doctor_department;index" json:"departmentId"`
+
+	// Relations
+	Doctor     User       `gorm:"foreignKey:DoctorID" json:"-"`
+	Department Department `gorm:"foreignKey:DepartmentID" json:"-"`
+}
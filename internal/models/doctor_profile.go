@@ -0,0 +1,30 @@
+package models
+
+// DoctorProfile holds the practice details of a user with the doctor role
+type DoctorProfile struct {
+	BaseModel
+	DoctorID  string `gorm:"size:36;uniqueIndex;not null" json:"doctorId"`
+	Specialty string `gorm:"size:100;index" json:"specialty"`
+	Bio       string `gorm:"type:text" json:"bio"`
+	// Patients who can be booked into overlapping appointments, more than 1 for group sessions
+	MaxConcurrentPerSlot int `gorm:"not null;default:1" json:"maxConcurrentPerSlot"`
+	// Appointments the doctor can take per (UTC) day, 0 means no limit
+	MaxAppointmentsPerDay int `gorm:"not null;default:0" json:"maxAppointmentsPerDay"`
+	// IANA timezone the doctor's schedule days are shown in by default, empty for UTC
+	Timezone string `gorm:"size:64" json:"timezone"`
+
+	// Relations
+	Doctor User `gorm:"foreignKey:DoctorID" json:"-"`
+}
+
+// DoctorAvailability is a block of working hours that repeats every week on the same weekday
+type DoctorAvailability struct {
+	BaseModel
+	DoctorID  string `gorm:"size:36;index;not null" json:"doctorId"`
+	Weekday   int    `gorm:"not null" json:"weekday"`          // 0 = Sunday ... 6 = Saturday, as time.Weekday
+	StartTime string `gorm:"size:5;not null" json:"startTime"` // Local clinic time, HH:MM
+	EndTime   string `gorm:"size:5;not null" json:"endTime"`   // Local clinic time, HH:MM, after StartTime
+
+	// Relations
+	Doctor User `gorm:"foreignKey:DoctorID" json:"-"`
+}
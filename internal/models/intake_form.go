@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// IntakeForm is the symptom questionnaire a patient fills in before an appointment
+type IntakeForm struct {
+	BaseModel
+	AppointmentID   string    `gorm:"size:36;uniqueIndex;not null" json:"appointmentId"` // One form per appointment
+	PatientID       string    `gorm:"size:36;index;not null" json:"patientId"`
+	Symptoms        string    `gorm:"type:text;not null" json:"symptoms"`
+	SymptomDuration string    `gorm:"size:100" json:"symptomDuration"` // e.g. "3 days"
+	Medications     string    `gorm:"type:text" json:"medications"`    // Medications the patient currently takes
+	Allergies       string    `gorm:"type:text" json:"allergies"`
+	AdditionalNotes string    `gorm:"type:text" json:"additionalNotes"`
+	SubmittedAt     time.Time `json:"submittedAt"` // Last time the patient submitted or revised the form
+	// Set once a doctor converts the form into a consultation note
+	MedicalRecordID *string `gorm:"size:36" json:"medicalRecordId,omitempty"`
+
+	// Relations
+	Appointment Appointment `gorm:"foreignKey:AppointmentID" json:"-"`
+	Patient     User        `gorm:"foreignKey:PatientID" json:"-"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Invitation lets the holder of its emailed link register an account with a role that public
+// registration does not grant, such as doctor or admin. Only a hash of the token is stored.
+type Invitation struct {
+	BaseModel
+	Email          string     `gorm:"size:255;index;not null" json:"email"`
+	Role           Role       `gorm:"size:20;not null" json:"role"`
+	OrganizationID *string    `gorm:"size:36;index" json:"organizationId,omitempty"` // Clinic the account joins when MULTI_TENANT is on
+	TokenHash      string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expiresAt"`
+	AcceptedAt     *time.Time `json:"acceptedAt,omitempty"` // Set when the account is registered; the link then stops working
+	InvitedByID    string     `gorm:"size:36;index;not null" json:"invitedById"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// JobRun is the stored state of a scheduled background job, one row per job name. Keeping the next run
+// in the database means a restart neither runs a job again early nor skips a run that fell due while the
+// server was down, and servers sharing the database claim each run only once.
+type JobRun struct {
+	BaseModel
+	Name string `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	// When the job is due next. A run that fails is retried by moving this forward by the retry delay.
+	NextRunAt time.Time `gorm:"index" json:"nextRunAt"`
+	// Set while a server runs the job, so no other server starts it too. A server that died mid-run leaves it
+	// behind; the job is picked up again once it has passed.
+	LockedUntil   *time.Time `json:"-"`
+	LastRunAt     *time.Time `json:"lastRunAt,omitempty"`                  // When the last attempt started
+	LastSuccessAt *time.Time `json:"lastSuccessAt,omitempty"`              // When an attempt last finished without error
+	LastError     string     `gorm:"type:text" json:"lastError,omitempty"` // Empty when the last attempt succeeded
+	LastDuration  int64      `gorm:"default:0" json:"lastDurationMs"`      // Milliseconds the last attempt took
+	// Failed attempts in a row of the current run; reset when it succeeds or the retries run out
+	Attempts int `gorm:"default:0" json:"attempts"`
+}
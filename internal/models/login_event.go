@@ -0,0 +1,12 @@
+package models
+
+// LoginEvent records a single login attempt, successful or not.
+type LoginEvent struct {
+	BaseModel
+	UserID        *string `gorm:"size:36;index" json:"userId,omitempty"` // Nil when the attempted email matches no user
+	Email         string  `gorm:"size:255;index" json:"email"`           // Email the attempt was made with
+	IPAddress     string  `gorm:"size:45" json:"ipAddress"`
+	UserAgent     string  `gorm:"size:512" json:"userAgent"`
+	Success       bool    `gorm:"default:false" json:"success"`
+	FailureReason string  `gorm:"size:100" json:"failureReason,omitempty"`
+}
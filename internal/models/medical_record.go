@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // MedicalRecordType represents the type of medical record
@@ -17,29 +19,68 @@ const (
 	RecordTypeDischargeSummary MedicalRecordType = "DischargeSummary"
 )
 
+// MedicalRecordTypes lists every defined record type.
+var MedicalRecordTypes = []MedicalRecordType{
+	RecordTypeConsultation,
+	RecordTypeLabResult,
+	RecordTypePrescription,
+	RecordTypeImagingReport,
+	RecordTypeVaccination,
+	RecordTypeAllergy,
+	RecordTypeDischargeSummary,
+}
+
+// IsValid reports whether t is exactly one of the defined record types; the comparison is case-sensitive.
+func (t MedicalRecordType) IsValid() bool {
+	for _, recordType := range MedicalRecordTypes {
+		if t == recordType {
+			return true
+		}
+	}
+	return false
+}
+
 // MedicalRecord represents a patient's medical record
 type MedicalRecord struct {
 	BaseModel
-	PatientID  string            `gorm:"size:36;index" json:"patientId"`
-	DoctorID   string            `gorm:"size:36;index" json:"doctorId"`
-	RecordType MedicalRecordType `gorm:"size:50" json:"recordType"`
-	RecordDate time.Time         `json:"date"`
-	Title      string            `gorm:"size:255;not null" json:"title"`
-	Department string            `gorm:"size:100" json:"department"`
-	Summary    string            `gorm:"type:text" json:"summary"`
-	Details    string            `gorm:"type:text" json:"details"`
+	PatientID      string            `gorm:"size:36;index" json:"patientId"`
+	DoctorID       string            `gorm:"size:36;index" json:"doctorId"`
+	OrganizationID *string           `gorm:"size:36;index" json:"organizationId,omitempty"` // Clinic when MULTI_TENANT is on, from the writing doctor or the patient
+	RecordType     MedicalRecordType `gorm:"size:50" json:"recordType"`
+	RecordDate     time.Time         `json:"date"`
+	Title          string            `gorm:"size:255;not null" json:"title"`
+	Department     string            `gorm:"size:100" json:"department"`
+	Summary        string            `gorm:"type:text" json:"summary"`
+	Details        string            `gorm:"type:text" json:"details"`
+	// Version is incremented on every update; clients send back the version they read so concurrent edits are detected
+	Version int `gorm:"not null;default:1" json:"version"`
+	// Deleted records stay in the trash until the purge job removes them after the retention window
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
 	Patient     User                      `gorm:"foreignKey:PatientID" json:"-"`
 	Doctor      User                      `gorm:"foreignKey:DoctorID" json:"-"`
 	Attachments []MedicalRecordAttachment `gorm:"foreignKey:MedicalRecordID" json:"attachments,omitempty"`
+	Diagnoses   []RecordDiagnosis         `gorm:"foreignKey:MedicalRecordID" json:"diagnoses,omitempty"`
 }
 
 // MedicalRecordAttachment represents a file attached to a medical record
 type MedicalRecordAttachment struct {
 	BaseModel
-	MedicalRecordID string `json:"medicalRecordId" gorm:"not null;type:varchar(36)"` // Changed from uint to string to match MedicalRecord.ID
-	FileName        string `json:"fileName" gorm:"not null"`                         // Original name of the file
-	FileType        string `json:"fileType" gorm:"not null"`                         // MIME type of the file
-	FileData        []byte `json:"-" gorm:"type:longblob;not null"`                  // File content as binary data (longblob for MySQL)
+	MedicalRecordID string `json:"medicalRecordId" gorm:"not null;type:varchar(36)"`     // Changed from uint to string to match MedicalRecord.ID
+	FileName        string `json:"fileName" gorm:"not null"`                             // Original name of the file
+	FileType        string `json:"fileType" gorm:"not null"`                             // MIME type of the file
+	FileData        []byte `json:"-" gorm:"type:longblob;not null;serializer:encrypted"` // File content, encrypted at rest when ENCRYPTION_KEYS is set
+	ContentHash     string `json:"-" gorm:"size:64"`                                     // Hex SHA-256 of FileData, used as the download ETag
+	ThumbnailData   []byte `json:"-" gorm:"type:mediumblob;serializer:encrypted"`        // JPEG preview of image files, encrypted like FileData
+	HasThumbnail    bool   `json:"hasThumbnail" gorm:"not null;default:false"`           // Whether ThumbnailData holds a preview, so listings need not load it
+}
+
+// AttachmentMetadataColumns lists the attachment columns other than the file data and thumbnail,
+// for queries that must not load the blob.
+var AttachmentMetadataColumns = []string{"id", "medical_record_id", "file_name", "file_type", "content_hash", "has_thumbnail", "created_at", "updated_at"}
+
+// TenantOwnerID makes a record created outside a request belong to its patient's organization.
+func (r *MedicalRecord) TenantOwnerID() string {
+	return r.PatientID
 }
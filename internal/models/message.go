@@ -1,7 +1,12 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // MessageStatus represents the status of a message
@@ -16,15 +21,83 @@ const (
 // Message represents a message between users
 type Message struct {
 	BaseModel
-	SenderID   string        `gorm:"size:36;index" json:"senderId"`
-	ReceiverID string        `gorm:"size:36;index" json:"receiverId"`
-	ParentID   string        `gorm:"size:36;index" json:"parentId,omitempty"`
-	Content    string        `gorm:"type:text" json:"content"`
-	Subject    string        `gorm:"type:text" json:"subject"`
-	Status     MessageStatus `gorm:"size:20;default:'sent'" json:"status"`
-	ReadAt     *time.Time    `json:"readAt,omitempty"`
+	SenderID       string        `gorm:"size:36;index" json:"senderId"`
+	ReceiverID     string        `gorm:"size:36;index" json:"receiverId"`
+	OrganizationID *string       `gorm:"size:36;index" json:"organizationId,omitempty"` // Clinic when MULTI_TENANT is on, from the sender
+	ConversationID string        `gorm:"size:64;index" json:"conversationId"`           // Deterministic key of the sender/receiver pair, see ConversationKey
+	ParentID       string        `gorm:"size:36;index" json:"parentId,omitempty"`
+	Content        string        `gorm:"type:text;serializer:encrypted_optional" json:"content"` // Encrypted at rest when ENCRYPT_MESSAGES is on
+	Subject        string        `gorm:"type:text" json:"subject"`
+	Status         MessageStatus `gorm:"size:20;default:'sent'" json:"status"`
+	ReadAt         *time.Time    `json:"readAt,omitempty"`
+	// ArchivedAt hides the message from default listings; archiving is reversible and never deletes data
+	ArchivedAt *time.Time `gorm:"index" json:"archivedAt,omitempty"`
 
 	// Relations
 	Sender   User `gorm:"foreignKey:SenderID" json:"sender"`
 	Receiver User `gorm:"foreignKey:ReceiverID" json:"receiver"`
 }
+
+// ConversationKey returns the conversation identifier for a pair of users.
+// The IDs are sorted before hashing so both participants map to the same key.
+func ConversationKey(userA, userB string) string {
+	a, b := strings.ToLower(userA), strings.ToLower(userB)
+	if a > b {
+		a, b = b, a
+	}
+	sum := sha256.Sum256([]byte(a + ":" + b))
+	return hex.EncodeToString(sum[:])
+}
+
+// BeforeCreate sets the UUID and fills in the conversation key for every new message
+func (m *Message) BeforeCreate(tx *gorm.DB) error {
+	if err := m.BaseModel.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if m.ConversationID == "" {
+		m.ConversationID = ConversationKey(m.SenderID, m.ReceiverID)
+	}
+	return nil
+}
+
+// AfterCreate records the message on its conversation. It runs in the transaction that creates the
+// message, so every message, including those sent by jobs and announcements, is counted exactly once.
+func (m *Message) AfterCreate(tx *gorm.DB) error {
+	return recordConversationMessage(tx.Session(&gorm.Session{NewDB: true}), m)
+}
+
+// backfillConversationIDs sets the conversation key on messages created before the column existed.
+// It processes rows in batches and is a no-op once every message has a key.
+func backfillConversationIDs(db *gorm.DB) error {
+	var messages []Message
+	return db.Select("id", "sender_id", "receiver_id").
+		Where("conversation_id IS NULL OR conversation_id = ?", "").
+		FindInBatches(&messages, 500, func(tx *gorm.DB, batch int) error {
+			for _, msg := range messages {
+				if err := tx.Model(&Message{}).Where("id = ?", msg.ID).
+					UpdateColumn("conversation_id", ConversationKey(msg.SenderID, msg.ReceiverID)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// MessageFullTextIndex is the MySQL FULLTEXT index over message subjects and content that message search
+// matches against when MESSAGE_SEARCH_FULLTEXT is on.
+const MessageFullTextIndex = "idx_messages_fulltext"
+
+// EnsureMessageFullTextIndex creates MessageFullTextIndex unless it exists. Other databases than MySQL
+// have no FULLTEXT indexes and are left alone.
+func EnsureMessageFullTextIndex(db *gorm.DB) error {
+	if db.Dialector.Name() != "mysql" || db.Migrator().HasIndex(&Message{}, MessageFullTextIndex) {
+		return nil
+	}
+	return db.Exec("CREATE FULLTEXT INDEX " + MessageFullTextIndex + " ON messages (subject, content)").Error
+}
+
+// TenantOwnerID makes a message created outside a request, e.g. by a reminder job or a super admin's
+// announcement, belong to its receiver's organization.
+func (m *Message) TenantOwnerID() string {
+	return m.ReceiverID
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Organization is a clinic sharing the deployment with others when MULTI_TENANT is on. Its users,
+// appointments, medical records, messages and announcements are only visible within it.
+type Organization struct {
+	BaseModel
+	Name string `gorm:"size:255;not null" json:"name"`
+	// InviteCode lets patients and doctors register into the organization; rotating it invalidates the old one
+	InviteCode string `gorm:"size:32;uniqueIndex;not null" json:"inviteCode"`
+}
+
+// RotateInviteCode gives the organization a new random invite code.
+func (o *Organization) RotateInviteCode() error {
+	code := make([]byte, 8)
+	if _, err := rand.Read(code); err != nil {
+		return err
+	}
+	o.InviteCode = hex.EncodeToString(code)
+	return nil
+}
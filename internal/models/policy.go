@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PolicyType is the kind of legal document users accept.
+type PolicyType string
+
+const (
+	PolicyTermsOfService PolicyType = "terms_of_service"
+	PolicyPrivacy        PolicyType = "privacy_policy"
+)
+
+// IsValid reports whether t is one of the defined policy types.
+func (t PolicyType) IsValid() bool {
+	return t == PolicyTermsOfService || t == PolicyPrivacy
+}
+
+// PolicyDocument is one version of a terms of service or privacy policy. The version of a type in
+// effect is the one with the latest EffectiveAt that has passed, so publishing a new version is adding
+// a document, possibly ahead of its date. Documents someone accepted are never changed, so the
+// acceptances keep pointing at what was agreed to.
+type PolicyDocument struct {
+	BaseModel
+	Type        PolicyType `gorm:"size:30;not null;uniqueIndex:idx_policy_documents_type_version;uniqueIndex:idx_policy_documents_type_effective" json:"type"`
+	Version     string     `gorm:"size:50;not null;uniqueIndex:idx_policy_documents_type_version" json:"version"`
+	Content     string     `gorm:"type:text" json:"content,omitempty"`
+	URL         string     `gorm:"size:500" json:"url,omitempty"` // Where the document is published, instead of or besides its content
+	EffectiveAt time.Time  `gorm:"not null;uniqueIndex:idx_policy_documents_type_effective" json:"effectiveAt"`
+}
+
+// UserPolicyAcceptance records a user accepting a policy document, as proof of consent. The type and
+// version are copied from the document so the record reads on its own.
+type UserPolicyAcceptance struct {
+	BaseModel
+	UserID           string     `gorm:"size:36;not null;uniqueIndex:idx_policy_acceptances_user_document" json:"userId"`
+	PolicyDocumentID string     `gorm:"size:36;not null;uniqueIndex:idx_policy_acceptances_user_document" json:"policyDocumentId"`
+	PolicyType       PolicyType `gorm:"size:30;not null" json:"policyType"`
+	Version          string     `gorm:"size:50;not null" json:"version"`
+	AcceptedAt       time.Time  `gorm:"not null" json:"acceptedAt"`
+	IPAddress        string     `gorm:"size:45" json:"ipAddress"`
+}
+
+// currentPolicyDocuments limits a query of policy documents to the version of each type in effect at now.
+func currentPolicyDocuments(db *gorm.DB, now time.Time) *gorm.DB {
+	latest := db.Session(&gorm.Session{NewDB: true}).Model(&PolicyDocument{}).
+		Select("type, MAX(effective_at) AS effective_at").
+		Where("effective_at <= ?", now).
+		Group("type")
+	return db.Model(&PolicyDocument{}).
+		Where("(type, effective_at) IN (?)", latest).
+		Order("type asc")
+}
+
+// CurrentPolicies returns the version of each policy type in effect at now. Types nothing was
+// published for yet are left out.
+func CurrentPolicies(db *gorm.DB, now time.Time) ([]PolicyDocument, error) {
+	var documents []PolicyDocument
+	err := currentPolicyDocuments(db, now).Find(&documents).Error
+	return documents, err
+}
+
+// PendingPolicies returns the policy documents in effect at now that userID has not accepted.
+func PendingPolicies(db *gorm.DB, userID string, now time.Time) ([]PolicyDocument, error) {
+	accepted := db.Session(&gorm.Session{NewDB: true}).Model(&UserPolicyAcceptance{}).
+		Select("policy_document_id").
+		Where("user_id = ?", userID)
+	var documents []PolicyDocument
+	err := currentPolicyDocuments(db, now).
+		Where("id NOT IN (?)", accepted).
+		Omit("content").
+		Find(&documents).Error
+	return documents, err
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// PrescriptionStatus represents whether a prescription can still be dispensed
+type PrescriptionStatus string
+
+const (
+	PrescriptionStatusActive  PrescriptionStatus = "active"
+	PrescriptionStatusExpired PrescriptionStatus = "expired"
+)
+
+// Prescription represents a structured medication order, optionally linked to the medical record it was issued in
+type Prescription struct {
+	BaseModel
+	PatientID       string             `gorm:"size:36;index;not null" json:"patientId"`
+	DoctorID        string             `gorm:"size:36;index;not null" json:"doctorId"` // Prescribing doctor
+	MedicalRecordID *string            `gorm:"size:36;index" json:"medicalRecordId,omitempty"`
+	MedicationName  string             `gorm:"size:255;not null" json:"medicationName"`
+	Dosage          string             `gorm:"size:100;not null" json:"dosage"`    // e.g. "500 mg"
+	Frequency       string             `gorm:"size:100;not null" json:"frequency"` // e.g. "twice daily"
+	DurationDays    int                `json:"durationDays"`
+	Refills         int                `gorm:"default:0" json:"refills"`
+	IssueDate       time.Time          `json:"issueDate"`
+	Status          PrescriptionStatus `gorm:"size:20;default:'active';index" json:"status"`
+	Notes           string             `gorm:"type:text" json:"notes"`
+
+	// Relations
+	Patient       User           `gorm:"foreignKey:PatientID" json:"-"`
+	Doctor        User           `gorm:"foreignKey:DoctorID" json:"-"`
+	MedicalRecord *MedicalRecord `gorm:"foreignKey:MedicalRecordID" json:"-"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// AmendmentStatus is the state of a patient's request to correct a medical record.
+type AmendmentStatus string
+
+const (
+	AmendmentStatusPending  AmendmentStatus = "pending"
+	AmendmentStatusAccepted AmendmentStatus = "accepted"
+	AmendmentStatusRejected AmendmentStatus = "rejected"
+)
+
+// RecordAmendmentRequest is a patient's request to correct their medical record. The record's doctor or an
+// admin accepts it, optionally changing the record at the same time, or rejects it with a reason.
+type RecordAmendmentRequest struct {
+	BaseModel
+	MedicalRecordID string          `gorm:"size:36;index;not null" json:"medicalRecordId"`
+	PatientID       string          `gorm:"size:36;index;not null" json:"patientId"`
+	RequestedChange string          `gorm:"type:text;not null" json:"requestedChange"`
+	Status          AmendmentStatus `gorm:"size:20;default:'pending';index" json:"status"`
+	Response        string          `gorm:"type:text" json:"response,omitempty"` // The doctor's answer; required when rejecting
+	RespondedByID   *string         `gorm:"size:36" json:"respondedById,omitempty"`
+	RespondedAt     *time.Time      `json:"respondedAt,omitempty"`
+	AppliedVersion  *int            `json:"appliedVersion,omitempty"` // Record version that contains the accepted change, if one was applied
+}
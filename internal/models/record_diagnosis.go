@@ -0,0 +1,11 @@
+package models
+
+// RecordDiagnosis is an ICD-10 coded diagnosis on a medical record, for coding-based reporting and
+// insurance claims that the free-text details cannot serve. A record holds each code at most once.
+type RecordDiagnosis struct {
+	BaseModel
+	MedicalRecordID string `gorm:"size:36;not null;uniqueIndex:idx_record_diagnosis_code" json:"medicalRecordId"`
+	Code            string `gorm:"size:10;not null;uniqueIndex:idx_record_diagnosis_code;index" json:"code"` // Normalized ICD-10 code, e.g. E11.9
+	Description     string `gorm:"size:255" json:"description"`
+	AddedByID       string `gorm:"size:36" json:"addedById"`
+}
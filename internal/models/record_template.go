@@ -0,0 +1,15 @@
+package models
+
+// RecordTemplate is a reusable skeleton for a medical record of a common consultation type.
+// Templates owned by a doctor are private to them; templates without an owner are global and admin managed.
+// Records created from a template copy its values, so editing or deleting it never changes existing records.
+type RecordTemplate struct {
+	BaseModel
+	OwnerDoctorID   *string           `gorm:"size:36;index" json:"ownerDoctorId"` // nil for global templates
+	Name            string            `gorm:"size:100;not null" json:"name"`
+	RecordType      MedicalRecordType `gorm:"size:50;index" json:"recordType"`
+	TitlePattern    string            `gorm:"size:255" json:"titlePattern"` // May contain {patientName} and {date}
+	Department      string            `gorm:"size:100" json:"department"`
+	SummarySkeleton string            `gorm:"type:text" json:"summarySkeleton"`
+	DetailsSkeleton string            `gorm:"type:text" json:"detailsSkeleton"`
+}
@@ -11,7 +11,27 @@ type RefreshToken struct {
 	Token     string    `gorm:"type:text;not null" json:"-"`
 	ExpiresAt time.Time `json:"expiresAt"`
 	IsRevoked bool      `gorm:"default:false" json:"isRevoked"`
+	// LastUsedAt is the last time the session refreshed: when this token was issued by a refresh or login,
+	// or when it was itself used to refresh. Nil for tokens issued before it was tracked.
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+
+	// Rotation tracking: every token issued by refreshing shares the FamilyID of the token issued at login,
+	// and ParentTokenID points at the token it replaced. Nil ParentTokenID means the token was issued at login.
+	FamilyID      string  `gorm:"size:36;index" json:"-"`
+	ParentTokenID *string `gorm:"size:36;index" json:"-"`
 
 	// Define the relationship to User
 	User User `gorm:"foreignKey:UserID" json:"-"`
 }
+
+// IdleExpired reports whether the token went unused for longer than idle at now. An idle of 0 never expires.
+func (t RefreshToken) IdleExpired(now time.Time, idle time.Duration) bool {
+	if idle <= 0 {
+		return false
+	}
+	lastUsed := t.CreatedAt
+	if t.LastUsedAt != nil {
+		lastUsed = *t.LastUsedAt
+	}
+	return now.Sub(lastUsed) > idle
+}
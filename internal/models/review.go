@@ -0,0 +1,16 @@
+package models
+
+// Review represents a patient's rating of a doctor after a completed appointment
+type Review struct {
+	BaseModel
+	AppointmentID string `gorm:"size:36;uniqueIndex;not null" json:"appointmentId"` // One review per appointment, enforced by the DB
+	PatientID     string `gorm:"size:36;index;not null" json:"patientId"`
+	DoctorID      string `gorm:"size:36;index;not null" json:"doctorId"`
+	Rating        int    `gorm:"not null" json:"rating"`
+	Comment       string `gorm:"type:text" json:"comment"`
+
+	// Relations
+	Appointment Appointment `gorm:"foreignKey:AppointmentID" json:"-"`
+	Patient     User        `gorm:"foreignKey:PatientID" json:"-"`
+	Doctor      User        `gorm:"foreignKey:DoctorID" json:"-"`
+}
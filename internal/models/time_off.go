@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// TimeOff is a period, such as a vacation, during which a doctor cannot be booked
+type TimeOff struct {
+	BaseModel
+	DoctorID    string    `gorm:"size:36;index;not null" json:"doctorId"`
+	StartTime   time.Time `gorm:"not null" json:"startTime"`
+	EndTime     time.Time `gorm:"not null" json:"endTime"`
+	Reason      string    `gorm:"size:255" json:"reason"`
+	CreatedByID string    `gorm:"size:36;not null" json:"createdById"` // The doctor or the admin who added the block
+
+	// Relations
+	Doctor User `gorm:"foreignKey:DoctorID" json:"-"`
+}
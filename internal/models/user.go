@@ -1,9 +1,13 @@
 package models
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 // Role enum
@@ -13,26 +17,61 @@ const (
 	RoleAdmin   Role = "admin"
 	RoleDoctor  Role = "doctor"
 	RolePatient Role = "patient"
-	RoleUser    Role = "user"
+	// RoleSuperAdmin administers every organization when MULTI_TENANT is on, while RoleAdmin is limited to
+	// its own. It can do anything RoleAdmin can and is only granted with cmd/super-admin, never through the API.
+	RoleSuperAdmin Role = "super_admin"
 )
 
+// legacyRoleUser is the column default older rows were created with; it always meant a patient.
+const legacyRoleUser Role = "user"
+
+// Normalize returns the role in the lowercase form the constants use, as older rows and tokens
+// may hold it in uppercase. The legacy "user" role becomes RolePatient.
+func (r Role) Normalize() Role {
+	role := Role(strings.ToLower(string(r)))
+	if role == legacyRoleUser {
+		return RolePatient
+	}
+	return role
+}
+
+// IsValid reports whether r is one of the defined roles, in any letter case.
+func (r Role) IsValid() bool {
+	switch r.Normalize() {
+	case RoleAdmin, RoleDoctor, RolePatient, RoleSuperAdmin:
+		return true
+	}
+	return false
+}
+
+// IsAdmin reports whether r has admin rights: RoleAdmin, or RoleSuperAdmin which has them everywhere.
+func (r Role) IsAdmin() bool {
+	return r == RoleAdmin || r == RoleSuperAdmin
+}
+
 // User represents a user in the system
 type User struct {
 	BaseModel
 	Email             string     `gorm:"uniqueIndex;size:255;not null" json:"email"`
 	Password          string     `gorm:"size:255;not null" json:"-"` // Never send password in JSON
-	FirstName         string     `gorm:"size:100" json:"firstName"`
-	LastName          string     `gorm:"size:100" json:"lastName"`
-	Role              Role       `gorm:"size:20;default:'user'" json:"role"`
+	FirstName         string     `gorm:"size:100;index" json:"firstName"`
+	LastName          string     `gorm:"size:100;index" json:"lastName"`
+	Role              Role       `gorm:"size:20;default:'patient'" json:"role"`
 	DateOfBirth       *time.Time `json:"dateOfBirth,omitempty"`
-	PhoneNumber       string     `json:"phoneNumber,omitempty"`
-	Address           string     `json:"address,omitempty"`
-	ProfileImage      string     `json:"profileImage,omitempty"`
-	VerificationToken string     `gorm:"size:255" json:"-"`
-	IsVerified        bool       `gorm:"default:false" json:"isVerified"`
-	ResetToken        string     `gorm:"size:255" json:"-"`
-	ResetTokenExpiry  *time.Time `json:"-"`
-	GoogleID          string     `gorm:"size:255" json:"-"`
+	PhoneNumber       string     `gorm:"size:30" json:"phoneNumber,omitempty"`
+	Address           string     `gorm:"size:255" json:"address,omitempty"`
+	ProfileImage      string     `gorm:"size:500" json:"profileImage,omitempty"` // URL of the user's picture
+	VerificationToken string     `gorm:"size:255;index" json:"-"`
+	// When the emailed verification link stops working
+	VerificationTokenExpiry *time.Time `json:"-"`
+	IsVerified              bool       `gorm:"default:false" json:"isVerified"`
+	ResetToken              string     `gorm:"size:255" json:"-"`
+	ResetTokenExpiry        *time.Time `json:"-"`
+	GoogleID                string     `gorm:"size:255" json:"-"`
+	LastLoginAt             *time.Time `json:"lastLoginAt,omitempty"`
+	LastLoginIP             string     `gorm:"size:45" json:"-"`
+	// Clinic the user belongs to when MULTI_TENANT is on; nil for super admins and users from before it
+	OrganizationID *string `gorm:"size:36;index" json:"organizationId,omitempty"`
 
 	// Relations (not always preloaded)
 	RefreshTokens       []RefreshToken  `gorm:"foreignKey:UserID" json:"-"`
@@ -41,27 +80,75 @@ type User struct {
 	MedicalRecords      []MedicalRecord `gorm:"foreignKey:PatientID" json:"-"`
 	SentMessages        []Message       `gorm:"foreignKey:SenderID" json:"-"`
 	ReceivedMessages    []Message       `gorm:"foreignKey:ReceiverID" json:"-"`
+	LoginEvents         []LoginEvent    `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// BeforeSave stores the role normalized, so roles can be compared exactly everywhere else.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.Role = u.Role.Normalize()
+	return nil
+}
+
+// normalizeRoles rewrites the roles of rows saved before BeforeSave normalized them.
+// Matching on LOWER(role) works whatever the column's collation, and the updates are
+// no-ops once every row is normalized.
+func normalizeRoles(db *gorm.DB) error {
+	for _, role := range []Role{RoleAdmin, RoleDoctor, RolePatient, RoleSuperAdmin, legacyRoleUser} {
+		if err := db.Model(&User{}).Where("LOWER(role) = ?", role).
+			UpdateColumn("role", role.Normalize()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // UserSanitized represents the user data that is safe to send in API responses.
 type UserSanitized struct {
-	ID           string     `json:"id"`
-	Email        string     `json:"email"`
-	FirstName    string     `json:"firstName"`
-	LastName     string     `json:"lastName"`
-	Role         Role       `json:"role"`
-	DateOfBirth  *time.Time `json:"dateOfBirth,omitempty"`
-	PhoneNumber  string     `json:"phoneNumber,omitempty"`
-	Address      string     `json:"address,omitempty"`
-	ProfileImage string     `json:"profileImage,omitempty"`
-	IsVerified   bool       `json:"isVerified"`
-	CreatedAt    time.Time  `json:"createdAt"`
-	UpdatedAt    time.Time  `json:"updatedAt"`
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	FirstName      string     `json:"firstName"`
+	LastName       string     `json:"lastName"`
+	Role           Role       `json:"role"`
+	OrganizationID *string    `json:"organizationId,omitempty"`
+	DateOfBirth    *time.Time `json:"dateOfBirth,omitempty"`
+	PhoneNumber    string     `json:"phoneNumber,omitempty"`
+	Address        string     `json:"address,omitempty"`
+	ProfileImage   string     `json:"profileImage,omitempty"`
+	IsVerified     bool       `json:"isVerified"`
+	LastLoginAt    *time.Time `json:"lastLoginAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// passwordCost is the bcrypt cost new password hashes are made with. It is configured at startup via SetPasswordCost.
+var passwordCost = bcrypt.DefaultCost
+
+// SetPasswordCost sets the bcrypt cost of new password hashes.
+func SetPasswordCost(cost int) {
+	if cost >= bcrypt.MinCost && cost <= bcrypt.MaxCost {
+		passwordCost = cost
+	}
 }
 
 // SetPassword hashes a password and sets it on the user
 func (u *User) SetPassword(password string) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hashedPassword)
+	return nil
+}
+
+// SetRandomPassword sets a random password nobody knows, for accounts whose owner chooses
+// their own password through a reset link. The password has 256 bits of entropy, so the
+// minimum bcrypt cost is enough and bulk imports stay fast.
+func (u *User) SetRandomPassword() error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), bcrypt.MinCost)
 	if err != nil {
 		return err
 	}
@@ -75,20 +162,29 @@ func (u *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// PasswordNeedsRehash reports whether the user's password hash was made with a lower bcrypt cost
+// than the configured one, so it should be replaced the next time the plaintext is known.
+func (u *User) PasswordNeedsRehash() bool {
+	cost, err := bcrypt.Cost([]byte(u.Password))
+	return err == nil && cost < passwordCost
+}
+
 // Sanitize creates a UserSanitized struct from a User model, excluding sensitive data.
 func (u *User) Sanitize() UserSanitized {
 	return UserSanitized{
-		ID:           u.ID,
-		Email:        u.Email,
-		FirstName:    u.FirstName,
-		LastName:     u.LastName,
-		Role:         u.Role,
-		DateOfBirth:  u.DateOfBirth,
-		PhoneNumber:  u.PhoneNumber,
-		Address:      u.Address,
-		ProfileImage: u.ProfileImage,
-		IsVerified:   u.IsVerified,
-		CreatedAt:    u.CreatedAt,
-		UpdatedAt:    u.UpdatedAt,
+		ID:             u.ID,
+		Email:          u.Email,
+		FirstName:      u.FirstName,
+		LastName:       u.LastName,
+		Role:           u.Role,
+		OrganizationID: u.OrganizationID,
+		DateOfBirth:    u.DateOfBirth,
+		PhoneNumber:    u.PhoneNumber,
+		Address:        u.Address,
+		ProfileImage:   u.ProfileImage,
+		IsVerified:     u.IsVerified,
+		LastLoginAt:    u.LastLoginAt,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
 	}
 }
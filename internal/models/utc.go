@@ -0,0 +1,67 @@
+package models
+
+import (
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// UseUTC makes db store every timestamp in UTC: automatic CreatedAt/UpdatedAt values are taken
+// in UTC and the time fields of models being created or saved are converted to UTC first, so clients
+// sending other offsets get the same instant back in UTC. Used by InitDB and test databases.
+func UseUTC(db *gorm.DB) error {
+	db.Config.NowFunc = func() time.Time {
+		return time.Now().UTC()
+	}
+
+	if err := db.Callback().Create().Before("gorm:create").Register("app:utc_times", normalizeTimesToUTC); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("app:utc_times", normalizeTimesToUTC)
+}
+
+// normalizeTimesToUTC converts the time.Time and *time.Time fields of the statement's model, or of each
+// model in a batch, to UTC.
+func normalizeTimesToUTC(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+
+	switch value := db.Statement.ReflectValue; value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			normalizeModelTimes(db, reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		normalizeModelTimes(db, value)
+	}
+}
+
+// normalizeModelTimes converts the non-zero time fields of a single model to UTC.
+func normalizeModelTimes(db *gorm.DB, model reflect.Value) {
+	if model.Kind() != reflect.Struct {
+		return
+	}
+	for _, field := range db.Statement.Schema.Fields {
+		if field.IndirectFieldType != reflect.TypeOf(time.Time{}) || field.DataType != schema.Time {
+			continue
+		}
+		value, isZero := field.ValueOf(db.Statement.Context, model)
+		if isZero {
+			continue
+		}
+		switch t := value.(type) {
+		case time.Time:
+			if t.Location() != time.UTC {
+				db.AddError(field.Set(db.Statement.Context, model, t.UTC()))
+			}
+		case *time.Time:
+			if t != nil && t.Location() != time.UTC {
+				utc := t.UTC()
+				db.AddError(field.Set(db.Statement.Context, model, &utc))
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// VitalType is the kind of measurement a Vital holds
+type VitalType string
+
+const (
+	VitalBloodPressure    VitalType = "blood_pressure" // Value is the systolic, SecondaryValue the diastolic pressure
+	VitalHeartRate        VitalType = "heart_rate"
+	VitalWeight           VitalType = "weight"
+	VitalGlucose          VitalType = "glucose"
+	VitalTemperature      VitalType = "temperature"
+	VitalOxygenSaturation VitalType = "oxygen_saturation"
+)
+
+// VitalTypes lists every defined vital type.
+var VitalTypes = []VitalType{
+	VitalBloodPressure,
+	VitalHeartRate,
+	VitalWeight,
+	VitalGlucose,
+	VitalTemperature,
+	VitalOxygenSaturation,
+}
+
+// vitalUnits lists the units each vital type may be recorded in; the first is the default.
+var vitalUnits = map[VitalType][]string{
+	VitalBloodPressure:    {"mmHg"},
+	VitalHeartRate:        {"bpm"},
+	VitalWeight:           {"kg", "lb"},
+	VitalGlucose:          {"mg/dL", "mmol/L"},
+	VitalTemperature:      {"C", "F"},
+	VitalOxygenSaturation: {"%"},
+}
+
+// IsValid reports whether t is exactly one of the defined vital types.
+func (t VitalType) IsValid() bool {
+	_, ok := vitalUnits[t]
+	return ok
+}
+
+// Units returns the units t may be recorded in, the default first.
+func (t VitalType) Units() []string {
+	return vitalUnits[t]
+}
+
+// Vital is a single measurement of a patient, self-recorded or taken by a doctor
+type Vital struct {
+	BaseModel
+	PatientID      string    `gorm:"size:36;index:idx_vitals_patient_type_time,priority:1;not null" json:"patientId"`
+	Type           VitalType `gorm:"size:30;index:idx_vitals_patient_type_time,priority:2;not null" json:"type"`
+	Value          float64   `gorm:"not null" json:"value"`
+	SecondaryValue *float64  `json:"secondaryValue,omitempty"` // Only for blood_pressure: the diastolic pressure
+	Unit           string    `gorm:"size:10;not null" json:"unit"`
+	RecordedAt     time.Time `gorm:"index:idx_vitals_patient_type_time,priority:3;not null" json:"recordedAt"`
+	RecordedByID   string    `gorm:"size:36;not null" json:"recordedById"` // The patient or the doctor who took it
+	Notes          string    `gorm:"size:500" json:"notes,omitempty"`
+
+	// Relations
+	Patient User `gorm:"foreignKey:PatientID" json:"-"`
+}
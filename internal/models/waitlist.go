@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// WaitlistEntry represents a patient queueing for a cancellation in a doctor's calendar
+type WaitlistEntry struct {
+	BaseModel
+	PatientID   string     `gorm:"size:36;index;not null" json:"patientId"`
+	DoctorID    string     `gorm:"size:36;index;not null" json:"doctorId"`
+	DesiredFrom time.Time  `gorm:"not null" json:"desiredFrom"`
+	DesiredTo   time.Time  `gorm:"not null" json:"desiredTo"`
+	NotifiedAt  *time.Time `json:"notifiedAt,omitempty"` // Set once the patient has been told about an opened slot
+
+	// Relations
+	Patient User `gorm:"foreignKey:PatientID" json:"-"`
+	Doctor  User `gorm:"foreignKey:DoctorID" json:"-"`
+}
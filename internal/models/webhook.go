@@ -0,0 +1,82 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook events delivered to subscriptions.
+const (
+	WebhookAppointmentCreated     = "appointment.created"
+	WebhookAppointmentConfirmed   = "appointment.confirmed"
+	WebhookAppointmentCancelled   = "appointment.cancelled"
+	WebhookAppointmentRescheduled = "appointment.rescheduled"
+	WebhookMedicalRecordCreated   = "medicalrecord.created"
+	WebhookMessageSent            = "message.sent"
+)
+
+// WebhookEvents lists every event a subscription can ask for.
+var WebhookEvents = []string{
+	WebhookAppointmentCreated,
+	WebhookAppointmentConfirmed,
+	WebhookAppointmentCancelled,
+	WebhookAppointmentRescheduled,
+	WebhookMedicalRecordCreated,
+	WebhookMessageSent,
+}
+
+// WebhookSubscription is an external endpoint that receives the events it subscribed to,
+// e.g. a clinic's practice-management software. Subscriptions are managed by admins.
+type WebhookSubscription struct {
+	BaseModel
+	URL    string `gorm:"size:2048;not null" json:"url"`
+	Secret string `gorm:"type:text;serializer:encrypted" json:"-"` // Signs deliveries; only returned when the subscription is created
+	// Comma-separated events, see WebhookEvents and Subscribes
+	EventTypes string `gorm:"size:500;not null" json:"-"`
+	Active     bool   `gorm:"default:true" json:"active"`
+	// Deliveries that failed in a row after all their retries; reset by a successful delivery
+	ConsecutiveFailures int        `gorm:"default:0" json:"consecutiveFailures"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"` // Set when the subscription was disabled for failing
+	CreatedByID         string     `gorm:"size:36" json:"createdById"`
+}
+
+// Events returns the events the subscription receives.
+func (s WebhookSubscription) Events() []string {
+	if s.EventTypes == "" {
+		return []string{}
+	}
+	return strings.Split(s.EventTypes, ",")
+}
+
+// Subscribes reports whether the subscription receives event.
+func (s WebhookSubscription) Subscribes(event string) bool {
+	for _, subscribed := range s.Events() {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus represents the state of a webhook delivery.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // Waiting for its first attempt or a retry
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // The endpoint answered with a 2xx status
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"    // Every attempt failed, or the subscription was disabled or deleted
+)
+
+// WebhookDelivery is one event queued for one subscription, together with the outcome of its attempts.
+type WebhookDelivery struct {
+	BaseModel
+	SubscriptionID string                `gorm:"size:36;index;not null" json:"subscriptionId"`
+	Event          string                `gorm:"size:50;not null" json:"event"`
+	Payload        string                `gorm:"type:text;not null" json:"payload"` // The JSON body sent, IDs and structural fields only
+	Status         WebhookDeliveryStatus `gorm:"size:20;index;default:'pending'" json:"status"`
+	Attempts       int                   `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  time.Time             `gorm:"index" json:"nextAttemptAt"`
+	LastStatusCode int                   `json:"lastStatusCode,omitempty"` // HTTP status of the last attempt, 0 when no response was received
+	LastError      string                `gorm:"size:500" json:"lastError,omitempty"`
+	DeliveredAt    *time.Time            `json:"deliveredAt,omitempty"`
+}
@@ -0,0 +1,319 @@
+// Package realtime pushes messaging events to connected clients over a WebSocket: typing indicators,
+// read receipts and whether conversation partners are online.
+//
+// Every frame is one JSON object with a "type". Clients send:
+//
+//	{"type": "typing", "conversationId": "<conversation>"}
+//	{"type": "read", "messageIds": ["<message>", ...]}
+//
+// and receive, about their conversation partners:
+//
+//	{"type": "typing", "conversationId": "<conversation>", "userId": "<partner>"}
+//	{"type": "read", "conversationId": "<conversation>", "userId": "<reader>", "messageIds": ["<message>", ...], "readAt": "<RFC 3339>"}
+//	{"type": "presence", "userId": "<partner>", "online": true}
+//
+// and {"type": "error", "error": "<reason>"} for a frame that could not be handled; the connection stays open.
+// Typing indicators are not stored. Read events mark the messages read exactly like the REST API, which
+// sends the same receipts. Presence is sent when a user's first connection opens and their last one
+// closes, and for every partner already online when a connection opens.
+package realtime
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	EventTyping   = "typing"
+	EventRead     = "read"
+	EventPresence = "presence"
+	EventError    = "error"
+)
+
+const (
+	// TypingThrottle is the shortest time between two typing events a user sends in one conversation;
+	// more frequent ones are dropped.
+	TypingThrottle = 3 * time.Second
+	// MaxReadBatch is how many messages one read event may mark.
+	MaxReadBatch = 100
+	// sendBuffer is how many events may wait for a slow client before it is disconnected.
+	sendBuffer = 32
+)
+
+// ClientEvent is a frame sent by a client.
+type ClientEvent struct {
+	Type           string   `json:"type"`
+	ConversationID string   `json:"conversationId,omitempty"` // typing
+	MessageIDs     []string `json:"messageIds,omitempty"`     // read
+}
+
+// ServerEvent is a frame sent to a client.
+type ServerEvent struct {
+	Type           string     `json:"type"`
+	ConversationID string     `json:"conversationId,omitempty"`
+	UserID         string     `json:"userId,omitempty"` // The partner typing, reading or coming online
+	MessageIDs     []string   `json:"messageIds,omitempty"`
+	ReadAt         *time.Time `json:"readAt,omitempty"`
+	Online         *bool      `json:"online,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// Conn is one client connection. ReadEvent blocks until the next frame arrives and fails once the
+// connection is closed.
+type Conn interface {
+	ReadEvent(event *ClientEvent) error
+	WriteEvent(event ServerEvent) error
+	Close() error
+}
+
+// Receipt tells a sender that some of their messages in a conversation were read.
+type Receipt struct {
+	SenderID       string
+	ConversationID string
+	MessageIDs     []string
+	ReadAt         time.Time
+}
+
+// Store is the message data the hub works with.
+type Store interface {
+	// Partners returns the users userID has exchanged messages with.
+	Partners(userID string) ([]string, error)
+	// ConversationPartner returns the other participant of the conversation, or "" when userID takes no part in it.
+	ConversationPartner(userID, conversationID string) (string, error)
+	// MarkRead marks the messages among messageIDs that userID received as read and returns the receipts
+	// for their senders. Messages that are already read, or were not sent to userID, are skipped.
+	MarkRead(userID string, messageIDs []string) ([]Receipt, error)
+}
+
+// client is a connection of a user to the hub.
+type client struct {
+	userID string
+	conn   Conn
+	send   chan ServerEvent
+}
+
+// Hub keeps track of connected users and routes events between conversation partners.
+// A user may be connected more than once, e.g. from a phone and a browser; events go to every connection.
+type Hub struct {
+	store Store
+	now   func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]map[*client]struct{}
+	typing  map[string]map[string]time.Time // When each user last sent a typing event, per conversation
+}
+
+// NewHub creates a Hub that reads and marks messages through store.
+func NewHub(store Store) *Hub {
+	return &Hub{
+		store:   store,
+		now:     time.Now,
+		clients: make(map[string]map[*client]struct{}),
+		typing:  make(map[string]map[string]time.Time),
+	}
+}
+
+// Serve runs conn for userID until it is closed, handling the events the client sends.
+func (h *Hub) Serve(userID string, conn Conn) {
+	c := &client{userID: userID, conn: conn, send: make(chan ServerEvent, sendBuffer)}
+	go c.writeEvents()
+
+	first := h.register(c)
+	partners, err := h.store.Partners(userID)
+	if err != nil {
+		log.Printf("realtime: failed to load partners of %s: %v", userID, err)
+	}
+	if first {
+		h.sendToUsers(partners, presenceEvent(userID, true))
+	}
+	for _, partner := range partners {
+		if h.IsOnline(partner) {
+			h.sendToClient(c, presenceEvent(partner, true))
+		}
+	}
+
+	for {
+		var event ClientEvent
+		if err := conn.ReadEvent(&event); err != nil {
+			break
+		}
+		h.handle(c, event)
+	}
+
+	if h.unregister(c) {
+		// Partners may have been added while the user was connected
+		if partners, err = h.store.Partners(userID); err != nil {
+			log.Printf("realtime: failed to load partners of %s: %v", userID, err)
+		}
+		h.sendToUsers(partners, presenceEvent(userID, false))
+	}
+}
+
+// IsOnline reports whether userID has at least one open connection.
+func (h *Hub) IsOnline(userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients[userID]) > 0
+}
+
+// SendReceipts tells the senders, if connected, that readerID read their messages. A nil Hub sends nothing,
+// so handlers can call it whether or not realtime delivery is set up.
+func (h *Hub) SendReceipts(readerID string, receipts []Receipt) {
+	if h == nil {
+		return
+	}
+	for _, receipt := range receipts {
+		readAt := receipt.ReadAt
+		h.sendToUsers([]string{receipt.SenderID}, ServerEvent{
+			Type:           EventRead,
+			ConversationID: receipt.ConversationID,
+			UserID:         readerID,
+			MessageIDs:     receipt.MessageIDs,
+			ReadAt:         &readAt,
+		})
+	}
+}
+
+// handle acts on one event from c.
+func (h *Hub) handle(c *client, event ClientEvent) {
+	switch event.Type {
+	case EventTyping:
+		if event.ConversationID == "" {
+			h.sendToClient(c, errorEvent("conversationId is required"))
+			return
+		}
+		if !h.allowTyping(c.userID, event.ConversationID) {
+			return
+		}
+		partner, err := h.store.ConversationPartner(c.userID, event.ConversationID)
+		if err != nil {
+			log.Printf("realtime: failed to load conversation %s: %v", event.ConversationID, err)
+			h.sendToClient(c, errorEvent("conversation could not be loaded"))
+			return
+		}
+		if partner == "" {
+			h.sendToClient(c, errorEvent("not a participant of this conversation"))
+			return
+		}
+		h.sendToUsers([]string{partner}, ServerEvent{Type: EventTyping, ConversationID: event.ConversationID, UserID: c.userID})
+
+	case EventRead:
+		if len(event.MessageIDs) == 0 || len(event.MessageIDs) > MaxReadBatch {
+			h.sendToClient(c, errorEvent("messageIds must list 1 to 100 messages"))
+			return
+		}
+		receipts, err := h.store.MarkRead(c.userID, event.MessageIDs)
+		if err != nil {
+			log.Printf("realtime: failed to mark messages read for %s: %v", c.userID, err)
+			h.sendToClient(c, errorEvent("messages could not be marked as read"))
+			return
+		}
+		h.SendReceipts(c.userID, receipts)
+
+	default:
+		h.sendToClient(c, errorEvent("unknown event type"))
+	}
+}
+
+// allowTyping records a typing event of userID in the conversation and reports whether it may be
+// forwarded, i.e. whether TypingThrottle has passed since the last forwarded one.
+func (h *Hub) allowTyping(userID, conversationID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if last, ok := h.typing[userID][conversationID]; ok && now.Sub(last) < TypingThrottle {
+		return false
+	}
+	if h.typing[userID] == nil {
+		h.typing[userID] = make(map[string]time.Time)
+	}
+	h.typing[userID][conversationID] = now
+	return true
+}
+
+// register adds c and reports whether it is the user's first connection.
+func (h *Hub) register(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[c.userID] == nil {
+		h.clients[c.userID] = make(map[*client]struct{})
+	}
+	h.clients[c.userID][c] = struct{}{}
+	return len(h.clients[c.userID]) == 1
+}
+
+// unregister removes c, stops its writer and reports whether it was the user's last connection.
+func (h *Hub) unregister(c *client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c.userID][c]; ok {
+		delete(h.clients[c.userID], c)
+		close(c.send)
+	}
+	if len(h.clients[c.userID]) > 0 {
+		return false
+	}
+	delete(h.clients, c.userID)
+	delete(h.typing, c.userID)
+	return true
+}
+
+// sendToUsers queues event for every connection of the given users.
+func (h *Hub) sendToUsers(userIDs []string, event ServerEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, userID := range userIDs {
+		for c := range h.clients[userID] {
+			c.enqueue(event)
+		}
+	}
+}
+
+// sendToClient queues event for c alone.
+func (h *Hub) sendToClient(c *client, event ServerEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c.userID][c]; ok {
+		c.enqueue(event)
+	}
+}
+
+// enqueue queues event without blocking. A client too slow to keep up is disconnected, which
+// unregisters it once its read fails. Callers hold the hub lock, so send is not closed meanwhile.
+func (c *client) enqueue(event ServerEvent) {
+	select {
+	case c.send <- event:
+	default:
+		c.conn.Close()
+	}
+}
+
+// writeEvents writes queued events until the client is unregistered, then closes the connection.
+func (c *client) writeEvents() {
+	failed := false
+	for event := range c.send {
+		if failed {
+			continue // Drain until unregister closes the channel
+		}
+		if err := c.conn.WriteEvent(event); err != nil {
+			failed = true
+			c.conn.Close()
+		}
+	}
+	c.conn.Close()
+}
+
+func presenceEvent(userID string, online bool) ServerEvent {
+	return ServerEvent{Type: EventPresence, UserID: userID, Online: &online}
+}
+
+func errorEvent(reason string) ServerEvent {
+	return ServerEvent{Type: EventError, Error: reason}
+}
@@ -0,0 +1,360 @@
+package realtime
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventTimeout is how long a test waits for an event it expects.
+const eventTimeout = time.Second
+
+// quietPeriod is how long a test waits to be sure no event arrives.
+const quietPeriod = 50 * time.Millisecond
+
+// fakeConn is an in-memory Conn. The test plays the client: it sends frames with send and reads
+// what the hub wrote with expect.
+type fakeConn struct {
+	in        chan ClientEvent
+	out       chan ServerEvent
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{in: make(chan ClientEvent), out: make(chan ServerEvent, 64), closed: make(chan struct{})}
+}
+
+func (c *fakeConn) ReadEvent(event *ClientEvent) error {
+	select {
+	case *event = <-c.in:
+		return nil
+	case <-c.closed:
+		return errors.New("connection closed")
+	}
+}
+
+func (c *fakeConn) WriteEvent(event ServerEvent) error {
+	select {
+	case <-c.closed:
+		return errors.New("connection closed")
+	default:
+	}
+	c.out <- event
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+// send delivers a frame from the client to the hub.
+func (c *fakeConn) send(t *testing.T, event ClientEvent) {
+	t.Helper()
+
+	select {
+	case c.in <- event:
+	case <-time.After(eventTimeout):
+		t.Fatalf("hub did not read %+v", event)
+	}
+}
+
+// expect returns the next event the hub wrote and fails unless it has the given type.
+func (c *fakeConn) expect(t *testing.T, eventType string) ServerEvent {
+	t.Helper()
+
+	select {
+	case event := <-c.out:
+		if event.Type != eventType {
+			t.Fatalf("got %+v, want a %s event", event, eventType)
+		}
+		return event
+	case <-time.After(eventTimeout):
+		t.Fatalf("no %s event", eventType)
+		return ServerEvent{}
+	}
+}
+
+// expectNone fails if the hub writes anything within quietPeriod.
+func (c *fakeConn) expectNone(t *testing.T) {
+	t.Helper()
+
+	select {
+	case event := <-c.out:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(quietPeriod):
+	}
+}
+
+// fakeStore is an in-memory Store with one conversation per entry in conversations.
+type fakeStore struct {
+	mu            sync.Mutex
+	conversations map[string][2]string // Conversation ID to its two participants
+	senders       map[string]string    // Message ID to its sender, for every unread message
+	readAt        time.Time
+	marked        []string
+}
+
+func (s *fakeStore) Partners(userID string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var partners []string
+	for _, participants := range s.conversations {
+		if participants[0] == userID {
+			partners = append(partners, participants[1])
+		} else if participants[1] == userID {
+			partners = append(partners, participants[0])
+		}
+	}
+	return partners, nil
+}
+
+func (s *fakeStore) ConversationPartner(userID, conversationID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	participants := s.conversations[conversationID]
+	switch userID {
+	case participants[0]:
+		return participants[1], nil
+	case participants[1]:
+		return participants[0], nil
+	}
+	return "", nil
+}
+
+func (s *fakeStore) MarkRead(userID string, messageIDs []string) ([]Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var receipts []Receipt
+	for _, id := range messageIDs {
+		sender, unread := s.senders[id]
+		if !unread {
+			continue
+		}
+		delete(s.senders, id)
+		s.marked = append(s.marked, id)
+		receipts = append(receipts, Receipt{SenderID: sender, ConversationID: "c1", MessageIDs: []string{id}, ReadAt: s.readAt})
+	}
+	return receipts, nil
+}
+
+// fakeClock is a settable time source for the typing throttle.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestHub returns a hub over a store where patient and doctor share conversation c1, and
+// outsider has no conversations, with its clock under the test's control.
+func newTestHub() (*Hub, *fakeStore, *fakeClock) {
+	store := &fakeStore{
+		conversations: map[string][2]string{"c1": {"patient", "doctor"}},
+		senders:       map[string]string{"m1": "doctor", "m2": "doctor"},
+		readAt:        time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC),
+	}
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+	hub := NewHub(store)
+	hub.now = clock.Now
+	return hub, store, clock
+}
+
+// connect serves a new fake connection for userID and waits until the hub has registered it.
+func connect(t *testing.T, hub *Hub, userID string) *fakeConn {
+	t.Helper()
+
+	hub.mu.Lock()
+	before := len(hub.clients[userID])
+	hub.mu.Unlock()
+
+	conn := newFakeConn()
+	go hub.Serve(userID, conn)
+	t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(eventTimeout)
+	for {
+		hub.mu.Lock()
+		registered := len(hub.clients[userID]) > before
+		hub.mu.Unlock()
+		if registered {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not registered", userID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHubBroadcastsPresenceToPartners(t *testing.T) {
+	hub, _, _ := newTestHub()
+
+	patient := connect(t, hub, "patient")
+	patient.expectNone(t)
+
+	// The doctor learns the patient is already online, and the patient that the doctor came online
+	doctor := connect(t, hub, "doctor")
+	if event := doctor.expect(t, EventPresence); event.UserID != "patient" || event.Online == nil || !*event.Online {
+		t.Errorf("doctor got %+v, want the patient online", event)
+	}
+	if event := patient.expect(t, EventPresence); event.UserID != "doctor" || event.Online == nil || !*event.Online {
+		t.Errorf("patient got %+v, want the doctor online", event)
+	}
+
+	// Users without conversations with them hear nothing
+	outsider := connect(t, hub, "outsider")
+	outsider.expectNone(t)
+	patient.expectNone(t)
+
+	doctor.Close()
+	if event := patient.expect(t, EventPresence); event.UserID != "doctor" || event.Online == nil || *event.Online {
+		t.Errorf("patient got %+v, want the doctor offline", event)
+	}
+	if hub.IsOnline("doctor") {
+		t.Error("doctor still online after closing their only connection")
+	}
+}
+
+func TestHubReportsOfflineOnlyAfterTheLastConnection(t *testing.T) {
+	hub, _, _ := newTestHub()
+	patient := connect(t, hub, "patient")
+
+	phone := connect(t, hub, "doctor")
+	patient.expect(t, EventPresence)
+	phone.expect(t, EventPresence)
+
+	// A second connection is not news to the partner
+	browser := connect(t, hub, "doctor")
+	browser.expect(t, EventPresence)
+	patient.expectNone(t)
+
+	phone.Close()
+	patient.expectNone(t)
+	if !hub.IsOnline("doctor") {
+		t.Fatal("doctor offline while the browser is still connected")
+	}
+
+	browser.Close()
+	if event := patient.expect(t, EventPresence); event.Online == nil || *event.Online {
+		t.Errorf("patient got %+v, want the doctor offline", event)
+	}
+}
+
+func TestHubForwardsTypingToThePartnerThrottled(t *testing.T) {
+	hub, _, clock := newTestHub()
+	patient := connect(t, hub, "patient")
+	doctor := connect(t, hub, "doctor")
+	patient.expect(t, EventPresence)
+	doctor.expect(t, EventPresence)
+
+	patient.send(t, ClientEvent{Type: EventTyping, ConversationID: "c1"})
+	if event := doctor.expect(t, EventTyping); event.ConversationID != "c1" || event.UserID != "patient" {
+		t.Errorf("doctor got %+v, want the patient typing in c1", event)
+	}
+	patient.expectNone(t)
+
+	// Further keystrokes within the throttle window are dropped
+	clock.Advance(TypingThrottle - time.Millisecond)
+	patient.send(t, ClientEvent{Type: EventTyping, ConversationID: "c1"})
+	doctor.expectNone(t)
+
+	clock.Advance(time.Millisecond)
+	patient.send(t, ClientEvent{Type: EventTyping, ConversationID: "c1"})
+	doctor.expect(t, EventTyping)
+
+	// The throttle is per user: the doctor may type straight away
+	doctor.send(t, ClientEvent{Type: EventTyping, ConversationID: "c1"})
+	if event := patient.expect(t, EventTyping); event.UserID != "doctor" {
+		t.Errorf("patient got %+v, want the doctor typing", event)
+	}
+}
+
+func TestHubRejectsTypingOutsideOwnConversations(t *testing.T) {
+	hub, _, _ := newTestHub()
+	doctor := connect(t, hub, "doctor")
+	outsider := connect(t, hub, "outsider")
+
+	outsider.send(t, ClientEvent{Type: EventTyping, ConversationID: "c1"})
+	outsider.expect(t, EventError)
+	doctor.expectNone(t)
+
+	outsider.send(t, ClientEvent{Type: EventTyping})
+	outsider.expect(t, EventError)
+}
+
+func TestHubReadEventsMarkMessagesAndNotifyTheSender(t *testing.T) {
+	hub, store, _ := newTestHub()
+	patient := connect(t, hub, "patient")
+	doctor := connect(t, hub, "doctor")
+	patient.expect(t, EventPresence)
+	doctor.expect(t, EventPresence)
+
+	patient.send(t, ClientEvent{Type: EventRead, MessageIDs: []string{"m1", "unknown"}})
+	event := doctor.expect(t, EventRead)
+	if event.UserID != "patient" || event.ConversationID != "c1" || len(event.MessageIDs) != 1 || event.MessageIDs[0] != "m1" {
+		t.Errorf("doctor got %+v, want the patient reading m1 in c1", event)
+	}
+	if event.ReadAt == nil || !event.ReadAt.Equal(store.readAt) {
+		t.Errorf("readAt = %v, want %v", event.ReadAt, store.readAt)
+	}
+	patient.expectNone(t)
+
+	// Reading again sends no second receipt
+	patient.send(t, ClientEvent{Type: EventRead, MessageIDs: []string{"m1"}})
+	doctor.expectNone(t)
+
+	store.mu.Lock()
+	marked := append([]string(nil), store.marked...)
+	store.mu.Unlock()
+	if len(marked) != 1 || marked[0] != "m1" {
+		t.Errorf("marked %v, want only m1", marked)
+	}
+}
+
+func TestHubRejectsInvalidFramesAndKeepsTheConnection(t *testing.T) {
+	hub, _, _ := newTestHub()
+	patient := connect(t, hub, "patient")
+	doctor := connect(t, hub, "doctor")
+	patient.expect(t, EventPresence)
+	doctor.expect(t, EventPresence)
+
+	tooMany := make([]string, MaxReadBatch+1)
+	for i := range tooMany {
+		tooMany[i] = "m"
+	}
+	for _, event := range []ClientEvent{
+		{Type: "shout"},
+		{Type: EventRead},
+		{Type: EventRead, MessageIDs: tooMany},
+	} {
+		patient.send(t, event)
+		if got := patient.expect(t, EventError); got.Error == "" {
+			t.Errorf("error event for %q has no reason", event.Type)
+		}
+	}
+
+	// The connection still works
+	patient.send(t, ClientEvent{Type: EventTyping, ConversationID: "c1"})
+	doctor.expect(t, EventTyping)
+}
+
+func TestNilHubSendsNoReceipts(t *testing.T) {
+	var hub *Hub
+	hub.SendReceipts("patient", []Receipt{{SenderID: "doctor", MessageIDs: []string{"m1"}}})
+}
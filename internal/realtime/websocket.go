@@ -0,0 +1,30 @@
+package realtime
+
+import "golang.org/x/net/websocket"
+
+// maxFrameBytes caps the size of a frame a client may send.
+const maxFrameBytes = 64 << 10
+
+// wsConn is a Conn over a WebSocket, exchanging events as JSON text frames.
+type wsConn struct {
+	ws *websocket.Conn
+}
+
+// NewWebSocketConn returns ws as a Conn.
+func NewWebSocketConn(ws *websocket.Conn) Conn {
+	ws.MaxPayloadBytes = maxFrameBytes
+	return &wsConn{ws: ws}
+}
+
+func (c *wsConn) ReadEvent(event *ClientEvent) error {
+	*event = ClientEvent{}
+	return websocket.JSON.Receive(c.ws, event)
+}
+
+func (c *wsConn) WriteEvent(event ServerEvent) error {
+	return websocket.JSON.Send(c.ws, event)
+}
+
+func (c *wsConn) Close() error {
+	return c.ws.Close()
+}
@@ -1,46 +1,101 @@
 package routes
 
 import (
+	"healthcare-app-server/internal/cache"
 	"healthcare-app-server/internal/config"
 	"healthcare-app-server/internal/handlers"
+	"healthcare-app-server/internal/jobs"
 	"healthcare-app-server/internal/middleware"
 	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/slowlog"
+	"healthcare-app-server/internal/webhooks"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// SetupRoutes configures the application routes.
-func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+// SetupRoutes configures the application routes. Handlers publish their webhook events to dispatcher;
+// the jobs of scheduler and slowLog are served to admins.
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config, dispatcher *webhooks.Dispatcher, scheduler *jobs.Scheduler, slowLog *slowlog.Log) {
+	// Doctor listings and profiles are read on every booking page, so they are cached in-process
+	doctorCache := cache.NewLoader(cache.NewMemory(), time.Duration(cfg.DoctorCacheTTL)*time.Second)
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db, cfg)
-	userHandler := handlers.NewUserHandler(db)
-	appointmentHandler := handlers.NewAppointmentHandler(db)
-	medicalRecordHandler := handlers.NewMedicalRecordHandler(db)
-	messageHandler := handlers.NewMessageHandler(db)
+	authHandler := handlers.NewAuthHandler(db, cfg, doctorCache)
+	userHandler := handlers.NewUserHandler(db, cfg, doctorCache)
+	appointmentHandler := handlers.NewAppointmentHandler(db, cfg, dispatcher)
+	medicalRecordHandler := handlers.NewMedicalRecordHandler(db, cfg, dispatcher)
+	// Typing indicators, read receipts and presence for connected messaging clients
+	realtimeHub := handlers.NewRealtimeHub(db)
+	messageHandler := handlers.NewMessageHandler(db, cfg, dispatcher, realtimeHub)
+	reviewHandler := handlers.NewReviewHandler(db, cfg, doctorCache)
+	waitlistHandler := handlers.NewWaitlistHandler(db)
+	prescriptionHandler := handlers.NewPrescriptionHandler(db)
+	vitalHandler := handlers.NewVitalHandler(db)
+	appointmentTypeHandler := handlers.NewAppointmentTypeHandler(db)
+	departmentHandler := handlers.NewDepartmentHandler(db)
+	consentHandler := handlers.NewConsentHandler(db, cfg)
+	announcementHandler := handlers.NewAnnouncementHandler(db)
+	doctorProfileHandler := handlers.NewDoctorProfileHandler(db, doctorCache, dispatcher)
+	recordTemplateHandler := handlers.NewRecordTemplateHandler(db)
+	metricsHandler := handlers.NewMetricsHandler(doctorCache)
+	webhookHandler := handlers.NewWebhookHandler(db)
+	realtimeHandler := handlers.NewRealtimeHandler(realtimeHub)
+	organizationHandler := handlers.NewOrganizationHandler(db)
+	slowQueryHandler := handlers.NewSlowQueryHandler(slowLog)
+	auditHandler := handlers.NewAuditHandler(db)
+	jobHandler := handlers.NewJobHandler(db, scheduler)
+	policyHandler := handlers.NewPolicyHandler(db)
+
+	// Loads the authenticated user for handlers that need the whole record; added per route to spare the
+	// query on routes that only use the ID and role from the token
+	loadUser := middleware.LoadUser(db)
+
+	// Attachment uploads and downloads move large blobs, so they replace the global request timeout
+	uploadTimeout := middleware.RequestTimeout(time.Duration(cfg.UploadRequestTimeout) * time.Second)
 
 	// Public routes (no authentication required)
 	public := router.Group("/api/v1")
 	{
 		authRoutes := public.Group("/auth")
 		{
-			authRoutes.POST("/register", authHandler.Register)
+			authRoutes.POST("/register", authHandler.Register) // Patients only
+			authRoutes.POST("/register-with-invite", authHandler.RegisterWithInvite)
 			authRoutes.POST("/login", authHandler.Login)
 			authRoutes.POST("/refresh-token", authHandler.RefreshToken)
+			authRoutes.POST("/reset-password", authHandler.ResetPassword) // Token from an invitation or reset link
+			authRoutes.POST("/verify-email", authHandler.VerifyEmail)
+			authRoutes.POST("/resend-verification", authHandler.ResendVerification)
 			// Logout can be here or in authenticated routes depending on if it needs to invalidate server-side session/token
 		}
+		// Terms of service and privacy policy versions in effect, to accept at signup
+		public.GET("/policies/current", policyHandler.GetCurrentPolicies)
 	}
 
 	// Authenticated routes
 	private := router.Group("/api/v1")
 	private.Use(middleware.AuthMiddleware(cfg)) // Apply JWT authentication middleware
+	// Hold users to the policy versions in effect; accepting them, reading the profile with its acceptance
+	// status, exporting one's data, logging out and managing the policies stay available
+	private.Use(middleware.RequirePolicyAcceptance(db, cfg.PolicyEnforcement,
+		"/api/v1/auth/accept-policies", "/api/v1/auth/profile", "/api/v1/auth/export", "/api/v1/auth/logout",
+		"/api/v1/admin/policies", "/api/v1/admin/policies/:id"))
 	{
 		// Auth related (e.g., profile, logout if it needs auth)
 		authRoutesPrivate := private.Group("/auth")
 		{
-			authRoutesPrivate.POST("/logout", authHandler.Logout) // Assuming logout might interact with user session
-			authRoutesPrivate.GET("/profile", authHandler.GetProfile)
-			authRoutesPrivate.PUT("/profile", authHandler.UpdateProfile)
+			authRoutesPrivate.POST("/logout", authHandler.Logout)          // Assuming logout might interact with user session
+			authRoutesPrivate.GET("/verify", authHandler.VerifyToken)      // Lightweight session check, no DB access
+			authRoutesPrivate.GET("/token-info", authHandler.GetTokenInfo) // Token lifetime for scheduling refreshes, no DB access
+			authRoutesPrivate.GET("/profile", loadUser, authHandler.GetProfile)
+			authRoutesPrivate.PUT("/profile", loadUser, authHandler.UpdateProfile)
+			authRoutesPrivate.GET("/profile/dashboard", loadUser, authHandler.GetProfileDashboard) // Role-aware summary for the dashboard in one call
+			authRoutesPrivate.GET("/export", loadUser, authHandler.ExportData)                     // Self-service data portability export
+			authRoutesPrivate.GET("/login-history", authHandler.GetLoginHistory)
+			authRoutesPrivate.POST("/accept-policies", policyHandler.AcceptPolicies)
+			// Doctors the patient has appointments or medical records with
+			authRoutesPrivate.GET("/my-doctors", middleware.RoleAuthMiddleware(models.RolePatient), userHandler.GetMyDoctors)
 		}
 		// User management routes (typically admin-only)
 		userRoutes := private.Group("/users")
@@ -48,7 +103,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			// Special endpoint to get doctors - accessible by all authenticated users
 			userRoutes.GET("/doctors", userHandler.GetDoctors)
 
-			// Special endpoint to get patients for a doctor - accessible by doctors and admins
+			// Patients of the requesting doctor (or all patients for admins) - accessible by doctors and admins
 			userRoutes.GET("/doctor-patients", userHandler.GetDoctorPatients)
 
 			// Admin-only routes
@@ -60,6 +115,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 				adminRoutes.GET("/:id", userHandler.GetUserByID)
 				adminRoutes.PUT("/:id", userHandler.UpdateUser)
 				adminRoutes.DELETE("/:id", userHandler.DeleteUser)
+				adminRoutes.GET("/:id/login-history", userHandler.GetUserLoginHistory)
 			}
 		}
 
@@ -73,14 +129,125 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			// All authenticated users can get their own appointments
 			appointmentRoutes.GET("", appointmentHandler.GetAppointmentsForUser) // Logic inside handler differentiates by role
 
+			// A doctor's appointments and free gaps for one day (doctors their own, admins any with doctorId)
+			appointmentRoutes.GET("/schedule", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), appointmentHandler.GetDaySchedule)
+
+			// Counts by status and completion rate, scoped by role in handler, for dashboard widgets
+			appointmentRoutes.GET("/stats", appointmentHandler.GetAppointmentStats)
+
+			// CSV download of the user's appointments for reporting and billing (scoped by role in handler)
+			appointmentRoutes.GET("/export.csv", appointmentHandler.ExportAppointmentsCSV)
+
+			// One patient's appointments with the requesting doctor, or all of them for admins
+			appointmentRoutes.GET("/patient/:patientId", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), appointmentHandler.GetAppointmentsForPatient)
+
 			// Specific appointment access (Patient involved, Doctor involved, or Admin)
 			appointmentRoutes.GET("/:id", appointmentHandler.GetAppointmentByID) // Authorization inside handler
 
 			// Status updates (Doctor, Admin, Patient for cancellation)
 			appointmentRoutes.PATCH("/:id/status", appointmentHandler.UpdateAppointmentStatus) // Authorization inside handler
+			// The appointment's patient confirms a pending or proposed appointment (checked in handler)
+			appointmentRoutes.POST("/:id/confirm", middleware.RoleAuthMiddleware(models.RolePatient), appointmentHandler.ConfirmAppointment)
 
 			// Reschedule (Doctor, Admin, Patient if allowed)
 			appointmentRoutes.PATCH("/:id/reschedule", appointmentHandler.RescheduleAppointment) // Authorization inside handler
+
+			// Notes, including doctor-only private notes (appointment's Doctor or Admin, checked in handler)
+			appointmentRoutes.PATCH("/:id/notes", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), appointmentHandler.UpdateAppointmentNotes)
+
+			// Reviews (only the patient of a completed appointment, checked in handler)
+			appointmentRoutes.POST("/:id/review", reviewHandler.CreateReview)
+			appointmentRoutes.PUT("/:id/review", reviewHandler.UpdateReview)
+
+			// Intake forms (the patient before the visit; the involved doctor can view and convert, checked in handler)
+			appointmentRoutes.POST("/:id/intake", middleware.RoleAuthMiddleware(models.RolePatient), appointmentHandler.SubmitIntakeForm)
+			appointmentRoutes.PUT("/:id/intake", middleware.RoleAuthMiddleware(models.RolePatient), appointmentHandler.UpdateIntakeForm)
+			appointmentRoutes.GET("/:id/intake", appointmentHandler.GetIntakeForm)
+			appointmentRoutes.POST("/:id/intake/convert", middleware.RoleAuthMiddleware(models.RoleDoctor), appointmentHandler.ConvertIntakeForm)
+		}
+
+		// Appointment type routes
+		appointmentTypeRoutes := private.Group("/appointment-types")
+		{
+			// Active types for the booking UI - accessible by all authenticated users
+			appointmentTypeRoutes.GET("", appointmentTypeHandler.GetAppointmentTypes)
+
+			// Admin-only management and reporting
+			adminTypeRoutes := appointmentTypeRoutes.Group("")
+			adminTypeRoutes.Use(middleware.RoleAuthMiddleware(models.RoleAdmin))
+			{
+				adminTypeRoutes.GET("/stats", appointmentTypeHandler.GetAppointmentTypeStats)
+				// The catalog is shared by every organization
+				adminTypeRoutes.POST("", middleware.PlatformAdminMiddleware(cfg), appointmentTypeHandler.CreateAppointmentType)
+				adminTypeRoutes.PUT("/:id", middleware.PlatformAdminMiddleware(cfg), appointmentTypeHandler.UpdateAppointmentType)
+				adminTypeRoutes.DELETE("/:id", middleware.PlatformAdminMiddleware(cfg), appointmentTypeHandler.DeleteAppointmentType)
+			}
+		}
+
+		// Department routes
+		departmentRoutes := private.Group("/departments")
+		{
+			// Active departments and their doctors for dropdowns and booking - accessible by all authenticated users
+			departmentRoutes.GET("", departmentHandler.GetDepartments)
+			departmentRoutes.GET("/:id/doctors", departmentHandler.GetDepartmentDoctors)
+
+			// Admin-only management
+			adminDepartmentRoutes := departmentRoutes.Group("")
+			adminDepartmentRoutes.Use(middleware.RoleAuthMiddleware(models.RoleAdmin))
+			{
+				// The catalog is shared by every organization
+				adminDepartmentRoutes.POST("", middleware.PlatformAdminMiddleware(cfg), departmentHandler.CreateDepartment)
+				adminDepartmentRoutes.PUT("/:id", middleware.PlatformAdminMiddleware(cfg), departmentHandler.UpdateDepartment)
+				adminDepartmentRoutes.DELETE("/:id", middleware.PlatformAdminMiddleware(cfg), departmentHandler.DeleteDepartment)
+				// Doctors are assigned by the admins of their organization
+				adminDepartmentRoutes.POST("/:id/doctors", departmentHandler.AssignDoctorDepartment)
+				adminDepartmentRoutes.DELETE("/:id/doctors/:doctorId", departmentHandler.UnassignDoctorDepartment)
+			}
+		}
+
+		// Doctor routes
+		doctorRoutes := private.Group("/doctors")
+		{
+			// Reviews of a doctor - accessible by all authenticated users
+			doctorRoutes.GET("/:id/reviews", reviewHandler.GetDoctorReviews)
+			// Reviewing a doctor after a completed appointment with them - patients only (checked in handler)
+			doctorRoutes.POST("/:id/reviews", reviewHandler.CreateDoctorReview)
+
+			// Specialty, bio and weekly availability - readable by all, editable by the doctor or an admin (checked in handler)
+			doctorRoutes.GET("/:id/profile", doctorProfileHandler.GetDoctorProfile)
+			doctorRoutes.PUT("/:id/profile", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), doctorProfileHandler.UpdateDoctorProfile)
+			// Bookable slots of a day with their remaining capacity - accessible by all authenticated users
+			doctorRoutes.GET("/:id/slots", doctorProfileHandler.GetDoctorSlots)
+
+			// Time off that blocks booking - managed by the doctor or an admin (checked in handler)
+			timeOffRoutes := doctorRoutes.Group("/:id/time-off")
+			timeOffRoutes.Use(middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin))
+			{
+				timeOffRoutes.GET("", doctorProfileHandler.GetTimeOff)
+				timeOffRoutes.POST("", doctorProfileHandler.CreateTimeOff)           // ?force=true cancels overlapping appointments
+				timeOffRoutes.PUT("/:timeOffId", doctorProfileHandler.UpdateTimeOff) // ?force=true as above
+				timeOffRoutes.DELETE("/:timeOffId", doctorProfileHandler.DeleteTimeOff)
+			}
+		}
+
+		// Review moderation (admin-only)
+		reviewRoutes := private.Group("/reviews")
+		reviewRoutes.Use(middleware.RoleAuthMiddleware(models.RoleAdmin))
+		{
+			reviewRoutes.DELETE("/:id", reviewHandler.DeleteReview)
+		}
+
+		// Waitlist routes
+		waitlistRoutes := private.Group("/waitlist")
+		{
+			// Patients queue for cancellations with a doctor
+			waitlistRoutes.POST("", middleware.RoleAuthMiddleware(models.RolePatient), waitlistHandler.JoinWaitlist)
+
+			// Doctors see their waitlist, admins see all, patients see their own entries
+			waitlistRoutes.GET("", waitlistHandler.GetWaitlist) // Scoped by role in handler
+
+			// The entry's patient or an admin can remove it
+			waitlistRoutes.DELETE("/:id", waitlistHandler.LeaveWaitlist) // Auth in handler
 		}
 
 		// Medical Record routes
@@ -89,8 +256,24 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			// Doctors create medical records
 			medicalRecordRoutes.POST("", middleware.RoleAuthMiddleware(models.RoleDoctor), medicalRecordHandler.CreateMedicalRecord)
 
+			// Record templates: doctors manage their own, admins the global ones (ownership checked in handler)
+			templateRoutes := medicalRecordRoutes.Group("/templates")
+			templateRoutes.Use(middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin))
+			{
+				templateRoutes.GET("", recordTemplateHandler.GetRecordTemplates)
+				templateRoutes.POST("", recordTemplateHandler.CreateRecordTemplate)
+				templateRoutes.PUT("/:templateId", recordTemplateHandler.UpdateRecordTemplate)
+				templateRoutes.DELETE("/:templateId", recordTemplateHandler.DeleteRecordTemplate)
+			}
+
+			// Deleted records that can still be restored (doctors see their own, admins all)
+			medicalRecordRoutes.GET("/trash", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), medicalRecordHandler.GetMedicalRecordTrash)
+			medicalRecordRoutes.POST("/:id/restore", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), medicalRecordHandler.RestoreMedicalRecord) // Ownership checked in handler
+
 			// Patient can get their own, Doctors can get for their patients (or any, depending on policy)
 			medicalRecordRoutes.GET("/patient/:patientId", medicalRecordHandler.GetMedicalRecordsForPatient) // Auth in handler
+			// Records the requesting doctor wrote, across all patients
+			medicalRecordRoutes.GET("/authored", middleware.RoleAuthMiddleware(models.RoleDoctor), medicalRecordHandler.GetAuthoredMedicalRecords)
 
 			// Get specific record (Patient if theirs, Doctor if involved/theirs, Admin)
 			medicalRecordRoutes.GET("/:id", medicalRecordHandler.GetMedicalRecordByID) // Auth in handler
@@ -98,39 +281,184 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			// Doctors update their records, Admins can update any
 			medicalRecordRoutes.PUT("/:id", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), medicalRecordHandler.UpdateMedicalRecord) // Further auth in handler if needed (e.g. doctor owns record)
 
-			// Doctors delete their records, Admins can delete any
+			// Patients ask for corrections to their own records
+			medicalRecordRoutes.POST("/:id/amendment-requests", middleware.RoleAuthMiddleware(models.RolePatient), medicalRecordHandler.CreateAmendmentRequest) // Ownership checked in handler
+			// A record's amendment requests - its patient, its doctor and admins
+			medicalRecordRoutes.GET("/:id/amendment-requests", medicalRecordHandler.GetAmendmentRequests) // Auth in handler
+
+			// ICD-10 coded diagnoses - the record's doctor or an admin (checked in handler)
+			medicalRecordRoutes.POST("/:id/diagnoses", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), medicalRecordHandler.AddRecordDiagnosis)
+			medicalRecordRoutes.DELETE("/:id/diagnoses/:code", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), medicalRecordHandler.RemoveRecordDiagnosis)
+
+			// Doctors delete their records, Admins can delete any; deleted records go to the trash
 			medicalRecordRoutes.DELETE("/:id", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), medicalRecordHandler.DeleteMedicalRecord) // Further auth in handler
 
 			// Attachment routes for a specific medical record
 			attachmentRoutes := medicalRecordRoutes.Group("/:id/attachments")
 			attachmentRoutes.Use(middleware.RoleAuthMiddleware(models.RoleDoctor)) // Only Doctors can manage attachments
 			{
-				attachmentRoutes.POST("", medicalRecordHandler.UploadMedicalRecordAttachment)
+				attachmentRoutes.POST("", middleware.BodySizeLimit(cfg.MaxUploadBytes), uploadTimeout, medicalRecordHandler.UploadMedicalRecordAttachment)
 				// Potentially add GET for listing attachments for a record, DELETE for an attachment, etc.
 			}
 
 			// New route to get a specific attachment by its own ID
 			// This is outside the /:id/attachments group because attachment ID is globally unique
 			// Accessible by users who have access to the parent medical record (handled in the handler)
-			private.GET("/medical-records/attachments/:attachmentId", medicalRecordHandler.GetMedicalRecordAttachment)
+			private.GET("/medical-records/attachments/:attachmentId", uploadTimeout, medicalRecordHandler.GetMedicalRecordAttachment)
+			// Preview of an image attachment, same access as the download; 404 for files without one
+			private.GET("/medical-records/attachments/:attachmentId/thumbnail", medicalRecordHandler.GetMedicalRecordAttachmentThumbnail)
+		}
+		// The record's doctor or an admin accepts or rejects an amendment request (checked in handler)
+		private.PATCH("/amendment-requests/:id", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), medicalRecordHandler.DecideAmendmentRequest)
+
+		// Consent patients give doctors to read their records under STRICT_RECORD_ACCESS
+		consentRoutes := private.Group("/consents")
+		{
+			// Grants a patient gave, or the active grants a doctor holds
+			consentRoutes.GET("", middleware.RoleAuthMiddleware(models.RolePatient, models.RoleDoctor), consentHandler.GetConsentGrants)
+			consentRoutes.POST("", middleware.RoleAuthMiddleware(models.RolePatient), consentHandler.CreateConsentGrant)
+			consentRoutes.DELETE("/:id", middleware.RoleAuthMiddleware(models.RolePatient), consentHandler.RevokeConsentGrant) // Ownership checked in handler
+			// Doctors who can read the patient's records and how
+			consentRoutes.GET("/access", middleware.RoleAuthMiddleware(models.RolePatient), consentHandler.GetRecordAccess)
 		}
+
+		// Prescription routes
+		prescriptionRoutes := private.Group("/prescriptions")
+		{
+			// Doctors issue prescriptions
+			prescriptionRoutes.POST("", middleware.RoleAuthMiddleware(models.RoleDoctor), prescriptionHandler.CreatePrescription)
+
+			// Patient can get their own, Doctors can get any patient's (same rules as medical records)
+			prescriptionRoutes.GET("/patient/:patientId", prescriptionHandler.GetPrescriptionsForPatient) // Auth in handler
+
+			// Prescribing doctor or Admin marks a prescription active/expired
+			prescriptionRoutes.PATCH("/:id/status", middleware.RoleAuthMiddleware(models.RoleDoctor, models.RoleAdmin), prescriptionHandler.UpdatePrescriptionStatus)
+		}
+
+		// Patient health data
+		patientRoutes := private.Group("/patients")
+		{
+			// Vitals: patients record their own, doctors any patient's (checked in handler)
+			patientRoutes.POST("/:patientId/vitals", vitalHandler.RecordVital)
+			// Time series per type; the patient, any doctor or an admin (checked in handler)
+			patientRoutes.GET("/:patientId/vitals", vitalHandler.GetVitals)
+			// Recent no-shows, flagged when frequent; the patient, any doctor or an admin (checked in handler)
+			patientRoutes.GET("/:patientId/no-shows", appointmentHandler.GetPatientNoShows)
+		}
+
 		// Messaging routes
 		messageRoutes := private.Group("/messages")
 		{
 			// Authenticated users (Patient, Doctor) can send messages based on rules in handler
-			messageRoutes.POST("/send", messageHandler.SendMessage)
+			messageRoutes.POST("/send", loadUser, messageHandler.SendMessage)
 
 			// Get messages for the current user (either all or with a specific user)
 			messageRoutes.GET("", messageHandler.GetMessagesForUser) // Auth in handler
 
+			// Search the current user's messages by subject and content
+			messageRoutes.GET("/search", messageHandler.SearchMessages) // Auth in handler
+
 			// Get new messages since a specified timestamp
 			messageRoutes.GET("/new", messageHandler.GetNewMessages) // Auth in handler
 
+			// WebSocket for typing indicators, read receipts and partner presence
+			messageRoutes.GET("/realtime", realtimeHandler.Connect)
+
 			// Get a list of conversations
-			messageRoutes.GET("/conversations", messageHandler.GetConversations)      // Auth in handler			// Mark a specific message as read
+			messageRoutes.GET("/conversations", messageHandler.GetConversations) // Auth in handler
+
+			// Archive or mute the conversation with a partner for the current user only; messages are never deleted
+			messageRoutes.PATCH("/conversations/:partnerId", messageHandler.UpdateConversationState)
+			messageRoutes.POST("/conversations/:partnerId/archive", messageHandler.ArchiveConversation)
+
+			// A single message, for the sender or recipient; the recipient fetching it marks it as read
+			messageRoutes.GET("/:messageId", messageHandler.GetMessage) // Auth in handler
+
+			// Mark a specific message as read
 			messageRoutes.PATCH("/:messageId/read", messageHandler.MarkMessageAsRead) // Auth in handler
 		}
 
+		// Admin routes
+		adminRoutes := private.Group("/admin")
+		adminRoutes.Use(middleware.RoleAuthMiddleware(models.RoleAdmin))
+		{
+			// Broadcasts to all patients, all doctors or everyone, delivered as messages in the background
+			adminRoutes.POST("/announcements", announcementHandler.CreateAnnouncement)
+			adminRoutes.GET("/announcements", announcementHandler.GetAnnouncements)
+
+			// Clinic onboarding: create many users from JSON or CSV, each invited to set a password
+			adminRoutes.POST("/users/import", userHandler.ImportUsers)
+
+			// Invite a doctor, admin or patient to register with that role
+			adminRoutes.POST("/invitations", userHandler.CreateInvitation)
+
+			// Move a departing doctor's future appointments to another doctor; conflicting ones are reported back
+			adminRoutes.POST("/doctors/:id/reassign", appointmentHandler.ReassignDoctorAppointments)
+			// Cancel a doctor's pending and confirmed appointments in a date range, e.g. for leave; patients are messaged
+			adminRoutes.POST("/doctors/:id/cancel-appointments", appointmentHandler.CancelDoctorAppointments)
+
+			// Delete finished appointments past APPOINTMENT_RETENTION_DAYS now instead of at the next daily run
+			adminRoutes.POST("/appointments/purge", middleware.PlatformAdminMiddleware(cfg), appointmentHandler.PurgeOldAppointments)
+
+			// Move one patient's care to another doctor: future appointments, optional record notes, messages to all three
+			adminRoutes.POST("/patients/:patientId/transfer", appointmentHandler.TransferPatient)
+
+			// Reporting
+			adminRoutes.GET("/stats/no-shows", appointmentHandler.GetNoShowStats)
+
+			// Runtime counters such as doctor cache hits and misses
+			adminRoutes.GET("/metrics", middleware.PlatformAdminMiddleware(cfg), metricsHandler.GetMetrics)
+			// Who did what to which entity, such as decisions on record amendment requests
+			adminRoutes.GET("/audit-events", auditHandler.GetAuditEvents)
+
+			// Most recent slow database queries and HTTP requests, redacted
+			adminRoutes.GET("/slow-queries", middleware.PlatformAdminMiddleware(cfg), slowQueryHandler.GetSlowQueries)
+
+			// Terms of service and privacy policy versions, shared by every organization
+			policyRoutes := adminRoutes.Group("/policies")
+			policyRoutes.Use(middleware.PlatformAdminMiddleware(cfg))
+			{
+				policyRoutes.GET("", policyHandler.GetPolicyDocuments)
+				policyRoutes.POST("", policyHandler.CreatePolicyDocument)
+				policyRoutes.PUT("/:id", policyHandler.UpdatePolicyDocument)
+				policyRoutes.DELETE("/:id", policyHandler.DeletePolicyDocument)
+			}
+
+			// Scheduled background jobs; they work on every organization's data
+			jobRoutes := adminRoutes.Group("/jobs")
+			jobRoutes.Use(middleware.PlatformAdminMiddleware(cfg))
+			{
+				jobRoutes.GET("", jobHandler.GetJobs)
+				jobRoutes.POST("/:name/run-now", jobHandler.RunJobNow)
+			}
+
+			// Webhook subscriptions of external systems and their delivery log; they see every organization's events
+			webhookRoutes := adminRoutes.Group("/webhooks")
+			webhookRoutes.Use(middleware.PlatformAdminMiddleware(cfg))
+			{
+				webhookRoutes.POST("", webhookHandler.CreateWebhook)
+				webhookRoutes.GET("", webhookHandler.GetWebhooks)
+				webhookRoutes.GET("/:id", webhookHandler.GetWebhook)
+				webhookRoutes.PUT("/:id", webhookHandler.UpdateWebhook)
+				webhookRoutes.DELETE("/:id", webhookHandler.DeleteWebhook)
+				webhookRoutes.GET("/:id/deliveries", webhookHandler.GetWebhookDeliveries)
+			}
+
+			// The admin's own organization and the invite code patients and doctors register with (MULTI_TENANT)
+			adminRoutes.GET("/organization", organizationHandler.GetOwnOrganization)
+			adminRoutes.POST("/organization/invite-code", organizationHandler.RotateOwnInviteCode)
+		}
+
+		// Platform routes of a multi-tenant deployment (super admin only)
+		superAdminRoutes := private.Group("/super-admin")
+		superAdminRoutes.Use(middleware.RoleAuthMiddleware(models.RoleSuperAdmin))
+		{
+			// Organizations (clinics); their first admin is created with POST /users and organizationId
+			superAdminRoutes.POST("/organizations", organizationHandler.CreateOrganization)
+			superAdminRoutes.GET("/organizations", organizationHandler.GetOrganizations)
+			superAdminRoutes.POST("/organizations/:id/invite-code", organizationHandler.RotateOrganizationInviteCode)
+		}
+
 	}
 
 	// Simple health check endpoint
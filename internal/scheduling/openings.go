@@ -0,0 +1,174 @@
+package scheduling
+
+import (
+	"healthcare-app-server/internal/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// availabilityTimeLayout is the HH:MM format of DoctorAvailability start and end times.
+const availabilityTimeLayout = "15:04"
+
+// interval is a [start, end) stretch of time.
+type interval struct {
+	start, end time.Time
+}
+
+// DoctorsWithOpenings returns the IDs of the doctors who have at least one free stretch of minLength
+// on the UTC day starting at day, not earlier than notBefore. A stretch is free when it lies inside
+// one of the doctor's weekly availability blocks for that weekday (read as UTC, like the rest of the
+// schedule), outside their time off, and overlaps fewer appointments than MaxConcurrentPerSlot.
+// Doctors who reached MaxAppointmentsPerDay that day have no openings. Availability, appointments,
+// time off and profiles are each loaded for all doctors at once, so the cost does not grow with
+// one query per doctor.
+func DoctorsWithOpenings(db *gorm.DB, day time.Time, minLength time.Duration, notBefore time.Time) ([]string, error) {
+	day = day.UTC().Truncate(24 * time.Hour)
+	dayEnd := day.Add(24 * time.Hour)
+
+	var blocks []models.DoctorAvailability
+	if err := db.Where("weekday = ?", int(day.Weekday())).Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	working := make(map[string][]interval)
+	for _, block := range blocks {
+		start, startErr := time.Parse(availabilityTimeLayout, block.StartTime)
+		end, endErr := time.Parse(availabilityTimeLayout, block.EndTime)
+		if startErr != nil || endErr != nil {
+			continue // Validated when saved; skip anything stored otherwise
+		}
+		hours := interval{
+			start: day.Add(time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute),
+			end:   day.Add(time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute),
+		}
+		if hours.start.Before(notBefore) {
+			hours.start = notBefore
+		}
+		if hours.end.Sub(hours.start) >= minLength {
+			working[block.DoctorID] = append(working[block.DoctorID], hours)
+		}
+	}
+	if len(working) == 0 {
+		return []string{}, nil
+	}
+	doctorIDs := make([]string, 0, len(working))
+	for doctorID := range working {
+		doctorIDs = append(doctorIDs, doctorID)
+	}
+	sort.Strings(doctorIDs)
+
+	var profiles []models.DoctorProfile
+	if err := db.Where("doctor_id IN ?", doctorIDs).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	limits := make(map[string]BookingLimits, len(profiles))
+	for _, profile := range profiles {
+		doctorLimits := BookingLimits{MaxConcurrentPerSlot: 1, MaxAppointmentsPerDay: profile.MaxAppointmentsPerDay}
+		if profile.MaxConcurrentPerSlot > 0 {
+			doctorLimits.MaxConcurrentPerSlot = profile.MaxConcurrentPerSlot
+		}
+		limits[profile.DoctorID] = doctorLimits
+	}
+
+	var appointments []models.Appointment
+	if err := db.Where("doctor_id IN ? AND status IN ?", doctorIDs, BlockingStatuses).
+		Where("start_time < ? AND (end_time > ? OR start_time >= ?)", dayEnd, day, day).
+		Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+	booked := make(map[string][]interval)
+	bookedToday := make(map[string]int)
+	for _, appointment := range appointments {
+		if !appointment.StartTime.Before(day) {
+			bookedToday[appointment.DoctorID]++
+		}
+		if appointment.EndTime.After(appointment.StartTime) {
+			booked[appointment.DoctorID] = append(booked[appointment.DoctorID],
+				interval{start: appointment.StartTime, end: appointment.EndTime})
+		}
+	}
+
+	var timeOff []models.TimeOff
+	if err := db.Where("doctor_id IN ? AND start_time < ? AND end_time > ?", doctorIDs, dayEnd, day).
+		Find(&timeOff).Error; err != nil {
+		return nil, err
+	}
+	away := make(map[string][]interval)
+	for _, block := range timeOff {
+		away[block.DoctorID] = append(away[block.DoctorID], interval{start: block.StartTime, end: block.EndTime})
+	}
+
+	available := []string{}
+	for _, doctorID := range doctorIDs {
+		doctorLimits, ok := limits[doctorID]
+		if !ok {
+			doctorLimits = BookingLimits{MaxConcurrentPerSlot: 1}
+		}
+		if doctorLimits.MaxAppointmentsPerDay > 0 && bookedToday[doctorID] >= doctorLimits.MaxAppointmentsPerDay {
+			continue
+		}
+		busy := append(fullyBooked(booked[doctorID], doctorLimits.MaxConcurrentPerSlot), away[doctorID]...)
+		if hasOpening(working[doctorID], busy, minLength) {
+			available = append(available, doctorID)
+		}
+	}
+	return available, nil
+}
+
+// fullyBooked returns the stretches during which at least capacity of the appointments overlap.
+func fullyBooked(appointments []interval, capacity int) []interval {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	edges := make([]edge, 0, 2*len(appointments))
+	for _, appointment := range appointments {
+		edges = append(edges, edge{appointment.start, 1}, edge{appointment.end, -1})
+	}
+	// Ends sort before starts at the same instant, so back-to-back appointments do not overlap
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+
+	var full []interval
+	overlapping := 0
+	var fullSince time.Time
+	for _, e := range edges {
+		before := overlapping
+		overlapping += e.delta
+		if before < capacity && overlapping >= capacity {
+			fullSince = e.at
+		} else if before >= capacity && overlapping < capacity && e.at.After(fullSince) {
+			full = append(full, interval{start: fullSince, end: e.at})
+		}
+	}
+	return full
+}
+
+// hasOpening reports whether any of the working stretches has minLength not covered by busy.
+func hasOpening(working, busy []interval, minLength time.Duration) bool {
+	sort.Slice(busy, func(i, j int) bool { return busy[i].start.Before(busy[j].start) })
+	for _, hours := range working {
+		free := hours.start
+		for _, period := range busy {
+			if !period.end.After(free) {
+				continue
+			}
+			if !period.start.Before(hours.end) {
+				break
+			}
+			if period.start.Sub(free) >= minLength {
+				return true
+			}
+			free = period.end
+		}
+		if hours.end.Sub(free) >= minLength {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+// Package scheduling contains the appointment booking rules shared by every code path
+// that places an appointment on a doctor's calendar (create, reschedule, and future booking features).
+package scheduling
+
+import (
+	"healthcare-app-server/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BlockingStatuses are the appointment statuses that occupy a slot on the doctor's calendar,
+// i.e. the appointments that are still going to happen. Proposals hold their slot until the patient
+// answers or they expire.
+var BlockingStatuses = []models.AppointmentStatus{
+	models.StatusPending,
+	models.StatusConfirmed,
+	models.StatusRescheduled,
+	models.StatusProposed,
+}
+
+// ConflictingAppointments returns the doctor's appointments that overlap the [start, end) interval.
+// Cancelled and completed appointments never conflict. excludeAppointmentID (may be empty) is
+// ignored, so an appointment being rescheduled does not conflict with itself.
+// Appointments stored without an end time are treated as occupying only their start instant.
+func ConflictingAppointments(db *gorm.DB, doctorID string, start, end time.Time, excludeAppointmentID string) ([]models.Appointment, error) {
+	query := db.Where("doctor_id = ? AND status IN ?", doctorID, BlockingStatuses).
+		Where("start_time < ?", end).
+		Where("end_time > ? OR (end_time <= start_time AND start_time >= ?)", start, start)
+	if excludeAppointmentID != "" {
+		query = query.Where("id <> ?", excludeAppointmentID)
+	}
+
+	var conflicts []models.Appointment
+	if err := query.Order("start_time asc").Find(&conflicts).Error; err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// Limit names the booking limit an appointment would exceed.
+type Limit string
+
+const (
+	LimitNone Limit = ""     // The appointment fits
+	LimitSlot Limit = "slot" // The slot already has MaxConcurrentPerSlot overlapping appointments
+	LimitDay  Limit = "day"  // The day already has MaxAppointmentsPerDay appointments
+)
+
+// BookingLimits are a doctor's capacity settings. Doctors without a profile take one patient
+// per slot and have no daily limit.
+type BookingLimits struct {
+	MaxConcurrentPerSlot  int
+	MaxAppointmentsPerDay int // 0 means no limit
+}
+
+// LoadBookingLimits returns the doctor's capacity settings from their profile.
+func LoadBookingLimits(db *gorm.DB, doctorID string) (BookingLimits, error) {
+	limits := BookingLimits{MaxConcurrentPerSlot: 1}
+
+	var profile models.DoctorProfile
+	err := db.Where("doctor_id = ?", doctorID).First(&profile).Error
+	if err == gorm.ErrRecordNotFound {
+		return limits, nil
+	} else if err != nil {
+		return limits, err
+	}
+	if profile.MaxConcurrentPerSlot > 0 {
+		limits.MaxConcurrentPerSlot = profile.MaxConcurrentPerSlot
+	}
+	limits.MaxAppointmentsPerDay = profile.MaxAppointmentsPerDay
+	return limits, nil
+}
+
+// CheckCapacity reports which of the doctor's booking limits an appointment in [start, end) would exceed,
+// together with the value of that limit. The slot limit counts the appointments overlapping the interval;
+// the daily limit counts those starting on the same UTC day. Both only count appointments that are still
+// going to happen, and excludeAppointmentID (may be empty) is ignored as in ConflictingAppointments.
+func CheckCapacity(db *gorm.DB, doctorID string, start, end time.Time, excludeAppointmentID string) (Limit, int, error) {
+	limits, err := LoadBookingLimits(db, doctorID)
+	if err != nil {
+		return LimitNone, 0, err
+	}
+
+	conflicts, err := ConflictingAppointments(db, doctorID, start, end, excludeAppointmentID)
+	if err != nil {
+		return LimitNone, 0, err
+	}
+	if len(conflicts) >= limits.MaxConcurrentPerSlot {
+		return LimitSlot, limits.MaxConcurrentPerSlot, nil
+	}
+
+	if limits.MaxAppointmentsPerDay > 0 {
+		booked, err := countDayAppointments(db, doctorID, start, excludeAppointmentID)
+		if err != nil {
+			return LimitNone, 0, err
+		}
+		if booked >= int64(limits.MaxAppointmentsPerDay) {
+			return LimitDay, limits.MaxAppointmentsPerDay, nil
+		}
+	}
+	return LimitNone, 0, nil
+}
+
+// RemainingCapacity returns how many more appointments in [start, end) CheckCapacity would accept
+// under limits: the free places in the slot, capped by what is left of the UTC day's limit.
+func RemainingCapacity(db *gorm.DB, doctorID string, limits BookingLimits, start, end time.Time) (int, error) {
+	conflicts, err := ConflictingAppointments(db, doctorID, start, end, "")
+	if err != nil {
+		return 0, err
+	}
+	remaining := limits.MaxConcurrentPerSlot - len(conflicts)
+
+	if limits.MaxAppointmentsPerDay > 0 {
+		booked, err := countDayAppointments(db, doctorID, start, "")
+		if err != nil {
+			return 0, err
+		}
+		if left := limits.MaxAppointmentsPerDay - int(booked); left < remaining {
+			remaining = left
+		}
+	}
+	if remaining < 0 {
+		return 0, nil
+	}
+	return remaining, nil
+}
+
+// countDayAppointments counts the doctor's appointments that are still going to happen and start
+// on the UTC day of start, ignoring excludeAppointmentID (may be empty).
+func countDayAppointments(db *gorm.DB, doctorID string, start time.Time, excludeAppointmentID string) (int64, error) {
+	dayStart := start.UTC().Truncate(24 * time.Hour)
+	query := db.Model(&models.Appointment{}).
+		Where("doctor_id = ? AND status IN ?", doctorID, BlockingStatuses).
+		Where("start_time >= ? AND start_time < ?", dayStart, dayStart.Add(24*time.Hour))
+	if excludeAppointmentID != "" {
+		query = query.Where("id <> ?", excludeAppointmentID)
+	}
+	var booked int64
+	err := query.Count(&booked).Error
+	return booked, err
+}
+
+// OverlappingTimeOff returns the doctor's time-off blocks that overlap the [start, end) interval.
+// excludeTimeOffID (may be empty) is ignored, so a block being edited does not overlap itself.
+func OverlappingTimeOff(db *gorm.DB, doctorID string, start, end time.Time, excludeTimeOffID string) ([]models.TimeOff, error) {
+	query := db.Where("doctor_id = ? AND start_time < ? AND end_time > ?", doctorID, end, start)
+	if excludeTimeOffID != "" {
+		query = query.Where("id <> ?", excludeTimeOffID)
+	}
+
+	var blocks []models.TimeOff
+	if err := query.Order("start_time asc").Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// IsDuringTimeOff reports whether any part of [start, end) falls inside one of the doctor's time-off blocks.
+func IsDuringTimeOff(db *gorm.DB, doctorID string, start, end time.Time) (bool, error) {
+	// Appointments stored without an end time still occupy their start instant
+	if !end.After(start) {
+		end = start.Add(time.Nanosecond)
+	}
+	blocks, err := OverlappingTimeOff(db, doctorID, start, end, "")
+	if err != nil {
+		return false, err
+	}
+	return len(blocks) > 0, nil
+}
@@ -0,0 +1,176 @@
+// Package seed creates the accounts a fresh deployment needs to be usable: the initial admin from
+// ADMIN_EMAIL and ADMIN_PASSWORD, and with SEED_DEMO a demo doctor and patient with an appointment
+// and a medical record. Seeding runs at every startup and only creates what is missing; it never
+// changes existing users, their passwords included.
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"healthcare-app-server/internal/models"
+)
+
+// Demo account emails. They use a reserved test domain, so no mail is ever delivered to them.
+const (
+	DemoDoctorEmail  = "doctor@demo.medivuno.test"
+	DemoPatientEmail = "patient@demo.medivuno.test"
+)
+
+// DemoPassword is the password of the demo accounts. It is public, which is why SEED_DEMO is refused in production.
+const DemoPassword = "demo-password"
+
+// demoOrganizationName is the clinic the demo accounts join when MULTI_TENANT is on.
+const demoOrganizationName = "Demo Clinic"
+
+// Admin creates a verified admin with email and password unless an admin already exists. With
+// multiTenant it creates a super admin instead, as admins outside every organization cannot sign in.
+// If a user already has email, it is left alone with a warning. It reports whether the admin was created.
+func Admin(db *gorm.DB, email, password string, multiTenant bool) (bool, error) {
+	var admins int64
+	if err := db.Model(&models.User{}).Where("role IN ?", []models.Role{models.RoleAdmin, models.RoleSuperAdmin}).
+		Count(&admins).Error; err != nil {
+		return false, fmt.Errorf("counting admins: %w", err)
+	}
+	if admins > 0 {
+		return false, nil
+	}
+
+	var existing int64
+	if err := db.Model(&models.User{}).Where("email = ?", email).Count(&existing).Error; err != nil {
+		return false, fmt.Errorf("looking up %s: %w", email, err)
+	}
+	if existing > 0 {
+		log.Printf("WARN ADMIN_EMAIL %s belongs to an existing user who is not an admin; no admin was seeded", email)
+		return false, nil
+	}
+
+	role := models.RoleAdmin
+	if multiTenant {
+		role = models.RoleSuperAdmin
+	}
+	admin := models.User{Email: email, FirstName: "Admin", Role: role, IsVerified: true}
+	if err := admin.SetPassword(password); err != nil {
+		return false, fmt.Errorf("hashing the admin password: %w", err)
+	}
+	if err := db.Create(&admin).Error; err != nil {
+		return false, fmt.Errorf("creating the admin: %w", err)
+	}
+	log.Printf("WARN Created the initial %s account %s from ADMIN_EMAIL and ADMIN_PASSWORD; "+
+		"change its password and remove ADMIN_PASSWORD from the environment", role, email)
+	return true, nil
+}
+
+// Demo creates the demo doctor and patient, the doctor's profile, and an appointment and a medical record
+// between them, whichever of these are missing. With multiTenant the accounts it creates join a demo clinic.
+func Demo(db *gorm.DB, multiTenant bool) error {
+	var organizationID *string
+	if multiTenant {
+		var organization models.Organization
+		err := db.Where("name = ?", demoOrganizationName).First(&organization).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			organization.Name = demoOrganizationName
+			if err := organization.RotateInviteCode(); err != nil {
+				return fmt.Errorf("creating the demo organization's invite code: %w", err)
+			}
+			err = db.Create(&organization).Error
+		}
+		if err != nil {
+			return fmt.Errorf("creating the demo organization: %w", err)
+		}
+		organizationID = &organization.ID
+	}
+
+	doctor, err := demoUser(db, DemoDoctorEmail, "Demo", "Doctor", models.RoleDoctor, organizationID)
+	if err != nil {
+		return err
+	}
+	patient, err := demoUser(db, DemoPatientEmail, "Demo", "Patient", models.RolePatient, organizationID)
+	if err != nil {
+		return err
+	}
+
+	var profile models.DoctorProfile
+	err = db.Where("doctor_id = ?", doctor.ID).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		profile = models.DoctorProfile{DoctorID: doctor.ID, Specialty: "General Practice", Bio: "Demo account."}
+		err = db.Create(&profile).Error
+	}
+	if err != nil {
+		return fmt.Errorf("creating the demo doctor profile: %w", err)
+	}
+
+	var appointments int64
+	if err := db.Model(&models.Appointment{}).Where("doctor_id = ? AND patient_id = ?", doctor.ID, patient.ID).
+		Count(&appointments).Error; err != nil {
+		return fmt.Errorf("counting demo appointments: %w", err)
+	}
+	if appointments == 0 {
+		start := time.Now().UTC().Add(7 * 24 * time.Hour).Truncate(time.Hour)
+		appointment := models.Appointment{
+			PatientID: patient.ID,
+			DoctorID:  doctor.ID,
+			StartTime: start,
+			EndTime:   start.Add(30 * time.Minute),
+			Status:    models.StatusConfirmed,
+			Reason:    "Routine check-up",
+		}
+		if err := db.Create(&appointment).Error; err != nil {
+			return fmt.Errorf("creating the demo appointment: %w", err)
+		}
+	}
+
+	var records int64
+	if err := db.Model(&models.MedicalRecord{}).Where("doctor_id = ? AND patient_id = ?", doctor.ID, patient.ID).
+		Count(&records).Error; err != nil {
+		return fmt.Errorf("counting demo medical records: %w", err)
+	}
+	if records == 0 {
+		record := models.MedicalRecord{
+			PatientID:  patient.ID,
+			DoctorID:   doctor.ID,
+			RecordType: models.RecordTypeConsultation,
+			RecordDate: time.Now().UTC(),
+			Title:      "Initial consultation",
+			Department: "General Practice",
+			Summary:    "Patient in good health. No medication.",
+		}
+		if err := db.Create(&record).Error; err != nil {
+			return fmt.Errorf("creating the demo medical record: %w", err)
+		}
+	}
+	return nil
+}
+
+// demoUser returns the user with email, creating a verified one with DemoPassword if there is none.
+func demoUser(db *gorm.DB, email, firstName, lastName string, role models.Role, organizationID *string) (models.User, error) {
+	var user models.User
+	err := db.Where("email = ?", email).First(&user).Error
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, fmt.Errorf("looking up %s: %w", email, err)
+	}
+
+	user = models.User{
+		Email:          email,
+		FirstName:      firstName,
+		LastName:       lastName,
+		Role:           role,
+		IsVerified:     true,
+		OrganizationID: organizationID,
+	}
+	if err := user.SetPassword(DemoPassword); err != nil {
+		return user, fmt.Errorf("hashing the demo password: %w", err)
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return user, fmt.Errorf("creating %s: %w", email, err)
+	}
+	log.Printf("Created the demo %s account %s", role, email)
+	return user, nil
+}
@@ -0,0 +1,87 @@
+package slowlog
+
+import (
+	"log"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// startKey holds the time a statement started, in the statement's instance settings.
+const startKey = "slowlog:start"
+
+// literalPattern matches the literals that can end up in SQL built by hand: quoted strings, UUIDs and numbers.
+// Values passed as arguments are bound separately and never part of the SQL to begin with.
+var literalPattern = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.)*"|\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b|\b\d+(?:\.\d+)?\b`)
+
+// Redact replaces every literal in sql with ?.
+func Redact(sql string) string {
+	return literalPattern.ReplaceAllString(sql, "?")
+}
+
+// Plugin is a GORM plugin that records queries slower than Threshold in Log. Install it with db.Use.
+type Plugin struct {
+	Log       *Log
+	Threshold time.Duration
+}
+
+// Name implements gorm.Plugin.
+func (p *Plugin) Name() string {
+	return "slowlog"
+}
+
+// Initialize implements gorm.Plugin by timing every kind of statement.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	for _, register := range []func() error{
+		func() error { return callbacks.Create().Before("gorm:create").Register("slowlog:start", start) },
+		func() error { return callbacks.Create().After("gorm:create").Register("slowlog:finish", p.finish) },
+		func() error { return callbacks.Query().Before("gorm:query").Register("slowlog:start", start) },
+		func() error { return callbacks.Query().After("gorm:query").Register("slowlog:finish", p.finish) },
+		func() error { return callbacks.Update().Before("gorm:update").Register("slowlog:start", start) },
+		func() error { return callbacks.Update().After("gorm:update").Register("slowlog:finish", p.finish) },
+		func() error { return callbacks.Delete().Before("gorm:delete").Register("slowlog:start", start) },
+		func() error { return callbacks.Delete().After("gorm:delete").Register("slowlog:finish", p.finish) },
+		func() error { return callbacks.Row().Before("gorm:row").Register("slowlog:start", start) },
+		func() error { return callbacks.Row().After("gorm:row").Register("slowlog:finish", p.finish) },
+		func() error { return callbacks.Raw().Before("gorm:raw").Register("slowlog:start", start) },
+		func() error { return callbacks.Raw().After("gorm:raw").Register("slowlog:finish", p.finish) },
+	} {
+		if err := register(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// start notes when a statement started.
+func start(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+// finish records the statement if it took longer than the threshold.
+func (p *Plugin) finish(db *gorm.DB) {
+	value, ok := db.InstanceGet(startKey)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(value.(time.Time))
+	if elapsed < p.Threshold {
+		return
+	}
+
+	route, requestID := originOf(db.Statement.Context)
+	rows := db.RowsAffected
+	entry := Entry{
+		Kind:         KindQuery,
+		At:           time.Now().UTC(),
+		DurationMs:   float64(elapsed.Microseconds()) / 1000,
+		Route:        route,
+		RequestID:    requestID,
+		SQL:          Redact(db.Statement.SQL.String()),
+		RowsAffected: &rows,
+	}
+	log.Printf("SLOW QUERY %.1fms rows=%d route=%q request=%s: %s", entry.DurationMs, rows, route, requestID, entry.SQL)
+	p.Log.Add(entry)
+}
@@ -0,0 +1,109 @@
+// Package slowlog records database queries and HTTP requests that took longer than a threshold.
+//
+// Each slow query or request is written to the standard log and kept in a fixed-size ring buffer that
+// admins can read through the API. Queries are logged without their bound values and with any literal
+// that slipped into the SQL replaced by ?, so patient identifiers and message content never appear;
+// routes are logged as their pattern, such as /api/v1/users/:id, for the same reason.
+package slowlog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Kinds of Entry.
+const (
+	KindQuery   = "query"
+	KindRequest = "request"
+)
+
+// Entry is one slow query or request.
+type Entry struct {
+	Kind         string    `json:"kind"`
+	At           time.Time `json:"at"`
+	DurationMs   float64   `json:"durationMs"`
+	Route        string    `json:"route"`                  // Method and route pattern, or "background" outside requests
+	RequestID    string    `json:"requestId,omitempty"`    // Matches the X-Request-ID of the response
+	SQL          string    `json:"sql,omitempty"`          // Redacted statement of a query
+	RowsAffected *int64    `json:"rowsAffected,omitempty"` // Set for queries
+	Status       int       `json:"status,omitempty"`       // HTTP status of a request
+}
+
+// Log holds the most recent entries in a ring buffer. It is safe for concurrent use.
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int  // Index the next entry is written to
+	full    bool // Whether entries has wrapped around
+}
+
+// New creates a Log that keeps the last size entries. A non-positive size keeps none.
+func New(size int) *Log {
+	if size < 0 {
+		size = 0
+	}
+	return &Log{entries: make([]Entry, size)}
+}
+
+// Add records entry, dropping the oldest one when the buffer is full.
+func (l *Log) Add(entry Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) == 0 {
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Recent returns up to limit entries, newest first. A non-positive limit returns all of them.
+func (l *Log) Recent(limit int) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+	if limit > 0 && limit < count {
+		count = limit
+	}
+	recent := make([]Entry, count)
+	for i := range recent {
+		recent[i] = l.entries[(l.next-1-i+len(l.entries))%len(l.entries)]
+	}
+	return recent
+}
+
+// Size returns how many entries the log keeps.
+func (l *Log) Size() int {
+	return len(l.entries)
+}
+
+type contextKey struct{}
+
+// origin is the request a query is made for.
+type origin struct {
+	route     string
+	requestID string
+}
+
+// WithRoute returns a copy of ctx that attributes the queries made with it to route and requestID.
+func WithRoute(ctx context.Context, route, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, origin{route: route, requestID: requestID})
+}
+
+// originOf returns the route and request ID ctx was tagged with, or "background" for queries outside requests.
+func originOf(ctx context.Context) (route, requestID string) {
+	if ctx != nil {
+		if o, ok := ctx.Value(contextKey{}).(origin); ok {
+			return o.route, o.requestID
+		}
+	}
+	return "background", ""
+}
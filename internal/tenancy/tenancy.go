@@ -0,0 +1,161 @@
+// Package tenancy keeps the data of the organizations (clinics) sharing one deployment apart.
+//
+// The organization of the requesting user travels in the request context. Once Register has installed
+// its callbacks, every query made with that context on a model that has an OrganizationID field is
+// limited to the organization's rows, preloads and subqueries included, and rows created with it are
+// stamped with the organization. Queries without an organization in their context, such as background
+// jobs and super admin requests, are not limited.
+package tenancy
+
+import (
+	"context"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// fieldName is the model field that holds a row's organization.
+const fieldName = "OrganizationID"
+
+type contextKey struct{}
+
+// Owned is implemented by models whose organization follows a user's, e.g. a message's sender. Rows
+// created without an organization in the context, such as by background jobs, take that user's.
+type Owned interface {
+	TenantOwnerID() string
+}
+
+// WithOrganization returns a copy of ctx whose queries are limited to organizationID.
+func WithOrganization(ctx context.Context, organizationID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, organizationID)
+}
+
+// OrganizationID returns the organization queries made with ctx are limited to, if any.
+func OrganizationID(ctx context.Context) (string, bool) {
+	organizationID, ok := ctx.Value(contextKey{}).(string)
+	return organizationID, ok && organizationID != ""
+}
+
+// Unscoped returns a copy of ctx whose queries see every organization, keeping its deadline. It is for the
+// few checks that must look across organizations, such as whether an email address is taken.
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, "")
+}
+
+// Detach returns a context for background work started by a request: it keeps the request's
+// organization but not its deadline or cancellation.
+func Detach(ctx context.Context) context.Context {
+	if organizationID, ok := OrganizationID(ctx); ok {
+		return WithOrganization(context.Background(), organizationID)
+	}
+	return context.Background()
+}
+
+// Register installs the callbacks that scope queries, updates and deletes and stamp new rows.
+func Register(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenancy:scope", scopeStatement); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenancy:scope", scopeStatement); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenancy:scope", scopeStatement); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenancy:scope", scopeStatement); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register("tenancy:stamp", stampCreated)
+}
+
+// organizationField returns the OrganizationID field of the statement's model, if it has one.
+func organizationField(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(fieldName)
+}
+
+// scopeStatement adds the organization condition to a query, update or delete.
+func scopeStatement(db *gorm.DB) {
+	field := organizationField(db)
+	if field == nil {
+		return
+	}
+	organizationID, ok := OrganizationID(db.Statement.Context)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: organizationID},
+	}})
+}
+
+// stampCreated sets the organization of new rows that do not have one: the context's, or else their owner's.
+func stampCreated(db *gorm.DB) {
+	field := organizationField(db)
+	if field == nil || db.Error != nil {
+		return
+	}
+	organizationID, fromContext := OrganizationID(db.Statement.Context)
+	owners := make(map[string]string) // Batches often share an owner, e.g. an announcement's sender
+
+	stamp := func(row reflect.Value) {
+		if _, isZero := field.ValueOf(db.Statement.Context, row); !isZero {
+			return
+		}
+		rowOrganizationID := organizationID
+		if !fromContext {
+			if !row.CanAddr() {
+				return
+			}
+			owned, ok := row.Addr().Interface().(Owned)
+			if !ok {
+				return
+			}
+			ownerID := owned.TenantOwnerID()
+			cached, seen := owners[ownerID]
+			if !seen {
+				var err error
+				if cached, err = ownerOrganization(db, ownerID); err != nil {
+					db.AddError(err)
+					return
+				}
+				owners[ownerID] = cached
+			}
+			if rowOrganizationID = cached; rowOrganizationID == "" {
+				return
+			}
+		}
+		if err := field.Set(db.Statement.Context, row, &rowOrganizationID); err != nil {
+			db.AddError(err)
+		}
+	}
+
+	rows := reflect.Indirect(db.Statement.ReflectValue)
+	switch rows.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rows.Len(); i++ {
+			stamp(reflect.Indirect(rows.Index(i)))
+		}
+	case reflect.Struct:
+		stamp(rows)
+	}
+}
+
+// ownerOrganization returns the organization of the user userID, or "" when they have none.
+func ownerOrganization(db *gorm.DB, userID string) (string, error) {
+	if userID == "" {
+		return "", nil
+	}
+	var organizationIDs []*string
+	err := db.Session(&gorm.Session{NewDB: true}).Table("users").
+		Where("id = ?", userID).Limit(1).Pluck("organization_id", &organizationIDs).Error
+	if err != nil || len(organizationIDs) == 0 || organizationIDs[0] == nil {
+		return "", err
+	}
+	return *organizationIDs[0], nil
+}
@@ -0,0 +1,204 @@
+// Package testutil provides shared helpers for handler and middleware tests:
+// an in-memory database with the application schema, JWT minting for arbitrary
+// users and roles, and a gin router preconfigured like the real server.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/middleware"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewTestDB opens a private in-memory SQLite database migrated with the application schema.
+// Each call returns an isolated database that is closed when the test finishes.
+func NewTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	// A unique shared-cache name keeps all pool connections on the same in-memory database
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", uuid.New().String())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("testutil: opening test database: %v", err)
+	}
+	if err := models.UseUTC(db); err != nil {
+		t.Fatalf("testutil: configuring test database: %v", err)
+	}
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("testutil: migrating test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// NewTestConfig returns a configuration with fixed HS256 secrets and the defaults the server uses.
+func NewTestConfig() *config.Config {
+	return &config.Config{
+		Environment:               "test",
+		ErrorDetail:               "generic",
+		JWTSecret:                 "test_jwt_secret",
+		JWTRefreshSecret:          "test_refresh_secret",
+		JWTAlgorithm:              "HS256",
+		JWTExpirationMinutes:      15,
+		JWTRefreshExpirationHours: 168,
+		RefreshIdleHours:          72,
+		MaxBodyBytes:              1 << 20,
+		MaxUploadBytes:            25 << 20,
+		MaxMultipartMemory:        8 << 20,
+		AllowedAttachmentTypes:    []string{"application/pdf", "image/png", "image/jpeg", "application/dicom", "text/plain"},
+		AttachmentThumbnailSize:   256,
+		ReviewEditWindowHours:     48,
+		AppointmentDurationMins:   30,
+		DefaultLocale:             "en",
+		MaxPageSize:               100,
+		MaxMessageLength:          10000,
+		MaxMessageSubjectLength:   255,
+		MessageRatePerMinute:      10,
+		MessageRatePerHour:        60,
+		StaffMessageRatePerMinute: 30,
+		StaffMessageRatePerHour:   300,
+		MessageDuplicateWindow:    60,
+		AppointmentSweepPolicy:    "review",
+		ProposalExpiryHours:       48,
+		NoShowLimit:               3,
+		NoShowWindowDays:          90,
+		CookiePath:                "/",
+		CookieSameSite:            http.SameSiteLaxMode,
+		RequestTimeout:            10,
+		UploadRequestTimeout:      120,
+		MaxImportRows:             500,
+		BcryptCost:                10,
+		VerificationTokenExpiry:   24,
+		InvitationExpiryHours:     72,
+		RecordTrashRetentionDays:  30,
+		JobMaxAttempts:            3,
+		TokenCleanupSchedule:      "@hourly",
+		ReminderSchedule:          "*/15 * * * *",
+		AppointmentReminderHours:  24,
+		WebhookMaxAttempts:        6,
+		WebhookDisableAfter:       5,
+		WebhookTimeout:            10,
+		SlowQueryThresholdMs:      200,
+		SlowRequestThresholdMs:    1000,
+		SlowLogSize:               200,
+		PolicyEnforcement:         "hard",
+	}
+}
+
+// NewTestUser returns an unsaved user with a fresh ID and the given role.
+func NewTestUser(role models.Role) *models.User {
+	id := uuid.New().String()
+	return &models.User{
+		BaseModel: models.BaseModel{ID: id},
+		Email:     id + "@example.test",
+		FirstName: "Test",
+		LastName:  string(role),
+		Role:      role,
+	}
+}
+
+// MintAccessToken returns a valid access token for user, signed with cfg via utils.GenerateTokens.
+func MintAccessToken(t testing.TB, cfg *config.Config, user *models.User) string {
+	t.Helper()
+
+	accessToken, _, err := utils.GenerateTokens(user, cfg)
+	if err != nil {
+		t.Fatalf("testutil: minting access token: %v", err)
+	}
+	return accessToken
+}
+
+// MintExpiredAccessToken returns an access token for user that expired an hour ago.
+func MintExpiredAccessToken(t testing.TB, cfg *config.Config, user *models.User) string {
+	t.Helper()
+
+	expired := *cfg
+	expired.JWTExpirationMinutes = -60
+	return MintAccessToken(t, &expired, user)
+}
+
+// MintAccessTokenWithSecret returns an otherwise valid access token signed with a different HS256 secret,
+// for asserting that tokens from another issuer are rejected.
+func MintAccessTokenWithSecret(t testing.TB, cfg *config.Config, user *models.User, secret string) string {
+	t.Helper()
+
+	other := *cfg
+	other.JWTAlgorithm = "HS256"
+	other.JWTSecret = secret
+	return MintAccessToken(t, &other, user)
+}
+
+// GarbageToken is a string that is not a JWT at all.
+const GarbageToken = "not-a.jwt-token"
+
+// BearerHeader formats an Authorization header value for token.
+func BearerHeader(token string) string {
+	return "Bearer " + token
+}
+
+// NewRouter returns a gin engine in test mode with the global middleware the server installs
+// (request ID, panic recovery and locale), followed by any extra middleware.
+func NewRouter(cfg *config.Config, extra ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Recovery(), middleware.Locale(cfg.DefaultLocale))
+	router.Use(extra...)
+	return router
+}
+
+// PerformRequest sends a request through router and returns the recorded response.
+// A non-nil body is encoded as JSON; headers are added as given.
+func PerformRequest(t testing.TB, router http.Handler, method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("testutil: encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// DecodeResponse decodes a standard API response envelope from recorder.
+func DecodeResponse(t testing.TB, recorder *httptest.ResponseRecorder) utils.ResponseData {
+	t.Helper()
+
+	var response utils.ResponseData
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("testutil: decoding response %q: %v", recorder.Body.String(), err)
+	}
+	return response
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// dicomMagic is the marker DICOM files carry after their 128-byte preamble.
+// http.DetectContentType does not know the format, so it is checked separately.
+var dicomMagic = []byte("DICM")
+
+// inlineSafeContentTypes are the types browsers may render directly without running scripts.
+var inlineSafeContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+}
+
+// DetectContentType sniffs the media type of data from its leading bytes, ignoring
+// whatever the client claimed. The result carries no parameters such as charset.
+func DetectContentType(data []byte) string {
+	if len(data) >= 132 && bytes.Equal(data[128:132], dicomMagic) {
+		return "application/dicom"
+	}
+	return MediaType(http.DetectContentType(data))
+}
+
+// MediaType returns the lowercase media type of a Content-Type value without its parameters.
+func MediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return mediaType
+}
+
+// IsInlineSafeContentType reports whether a file of this type may be shown inline in the browser.
+func IsInlineSafeContentType(contentType string) bool {
+	return inlineSafeContentTypes[MediaType(contentType)]
+}
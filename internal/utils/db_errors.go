@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for a unique constraint violation.
+const mysqlDuplicateEntry = 1062
+
+// IsDuplicateKeyError reports whether err was caused by a unique index violation.
+func IsDuplicateKeyError(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+// HandleDBError sends a safe response for a failed database operation and logs the full error with the request ID.
+// Duplicate keys map to 409, missing records to 404 and timeouts to 503; anything else is a 500 with messageKey.
+// The raw driver error (SQL fragments, table names) only reaches the client in the debug field outside production.
+func HandleDBError(c *gin.Context, err error, messageKey string) {
+	statusCode, key := http.StatusInternalServerError, messageKey
+	switch {
+	case IsDuplicateKeyError(err):
+		statusCode, key = http.StatusConflict, "common.duplicate_resource"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		statusCode, key = http.StatusNotFound, "common.resource_not_found"
+	case errors.Is(err, context.DeadlineExceeded):
+		statusCode, key = http.StatusServiceUnavailable, "common.request_timed_out"
+	}
+
+	logRequestError(c, statusCode, err)
+	writeErrorWithDetail(c, statusCode, key, err)
+}
@@ -0,0 +1,85 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"healthcare-app-server/internal/i18n"
+	"healthcare-app-server/internal/testutil"
+	"healthcare-app-server/internal/utils"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// rawSQLFragments are pieces of the simulated driver errors that must never reach a client.
+var rawSQLFragments = []string{"Duplicate entry", "idx_users_email", "Error 1146", "medivuno.users", "SELECT", "dial tcp"}
+
+// performDBError routes a request to a handler failing with err and returns the decoded envelope and raw body.
+func performDBError(t *testing.T, err error) (utils.ResponseData, int, string) {
+	t.Helper()
+
+	router := testutil.NewRouter(testutil.NewTestConfig())
+	router.GET("/fail", func(c *gin.Context) {
+		utils.HandleDBError(c, err, "users.create_failed")
+	})
+	recorder := testutil.PerformRequest(t, router, http.MethodGet, "/fail", nil, nil)
+	return testutil.DecodeResponse(t, recorder), recorder.Code, recorder.Body.String()
+}
+
+func TestHandleDBError(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.test' for key 'users.idx_users_email'"}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantKey    string
+	}{
+		{"MySQL duplicate entry", fmt.Errorf("INSERT INTO users: %w", duplicate), http.StatusConflict, "common.duplicate_resource"},
+		{"gorm duplicated key", gorm.ErrDuplicatedKey, http.StatusConflict, "common.duplicate_resource"},
+		{"record not found", fmt.Errorf("SELECT * FROM medivuno.users: %w", gorm.ErrRecordNotFound), http.StatusNotFound, "common.resource_not_found"},
+		{"deadline exceeded", fmt.Errorf("dial tcp: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, "common.request_timed_out"},
+		{"anything else", errors.New("Error 1146: Table 'medivuno.users' doesn't exist; SELECT * FROM users"), http.StatusInternalServerError, "users.create_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !i18n.HasKey(tt.wantKey) {
+				t.Fatalf("message key %s is not in the catalog", tt.wantKey)
+			}
+
+			response, status, body := performDBError(t, tt.err)
+			if status != tt.wantStatus || response.Status != tt.wantStatus {
+				t.Errorf("status = %d (envelope %d), want %d", status, response.Status, tt.wantStatus)
+			}
+			if want := i18n.Translate("en", tt.wantKey, nil); response.Error != want {
+				t.Errorf("error = %q, want %q", response.Error, want)
+			}
+			if response.RequestID == "" {
+				t.Error("the response has no request ID")
+			}
+			for _, fragment := range rawSQLFragments {
+				if strings.Contains(body, fragment) {
+					t.Errorf("response leaks %q: %s", fragment, body)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleDBErrorDebugDetail(t *testing.T) {
+	utils.SetDebugErrors(true)
+	t.Cleanup(func() { utils.SetDebugErrors(false) })
+
+	response, _, _ := performDBError(t, errors.New("Error 1146: Table 'medivuno.users' doesn't exist"))
+	if !strings.Contains(response.Debug, "Error 1146") {
+		t.Errorf("debug = %q, want the raw error with ERROR_DETAIL=full", response.Debug)
+	}
+	if strings.Contains(response.Error, "1146") {
+		t.Errorf("error = %q, want only the safe message", response.Error)
+	}
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"healthcare-app-server/internal/models"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// enumTag is a binding tag that checks a string field against one of the model enums.
+type enumTag struct {
+	valid   func(value string) bool
+	allowed []string // Listed in the validation message
+}
+
+// clientRoles are the roles a user can be given through the API.
+var clientRoles = []models.Role{models.RolePatient, models.RoleDoctor, models.RoleAdmin}
+
+// clientAppointmentStatuses are the statuses clients may set directly. Rescheduling has its own
+// endpoint, and needs_review is only set by the appointment sweep.
+var clientAppointmentStatuses = []models.AppointmentStatus{
+	models.StatusPending, models.StatusConfirmed, models.StatusCancelled, models.StatusCompleted, models.StatusNoShow,
+}
+
+// enumTags are the custom binding tags for enum fields:
+//   - record_type: exactly one of the MedicalRecordType constants, case-sensitive
+//   - role: a role from clientRoles in any letter case; handlers store it normalized
+//   - appointment_status: a status from clientAppointmentStatuses in any letter case
+//   - webhook_event: exactly one of models.WebhookEvents
+//   - vital_type: exactly one of models.VitalTypes
+var enumTags = map[string]enumTag{
+	"record_type": {
+		valid: func(value string) bool { return models.MedicalRecordType(value).IsValid() },
+		allowed: func() []string {
+			values := make([]string, len(models.MedicalRecordTypes))
+			for i, recordType := range models.MedicalRecordTypes {
+				values[i] = string(recordType)
+			}
+			return values
+		}(),
+	},
+	"role": {
+		valid: func(value string) bool {
+			for _, role := range clientRoles {
+				if models.Role(value).Normalize() == role {
+					return true
+				}
+			}
+			return false
+		},
+		allowed: func() []string {
+			values := make([]string, len(clientRoles))
+			for i, role := range clientRoles {
+				values[i] = string(role)
+			}
+			return values
+		}(),
+	},
+	"appointment_status": {
+		valid: func(value string) bool {
+			for _, status := range clientAppointmentStatuses {
+				if models.AppointmentStatus(value).Normalize() == status {
+					return true
+				}
+			}
+			return false
+		},
+		allowed: func() []string {
+			values := make([]string, len(clientAppointmentStatuses))
+			for i, status := range clientAppointmentStatuses {
+				values[i] = string(status)
+			}
+			return values
+		}(),
+	},
+	"webhook_event": {
+		valid: func(value string) bool {
+			for _, event := range models.WebhookEvents {
+				if value == event {
+					return true
+				}
+			}
+			return false
+		},
+		allowed: models.WebhookEvents,
+	},
+	"vital_type": {
+		valid: func(value string) bool { return models.VitalType(value).IsValid() },
+		allowed: func() []string {
+			values := make([]string, len(models.VitalTypes))
+			for i, vitalType := range models.VitalTypes {
+				values[i] = string(vitalType)
+			}
+			return values
+		}(),
+	},
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	for name, tag := range enumTags {
+		valid := tag.valid
+		if err := v.RegisterValidation(name, func(fl validator.FieldLevel) bool {
+			return valid(fl.Field().String())
+		}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// enumAllowedValues returns the values the enum tag accepts, for validation messages,
+// or "" when tag is not an enum tag.
+func enumAllowedValues(tag string) string {
+	if enum, ok := enumTags[tag]; ok {
+		return strings.Join(enum.allowed, ", ")
+	}
+	return ""
+}
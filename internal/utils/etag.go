@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ComputeETag builds a strong, quoted ETag by hashing the given parts.
+func ComputeETag(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0}) // Separator so ("ab","c") and ("a","bc") differ
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+}
+
+// ETagVersion formats a row's ID and last modification time as an ETag part.
+func ETagVersion(id string, updatedAt time.Time) string {
+	return id + "@" + updatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// CheckNotModified sets the ETag header and, when the request's If-None-Match matches it,
+// sends a 304 Not Modified with no body and returns true.
+func CheckNotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache") // Clients may cache but must revalidate
+
+	ifNoneMatch := c.GetHeader("If-None-Match")
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			c.Status(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return true
+		}
+	}
+	return false
+}
+
+// CheckNotModifiedSince sets the Last-Modified header and, when the request carries
+// If-Modified-Since at or after modifiedAt (and no If-None-Match, which takes precedence),
+// sends a 304 Not Modified with no body and returns true.
+func CheckNotModifiedSince(c *gin.Context, modifiedAt time.Time) bool {
+	c.Header("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+
+	if c.GetHeader("If-None-Match") != "" {
+		return false
+	}
+	ifModifiedSince := c.GetHeader("If-Modified-Since")
+	if ifModifiedSince == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// HTTP dates have one-second precision
+	if !modifiedAt.Truncate(time.Second).After(since) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return true
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldSpec describes the fields a list endpoint can return for the `fields` query parameter.
+type FieldSpec struct {
+	// Required are the JSON fields always returned, such as the IDs
+	Required []string
+	// Columns are always selected: the columns of the required fields and any the handler needs itself
+	Columns []string
+	// Optional maps each field a client can ask for to the columns it is read from. Relations that are
+	// preloaded, rather than read from the row, map to no columns.
+	Optional map[string][]string
+}
+
+// FieldSet is the set of fields a list request asked for.
+type FieldSet struct {
+	spec     FieldSpec
+	selected map[string]bool // nil when the client asked for every field
+}
+
+// ParseFields reads the comma-separated `fields` query parameter against spec. Without it every field
+// is returned. On an unknown field it sends a 400 response listing the valid ones and returns ok=false.
+func ParseFields(c *gin.Context, spec FieldSpec) (FieldSet, bool) {
+	fields := FieldSet{spec: spec}
+	value := c.Query("fields")
+	if value == "" {
+		return fields, true
+	}
+
+	fields.selected = map[string]bool{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if _, ok := spec.Optional[field]; !ok && !contains(spec.Required, field) {
+			BadRequest(c, "common.invalid_fields", Params{"field": field, "options": strings.Join(spec.options(), ", ")})
+			return FieldSet{}, false
+		}
+		fields.selected[field] = true
+	}
+	return fields, true
+}
+
+// options returns every field of the spec, sorted.
+func (s FieldSpec) options() []string {
+	options := append([]string{}, s.Required...)
+	for field := range s.Optional {
+		options = append(options, field)
+	}
+	sort.Strings(options)
+	return options
+}
+
+// All reports whether every field is returned.
+func (f FieldSet) All() bool {
+	return f.selected == nil
+}
+
+// Has reports whether field is returned. Handlers use it to skip preloading relations nobody asked for.
+func (f FieldSet) Has(field string) bool {
+	return f.selected == nil || f.selected[field] || contains(f.spec.Required, field)
+}
+
+// Columns returns the columns to select for the returned fields, without duplicates.
+func (f FieldSet) Columns() []string {
+	columns := append([]string{}, f.spec.Columns...)
+	seen := map[string]bool{}
+	for _, column := range columns {
+		seen[column] = true
+	}
+	for _, field := range f.spec.optionalFields() {
+		if !f.Has(field) {
+			continue
+		}
+		for _, column := range f.spec.Optional[field] {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
+
+// optionalFields returns the optional fields, sorted so the selected columns come out in a stable order.
+func (s FieldSpec) optionalFields() []string {
+	fields := make([]string, 0, len(s.Optional))
+	for field := range s.Optional {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Apply removes the fields the client did not ask for from items, a slice of response structs. Items are
+// returned unchanged when every field was asked for.
+func (f FieldSet) Apply(items interface{}) (interface{}, error) {
+	if f.All() {
+		return items, nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		for field := range object {
+			if !f.Has(field) {
+				delete(object, field)
+			}
+		}
+	}
+	return objects, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// icd10Pattern matches an ICD-10 code (WHO or ICD-10-CM): a letter, two digits or a digit and a letter,
+// then optionally a dot and up to four more characters, e.g. J45, E11.9 or S72.001A.
+var icd10Pattern = regexp.MustCompile(`^[A-Z][0-9][0-9A-Z](\.[0-9A-Z]{1,4})?$`)
+
+// NormalizeICD10 returns code as it is stored, upper case with the dot after the category ("e119" and
+// "E11.9" both become "E11.9"), and reports whether it is a well-formed ICD-10 code. Whether the code
+// exists in the classification is not checked.
+func NormalizeICD10(code string) (string, bool) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if len(normalized) > 3 && !strings.Contains(normalized, ".") {
+		normalized = normalized[:3] + "." + normalized[3:]
+	}
+	return normalized, icd10Pattern.MatchString(normalized)
+}
+
+// The icd10 binding tag accepts a code NormalizeICD10 accepts.
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	if err := v.RegisterValidation("icd10", func(fl validator.FieldLevel) bool {
+		_, ok := NormalizeICD10(fl.Field().String())
+		return ok
+	}); err != nil {
+		panic(err)
+	}
+}
@@ -1,18 +1,33 @@
 package utils
 
 import (
+	"crypto/rsa"
+	"errors"
 	"fmt"
 	"healthcare-app-server/internal/config"
 	"healthcare-app-server/internal/models"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Errors returned by ValidateToken and ValidateAccessToken, wrapping the jwt error that caused them.
+// Only ErrTokenExpired means the token was genuine, so only then is refreshing worth a try.
+var (
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenMalformed   = errors.New("token is malformed")
+	ErrSignatureInvalid = errors.New("token signature is invalid")
+	ErrTokenInvalid     = errors.New("token is invalid") // Any other rejected claim, such as a future nbf
 )
 
 // Claims represents the JWT claims.
 type Claims struct {
-	UserID string      `json:"user_id"`
-	Role   models.Role `json:"role"`
+	UserID         string      `json:"user_id"`
+	Role           models.Role `json:"role"`
+	OrganizationID string      `json:"org_id,omitempty"` // The user's organization, which MULTI_TENANT scopes requests to
 	jwt.RegisteredClaims
 }
 
@@ -36,8 +51,9 @@ func GenerateTokens(user *models.User, cfg *config.Config) (accessToken string,
 func generateAccessToken(user *models.User, cfg *config.Config) (string, error) {
 	expirationTime := time.Now().Add(time.Duration(cfg.JWTExpirationMinutes) * time.Minute)
 	claims := &Claims{
-		UserID: user.ID, // Removed .String() as ID is already a string
-		Role:   user.Role,
+		UserID:         user.ID, // Removed .String() as ID is already a string
+		Role:           user.Role,
+		OrganizationID: organizationIDOf(user),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -45,20 +61,34 @@ func generateAccessToken(user *models.User, cfg *config.Config) (string, error)
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.JWTSecret))
+	method, key, err := accessTokenSigningKey(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign access token: %w", err)
 	}
 	return tokenString, nil
 }
 
+// organizationIDOf returns the user's organization ID, or "" when they have none.
+func organizationIDOf(user *models.User) string {
+	if user.OrganizationID == nil {
+		return ""
+	}
+	return *user.OrganizationID
+}
+
 func generateRefreshToken(user *models.User, cfg *config.Config) (string, error) {
 	expirationTime := time.Now().Add(time.Duration(cfg.JWTRefreshExpirationHours) * time.Hour)
 	claims := &Claims{
 		UserID: user.ID,   // Removed .String() as ID is already a string
 		Role:   user.Role, // Include role for potential future use, though typically refresh tokens are simpler
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(), // Unique per token so rotations within the same second never collide
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   user.ID, // Removed .String() as ID is already a string
@@ -73,23 +103,131 @@ func generateRefreshToken(user *models.User, cfg *config.Config) (string, error)
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token.
+// ValidateToken validates an HS256-signed JWT token using the given shared secret.
+// It is used for refresh tokens, which are only ever verified by this service.
 func ValidateToken(tokenString string, secretKey string) (*Claims, error) {
+	return parseToken(tokenString, jwt.SigningMethodHS256.Alg(), []byte(secretKey))
+}
+
+// ValidateAccessToken validates an access token using the algorithm and key configured in cfg.
+// Tokens signed with any other algorithm are rejected.
+func ValidateAccessToken(tokenString string, cfg *config.Config) (*Claims, error) {
+	if cfg.JWTAlgorithm == jwt.SigningMethodRS256.Alg() {
+		publicKey, err := loadRSAPublicKey(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return parseToken(tokenString, jwt.SigningMethodRS256.Alg(), publicKey)
+	}
+	return parseToken(tokenString, jwt.SigningMethodHS256.Alg(), []byte(cfg.JWTSecret))
+}
+
+// LoadJWTKeys loads and caches the RSA key pair when RS256 is configured,
+// so that misconfigured key files are reported at startup rather than on the first request.
+func LoadJWTKeys(cfg *config.Config) error {
+	if cfg.JWTAlgorithm != jwt.SigningMethodRS256.Alg() {
+		return nil
+	}
+	if _, err := loadRSAPrivateKey(cfg.JWTPrivateKeyFile); err != nil {
+		return err
+	}
+	_, err := loadRSAPublicKey(cfg.JWTPublicKeyFile)
+	return err
+}
+
+func parseToken(tokenString string, alg string, key interface{}) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if token.Method.Alg() != alg {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secretKey), nil
-	})
+		return key, nil
+	}, jwt.WithValidMethods([]string{alg}))
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
+		return nil, fmt.Errorf("%w: %w", tokenError(err), err)
 	}
 
 	if !token.Valid {
-		return nil, fmt.Errorf("invalid token")
+		return nil, ErrTokenInvalid
 	}
 
+	// Tokens issued before roles were normalized may carry them in uppercase
+	claims.Role = claims.Role.Normalize()
 	return claims, nil
 }
+
+// tokenError maps a jwt parse error to the error ValidateToken reports for it. jwt checks the signature
+// before the expiry, so a forged token never comes back as expired.
+func tokenError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ErrTokenMalformed
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return ErrSignatureInvalid
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	default:
+		return ErrTokenInvalid
+	}
+}
+
+// accessTokenSigningKey returns the signing method and key for access tokens based on the configured algorithm.
+func accessTokenSigningKey(cfg *config.Config) (jwt.SigningMethod, interface{}, error) {
+	if cfg.JWTAlgorithm == jwt.SigningMethodRS256.Alg() {
+		privateKey, err := loadRSAPrivateKey(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		return jwt.SigningMethodRS256, privateKey, nil
+	}
+	return jwt.SigningMethodHS256, []byte(cfg.JWTSecret), nil
+}
+
+// rsaKeyCache holds parsed RSA keys keyed by file path so PEM files are read and parsed only once.
+var rsaKeyCache = struct {
+	sync.Mutex
+	privateKeys map[string]*rsa.PrivateKey
+	publicKeys  map[string]*rsa.PublicKey
+}{
+	privateKeys: make(map[string]*rsa.PrivateKey),
+	publicKeys:  make(map[string]*rsa.PublicKey),
+}
+
+func loadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	rsaKeyCache.Lock()
+	defer rsaKeyCache.Unlock()
+
+	if key, ok := rsaKeyCache.privateKeys[path]; ok {
+		return key, nil
+	}
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT private key file: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT private key: %w", err)
+	}
+	rsaKeyCache.privateKeys[path] = key
+	return key, nil
+}
+
+func loadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	rsaKeyCache.Lock()
+	defer rsaKeyCache.Unlock()
+
+	if key, ok := rsaKeyCache.publicKeys[path]; ok {
+		return key, nil
+	}
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT public key file: %w", err)
+	}
+	key, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT public key: %w", err)
+	}
+	rsaKeyCache.publicKeys[path] = key
+	return key, nil
+}
@@ -0,0 +1,183 @@
+package utils_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/models"
+	"healthcare-app-server/internal/testutil"
+	"healthcare-app-server/internal/utils"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newRS256Config returns a test configuration signing access tokens with a fresh RSA key pair.
+func newRS256Config(t *testing.T) *config.Config {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("encoding RSA public key: %v", err)
+	}
+
+	dir := t.TempDir()
+	cfg := testutil.NewTestConfig()
+	cfg.JWTAlgorithm = "RS256"
+	cfg.JWTPrivateKeyFile = filepath.Join(dir, "private.pem")
+	cfg.JWTPublicKeyFile = filepath.Join(dir, "public.pem")
+	writePEM(t, cfg.JWTPrivateKeyFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	writePEM(t, cfg.JWTPublicKeyFile, "PUBLIC KEY", publicDER)
+	if err := utils.LoadJWTKeys(cfg); err != nil {
+		t.Fatalf("loading RSA keys: %v", err)
+	}
+	return cfg
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+}
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Config
+		alg  string
+	}{
+		{"HS256", testutil.NewTestConfig(), "HS256"},
+		{"RS256", newRS256Config(t), "RS256"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testutil.NewTestUser(models.RoleDoctor)
+			token := testutil.MintAccessToken(t, tt.cfg, user)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &utils.Claims{})
+			if err != nil {
+				t.Fatalf("parsing token: %v", err)
+			}
+			if parsed.Method.Alg() != tt.alg {
+				t.Errorf("alg = %s, want %s", parsed.Method.Alg(), tt.alg)
+			}
+
+			claims, err := utils.ValidateAccessToken(token, tt.cfg)
+			if err != nil {
+				t.Fatalf("ValidateAccessToken: %v", err)
+			}
+			if claims.UserID != user.ID || claims.Role != models.RoleDoctor {
+				t.Errorf("claims = %s/%s, want %s/%s", claims.UserID, claims.Role, user.ID, models.RoleDoctor)
+			}
+		})
+	}
+}
+
+func TestValidateAccessTokenRejectsAlgorithmMismatch(t *testing.T) {
+	hsConfig := testutil.NewTestConfig()
+	rsConfig := newRS256Config(t)
+	user := testutil.NewTestUser(models.RolePatient)
+
+	// The classic confusion attack: an HS256 token keyed with the RSA public key, which is no secret
+	publicPEM, err := os.ReadFile(rsConfig.JWTPublicKeyFile)
+	if err != nil {
+		t.Fatalf("reading public key: %v", err)
+	}
+	confused := *hsConfig
+	confused.JWTSecret = string(publicPEM)
+
+	tests := []struct {
+		name     string
+		token    string
+		validate *config.Config
+	}{
+		{"HS256 token under RS256", testutil.MintAccessToken(t, hsConfig, user), rsConfig},
+		{"HS256 token keyed with the RSA public key", testutil.MintAccessToken(t, &confused, user), rsConfig},
+		{"RS256 token under HS256", testutil.MintAccessToken(t, rsConfig, user), hsConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := utils.ValidateAccessToken(tt.token, tt.validate)
+			if err == nil {
+				t.Fatalf("token accepted with claims %+v", claims)
+			}
+			if !errors.Is(err, utils.ErrSignatureInvalid) {
+				t.Errorf("err = %v, want ErrSignatureInvalid", err)
+			}
+		})
+	}
+}
+
+// signClaims signs claims with HS256 and secret.
+func signClaims(t *testing.T, claims jwt.Claims, secret string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return token
+}
+
+func TestValidateTokenFailureModes(t *testing.T) {
+	const secret = "test_jwt_secret"
+	now := time.Now()
+	claimsAt := func(issued, expires, notBefore time.Time) *utils.Claims {
+		return &utils.Claims{UserID: "user-1", Role: models.RolePatient, RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: jwt.NewNumericDate(issued), ExpiresAt: jwt.NewNumericDate(expires), NotBefore: jwt.NewNumericDate(notBefore),
+		}}
+	}
+	valid := claimsAt(now, now.Add(time.Hour), now.Add(-time.Minute))
+	expired := claimsAt(now.Add(-2*time.Hour), now.Add(-time.Hour), now.Add(-2*time.Hour))
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, valid).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("building unsigned token: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", signClaims(t, expired, secret), utils.ErrTokenExpired},
+		{"garbage", testutil.GarbageToken, utils.ErrTokenMalformed},
+		{"empty", "", utils.ErrTokenMalformed},
+		{"truncated", signClaims(t, valid, secret)[:20], utils.ErrTokenMalformed},
+		{"wrong secret", signClaims(t, valid, "another_secret"), utils.ErrSignatureInvalid},
+		{"expired and forged", signClaims(t, expired, "another_secret"), utils.ErrSignatureInvalid},
+		{"unsigned", unsigned, utils.ErrSignatureInvalid},
+		{"not yet valid", signClaims(t, claimsAt(now, now.Add(2*time.Hour), now.Add(time.Hour)), secret), utils.ErrTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := utils.ValidateToken(tt.token, secret)
+			if err == nil {
+				t.Fatalf("token accepted with claims %+v", claims)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			// Each failure maps to exactly one typed error, so callers can switch on them
+			for _, other := range []error{utils.ErrTokenExpired, utils.ErrTokenMalformed, utils.ErrSignatureInvalid, utils.ErrTokenInvalid} {
+				if other != tt.want && errors.Is(err, other) {
+					t.Errorf("err = %v also matches %v", err, other)
+				}
+			}
+		})
+	}
+
+	if _, err := utils.ValidateToken(signClaims(t, valid, secret), secret); err != nil {
+		t.Errorf("valid token rejected: %v", err)
+	}
+}
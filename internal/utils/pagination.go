@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultPageSize is the number of items returned when the client does not send a limit.
+const DefaultPageSize = 20
+
+// maxPageSize caps the limit a client can request. It is configured at startup via SetMaxPageSize.
+var maxPageSize = 100
+
+// SetMaxPageSize sets the largest page any list endpoint will return.
+func SetMaxPageSize(size int) {
+	if size > 0 {
+		maxPageSize = size
+	}
+}
+
+// Pagination is the validated page selection of a list request.
+type Pagination struct {
+	Page   int
+	Limit  int // Applied limit, after clamping to the maximum page size
+	Offset int
+}
+
+// Meta builds the response metadata for the page, reporting the applied limit so clients can see clamping.
+func (p Pagination) Meta(total int64) PaginationMeta {
+	return PaginationMeta{Page: p.Page, Limit: p.Limit, Total: total}
+}
+
+// ParsePagination reads the `page` and `limit` query parameters, applying defaults and clamping the
+// limit to the configured maximum page size. On invalid input it sends a 400 response and returns ok=false.
+func ParsePagination(c *gin.Context) (Pagination, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		BadRequest(c, "common.invalid_page")
+		return Pagination{}, false
+	}
+
+	limit := min(DefaultPageSize, maxPageSize)
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 {
+			BadRequest(c, "common.invalid_limit")
+			return Pagination{}, false
+		}
+		limit = min(limit, maxPageSize)
+	}
+
+	return Pagination{Page: page, Limit: limit, Offset: (page - 1) * limit}, true
+}
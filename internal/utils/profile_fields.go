@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// e164Pattern matches E.164 phone numbers: a plus sign and up to 15 digits, without spaces or separators.
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// phoneSeparators are the characters people write phone numbers with that E.164 leaves out.
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "/", "", "(", "", ")", "")
+
+// minimumAge is the age in years a date of birth must give. It is configured at startup via SetMinimumAge.
+var minimumAge = 0
+
+// SetMinimumAge sets the age in years the birthdate binding tag requires.
+func SetMinimumAge(years int) {
+	if years >= 0 {
+		minimumAge = years
+	}
+}
+
+// IsPhoneNumber reports whether value is an E.164 phone number such as +48123456789.
+func IsPhoneNumber(value string) bool {
+	return e164Pattern.MatchString(value)
+}
+
+// NormalizePhoneNumber returns value in E.164 form, accepting the international numbers people
+// usually write: spaces, dashes, dots, slashes and parentheses are dropped, and a leading 00 stands
+// for the plus sign, so "(+48) 123-456-789" and "0048 123 456 789" both become "+48123456789".
+// It reports false for anything else, such as numbers without a country code. "" stays "".
+func NormalizePhoneNumber(value string) (string, bool) {
+	normalized := phoneSeparators.Replace(strings.TrimSpace(value))
+	if normalized == "" {
+		return "", true
+	}
+	if strings.HasPrefix(normalized, "00") {
+		normalized = "+" + normalized[2:]
+	}
+	return normalized, IsPhoneNumber(normalized)
+}
+
+// ParseBirthdate parses a YYYY-MM-DD date of birth and reports whether it lies in the past and gives
+// at least the minimum age.
+func ParseBirthdate(value string) (time.Time, bool) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	return date, date.Before(today) && !date.AddDate(minimumAge, 0, 0).After(today)
+}
+
+// isHTTPURL reports whether value is an absolute http or https URL.
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// profileTags are the binding tags of optional profile fields. Each also accepts "" so the field can be cleared.
+//   - phone: a phone number NormalizePhoneNumber accepts
+//   - birthdate: a date ParseBirthdate accepts
+//   - http_url_or_empty: an absolute http or https URL
+var profileTags = map[string]func(value string) bool{
+	"phone": func(value string) bool {
+		_, ok := NormalizePhoneNumber(value)
+		return ok
+	},
+	"birthdate": func(value string) bool {
+		_, ok := ParseBirthdate(value)
+		return value == "" || ok
+	},
+	"http_url_or_empty": func(value string) bool {
+		return value == "" || isHTTPURL(value)
+	},
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	for name, valid := range profileTags {
+		valid := valid
+		if err := v.RegisterValidation(name, func(fl validator.FieldLevel) bool {
+			return valid(fl.Field().String())
+		}); err != nil {
+			panic(err)
+		}
+	}
+}
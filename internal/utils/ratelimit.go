@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a simple in-memory fixed-window rate limiter keyed by an arbitrary string.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+}
+
+type rateLimitEntry struct {
+	count       int
+	windowStart time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing `limit` events per `window` for each key.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		entries: make(map[string]*rateLimitEntry),
+	}
+}
+
+// Allow reports whether another event for the key fits in the current window, and counts it if so.
+func (rl *RateLimiter) Allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	rl.evictExpired(now)
+
+	entry, exists := rl.entries[key]
+	if !exists || now.Sub(entry.windowStart) >= rl.window {
+		rl.entries[key] = &rateLimitEntry{count: 1, windowStart: now}
+		return true
+	}
+	if entry.count >= rl.limit {
+		return false
+	}
+	entry.count++
+	return true
+}
+
+// evictExpired drops keys whose window has passed so the map does not grow without bound.
+// The sweep runs at most once per window. Must be called with the mutex held.
+func (rl *RateLimiter) evictExpired(now time.Time) {
+	if now.Sub(rl.lastSweep) < rl.window {
+		return
+	}
+	rl.lastSweep = now
+	for key, entry := range rl.entries {
+		if now.Sub(entry.windowStart) >= rl.window {
+			delete(rl.entries, key)
+		}
+	}
+}
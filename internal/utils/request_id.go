@@ -0,0 +1,14 @@
+package utils
+
+import "github.com/gin-gonic/gin"
+
+// RequestIDContextKey is the gin context key under which the request ID is stored.
+const RequestIDContextKey = "requestID"
+
+// RequestIDHeader is the header carrying the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID returns the ID assigned to the request by the RequestID middleware, or "" if there is none.
+func RequestID(c *gin.Context) string {
+	return c.GetString(RequestIDContextKey)
+}
@@ -1,17 +1,51 @@
 package utils
 
 import (
+	"errors"
+	"healthcare-app-server/internal/i18n"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Params holds the values substituted into a localized message, e.g. utils.Params{"role": role}.
+type Params = i18n.Params
+
+// debugErrors controls whether raw technical error details are included in responses.
+// It is enabled with ERROR_DETAIL=full via SetDebugErrors.
+var debugErrors = false
+
+// SetDebugErrors enables or disables the debug field carrying raw error details in error responses, as set
+// by ERROR_DETAIL.
+func SetDebugErrors(enabled bool) {
+	debugErrors = enabled
+}
+
 // ResponseData represents the structure of a standard API response.
 type ResponseData struct {
-	Status  int         `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Status    int         `json:"status"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	Meta      interface{} `json:"meta,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Code      string      `json:"code,omitempty"`  // Machine-readable reason, for errors clients act on
+	Debug     string      `json:"debug,omitempty"` // Raw technical details, only outside production
+	RequestID string      `json:"requestId,omitempty"`
+}
+
+// Machine-readable error codes sent in the code field.
+const (
+	CodeTokenExpired        = "token_expired"         // The access token expired; refresh it and retry
+	CodeInvalidToken        = "invalid_token"         // The token is malformed, forged or otherwise unusable
+	CodeRefreshTokenExpired = "refresh_token_expired" // The refresh token expired; the user must log in again
+)
+
+// PaginationMeta describes the page of results returned in a list response.
+type PaginationMeta struct {
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+	Total int64 `json:"total"`
 }
 
 // Success sends a standard success response.
@@ -23,6 +57,16 @@ func Success(c *gin.Context, message string, data interface{}) {
 	})
 }
 
+// SuccessWithMeta sends a standard success response with additional metadata (e.g. pagination).
+func SuccessWithMeta(c *gin.Context, message string, data interface{}, meta interface{}) {
+	c.JSON(http.StatusOK, ResponseData{
+		Status:  http.StatusOK,
+		Message: message,
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
 // Created sends a standard resource created response.
 func Created(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusCreated, ResponseData{
@@ -32,36 +76,145 @@ func Created(c *gin.Context, message string, data interface{}) {
 	})
 }
 
-// Error sends a standard error response.
-func Error(c *gin.Context, statusCode int, errorMessage string) {
+// Accepted sends a response for work that has been queued and finishes after the request.
+func Accepted(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusAccepted, ResponseData{
+		Status:  http.StatusAccepted,
+		Message: message,
+		Data:    data,
+	})
+}
+
+// Locale returns the locale selected for the request by the Locale middleware.
+func Locale(c *gin.Context) string {
+	if locale := c.GetString(i18n.LocaleContextKey); locale != "" {
+		return locale
+	}
+	return i18n.DefaultLocale
+}
+
+// T translates a message key into the request's locale. Plain messages that are not catalog keys are returned as is.
+func T(c *gin.Context, key string, params ...Params) string {
+	var merged Params
+	if len(params) > 0 {
+		merged = params[0]
+	}
+	return i18n.Translate(Locale(c), key, merged)
+}
+
+// Error sends a standard error response. errorMessage is a message key (or plain text), localized for the request.
+// Server errors (5xx) are logged with the request ID, so the ID a client reports leads to the log line.
+func Error(c *gin.Context, statusCode int, errorMessage string, params ...Params) {
+	if statusCode >= http.StatusInternalServerError {
+		logRequestError(c, statusCode, errors.New(errorMessage))
+	}
+	c.JSON(statusCode, ResponseData{
+		Status:    statusCode,
+		Message:   T(c, "common.error_occurred"),
+		Error:     T(c, errorMessage, params...),
+		RequestID: RequestID(c),
+	})
+}
+
+// ErrorWithData sends a localized error response that also carries data, e.g. the per-item outcomes of a rejected batch.
+func ErrorWithData(c *gin.Context, statusCode int, errorMessage string, data interface{}) {
 	c.JSON(statusCode, ResponseData{
-		Status:  statusCode,
-		Message: "An error occurred",
-		Error:   errorMessage,
+		Status:    statusCode,
+		Message:   T(c, "common.error_occurred"),
+		Data:      data,
+		Error:     T(c, errorMessage),
+		RequestID: RequestID(c),
 	})
 }
 
+// ErrorWithDetail sends a localized error response and attaches err as a debug detail outside production.
+// Server errors (5xx) are also logged with the request ID.
+func ErrorWithDetail(c *gin.Context, statusCode int, errorMessage string, err error) {
+	if statusCode >= http.StatusInternalServerError {
+		logRequestError(c, statusCode, err)
+	}
+	writeErrorWithDetail(c, statusCode, errorMessage, err)
+}
+
+// ErrorWithCode sends a localized error response carrying a machine-readable code, and attaches err as
+// a debug detail outside production.
+func ErrorWithCode(c *gin.Context, statusCode int, code, errorMessage string, err error) {
+	if statusCode >= http.StatusInternalServerError {
+		logRequestError(c, statusCode, err)
+	}
+	writeErrorWithCode(c, statusCode, code, errorMessage, err)
+}
+
+func writeErrorWithDetail(c *gin.Context, statusCode int, errorMessage string, err error) {
+	writeErrorWithCode(c, statusCode, "", errorMessage, err)
+}
+
+func writeErrorWithCode(c *gin.Context, statusCode int, code, errorMessage string, err error) {
+	response := ResponseData{
+		Status:    statusCode,
+		Message:   T(c, "common.error_occurred"),
+		Error:     T(c, errorMessage),
+		Code:      code,
+		RequestID: RequestID(c),
+	}
+	if debugErrors && err != nil {
+		response.Debug = err.Error()
+	}
+	c.JSON(statusCode, response)
+}
+
+// logRequestError logs the full error server-side so clients never need to see it.
+func logRequestError(c *gin.Context, statusCode int, err error) {
+	log.Printf("request %s: %s %s -> %d: %v", RequestID(c), c.Request.Method, c.Request.URL.Path, statusCode, err)
+}
+
 // BadRequest sends a 400 Bad Request error response.
-func BadRequest(c *gin.Context, errorMessage string) {
-	Error(c, http.StatusBadRequest, errorMessage)
+func BadRequest(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusBadRequest, errorMessage, params...)
 }
 
 // Unauthorized sends a 401 Unauthorized error response.
-func Unauthorized(c *gin.Context, errorMessage string) {
-	Error(c, http.StatusUnauthorized, errorMessage)
+func Unauthorized(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusUnauthorized, errorMessage, params...)
 }
 
 // Forbidden sends a 403 Forbidden error response.
-func Forbidden(c *gin.Context, errorMessage string) {
-	Error(c, http.StatusForbidden, errorMessage)
+func Forbidden(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusForbidden, errorMessage, params...)
 }
 
 // NotFound sends a 404 Not Found error response.
-func NotFound(c *gin.Context, errorMessage string) {
-	Error(c, http.StatusNotFound, errorMessage)
+func NotFound(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusNotFound, errorMessage, params...)
+}
+
+// Conflict sends a 409 Conflict error response.
+func Conflict(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusConflict, errorMessage, params...)
+}
+
+// PayloadTooLarge sends a 413 Request Entity Too Large error response.
+func PayloadTooLarge(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusRequestEntityTooLarge, errorMessage, params...)
+}
+
+// UnsupportedMediaType sends a 415 Unsupported Media Type error response.
+func UnsupportedMediaType(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusUnsupportedMediaType, errorMessage, params...)
+}
+
+// TooManyRequests sends a 429 Too Many Requests error response.
+func TooManyRequests(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusTooManyRequests, errorMessage, params...)
 }
 
 // InternalServerError sends a 500 Internal Server Error response.
-func InternalServerError(c *gin.Context, errorMessage string) {
-	Error(c, http.StatusInternalServerError, errorMessage)
+func InternalServerError(c *gin.Context, errorMessage string, params ...Params) {
+	Error(c, http.StatusInternalServerError, errorMessage, params...)
+}
+
+// InternalServerErrorWithDetail sends a 500 response and logs its raw cause, which is only returned to the
+// client with ERROR_DETAIL=full.
+func InternalServerErrorWithDetail(c *gin.Context, errorMessage string, err error) {
+	ErrorWithDetail(c, http.StatusInternalServerError, errorMessage, err)
 }
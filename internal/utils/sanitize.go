@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SanitizeText strips HTML markup from a plain-text field before it is stored. Tags, comments and
+// doctypes are removed, and so is the content of script and style elements; everything else is kept
+// exactly as written. Character references are not decoded, so "&lt;script&gt;" stays escaped
+// text. The result cannot introduce elements when a client renders it as HTML, while clients that
+// render it as text, as they should, show what the user typed minus the markup.
+//
+// Fields are sanitized on write rather than escaped on output: the API returns them as JSON
+// strings, so escaping would show up as literal entities in every client that handles them correctly.
+func SanitizeText(s string) string {
+	// Elements such as textarea and title hold their content as raw text, so markup inside
+	// them survives a pass. Every pass that changes s shortens it, so this terminates.
+	for strings.Contains(s, "<") {
+		stripped := stripMarkup(s)
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	return s
+}
+
+// stripMarkup makes one pass of SanitizeText over s.
+func stripMarkup(s string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	skipping := ""
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() != io.EOF {
+				return "" // Not reachable with a strings.Reader; fail closed all the same
+			}
+			return b.String()
+		case html.TextToken:
+			if skipping == "" {
+				b.Write(z.Raw())
+			}
+		case html.StartTagToken:
+			// The tokenizer reads the content of these elements as one text token up to the closing tag
+			if name, _ := z.TagName(); string(name) == "script" || string(name) == "style" {
+				skipping = string(name)
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == skipping {
+				skipping = ""
+			}
+		}
+	}
+}
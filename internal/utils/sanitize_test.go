@@ -0,0 +1,34 @@
+package utils_test
+
+import (
+	"healthcare-app-server/internal/utils"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"plain text", "Take 2 tablets daily", "Take 2 tablets daily"},
+		{"comparison operators", "glucose < 7 and > 4", "glucose < 7 and > 4"},
+		{"script element", `Hello<script>alert("x")</script> there`, "Hello there"},
+		{"uppercase script", `<SCRIPT SRC="https://evil.test/x.js"></SCRIPT>ok`, "ok"},
+		{"style element", "<style>body{display:none}</style>visible", "visible"},
+		{"event handler attribute", `<img src=x onerror="alert(1)">caption`, "caption"},
+		{"javascript link", `<a href="javascript:alert(1)">click</a>`, "click"},
+		{"svg onload", `<svg onload=alert(1)>`, ""},
+		{"comment", "before<!-- <script>alert(1)</script> -->after", "beforeafter"},
+		{"script nested in raw text element", "<textarea><script>alert(1)</script></textarea>done", "done"},
+		{"split tag", "<scr<script>ipt>alert(1)</script>", "ipt>alert(1)"},
+		{"unterminated tag", `text <script src="x"`, "text "},
+		{"escaped markup stays escaped", "&lt;script&gt;alert(1)&lt;/script&gt;", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"unicode", "Zażółć gęślą jaźń", "Zażółć gęślą jaźń"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.SanitizeText(tt.input); got != tt.want {
+				t.Errorf("SanitizeText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
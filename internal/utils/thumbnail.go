@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // Registers the GIF decoder with image.Decode
+	"image/jpeg"
+	_ "image/png" // Registers the PNG decoder with image.Decode
+)
+
+// maxThumbnailSourcePixels bounds the images a thumbnail is made from, so a small file declaring
+// huge dimensions cannot make the decoder allocate gigabytes.
+const maxThumbnailSourcePixels = 50_000_000
+
+// thumbnailQuality is the JPEG quality thumbnails are encoded with.
+const thumbnailQuality = 80
+
+// ErrImageTooLarge is returned by MakeThumbnail for images with more than maxThumbnailSourcePixels pixels.
+var ErrImageTooLarge = errors.New("image too large for a thumbnail")
+
+// IsThumbnailableType reports whether MakeThumbnail can decode files of this media type.
+func IsThumbnailableType(contentType string) bool {
+	switch MediaType(contentType) {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// MakeThumbnail decodes a JPEG, PNG or GIF image and returns it as a JPEG whose longer side is at most
+// maxSize pixels, keeping the aspect ratio. Smaller images are re-encoded at their own size. Transparent
+// areas become white. Each thumbnail pixel is the average of the source pixels it covers.
+func MakeThumbnail(data []byte, maxSize int) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxThumbnailSourcePixels {
+		return nil, ErrImageTooLarge
+	}
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			width, height = maxSize, max(1, height*maxSize/bounds.Dx())
+		} else {
+			width, height = max(1, width*maxSize/bounds.Dy()), maxSize
+		}
+	}
+
+	// Flatten onto white first, so transparent pixels do not average to black
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), source, bounds.Min, draw.Over)
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*flat.Rect.Dy()/height, max((y+1)*flat.Rect.Dy()/height, y*flat.Rect.Dy()/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*flat.Rect.Dx()/width, max((x+1)*flat.Rect.Dx()/width, x*flat.Rect.Dx()/width+1)
+			var r, g, b, n int
+			for sy := y0; sy < y1; sy++ {
+				row := flat.Pix[sy*flat.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += int(row[sx*4])
+					g += int(row[sx*4+1])
+					b += int(row[sx*4+2])
+					n++
+				}
+			}
+			offset := y*thumbnail.Stride + x*4
+			thumbnail.Pix[offset] = uint8(r / n)
+			thumbnail.Pix[offset+1] = uint8(g / n)
+			thumbnail.Pix[offset+2] = uint8(b / n)
+			thumbnail.Pix[offset+3] = 0xff
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, thumbnail, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"errors"
+	"healthcare-app-server/internal/i18n"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -13,28 +17,67 @@ func Validate(s interface{}) error {
 	return validate.Struct(s)
 }
 
-// FormatValidationError formats validation errors into a readable string.
-func FormatValidationError(err error) string {
-	if errs, ok := err.(validator.ValidationErrors); ok {
-		var errorMessages []string
-		for _, e := range errs {
-			errorMessages = append(errorMessages, e.Translate(nil)) // Requires a translator for user-friendly messages
+// FormatValidationError formats validation errors into a readable string in the request's locale.
+// Each failed rule maps to the "validation.<tag>" message key, falling back to "validation.invalid".
+func FormatValidationError(c *gin.Context, err error) string {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err.Error()
+	}
+
+	var errorMessages []string
+	for _, e := range errs {
+		key := "validation." + e.Tag()
+		if !i18n.HasKey(key) {
+			key = "validation.invalid"
+		}
+		param := e.Param()
+		if allowed := enumAllowedValues(e.Tag()); allowed != "" {
+			param = allowed
+		} else if e.Tag() == "birthdate" {
+			param = strconv.Itoa(minimumAge)
 		}
-		return strings.Join(errorMessages, ", ")
+		errorMessages = append(errorMessages, T(c, key, Params{"field": e.Field(), "param": param}))
 	}
-	return err.Error()
+	return strings.Join(errorMessages, ", ")
+}
+
+// validationFailed sends a 400 response listing the failed validation rules.
+func validationFailed(c *gin.Context, err error) {
+	BadRequest(c, "common.validation_failed", Params{"details": FormatValidationError(c, err)})
 }
 
 // BindAndValidate binds the request body to a struct and validates it.
 // If validation fails, it sends a BadRequest response and returns false.
 func BindAndValidate(c *gin.Context, obj interface{}) bool {
 	if err := c.ShouldBindJSON(obj); err != nil {
-		BadRequest(c, "Invalid request payload: "+err.Error())
+		BindError(c, err)
 		return false
 	}
 	if err := Validate(obj); err != nil {
-		BadRequest(c, "Validation failed: "+FormatValidationError(err))
+		validationFailed(c, err)
 		return false
 	}
 	return true
 }
+
+// IsRequestTooLarge reports whether err was caused by the request body exceeding its size limit.
+func IsRequestTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// BindError sends the appropriate error response for a failed request body bind:
+// 413 if the body exceeded its size limit, 400 with localized field errors if binding rules failed, 400 otherwise.
+func BindError(c *gin.Context, err error) {
+	if IsRequestTooLarge(err) {
+		PayloadTooLarge(c, "common.payload_too_large")
+		return
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		validationFailed(c, err)
+		return
+	}
+	ErrorWithDetail(c, http.StatusBadRequest, "common.invalid_payload", err)
+}
@@ -0,0 +1,323 @@
+// Package webhooks delivers appointment, medical record and message events to the external
+// endpoints admins subscribed, e.g. a clinic's practice-management software.
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/mailer"
+	"healthcare-app-server/internal/models"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Headers sent with every delivery.
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature" // "sha256=" and the hex HMAC-SHA256 of the body, keyed with the subscription secret
+)
+
+const (
+	pollInterval = 30 * time.Second // How often due retries are looked for when no new event wakes the worker
+	retryBase    = time.Minute      // Wait before the first retry; it doubles with every further attempt
+	retryMax     = 12 * time.Hour   // Longest wait between two attempts
+	batchSize    = 50               // Due deliveries claimed per round
+	// A claimed delivery is not picked up again until this has passed, so another server sharing the
+	// database skips it while it is in flight
+	claimLease = 5 * time.Minute
+)
+
+// Envelope is the JSON body of a delivery.
+type Envelope struct {
+	ID         string      `json:"id"` // The delivery ID, also sent in DeliveryHeader; stays the same across retries
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// Dispatcher queues events for the subscriptions that want them and delivers them from a background worker.
+// Deliveries are stored before they are attempted, so queued events survive a restart.
+type Dispatcher struct {
+	db           *gorm.DB
+	client       *http.Client
+	mailer       *mailer.Mailer
+	maxAttempts  int
+	disableAfter int
+	adminRoles   []models.Role // Who manages subscriptions and is told when one is disabled
+	wake         chan struct{}
+}
+
+// NewDispatcher creates a Dispatcher configured by cfg. Nothing is delivered until Start is called.
+func NewDispatcher(db *gorm.DB, cfg *config.Config) *Dispatcher {
+	return &Dispatcher{
+		db:           db,
+		client:       &http.Client{Timeout: time.Duration(cfg.WebhookTimeout) * time.Second},
+		mailer:       mailer.New(cfg.Mailer),
+		maxAttempts:  cfg.WebhookMaxAttempts,
+		disableAfter: cfg.WebhookDisableAfter,
+		adminRoles:   webhookAdminRoles(cfg),
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// webhookAdminRoles returns the roles that manage webhooks: with MULTI_TENANT on, the subscriptions see
+// every organization's events, so only super admins do.
+func webhookAdminRoles(cfg *config.Config) []models.Role {
+	if cfg.MultiTenant {
+		return []models.Role{models.RoleSuperAdmin}
+	}
+	return []models.Role{models.RoleAdmin, models.RoleSuperAdmin}
+}
+
+// Start runs the delivery worker in the background.
+func (d *Dispatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			d.deliverDue()
+			select {
+			case <-ticker.C:
+			case <-d.wake:
+			}
+		}
+	}()
+}
+
+// Publish queues event with data for every active subscription that subscribes to it and wakes the worker.
+// Failures are logged, not returned, so the change that raised the event never fails because of a webhook.
+// A nil Dispatcher publishes nothing.
+func (d *Dispatcher) Publish(db *gorm.DB, event string, data interface{}) {
+	if d == nil {
+		return
+	}
+
+	var subscriptions []models.WebhookSubscription
+	if err := db.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+		log.Printf("webhooks: failed to load subscriptions for %s: %v", event, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	queued := false
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(event) {
+			continue
+		}
+		delivery := models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			Event:          event,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		}
+		delivery.ID = uuid.New().String() // Set up front, as the payload carries it
+		payload, err := json.Marshal(Envelope{ID: delivery.ID, Event: event, OccurredAt: now, Data: data})
+		if err != nil {
+			log.Printf("webhooks: failed to encode %s: %v", event, err)
+			return
+		}
+		delivery.Payload = string(payload)
+		if err := db.Create(&delivery).Error; err != nil {
+			log.Printf("webhooks: failed to queue %s for subscription %s: %v", event, subscription.ID, err)
+			continue
+		}
+		queued = true
+	}
+
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default: // The worker is already due to run
+		}
+	}
+}
+
+// Sign returns the SignatureHeader value of body for secret. Receivers compute the same value
+// over the raw request body and compare it in constant time.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverDue attempts every delivery whose next attempt is due, oldest first.
+func (d *Dispatcher) deliverDue() {
+	for {
+		var due []models.WebhookDelivery
+		if err := d.db.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now().UTC()).
+			Order("next_attempt_at asc").Limit(batchSize).
+			Find(&due).Error; err != nil {
+			log.Printf("webhooks: failed to load due deliveries: %v", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+
+		for _, delivery := range due {
+			// Push the next attempt out before delivering; the update only succeeds for one server
+			claim := d.db.Model(&models.WebhookDelivery{}).
+				Where("id = ? AND next_attempt_at = ?", delivery.ID, delivery.NextAttemptAt).
+				UpdateColumn("next_attempt_at", time.Now().UTC().Add(claimLease))
+			if claim.Error != nil {
+				log.Printf("webhooks: failed to claim delivery %s: %v", delivery.ID, claim.Error)
+				return
+			}
+			if claim.RowsAffected == 0 {
+				continue
+			}
+			d.attempt(delivery)
+		}
+	}
+}
+
+// attempt sends delivery once and records the outcome.
+func (d *Dispatcher) attempt(delivery models.WebhookDelivery) {
+	var subscription models.WebhookSubscription
+	err := d.db.First(&subscription, "id = ?", delivery.SubscriptionID).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		log.Printf("webhooks: failed to load subscription %s: %v", delivery.SubscriptionID, err)
+		return // Retried once the claim lease has passed
+	}
+	if err == gorm.ErrRecordNotFound || !subscription.Active {
+		d.update(delivery.ID, map[string]interface{}{
+			"status":     models.WebhookDeliveryFailed,
+			"last_error": "subscription deleted or disabled",
+		})
+		return
+	}
+
+	statusCode, sendErr := d.send(subscription, delivery)
+	delivery.Attempts++
+	updates := map[string]interface{}{
+		"attempts":         delivery.Attempts,
+		"last_status_code": statusCode,
+		"last_error":       "",
+	}
+
+	if sendErr == nil {
+		now := time.Now().UTC()
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = &now
+		d.update(delivery.ID, updates)
+		if subscription.ConsecutiveFailures > 0 {
+			if err := d.db.Model(&subscription).UpdateColumn("consecutive_failures", 0).Error; err != nil {
+				log.Printf("webhooks: failed to reset failures of subscription %s: %v", subscription.ID, err)
+			}
+		}
+		return
+	}
+
+	updates["last_error"] = truncate(sendErr.Error(), 500)
+	if delivery.Attempts < d.maxAttempts {
+		updates["next_attempt_at"] = time.Now().UTC().Add(retryDelay(delivery.Attempts))
+		d.update(delivery.ID, updates)
+		return
+	}
+
+	updates["status"] = models.WebhookDeliveryFailed
+	d.update(delivery.ID, updates)
+	d.recordFailure(subscription)
+}
+
+// send posts the delivery to the subscription's URL and returns the response status, 0 when there was none.
+// Any status outside 2xx is an error.
+func (d *Dispatcher) send(subscription models.WebhookSubscription, delivery models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// recordFailure counts a delivery that failed all its attempts against subscription, and disables
+// the subscription and emails the admins once too many failed in a row.
+func (d *Dispatcher) recordFailure(subscription models.WebhookSubscription) {
+	failures := subscription.ConsecutiveFailures + 1
+	updates := map[string]interface{}{"consecutive_failures": failures}
+	disable := d.disableAfter > 0 && failures >= d.disableAfter
+	if disable {
+		now := time.Now().UTC()
+		updates["active"] = false
+		updates["disabled_at"] = &now
+	}
+	if err := d.db.Model(&subscription).UpdateColumns(updates).Error; err != nil {
+		log.Printf("webhooks: failed to record failure of subscription %s: %v", subscription.ID, err)
+		return
+	}
+	if disable {
+		log.Printf("webhooks: disabled subscription %s after %d failed deliveries", subscription.ID, failures)
+		d.notifyAdmins(subscription, failures)
+	}
+}
+
+// notifyAdmins emails every admin who manages webhooks that subscription was disabled. Failures are logged.
+func (d *Dispatcher) notifyAdmins(subscription models.WebhookSubscription, failures int) {
+	var admins []models.User
+	if err := d.db.Where("role IN ?", d.adminRoles).Find(&admins).Error; err != nil {
+		log.Printf("webhooks: failed to load admins: %v", err)
+		return
+	}
+	body := fmt.Sprintf("The webhook subscription %s for %s has been disabled after %d deliveries in a row failed.\n\n"+
+		"Check the endpoint, review GET /api/v1/admin/webhooks/%s/deliveries, and set it active again once it is fixed.\n",
+		subscription.ID, subscription.URL, failures, subscription.ID)
+	for _, admin := range admins {
+		if err := d.mailer.Send(admin.Email, "A webhook subscription has been disabled", body); err != nil {
+			log.Printf("webhooks: failed to notify admin %s: %v", admin.ID, err)
+		}
+	}
+}
+
+// retryDelay returns the wait after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	if delay > retryMax {
+		return retryMax
+	}
+	return delay
+}
+
+// update writes updates to the delivery.
+func (d *Dispatcher) update(deliveryID string, updates map[string]interface{}) {
+	if err := d.db.Model(&models.WebhookDelivery{}).Where("id = ?", deliveryID).Updates(updates).Error; err != nil {
+		log.Printf("webhooks: failed to update delivery %s: %v", deliveryID, err)
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
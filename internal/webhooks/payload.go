@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"healthcare-app-server/internal/models"
+	"time"
+)
+
+// Payload data carries IDs and structural fields only. Free text such as notes, message content and
+// record summaries stays in the API, where access is checked per user; attachments and credentials
+// are never sent.
+
+// AppointmentData is the data of the appointment.* events.
+type AppointmentData struct {
+	ID                string                   `json:"id"`
+	PatientID         string                   `json:"patientId"`
+	DoctorID          string                   `json:"doctorId"`
+	AppointmentTypeID *string                  `json:"appointmentTypeId,omitempty"`
+	StartTime         time.Time                `json:"startTime"`
+	EndTime           time.Time                `json:"endTime"`
+	Status            models.AppointmentStatus `json:"status"`
+	IsFollowUp        bool                     `json:"isFollowUp"`
+}
+
+// NewAppointmentData returns the event data of appointment.
+func NewAppointmentData(appointment models.Appointment) AppointmentData {
+	return AppointmentData{
+		ID:                appointment.ID,
+		PatientID:         appointment.PatientID,
+		DoctorID:          appointment.DoctorID,
+		AppointmentTypeID: appointment.AppointmentTypeID,
+		StartTime:         appointment.StartTime,
+		EndTime:           appointment.EndTime,
+		Status:            appointment.Status.Normalize(),
+		IsFollowUp:        appointment.IsFollowUp,
+	}
+}
+
+// MedicalRecordData is the data of the medicalrecord.created event.
+type MedicalRecordData struct {
+	ID         string                   `json:"id"`
+	PatientID  string                   `json:"patientId"`
+	DoctorID   string                   `json:"doctorId"`
+	RecordType models.MedicalRecordType `json:"recordType"`
+	RecordDate time.Time                `json:"recordDate"`
+}
+
+// NewMedicalRecordData returns the event data of record.
+func NewMedicalRecordData(record models.MedicalRecord) MedicalRecordData {
+	return MedicalRecordData{
+		ID:         record.ID,
+		PatientID:  record.PatientID,
+		DoctorID:   record.DoctorID,
+		RecordType: record.RecordType,
+		RecordDate: record.RecordDate,
+	}
+}
+
+// MessageData is the data of the message.sent event.
+type MessageData struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversationId"`
+	SenderID       string    `json:"senderId"`
+	ReceiverID     string    `json:"receiverId"`
+	ParentID       string    `json:"parentId,omitempty"`
+	SentAt         time.Time `json:"sentAt"`
+}
+
+// NewMessageData returns the event data of message.
+func NewMessageData(message models.Message) MessageData {
+	return MessageData{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		ReceiverID:     message.ReceiverID,
+		ParentID:       message.ParentID,
+		SentAt:         message.CreatedAt,
+	}
+}
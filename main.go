@@ -3,14 +3,30 @@ package main
 import (
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"healthcare-app-server/internal/config"
+	"healthcare-app-server/internal/encryption"
+	"healthcare-app-server/internal/i18n"
+	"healthcare-app-server/internal/jobs"
+	"healthcare-app-server/internal/mailer"
+	"healthcare-app-server/internal/metrics"
+	"healthcare-app-server/internal/middleware"
 	"healthcare-app-server/internal/models"
 	"healthcare-app-server/internal/routes"
+	"healthcare-app-server/internal/seed"
+	"healthcare-app-server/internal/slowlog"
+	"healthcare-app-server/internal/tenancy"
+	"healthcare-app-server/internal/utils"
+	"healthcare-app-server/internal/webhooks"
 )
 
 func main() {
@@ -25,9 +41,39 @@ func main() {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	// Load JWT signing keys (no-op unless RS256 is configured)
+	if err := utils.LoadJWTKeys(cfg); err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+
+	// Load the at-rest encryption keys (no-op unless ENCRYPTION_KEYS is set)
+	if err := encryption.Configure(cfg); err != nil {
+		log.Fatalf("Error loading encryption keys: %v", err)
+	}
+
+	// Make sure every locale catalog defines the same message keys
+	if err := i18n.CheckCatalogs(); err != nil {
+		log.Fatalf("Error loading message catalogs: %v", err)
+	}
+	if !i18n.IsSupported(cfg.DefaultLocale) {
+		log.Fatalf("Unsupported DEFAULT_LOCALE: %s", cfg.DefaultLocale)
+	}
+	// Raw error details are only returned to clients with ERROR_DETAIL=full, the default while developing
+	utils.SetDebugErrors(cfg.ErrorDetail == "full")
+	if cfg.ErrorDetail == "full" && cfg.Environment == "production" {
+		log.Printf("Warning: ERROR_DETAIL=full returns raw error details, including database errors, to clients in production")
+	}
+	// Cap the page size of every list endpoint
+	utils.SetMaxPageSize(cfg.MaxPageSize)
+	// Require the configured minimum age of dates of birth entered on profiles
+	utils.SetMinimumAge(cfg.MinimumAgeYears)
+	// Hash new passwords, and upgrade weaker stored hashes at login, with the configured bcrypt cost
+	models.SetPasswordCost(cfg.BcryptCost)
+
 	// Create a DatabaseConfig for models
 	modelDbConfig := models.DatabaseConfig{
-		DSN: cfg.Database.DSN,
+		DSN:                   cfg.Database.DSN,
+		MessageFullTextSearch: cfg.MessageSearchFullText,
 	}
 
 	// Initialize database connection
@@ -36,24 +82,175 @@ func main() {
 		log.Fatalf("Error connecting to database: %v", err)
 	}
 
-	// Initialize Gin router
-	router := gin.Default()
+	// Keep the most recent slow queries and requests for GET /admin/slow-queries
+	slowLog := slowlog.New(cfg.SlowLogSize)
+	if cfg.SlowQueryThresholdMs > 0 {
+		plugin := &slowlog.Plugin{Log: slowLog, Threshold: time.Duration(cfg.SlowQueryThresholdMs) * time.Millisecond}
+		if err := db.Use(plugin); err != nil {
+			log.Fatalf("Error enabling the slow query log: %v", err)
+		}
+	}
+
+	if cfg.MultiTenant {
+		// Limit every request's queries to the requesting user's organization
+		if err := tenancy.Register(db); err != nil {
+			log.Fatalf("Error enabling multi-tenancy: %v", err)
+		}
+		// Rows created before MULTI_TENANT was turned on join their users' organizations
+		if updated, err := jobs.BackfillOrganizations(db); err != nil {
+			log.Printf("Failed to backfill organizations: %v", err)
+		} else if updated > 0 {
+			log.Printf("Backfilled the organization of %d rows", updated)
+		}
+	}
+
+	// Create the initial admin when none exists (off unless ADMIN_EMAIL is set)
+	if cfg.AdminEmail != "" {
+		if _, err := seed.Admin(db, cfg.AdminEmail, cfg.AdminPassword, cfg.MultiTenant); err != nil {
+			log.Fatalf("Error seeding the admin account: %v", err)
+		}
+	}
+	// Create the demo accounts and data for development (off unless SEED_DEMO is set)
+	if cfg.SeedDemo {
+		if err := seed.Demo(db, cfg.MultiTenant); err != nil {
+			log.Fatalf("Error seeding demo data: %v", err)
+		}
+	}
+
+	// Archive old messages in the background
+	jobs.StartMessageArchiver(db, time.Duration(cfg.MessageArchiveAfterDays)*24*time.Hour)
+	// Give confirmed appointments nobody closed an outcome
+	jobs.StartAppointmentSweep(db, time.Duration(cfg.AppointmentSweepHours)*time.Hour, cfg.AppointmentSweepPolicy == "complete")
+	// Cancel appointment proposals patients did not confirm in time
+	jobs.StartProposalExpiry(db, time.Duration(cfg.ProposalExpiryHours)*time.Hour)
+	// Cancel pending appointments nobody confirmed in time (off unless PENDING_CONFIRMATION_HOURS is set)
+	jobs.StartPendingExpiry(db, time.Duration(cfg.PendingConfirmationHours)*time.Hour)
+	// Empty the medical record trash once the retention window has passed
+	jobs.StartRecordPurge(db, time.Duration(cfg.RecordTrashRetentionDays)*24*time.Hour)
+	// Delete finished appointments older than the retention period
+	jobs.StartAppointmentPurge(db, time.Duration(cfg.AppointmentRetentionDays)*24*time.Hour)
+	// Deliver webhook events to subscribed endpoints, retrying failed deliveries
+	webhookDispatcher := webhooks.NewDispatcher(db, cfg)
+	webhookDispatcher.Start()
+	// Run the scheduled jobs; their next runs are kept in the database across restarts
+	scheduler := jobs.NewScheduler(db, jobs.SystemClock, cfg.JobMaxAttempts)
+	scheduleJob(scheduler, jobs.RefreshTokenCleanup{DB: db}, cfg.TokenCleanupSchedule)
+	scheduleJob(scheduler, jobs.AppointmentReminders{
+		DB:     db,
+		Mailer: mailer.New(cfg.Mailer),
+		Lead:   time.Duration(cfg.AppointmentReminderHours) * time.Hour,
+	}, cfg.ReminderSchedule)
+	scheduler.Start()
+
+	// Initialize Gin router; panics are recovered by our own middleware below instead of gin's
+	router := gin.New()
+	router.Use(gin.Logger())
+	router.MaxMultipartMemory = cfg.MaxMultipartMemory // Larger uploads spill to temp files instead of RAM
+
+	// Tag every request with an ID that error responses and logs share
+	router.Use(middleware.RequestID())
+
+	if cfg.MetricsAddr != "" {
+		// Serve Prometheus metrics on their own listener, away from the public API
+		if sqlDB, err := db.DB(); err != nil {
+			log.Fatalf("Error accessing database pool: %v", err)
+		} else if err := metrics.RegisterDB(sqlDB); err != nil {
+			log.Fatalf("Error registering database metrics: %v", err)
+		}
+		router.Use(middleware.Metrics())
+		go func() {
+			metricsMux := http.NewServeMux()
+			metricsMux.Handle("/metrics", promhttp.Handler())
+			fmt.Printf("Serving metrics on %s\n", cfg.MetricsAddr)
+			if err := http.ListenAndServe(cfg.MetricsAddr, metricsMux); err != nil {
+				log.Fatalf("Failed to start metrics server: %v", err)
+			}
+		}()
+	}
+
+	// Attribute queries to their route and log slow requests
+	router.Use(middleware.SlowRequests(slowLog, time.Duration(cfg.SlowRequestThresholdMs)*time.Millisecond))
+
+	// Answer panics and unhandled handler errors with the JSON error envelope
+	router.Use(middleware.Recovery())
 
 	// Configure CORS
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{cfg.Origin}
 	corsConfig.AllowCredentials = true
-	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowMethods = cfg.CORSAllowedMethods
+	corsConfig.AllowHeaders = cfg.CORSAllowedHeaders
+	// Retry-After tells clients when a rate-limited request may be sent again
+	corsConfig.ExposeHeaders = []string{utils.RequestIDHeader, "Retry-After", middleware.PendingPoliciesHeader}
+	if err := corsConfig.Validate(); err != nil {
+		log.Fatalf("Invalid CORS configuration: %v", err)
+	}
 	router.Use(cors.New(corsConfig))
+	log.Printf("CORS: origin %s, methods %s, headers %s", cfg.Origin,
+		strings.Join(corsConfig.AllowMethods, ","), strings.Join(corsConfig.AllowHeaders, ","))
+
+	// Pick the response language from Accept-Language
+	router.Use(middleware.Locale(cfg.DefaultLocale))
+
+	// Cap request bodies globally; upload routes raise the limit in routes.go
+	router.Use(middleware.BodySizeLimit(cfg.MaxBodyBytes))
+
+	// Cancel database work of slow requests; attachment routes get a longer timeout in routes.go
+	router.Use(middleware.RequestTimeout(time.Duration(cfg.RequestTimeout) * time.Second))
 
 	// Set up routes - passing DB and config to let routes.go create the handlers
-	routes.SetupRoutes(router, db, cfg)
+	routes.SetupRoutes(router, db, cfg, webhookDispatcher, scheduler, slowLog)
 
-	// Start server
+	// Start server, terminating TLS ourselves when a certificate is configured
 	serverAddr := fmt.Sprintf(":%s", cfg.Port)
+	if cfg.TLSEnabled() {
+		if cfg.HTTPRedirectPort != "" {
+			go func() {
+				redirectAddr := fmt.Sprintf(":%s", cfg.HTTPRedirectPort)
+				fmt.Printf("Redirecting HTTP on port %s to HTTPS\n", cfg.HTTPRedirectPort)
+				if err := http.ListenAndServe(redirectAddr, redirectToHTTPS(cfg.Port)); err != nil {
+					log.Fatalf("Failed to start HTTP redirect server: %v", err)
+				}
+			}()
+		}
+
+		fmt.Printf("Server running with TLS on port %s\n", cfg.Port)
+		if err := router.RunTLS(serverAddr, cfg.TLSCertFile, cfg.TLSKeyFile); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+		return
+	}
+
 	fmt.Printf("Server running on port %s\n", cfg.Port)
 	if err := router.Run(serverAddr); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// scheduleJob registers job on the schedule spec from config; "off" leaves it unregistered.
+func scheduleJob(scheduler *jobs.Scheduler, job jobs.Job, spec string) {
+	if spec == "off" {
+		return
+	}
+	schedule, err := jobs.ParseSchedule(spec)
+	if err != nil {
+		log.Fatalf("Error scheduling job %s: %v", job.Name(), err)
+	}
+	if err := scheduler.Register(job, schedule); err != nil {
+		log.Fatalf("Error scheduling job %s: %v", job.Name(), err)
+	}
+}
+
+// redirectToHTTPS permanently redirects every request to the same host and path on the HTTPS port.
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}